@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// colorMode is the --color setting: auto, always or never.
+var colorMode = "auto"
+
+// ANSI SGR codes used by the CLI
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+)
+
+// statusColors maps each status to the color of its icon.
+var statusColors = map[types.Status]string{
+	types.StatusOpen:       ansiBlue,
+	types.StatusInProgress: ansiYellow,
+	types.StatusBlocked:    ansiRed,
+	types.StatusReview:     ansiCyan,
+	types.StatusDone:       ansiGreen,
+}
+
+// useColor reports whether output should be colored. JSON output never
+// is; in auto mode color also requires stdout to be a terminal and
+// NO_COLOR to be unset.
+func useColor() bool {
+	if jsonOutput {
+		return false
+	}
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is a character device such as a TTY.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the given ANSI code when color is enabled.
+func colorize(code, s string) string {
+	if !useColor() {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// statusIcon returns the status icon, colored by status.
+func statusIcon(s types.Status) string {
+	return colorize(statusColors[s], s.Icon())
+}
+
+// header formats a section header, bold when color is enabled.
+func header(format string, args ...any) string {
+	return colorize(ansiBold, fmt.Sprintf(format, args...))
+}
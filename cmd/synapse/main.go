@@ -5,12 +5,22 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/swiftj/synapse/internal/export"
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/internal/importer"
 	"github.com/swiftj/synapse/internal/mcp"
 	"github.com/swiftj/synapse/internal/skill"
 	"github.com/swiftj/synapse/internal/storage"
@@ -22,6 +32,13 @@ const version = "1.0.7"
 
 var jsonOutput bool
 
+// autoCommit forces Git auto-commit on for this run, from --auto-commit.
+var autoCommit bool
+
+// sessionID is the agent session changes are recorded under, from --session
+// or SYNAPSE_SESSION.
+var sessionID = os.Getenv("SYNAPSE_SESSION")
+
 // jsonOut writes v as indented JSON to stdout.
 func jsonOut(v any) {
 	enc := json.NewEncoder(os.Stdout)
@@ -29,18 +46,95 @@ func jsonOut(v any) {
 	enc.Encode(v)
 }
 
-// extractGlobalFlags scans os.Args for --json, sets jsonOutput, and strips
-// the flag so per-command parsers don't see it.
+// Error codes reported in the --json error envelope.
+const (
+	codeNotFound  = "not_found"  // No task or breadcrumb with that ID or key
+	codeInvalidID = "invalid_id" // The ID argument isn't a number
+	codeConflict  = "conflict"   // The task's state or claim doesn't allow the change
+	codeUsage     = "usage"      // Missing or malformed arguments
+	codeInternal  = "internal"   // Loading or saving the store failed
+)
+
+// errorEnvelope replaces a command's result on stdout when it fails in
+// --json mode, so consumers always get something parseable.
+type errorEnvelope struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	Hint  string `json:"hint,omitempty"`
+}
+
+// fail reports an error and exits 1: as an errorEnvelope on stdout in
+// --json mode, otherwise as "error: ..." on stderr.
+func fail(code, format string, args ...any) {
+	failHint(code, "", format, args...)
+}
+
+// failHint is fail with a suggestion for getting past the error.
+func failHint(code, hint, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if jsonOutput {
+		jsonOut(errorEnvelope{Error: msg, Code: code, Hint: hint})
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "error: %s\n", msg)
+	if hint != "" {
+		fmt.Fprintf(os.Stderr, "hint: %s\n", hint)
+	}
+	os.Exit(1)
+}
+
+// failErr reports a store error, choosing the code from its type.
+func failErr(err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		fail(codeNotFound, "%v", err)
+	case errors.Is(err, storage.ErrStaleWrite):
+		fail(codeConflict, "%v", err)
+	default:
+		fail(codeInternal, "%v", err)
+	}
+}
+
+// parseID parses a task ID argument, failing with invalid_id.
+func parseID(arg string) int {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		fail(codeInvalidID, "invalid ID: %s", arg)
+	}
+	return id
+}
+
+// extractGlobalFlags scans os.Args for --json, --color, --session and
+// --auto-commit, sets the matching variables, and strips the flags so per-command parsers don't see them.
 func extractGlobalFlags() {
 	filtered := os.Args[:0]
-	for _, arg := range os.Args {
-		if arg == "--json" {
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--json":
 			jsonOutput = true
-		} else {
+		case arg == "--color" && i+1 < len(os.Args):
+			i++
+			colorMode = os.Args[i]
+		case strings.HasPrefix(arg, "--color="):
+			colorMode = strings.TrimPrefix(arg, "--color=")
+		case arg == "--auto-commit":
+			autoCommit = true
+		case arg == "--session" && i+1 < len(os.Args):
+			i++
+			sessionID = os.Args[i]
+		default:
 			filtered = append(filtered, arg)
 		}
 	}
 	os.Args = filtered
+
+	switch colorMode {
+	case "auto", "always", "never":
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid --color %q (want auto, always or never)\n", colorMode)
+		os.Exit(1)
+	}
 }
 
 func main() {
@@ -63,24 +157,80 @@ func main() {
 		cmdList(args)
 	case "ready":
 		cmdReady(args)
+	case "overdue":
+		cmdOverdue()
+	case "count":
+		cmdCount(args)
+	case "critical-path":
+		cmdCriticalPath(args)
+	case "plan":
+		cmdPlan(args)
 	case "get":
 		cmdGet(args)
 	case "claim":
 		cmdClaim(args)
+	case "renew":
+		cmdRenew(args)
 	case "done":
 		cmdDone(args)
+	case "review":
+		cmdReview(args)
+	case "reopen":
+		cmdReopen(args)
+	case "edit":
+		cmdEdit(args)
+	case "move", "mv":
+		cmdMove(args)
+	case "archive":
+		cmdArchive(args, true)
+	case "unarchive":
+		cmdArchive(args, false)
 	case "all-done":
-		cmdDoneAll()
+		cmdDoneAll(args)
 	case "delete", "rm":
 		cmdDelete(args)
 	case "breadcrumb", "bc":
 		cmdBreadcrumb(args)
+	case "template", "tmpl":
+		cmdTemplate(args)
 	case "skill":
 		cmdSkill(args)
 	case "serve":
-		cmdServe()
+		cmdServe(args)
 	case "view":
 		cmdView(args)
+	case "export":
+		cmdExport(args)
+	case "log":
+		cmdLog(args)
+	case "blame":
+		cmdBlame(args)
+	case "session":
+		cmdSession(args)
+	case "diff":
+		cmdDiff(args)
+	case "resolve":
+		cmdResolve(args)
+	case "compact", "gc":
+		cmdCompact(args)
+	case "stats":
+		cmdStats(args)
+	case "workload":
+		cmdWorkload(args)
+	case "snapshot":
+		cmdSnapshot(args)
+	case "restore":
+		cmdRestore(args)
+	case "doctor":
+		cmdDoctor(args)
+	case "webhook":
+		cmdWebhook(args)
+	case "config":
+		cmdConfig(args)
+	case "role":
+		cmdRole(args)
+	case "import":
+		cmdImport(args)
 	case "version", "-v", "--version":
 		if jsonOutput {
 			jsonOut(map[string]string{"version": version})
@@ -104,24 +254,79 @@ Usage:
 
 Global Flags:
   --json            Output structured JSON (works with any command)
+  --color M         Color status icons and headers: auto (default), always, never
+  --session S       Record changes under agent session S (default: $SYNAPSE_SESSION)
+  --auto-commit     Commit memory.jsonl to Git after each change (or set config auto-commit)
 
 Commands:
   init              Initialize .synapse directory in current project
       --git         Also stage memory.jsonl for commit
   add <title>       Create a new synapse task
       --blocks N    Block on synapse N (can repeat)
+      --related N   Link to synapse N without blocking on it (can repeat)
       --parent N    Set parent synapse ID
       --assignee X  Assign to role (e.g., @qa, @coder)
+      --estimate M  Estimated effort in minutes
+      --due D       Due date: YYYY-MM-DD, RFC3339, or a duration from now like 3d
+      --file F      Read title, description and fields from a front-matter file
+      --editor      Write the task in $EDITOR, like git commit
+      --external-id K   Idempotency key: if a task with K exists, print it instead of adding
+      --agent A     Record agent A as the creator (default: $SYNAPSE_AGENT)
   list, ls          List all synapses
       --status X    Filter by status (open, in-progress, blocked, review, done)
+      --assignee X  Filter by assignee ("" for unassigned)
+      --unassigned  Only tasks with no assignee
+      --completed-by A   Only tasks completed by agent A, most recent first
+      --created-after T  Only tasks created after T (RFC3339 or age like 24h, 7d)
+      --updated-after T  Only tasks updated after T (RFC3339 or age like 24h, 7d)
+      --sort K      Sort by id (default), priority, updated, created, title, status
+      --order O     Sort order: asc (default) or desc
       --limit N     Limit output to N tasks (default 20, 0 for unlimited)
       --summary     Condensed output (default)
       --full        Show all fields for each task
+      --format T    Print each task with Go template T, e.g. '{{.ID}} {{icon .Status}} {{.Title}}'
+      --include-archived  Also list archived tasks
   ready             List ready (unblocked, open) tasks
+      --assignee X  Only tasks assigned to role X
+      --include-archived  Also list archived tasks
+  overdue           List unfinished tasks past their due date, most overdue first
+  count             Print the number of tasks matching every given filter
+      --status X    Only tasks with status X
+      --label Y     Only tasks labeled Y
+      --assignee Z  Only tasks assigned to Z
+      --unassigned  Only tasks with no assignee
+      --include-archived  Also count archived tasks
+  critical-path <id>  List the longest chain of unfinished blockers leading to a synapse
+      --estimates   Weigh each task by its estimate instead of counting steps
+  plan              Print unfinished tasks in dependency order, grouped into waves that can run in parallel
   get <id>          Get details of a specific synapse
+      --discovered  List the tasks discovered while working on it instead
+      --context     Print an agent briefing as JSON: notes, parents, blockers, siblings, breadcrumbs
+      --format T    Print with Go template T (see list)
   claim <id>        Mark synapse as in-progress
+      --agent X     Claim it for agent X, refusing if another agent holds an active claim
+      --steal       With --agent, take over an active claim (noted on the task)
+      --force       Skip status transition rules
+  renew <id>        Extend an agent's claim so it doesn't expire mid-work
+      --agent X     Agent holding the claim (required)
   done <id>         Mark synapse as done
+      --force       Skip status transition rules
+  review <id>       Hand an in-progress synapse off for review
+      --to X        Reassign to reviewer role X (e.g., @qa)
+      --by NAME     Who is requesting review (default: the claiming agent)
+      --force       Skip status transition rules
+  reopen <id>       Move a synapse (e.g. a done one) back to open
+  edit <id>         Change a synapse's fields
+      --due D       Deadline: YYYY-MM-DD, RFC3339, or a duration like 3d; none clears it
+      --related N   Add a "see also" link to task N (repeatable)
+      --unrelated N Remove the link to task N (repeatable)
+  move, mv <id>     Re-parent a synapse and print the resulting tree
+      --under N     New parent (0 for top level, required)
+      --subtree     Bring its descendants along (default: its children move up to its old parent)
+  archive <id>      Hide a synapse from list, ready and the graph without deleting it
+  unarchive <id>    Return an archived synapse to the board
   all-done          Mark all tasks as done (cleanup command)
+      --force       Don't ask first when memory.jsonl has uncommitted changes
   delete, rm <id>   Delete a synapse task
       --all         Delete all tasks
       --done        Delete all completed tasks (cleanup)
@@ -130,7 +335,19 @@ Commands:
           --task-id N     Link to task ID
       get <key>           Get a breadcrumb value
       list [prefix]       List breadcrumbs (optionally filter by prefix)
+      namespaces          List key namespaces (first segment before '.') with counts
       delete <key>        Delete a breadcrumb
+      delete --prefix P   Delete every breadcrumb whose key starts with P (--all for every key)
+  template, tmpl    Manage recurring-task templates (kept out of list/ready)
+      set <name>          Define or replace a template
+          --title T       Title of tasks created from it (required)
+          --description D Description
+          --label X       Label (can repeat)
+          --assignee X    Assignee
+          --interval D    How often it recurs, e.g. 7d; new tasks are due within it
+      list                List templates
+      delete <name>       Delete a template
+      instantiate <name>  Create a fresh task from a template
   skill             Manage agentic skill installations
       install <agent>   Install skill for an agent
           --level L     Install level: user or project (default: project)
@@ -141,8 +358,67 @@ Commands:
           --level L     Install level: user or project (default: project)
       show              Print the embedded SKILL.md content
   serve             Start MCP server (JSON-RPC over stdio)
+      --verbose     Log every request and response (same as SYNAPSE_LOG=debug)
   view              Start visualization web server
-      --port N      Port to listen on (default: 8080)
+      --port N      Port to listen on (default: view-port setting, else 8080)
+      --host H      Interface to bind (default: localhost; 0.0.0.0 exposes the board to the network)
+      --no-reload   Serve a startup snapshot instead of reloading on file changes
+      --auth-token T  Require token T as a Bearer header or ?token= (default: $SYNAPSE_VIEW_TOKEN)
+      --cors-origin O Let browser pages on origin O call /api/* (repeatable or comma-separated; * for any)
+      --export F    Print the graph as F (mermaid, dot) and exit instead of serving
+      --output P    With --export, write the graph to file P instead of stdout
+      --include-archived  Also show archived tasks
+  export            Export all tasks to stdout
+      --format F    Output format: markdown (default), dot, csv
+      --status X    Only export tasks with this status
+      --assignee X  Only export tasks with this assignee
+  compact, gc       Remove old tasks to keep memory.jsonl small
+      --older-than D  Only tasks not updated within D, e.g. 30d, 2w, 12h (required unless --rewrite)
+      --status X      Only tasks with this status (default: done)
+      --archive       Append removed tasks to done-archive.jsonl first
+      --dry-run       Show what would be removed without changing anything
+      --rewrite       Rewrite memory.jsonl in canonical form, folding append-only history
+      --force         Don't ask first when memory.jsonl has uncommitted changes
+  log               Replay the audit log of task transitions (oldest first)
+      --task N      Only show events for task N
+      --agent X     Only show events by agent X
+      --follow, -f  Keep printing new events as they are recorded (JSON: one per line)
+  diff              Show how the board changed since a Git commit
+      --since REF   Commit (or branch, tag) to compare memory.jsonl against (required)
+  session [id]      Replay an agent session's events and list the tasks it touched
+  blame <id>        Tell a synapse's story: origin, parents, who created, claimed and finished it, and its notes
+  stats             Report board statistics
+      --effort      Estimate-vs-actual accuracy across done tasks (required)
+  workload          Count each assignee's tasks by status, busiest first
+  snapshot          Copy memory.jsonl and breadcrumbs.jsonl to .synapse/snapshots/
+      --name L      Snapshot name (default: current UTC timestamp)
+      --force       Overwrite an existing snapshot with the same name
+      --list        List available snapshots instead
+  restore <name>    Replace tasks and breadcrumbs with a snapshot's copies
+      --yes         Don't ask for confirmation
+  resolve           Settle a Git merge of memory.jsonl: drop conflict markers, de-duplicate IDs
+      --keep-latest Keep only the most recently updated version of each duplicated ID
+      --renumber    Give every other version of a duplicated ID a new ID
+      --yes         Don't ask for confirmation
+  doctor            Check the board for inconsistencies (exits 1 if any are found)
+      --fix         Repair the safe ones: dangling blockers and related links, expired claims, stuck blocked tasks
+  config            Show or change project settings in .synapse/config.json
+      list          Show every setting with its source (env, file, or default)
+      get <key>     Print one setting's effective value
+      set <key> <v> Write a setting: storage-dir, claim-timeout, default-assignee, view-port
+  role              Map roles (e.g. @qa) to the agents filling them in .synapse/roles.json
+      list          Show every role with its members
+      add <role> <agent>...   Add agents to a role
+      rm <role> [agent]...    Remove agents from a role, or the whole role
+  webhook test      POST a test payload to each webhook in .synapse/webhooks.json
+      --url U       Send to U instead
+  import <file>     Import tasks from a JSON array or JSONL file (fresh IDs)
+      --github R    Import issues from GitHub repo owner/repo instead of a file
+      --token T     GitHub API token (default: $GITHUB_TOKEN, else the gh CLI)
+      --state S     GitHub issue state: open (default), closed, all
+      --link-existing  Let blocker/related/parent IDs not in the import refer to existing tasks
+      --dry-run     Preview the ID remapping without saving
+      --force       Don't ask first when memory.jsonl has uncommitted changes
   version           Print version
   help              Print this help message
 
@@ -154,19 +430,226 @@ Examples:
   synapse --json done 5`)
 }
 
+// projectConfig is the resolved configuration, loaded by getConfig on
+// first use.
+var projectConfig *storage.Config
+
+// getConfig resolves the project configuration: environment variables
+// over config.json over the defaults.
+func getConfig() *storage.Config {
+	if projectConfig == nil {
+		cfg, _, err := storage.ResolveConfig(storage.ConfigDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		projectConfig = cfg
+	}
+	return projectConfig
+}
+
+// dataDir returns the directory holding tasks, breadcrumbs, and the other
+// data files.
+func dataDir() string {
+	return getConfig().StorageDir
+}
+
+// newStore creates the store in the configured directory and storage mode.
+func newStore() *storage.JSONLStore {
+	store := storage.NewJSONLStore(dataDir())
+	store.SetAppendOnly(getConfig().StorageMode == storage.StorageAppend)
+	return store
+}
+
 func getStore() *storage.JSONLStore {
-	store := storage.NewJSONLStore(storage.DefaultDir)
+	store := newStore()
+	if err := store.Load(); err != nil {
+		failLoad(err)
+	}
+	warnDuplicates(store)
+	store.SetClaimTimeout(getConfig().ClaimTimeout())
+	store.SetSession(sessionID)
+	setRoles(store)
+	subscribeWebhooks(store)
+	subscribeAutoCommit(store)
+	return store
+}
+
+// setRoles gives store the role memberships from roles.json. A broken
+// file is reported but doesn't stop the command.
+func setRoles(store *storage.JSONLStore) {
+	roles, err := storage.LoadRoles(dataDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: roles disabled: %v\n", err)
+		return
+	}
+	store.SetRoles(roles)
+}
+
+// webhooks delivers the events of saved changes to the URLs configured in
+// webhooks.json; nil when none are configured.
+var webhooks *storage.WebhookNotifier
+
+// webhookFlushTimeout bounds how long a command waits for webhook
+// deliveries after saving before it exits.
+const webhookFlushTimeout = 10 * time.Second
+
+// subscribeWebhooks attaches the configured webhooks to store. A broken
+// config is reported but doesn't stop the command.
+func subscribeWebhooks(store *storage.JSONLStore) {
+	hooks, err := storage.LoadWebhooks(dataDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: webhooks disabled: %v\n", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	webhooks = storage.NewWebhookNotifier(hooks)
+	store.Subscribe(webhooks)
+}
+
+// subscribeAutoCommit commits each of store's saves to Git when
+// --auto-commit or the auto-commit setting asks for it.
+func subscribeAutoCommit(store *storage.JSONLStore) {
+	if !autoCommit && !getConfig().AutoCommit {
+		return
+	}
+	git := storage.NewGitIntegration()
+	if git == nil {
+		fmt.Fprintln(os.Stderr, "warning: auto-commit disabled: not in a Git repository")
+		return
+	}
+	committer, err := storage.NewAutoCommitter(git, dataDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: auto-commit disabled: %v\n", err)
+		return
+	}
+	store.Subscribe(committer)
+}
+
+// storeUnlock keeps the file lock taken by getLockedStore reachable; the
+// lock is released when the process exits.
+var storeUnlock func() error
+
+// getLockedStore takes the store's cross-process file lock and then loads
+// it, for commands that load, mutate, and save.
+func getLockedStore() *storage.JSONLStore {
+	store := lockStore()
 	if err := store.Load(); err != nil {
-		fmt.Fprintf(os.Stderr, "error loading store: %v\n", err)
+		failLoad(err)
+	}
+	warnDuplicates(store)
+	store.SetClaimTimeout(getConfig().ClaimTimeout())
+	store.SetSession(sessionID)
+	setRoles(store)
+	subscribeWebhooks(store)
+	subscribeAutoCommit(store)
+	return store
+}
+
+// lockStore takes the store's cross-process file lock without loading it.
+func lockStore() *storage.JSONLStore {
+	store := newStore()
+	unlock, err := store.Lock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error locking store: %v\n", err)
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "hint: run 'synapse init' first")
+		}
 		os.Exit(1)
 	}
+	storeUnlock = unlock
 	return store
 }
 
+// failLoad reports a store that failed to load, pointing merge conflicts
+// at synapse resolve.
+func failLoad(err error) {
+	if errors.Is(err, storage.ErrMergeConflict) {
+		failHint(codeConflict, "run 'synapse resolve' to keep both sides and settle duplicate IDs", "loading store: %v", err)
+	}
+	fail(codeInternal, "loading store: %v", err)
+}
+
+// warnDuplicates warns about task IDs that appear on more than one line of
+// the memory file, as a Git merge can leave them; Save keeps only the last.
+func warnDuplicates(store *storage.JSONLStore) {
+	if ids := store.DuplicateIDs(); len(ids) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: tasks %v appear on more than one line; run 'synapse resolve' before changing them\n", ids)
+	}
+}
+
+// confirmDirty guards a destructive command against uncommitted changes
+// to memory.jsonl, which it could make hard to get back: if Git reports
+// the file changed since the last commit it warns and, unless force is
+// set, asks before going on. Outside a repository it does nothing.
+func confirmDirty(command string, force bool) {
+	git := storage.NewGitIntegration()
+	if git == nil {
+		return
+	}
+	rel, err := git.RelPath(filepath.Join(dataDir(), storage.MemoryFile))
+	if err != nil {
+		return
+	}
+	clean, err := git.IsClean(rel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't check %s for uncommitted changes: %v\n", rel, err)
+		return
+	}
+	if clean {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "warning: %s has uncommitted changes; commit it or run 'synapse snapshot' before %s\n", rel, command)
+	if force {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Run %s anyway? [y/N] ", command)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		failHint(codeConflict, "use --force to skip this check", "aborted: %s has uncommitted changes", rel)
+	}
+}
+
+// extractFlag removes a boolean flag from args, reporting whether it was present.
+func extractFlag(args []string, flag string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// checkTransition exits with an error if syn may not move to next, unless
+// force is set.
+func checkTransition(store *storage.JSONLStore, syn *types.Synapse, next types.Status, force bool) {
+	if force {
+		return
+	}
+	if err := syn.ValidateTransition(next, store.IsDone); err != nil {
+		failHint(codeConflict, "use --force to override", "%v", err)
+	}
+}
+
+// updateSynapse records a change to syn in the store, bumping its Version.
+func updateSynapse(store *storage.JSONLStore, syn *types.Synapse) {
+	if err := store.Update(syn); err != nil {
+		failErr(err)
+	}
+}
+
 func saveStore(store *storage.JSONLStore) {
 	if err := store.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "error saving store: %v\n", err)
-		os.Exit(1)
+		fail(codeInternal, "saving store: %v", err)
+	}
+	if webhooks != nil && !webhooks.Flush(webhookFlushTimeout) {
+		fmt.Fprintln(os.Stderr, "warning: timed out delivering webhooks")
 	}
 }
 
@@ -179,7 +662,7 @@ func cmdInit(args []string) {
 		}
 	}
 
-	store := storage.NewJSONLStore(storage.DefaultDir)
+	store := storage.NewJSONLStore(dataDir())
 	result, err := store.InitWithOptions(stageMemory)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -199,6 +682,9 @@ func cmdInit(args []string) {
 	}
 
 	if result.GitRepoDetected {
+		for _, pattern := range result.GitignoreAdded {
+			fmt.Printf("  ✓ Added %s to .gitignore\n", pattern)
+		}
 		if result.MemoryStaged {
 			fmt.Println("  ✓ Staged .synapse/memory.jsonl for commit")
 		} else if stageMemory {
@@ -212,14 +698,23 @@ func cmdInit(args []string) {
 func cmdAdd(args []string) {
 	if len(args) == 0 {
 		fmt.Fprintln(os.Stderr, "error: title required")
-		fmt.Fprintln(os.Stderr, "usage: synapse add <title> [--blocks N] [--parent N] [--assignee X]")
+		fmt.Fprintln(os.Stderr, "usage: synapse add <title> [--blocks N] [--parent N] [--assignee X] [--related N] [--estimate M] [--due D] [--agent A]")
+		fmt.Fprintln(os.Stderr, "       synapse add --file task.md | --editor")
 		os.Exit(1)
 	}
 
 	var title string
 	var blocks []int
+	var related []int
 	var parentID int
-	var assignee string
+	assignee := getConfig().DefaultAssignee
+	var assigneeSet bool
+	var taskFile string
+	var externalID string
+	var useEditor bool
+	var estimate int
+	var dueAt *time.Time
+	createdBy := os.Getenv("SYNAPSE_AGENT")
 
 	// Parse arguments
 	i := 0
@@ -234,6 +729,14 @@ func cmdAdd(args []string) {
 				os.Exit(1)
 			}
 			blocks = append(blocks, id)
+		case arg == "--related" && i+1 < len(args):
+			i++
+			id, err := strconv.Atoi(args[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: invalid related ID: %s\n", args[i])
+				os.Exit(1)
+			}
+			related = append(related, id)
 		case arg == "--parent" && i+1 < len(args):
 			i++
 			id, err := strconv.Atoi(args[i])
@@ -245,6 +748,34 @@ func cmdAdd(args []string) {
 		case arg == "--assignee" && i+1 < len(args):
 			i++
 			assignee = args[i]
+			assigneeSet = true
+		case arg == "--file" && i+1 < len(args):
+			i++
+			taskFile = args[i]
+		case arg == "--external-id" && i+1 < len(args):
+			i++
+			externalID = args[i]
+		case arg == "--agent" && i+1 < len(args):
+			i++
+			createdBy = args[i]
+		case arg == "--editor":
+			useEditor = true
+		case arg == "--estimate" && i+1 < len(args):
+			i++
+			m, err := strconv.Atoi(args[i])
+			if err != nil || m < 0 {
+				fmt.Fprintf(os.Stderr, "error: invalid estimate (minutes): %s\n", args[i])
+				os.Exit(1)
+			}
+			estimate = m
+		case arg == "--due" && i+1 < len(args):
+			i++
+			due, err := storage.ParseDue(args[i], time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			dueAt = &due
 		case !strings.HasPrefix(arg, "--"):
 			if title == "" {
 				title = arg
@@ -258,21 +789,68 @@ func cmdAdd(args []string) {
 		i++
 	}
 
+	// A task file supplies the description and defaults; flags and a
+	// title on the command line take precedence
+	var description string
+	var labels []string
+	var priority int
+	if taskFile != "" || useEditor {
+		tf := readTaskFile(taskFile, assignee)
+		if title == "" {
+			title = tf.Title
+		}
+		description = tf.Description
+		if !assigneeSet && tf.Assignee != "" {
+			assignee = tf.Assignee
+		}
+		blocks = append(tf.Blocks, blocks...)
+		related = append(tf.Related, related...)
+		if parentID == 0 {
+			parentID = tf.Parent
+		}
+		labels = tf.Labels
+		priority = tf.Priority
+	}
+
 	if title == "" {
 		fmt.Fprintln(os.Stderr, "error: title required")
 		os.Exit(1)
 	}
 
-	store := getStore()
-	syn, err := store.Create(title)
+	store := getLockedStore()
+	for _, id := range related {
+		if _, err := store.Get(id); err != nil {
+			fmt.Fprintf(os.Stderr, "error: related task: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	syn, created, err := store.CreateIdempotent(title, externalID)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	if !created {
+		// Already created by an earlier run; leave it untouched
+		if jsonOutput {
+			jsonOut(syn)
+			return
+		}
+		fmt.Printf("Synapse #%d already exists for external ID %s: %s\n", syn.ID, externalID, syn.Title)
+		return
+	}
 
+	syn.Description = description
+	syn.Labels = labels
+	syn.Priority = priority
 	syn.BlockedBy = blocks
+	for _, id := range related {
+		syn.AddRelated(id)
+	}
 	syn.ParentID = parentID
 	syn.Assignee = assignee
+	syn.EstimateMinutes = estimate
+	syn.DueAt = dueAt
+	syn.CreatedBy = createdBy
 
 	if len(blocks) > 0 {
 		syn.Status = types.StatusBlocked
@@ -297,9 +875,72 @@ func cmdAdd(args []string) {
 	}
 }
 
+// readTaskFile parses a task file for `add --file`, or with an empty path
+// opens $VISUAL or $EDITOR (default vi) on a template, like git commit,
+// and parses what was saved.
+func readTaskFile(path, assignee string) *importer.TaskFile {
+	var data []byte
+	var err error
+	if path != "" {
+		data, err = os.ReadFile(path)
+	} else {
+		data, err = editTaskFile(importer.EditTemplate(assignee))
+	}
+	if err != nil {
+		fail(codeUsage, "%v", err)
+	}
+	tf, err := importer.ParseTaskFile(data)
+	if err != nil {
+		if path == "" {
+			path = "edited task"
+		}
+		fail(codeUsage, "%s: %v", path, err)
+	}
+	return tf
+}
+
+// editTaskFile writes template to a temp file, waits for the user's editor
+// to exit, and returns the saved contents.
+func editTaskFile(template []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "synapse-task-*.md")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(template); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("write temp file: %w", err)
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// Run through the shell so editors with arguments, like "code --wait", work
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %s: %w", editor, err)
+	}
+	return os.ReadFile(f.Name())
+}
+
 func cmdList(args []string) {
 	var statusFilter string
+	var assigneeFilter string
+	var filterAssignee bool
+	var createdAfter, updatedAfter string
+	var completedBy string
+	var sortKey, sortOrder string
 	var fullOutput bool
+	var format string
+	var includeArchived bool
 	limit := 20 // default limit
 
 	for i := 0; i < len(args); i++ {
@@ -309,6 +950,40 @@ func cmdList(args []string) {
 				i++
 				statusFilter = args[i]
 			}
+		case "--assignee":
+			if i+1 < len(args) {
+				i++
+				assigneeFilter = args[i]
+				filterAssignee = true
+			}
+		case "--unassigned":
+			assigneeFilter = ""
+			filterAssignee = true
+		case "--sort":
+			if i+1 < len(args) {
+				i++
+				sortKey = args[i]
+			}
+		case "--order":
+			if i+1 < len(args) {
+				i++
+				sortOrder = args[i]
+			}
+		case "--completed-by":
+			if i+1 < len(args) {
+				i++
+				completedBy = args[i]
+			}
+		case "--created-after":
+			if i+1 < len(args) {
+				i++
+				createdAfter = args[i]
+			}
+		case "--updated-after":
+			if i+1 < len(args) {
+				i++
+				updatedAfter = args[i]
+			}
 		case "--limit":
 			if i+1 < len(args) {
 				i++
@@ -323,8 +998,16 @@ func cmdList(args []string) {
 			fullOutput = true
 		case "--summary":
 			fullOutput = false // explicit summary mode (default)
+		case "--include-archived":
+			includeArchived = true
+		case "--format":
+			if i+1 < len(args) {
+				i++
+				format = args[i]
+			}
 		}
 	}
+	tmpl := parseFormat(format)
 
 	store := getStore()
 	var synapses []*types.Synapse
@@ -340,6 +1023,50 @@ func cmdList(args []string) {
 	} else {
 		synapses = store.All()
 	}
+	if !includeArchived {
+		synapses = storage.Unarchived(synapses)
+	}
+
+	// An empty --assignee (or --unassigned) matches tasks nobody owns
+	if filterAssignee {
+		var filtered []*types.Synapse
+		for _, syn := range synapses {
+			if syn.Assignee == assigneeFilter {
+				filtered = append(filtered, syn)
+			}
+		}
+		synapses = filtered
+	}
+
+	// Most recently completed first, for auditing an agent's work
+	if completedBy != "" {
+		synapses = storage.Intersect(store.CompletedBy(completedBy), synapses)
+	}
+
+	now := time.Now()
+	if createdAfter != "" {
+		since, err := storage.ParseSince(createdAfter, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: --created-after: %v\n", err)
+			os.Exit(1)
+		}
+		synapses = storage.Intersect(synapses, store.CreatedSince(since))
+	}
+	if updatedAfter != "" {
+		since, err := storage.ParseSince(updatedAfter, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: --updated-after: %v\n", err)
+			os.Exit(1)
+		}
+		synapses = storage.Intersect(synapses, store.ModifiedSince(since))
+	}
+
+	if sortKey != "" || sortOrder != "" {
+		if err := types.SortSynapses(synapses, sortKey, sortOrder); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	totalCount := len(synapses)
 
@@ -348,6 +1075,11 @@ func cmdList(args []string) {
 		synapses = synapses[:limit]
 	}
 
+	if tmpl != nil {
+		writeFormatted(tmpl, synapses)
+		return
+	}
+
 	if jsonOutput {
 		jsonOut(synapses)
 		return
@@ -359,9 +1091,9 @@ func cmdList(args []string) {
 	}
 
 	if totalCount > len(synapses) {
-		fmt.Printf("Showing %d of %d synapse(s) (use --limit 0 for all):\n\n", len(synapses), totalCount)
+		fmt.Printf("%s\n\n", header("Showing %d of %d synapse(s) (use --limit 0 for all):", len(synapses), totalCount))
 	} else {
-		fmt.Printf("Found %d synapse(s):\n\n", len(synapses))
+		fmt.Printf("%s\n\n", header("Found %d synapse(s):", len(synapses)))
 	}
 
 	for _, syn := range synapses {
@@ -375,8 +1107,32 @@ func cmdList(args []string) {
 }
 
 func cmdReady(args []string) {
+	args, includeArchived := extractFlag(args, "--include-archived")
+	var assignee string
+	var filterAssignee bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--assignee":
+			if i+1 < len(args) {
+				i++
+				assignee = args[i]
+				filterAssignee = true
+			}
+		}
+	}
+
 	store := getStore()
-	ready := store.Ready()
+	var ready []*types.Synapse
+	switch {
+	case includeArchived && filterAssignee:
+		ready = storage.Intersect(store.ReadyWithArchived(), store.ByRole(assignee))
+	case includeArchived:
+		ready = store.ReadyWithArchived()
+	case filterAssignee:
+		ready = store.ReadyFor(assignee)
+	default:
+		ready = store.Ready()
+	}
 
 	if jsonOutput {
 		jsonOut(ready)
@@ -388,59 +1144,308 @@ func cmdReady(args []string) {
 		return
 	}
 
-	fmt.Printf("Ready tasks (%d):\n\n", len(ready))
+	fmt.Printf("%s\n\n", header("Ready tasks (%d):", len(ready)))
 	for _, syn := range ready {
 		printSynapse(syn)
 	}
 }
 
-func cmdGet(args []string) {
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: synapse ID required")
-		os.Exit(1)
+func cmdCount(args []string) {
+	var filter storage.CountFilter
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--status" && i+1 < len(args):
+			i++
+			filter.Status = types.Status(args[i])
+			if !filter.Status.IsValid() {
+				fail(codeUsage, "invalid status: %s", args[i])
+			}
+		case args[i] == "--label" && i+1 < len(args):
+			i++
+			filter.Label = args[i]
+		case args[i] == "--assignee" && i+1 < len(args):
+			i++
+			filter.Assignee = args[i]
+		case args[i] == "--unassigned":
+			filter.Unassigned = true
+		case args[i] == "--include-archived":
+			filter.IncludeArchived = true
+		default:
+			fail(codeUsage, "unknown flag or missing value: %s", args[i])
+		}
 	}
 
-	id, err := strconv.Atoi(args[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: invalid ID: %s\n", args[0])
-		os.Exit(1)
+	count := getStore().CountWhere(filter)
+	if jsonOutput {
+		jsonOut(map[string]int{"count": count})
+		return
 	}
+	fmt.Println(count)
+}
 
+func cmdOverdue() {
 	store := getStore()
-	syn, err := store.Get(id)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
-	}
+	overdue := store.Overdue()
 
 	if jsonOutput {
-		jsonOut(syn)
+		jsonOut(overdue)
 		return
 	}
 
-	printSynapseDetailed(syn)
+	if len(overdue) == 0 {
+		fmt.Println("No overdue tasks")
+		return
+	}
+
+	fmt.Printf("%s\n\n", header("Overdue tasks (%d):", len(overdue)))
+	now := time.Now()
+	for _, syn := range overdue {
+		fmt.Printf("%s [%s] #%d: %s\n", statusIcon(syn.Status), syn.Status, syn.ID, syn.Title)
+		fmt.Printf("   Due: %s (%s ago)\n", syn.DueAt.Local().Format("2006-01-02 15:04"), formatAge(now.Sub(*syn.DueAt)))
+		if syn.Assignee != "" {
+			fmt.Printf("   Assignee: %s\n", syn.Assignee)
+		}
+		fmt.Println()
+	}
 }
 
-func cmdClaim(args []string) {
+func cmdCriticalPath(args []string) {
+	args, byEstimate := extractFlag(args, "--estimates")
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: synapse ID required")
-		os.Exit(1)
+		fail(codeUsage, "usage: synapse critical-path <id> [--estimates]")
 	}
+	id := parseID(args[0])
 
-	id, err := strconv.Atoi(args[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: invalid ID: %s\n", args[0])
-		os.Exit(1)
+	store := getStore()
+	criticalPath := store.CriticalPath
+	if byEstimate {
+		criticalPath = store.CriticalPathByEstimate
+	}
+	chain, err := criticalPath(id)
+	if errors.Is(err, storage.ErrBlockerCycle) {
+		fail(codeConflict, "%v", err)
+	} else if err != nil {
+		failErr(err)
+	}
+
+	estimate := 0
+	for _, syn := range chain {
+		estimate += syn.EstimateMinutes
+	}
+
+	if jsonOutput {
+		jsonOut(map[string]any{"id": id, "path": chain, "steps": len(chain), "estimate_minutes": estimate})
+		return
+	}
+
+	fmt.Printf("%s\n\n", header("Critical path to #%d (%d step(s), %dm estimated):", id, len(chain), estimate))
+	for i, syn := range chain {
+		fmt.Printf("%3d. %s [%s] #%d: %s", i+1, statusIcon(syn.Status), syn.Status, syn.ID, syn.Title)
+		if syn.EstimateMinutes > 0 {
+			fmt.Printf(" (%dm)", syn.EstimateMinutes)
+		}
+		fmt.Println()
+	}
+}
+
+func cmdPlan(args []string) {
+	if len(args) > 0 {
+		fail(codeUsage, "usage: synapse plan")
+	}
+
+	store := getStore()
+	waves, err := store.PlanWaves()
+	if errors.Is(err, storage.ErrBlockerCycle) {
+		fail(codeConflict, "%v", err)
+	} else if err != nil {
+		failErr(err)
+	}
+
+	if jsonOutput {
+		if waves == nil {
+			waves = [][]*types.Synapse{}
+		}
+		jsonOut(map[string]any{"waves": waves})
+		return
+	}
+
+	if len(waves) == 0 {
+		fmt.Println("No unfinished tasks")
+		return
+	}
+	for i, wave := range waves {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(header("Wave %d (%d task(s)):", i+1, len(wave)))
+		for _, syn := range wave {
+			fmt.Printf("  %s [%s] #%d: %s", statusIcon(syn.Status), syn.Status, syn.ID, syn.Title)
+			if syn.Priority > 0 {
+				fmt.Printf(" P%d", syn.Priority)
+			}
+			if syn.Assignee != "" {
+				fmt.Printf(" %s", syn.Assignee)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// formatAge renders a duration coarsely, e.g. "3d", "5h" or "12m".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+}
+
+func cmdGet(args []string) {
+	args, discovered := extractFlag(args, "--discovered")
+	args, withContext := extractFlag(args, "--context")
+	var format string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) == 0 {
+		fail(codeUsage, "synapse ID required")
 	}
+	tmpl := parseFormat(format)
+
+	id := parseID(rest[0])
 
 	store := getStore()
 	syn, err := store.Get(id)
 	if err != nil {
+		failErr(err)
+	}
+
+	// The briefing is always JSON, sized like the get_task_context MCP tool
+	if withContext {
+		ctx, err := storage.BuildTaskContext(store, getBreadcrumbStore(), syn.ID, mcp.MaxResponseSize)
+		if err != nil {
+			failErr(err)
+		}
+		jsonOut(ctx)
+		return
+	}
+
+	if discovered {
+		found := store.DiscoveredFrom(syn.ID)
+		if tmpl != nil {
+			writeFormatted(tmpl, found)
+			return
+		}
+		if jsonOutput {
+			if found == nil {
+				found = []*types.Synapse{}
+			}
+			jsonOut(found)
+			return
+		}
+		if len(found) == 0 {
+			fmt.Printf("Nothing discovered from synapse #%d\n", syn.ID)
+			return
+		}
+		fmt.Printf("Discovered from synapse #%d (%d):\n\n", syn.ID, len(found))
+		for _, d := range found {
+			printSynapse(d)
+		}
+		return
+	}
+
+	if tmpl != nil {
+		writeFormatted(tmpl, []*types.Synapse{syn})
+		return
+	}
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+
+	printSynapseDetailed(syn)
+}
+
+// parseFormat parses a --format template, exiting on errors so they are
+// reported before any output. It returns nil if no format was given.
+func parseFormat(format string) *template.Template {
+	if format == "" {
+		return nil
+	}
+	tmpl, err := export.ParseFormat(format)
+	if err != nil {
+		fail(codeUsage, "%v", err)
+	}
+	return tmpl
+}
+
+// writeFormatted prints synapses through a --format template, one per line.
+func writeFormatted(tmpl *template.Template, synapses []*types.Synapse) {
+	if err := export.WriteFormatted(os.Stdout, tmpl, synapses); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+func cmdClaim(args []string) {
+	args, force := extractFlag(args, "--force")
+	args, steal := extractFlag(args, "--steal")
+	var agentID string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--agent" && i+1 < len(args):
+			i++
+			agentID = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) == 0 {
+		fail(codeUsage, "synapse ID required")
+	}
+	if steal && agentID == "" {
+		fail(codeUsage, "--steal requires --agent")
+	}
+
+	id := parseID(rest[0])
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		failErr(err)
+	}
 
-	syn.MarkInProgress()
+	checkTransition(store, syn, types.StatusInProgress, force)
+	var stolenFrom string
+	switch {
+	case steal:
+		var ok bool
+		if stolenFrom, ok = syn.StealClaim(agentID); !ok {
+			fail(codeConflict, "synapse #%d is done; its claim can't be taken over", id)
+		}
+	case agentID != "":
+		if !syn.Claim(agentID, store.ClaimTimeout()) {
+			failHint(codeConflict, "use --steal to take it over", "synapse #%d is claimed by %s", id, syn.ClaimedBy)
+		}
+	default:
+		// MarkInProgress records when work started so completion can
+		// measure the actual time; without an agent ID there is no claim to
+		// lock out MCP agents
+		syn.MarkInProgress()
+	}
+	updateSynapse(store, syn)
 	saveStore(store)
 
 	if jsonOutput {
@@ -449,10 +1454,155 @@ func cmdClaim(args []string) {
 	}
 
 	fmt.Printf("Claimed synapse #%d: %s\n", syn.ID, syn.Title)
+	if stolenFrom != "" && stolenFrom != agentID {
+		fmt.Printf("Claim taken over from %s\n", stolenFrom)
+	}
 	fmt.Printf("Status: %s\n", syn.Status)
 }
 
+func cmdRenew(args []string) {
+	var agentID string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--agent" && i+1 < len(args):
+			i++
+			agentID = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) == 0 || agentID == "" {
+		fail(codeUsage, "usage: synapse renew <id> --agent X")
+	}
+
+	id := parseID(rest[0])
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		failErr(err)
+	}
+
+	if !syn.Renew(agentID) {
+		if syn.ClaimedBy == "" {
+			fail(codeConflict, "synapse #%d is not claimed", id)
+		}
+		fail(codeConflict, "synapse #%d is claimed by %s, not %s", id, syn.ClaimedBy, agentID)
+	}
+	updateSynapse(store, syn)
+	saveStore(store)
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+
+	fmt.Printf("Renewed claim on synapse #%d: %s\n", syn.ID, syn.Title)
+}
+
 func cmdDone(args []string) {
+	args, force := extractFlag(args, "--force")
+	if len(args) == 0 {
+		fail(codeUsage, "synapse ID required")
+	}
+
+	id := parseID(args[0])
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		failErr(err)
+	}
+
+	checkTransition(store, syn, types.StatusDone, force)
+	unblocked, err := store.Complete(syn, "")
+	if err != nil {
+		failErr(err)
+	}
+	saveStore(store)
+
+	if jsonOutput {
+		if len(unblocked) == 0 {
+			jsonOut(syn)
+			return
+		}
+		data, err := syn.MarshalWith(map[string]any{"unblocked": unblocked})
+		if err != nil {
+			fail(codeInternal, "%v", err)
+		}
+		jsonOut(json.RawMessage(data))
+		return
+	}
+
+	fmt.Printf("Completed synapse #%d: %s\n", syn.ID, syn.Title)
+	for _, id := range unblocked {
+		if freed, err := store.Get(id); err == nil {
+			fmt.Printf("Unblocked synapse #%d: %s\n", freed.ID, freed.Title)
+		}
+	}
+}
+
+func cmdReview(args []string) {
+	args, force := extractFlag(args, "--force")
+	var reviewer, requestedBy string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--to":
+			if i+1 < len(args) {
+				i++
+				reviewer = args[i]
+			}
+		case "--by":
+			if i+1 < len(args) {
+				i++
+				requestedBy = args[i]
+			}
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) == 0 {
+		fmt.Fprintln(os.Stderr, "error: synapse ID required")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(rest[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid ID: %s\n", rest[0])
+		os.Exit(1)
+	}
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Default to the claiming agent, who is usually the one handing off
+	if requestedBy == "" {
+		requestedBy = syn.ClaimedBy
+	}
+
+	checkTransition(store, syn, types.StatusReview, force)
+	syn.RequestReview(requestedBy, reviewer)
+	updateSynapse(store, syn)
+	saveStore(store)
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+
+	fmt.Printf("Requested review of synapse #%d: %s\n", syn.ID, syn.Title)
+	if reviewer != "" {
+		fmt.Printf("  Assigned to %s\n", reviewer)
+	}
+}
+
+func cmdReopen(args []string) {
 	if len(args) == 0 {
 		fmt.Fprintln(os.Stderr, "error: synapse ID required")
 		os.Exit(1)
@@ -464,14 +1614,21 @@ func cmdDone(args []string) {
 		os.Exit(1)
 	}
 
-	store := getStore()
+	store := getLockedStore()
 	syn, err := store.Get(id)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	syn.MarkDone()
+	// open is reachable from every status, so no transition check is needed
+	syn.Status = types.StatusOpen
+	syn.CompletedBy = ""
+	syn.ClaimedBy = ""
+	syn.ClaimedAt = nil
+	syn.StartedAt = nil
+	syn.UpdatedAt = time.Now().UTC()
+	updateSynapse(store, syn)
 	saveStore(store)
 
 	if jsonOutput {
@@ -479,12 +1636,11 @@ func cmdDone(args []string) {
 		return
 	}
 
-	fmt.Printf("Completed synapse #%d: %s\n", syn.ID, syn.Title)
+	fmt.Printf("Reopened synapse #%d: %s\n", syn.ID, syn.Title)
 }
 
 func printSynapse(syn *types.Synapse) {
-	statusIcon := statusToIcon(syn.Status)
-	fmt.Printf("%s [%s] #%d: %s\n", statusIcon, syn.Status, syn.ID, syn.Title)
+	fmt.Printf("%s [%s] #%d: %s\n", statusIcon(syn.Status), syn.Status, syn.ID, syn.Title)
 	if syn.Assignee != "" {
 		fmt.Printf("   Assignee: %s\n", syn.Assignee)
 	}
@@ -495,9 +1651,12 @@ func printSynapse(syn *types.Synapse) {
 }
 
 func printSynapseDetailed(syn *types.Synapse) {
-	fmt.Printf("Synapse #%d\n", syn.ID)
+	fmt.Println(header("Synapse #%d", syn.ID))
 	fmt.Printf("  Title:       %s\n", syn.Title)
-	fmt.Printf("  Status:      %s %s\n", statusToIcon(syn.Status), syn.Status)
+	fmt.Printf("  Status:      %s %s\n", statusIcon(syn.Status), syn.Status)
+	if syn.Archived {
+		fmt.Println("  Archived:    yes")
+	}
 	if syn.Description != "" {
 		fmt.Printf("  Description: %s\n", syn.Description)
 	}
@@ -507,38 +1666,50 @@ func printSynapseDetailed(syn *types.Synapse) {
 	if syn.ParentID > 0 {
 		fmt.Printf("  Parent:      #%d\n", syn.ParentID)
 	}
+	if syn.DiscoveredFrom > 0 {
+		fmt.Printf("  Discovered:  from #%d\n", syn.DiscoveredFrom)
+	}
 	if len(syn.BlockedBy) > 0 {
 		fmt.Printf("  Blocked by:  %v\n", syn.BlockedBy)
 	}
+	if len(syn.RelatedTo) > 0 {
+		fmt.Printf("  Related:     %v\n", syn.RelatedTo)
+	}
+	if syn.DueAt != nil {
+		overdue := ""
+		if syn.IsOverdue(time.Now()) {
+			overdue = " (overdue)"
+		}
+		fmt.Printf("  Due:         %s%s\n", syn.DueAt.Local().Format("2006-01-02 15:04"), overdue)
+	}
+	if syn.EstimateMinutes > 0 {
+		fmt.Printf("  Estimate:    %dm\n", syn.EstimateMinutes)
+	}
+	if syn.ActualMinutes > 0 {
+		fmt.Printf("  Actual:      %dm\n", syn.ActualMinutes)
+	}
+	if syn.CreatedBy != "" {
+		fmt.Printf("  Created by:  %s\n", syn.CreatedBy)
+	}
 	fmt.Printf("  Created:     %s\n", syn.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("  Updated:     %s\n", syn.UpdatedAt.Format("2006-01-02 15:04:05"))
 }
 
-func statusToIcon(status types.Status) string {
-	switch status {
-	case types.StatusOpen:
-		return "○"
-	case types.StatusInProgress:
-		return "◐"
-	case types.StatusBlocked:
-		return "◌"
-	case types.StatusReview:
-		return "◑"
-	case types.StatusDone:
-		return "●"
-	default:
-		return "?"
+func cmdDoneAll(args []string) {
+	args, force := extractFlag(args, "--force")
+	if len(args) > 0 {
+		fail(codeUsage, "usage: synapse all-done [--force]")
 	}
-}
 
-func cmdDoneAll() {
-	store := getStore()
+	confirmDirty("all-done", force)
+	store := getLockedStore()
 	all := store.All()
 
 	count := 0
 	for _, syn := range all {
 		if syn.Status != types.StatusDone {
 			syn.MarkDone()
+			updateSynapse(store, syn)
 			count++
 		}
 	}
@@ -563,7 +1734,7 @@ func cmdDoneAll() {
 }
 
 func cmdDelete(args []string) {
-	store := getStore()
+	store := getLockedStore()
 
 	// Check for --all flag
 	if len(args) > 0 && args[0] == "--all" {
@@ -654,25 +1825,22 @@ func cmdDelete(args []string) {
 }
 
 func getBreadcrumbStore() *storage.BreadcrumbStore {
-	store := storage.NewBreadcrumbStore(storage.DefaultDir)
+	store := storage.NewBreadcrumbStore(dataDir())
 	if err := store.Load(); err != nil {
-		fmt.Fprintf(os.Stderr, "error loading breadcrumbs: %v\n", err)
-		os.Exit(1)
+		fail(codeInternal, "loading breadcrumbs: %v", err)
 	}
 	return store
 }
 
 func saveBreadcrumbStore(store *storage.BreadcrumbStore) {
 	if err := store.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "error saving breadcrumbs: %v\n", err)
-		os.Exit(1)
+		fail(codeInternal, "saving breadcrumbs: %v", err)
 	}
 }
 
 func cmdBreadcrumb(args []string) {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: subcommand required (set, get, list, delete)")
-		os.Exit(1)
+		fail(codeUsage, "subcommand required (set, get, list, namespaces, delete)")
 	}
 
 	subcmd := args[0]
@@ -685,19 +1853,18 @@ func cmdBreadcrumb(args []string) {
 		cmdBreadcrumbGet(subargs)
 	case "list", "ls":
 		cmdBreadcrumbList(subargs)
+	case "namespaces", "ns":
+		cmdBreadcrumbNamespaces()
 	case "delete", "rm":
 		cmdBreadcrumbDelete(subargs)
 	default:
-		fmt.Fprintf(os.Stderr, "error: unknown breadcrumb subcommand: %s\n", subcmd)
-		os.Exit(1)
+		fail(codeUsage, "unknown breadcrumb subcommand: %s", subcmd)
 	}
 }
 
 func cmdBreadcrumbSet(args []string) {
 	if len(args) < 2 {
-		fmt.Fprintln(os.Stderr, "error: key and value required")
-		fmt.Fprintln(os.Stderr, "usage: synapse breadcrumb set <key> <value> [--task-id N]")
-		os.Exit(1)
+		failHint(codeUsage, "synapse breadcrumb set <key> <value> [--task-id N]", "key and value required")
 	}
 
 	key := args[0]
@@ -710,12 +1877,7 @@ func cmdBreadcrumbSet(args []string) {
 		arg := args[i]
 		if arg == "--task-id" && i+1 < len(args) {
 			i++
-			id, err := strconv.Atoi(args[i])
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "error: invalid task ID: %s\n", args[i])
-				os.Exit(1)
-			}
-			taskID = id
+			taskID = parseID(args[i])
 		} else if !strings.HasPrefix(arg, "--") {
 			if value == "" {
 				value = arg
@@ -723,22 +1885,19 @@ func cmdBreadcrumbSet(args []string) {
 				value = value + " " + arg
 			}
 		} else {
-			fmt.Fprintf(os.Stderr, "error: unknown flag: %s\n", arg)
-			os.Exit(1)
+			fail(codeUsage, "unknown flag: %s", arg)
 		}
 		i++
 	}
 
 	if value == "" {
-		fmt.Fprintln(os.Stderr, "error: value required")
-		os.Exit(1)
+		fail(codeUsage, "value required")
 	}
 
 	store := getBreadcrumbStore()
 	_, err := store.Set(key, value, taskID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		fail(codeInternal, "%v", err)
 	}
 	saveBreadcrumbStore(store)
 
@@ -756,9 +1915,7 @@ func cmdBreadcrumbSet(args []string) {
 
 func cmdBreadcrumbGet(args []string) {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: key required")
-		fmt.Fprintln(os.Stderr, "usage: synapse breadcrumb get <key>")
-		os.Exit(1)
+		failHint(codeUsage, "synapse breadcrumb get <key>", "key required")
 	}
 
 	key := args[0]
@@ -766,8 +1923,7 @@ func cmdBreadcrumbGet(args []string) {
 
 	b, found := store.Get(key)
 	if !found {
-		fmt.Fprintf(os.Stderr, "breadcrumb not found: %s\n", key)
-		os.Exit(1)
+		fail(codeNotFound, "breadcrumb not found: %s", key)
 	}
 
 	if jsonOutput {
@@ -820,19 +1976,62 @@ func cmdBreadcrumbList(args []string) {
 	}
 }
 
+func cmdBreadcrumbNamespaces() {
+	store := getBreadcrumbStore()
+	namespaces := store.Namespaces()
+
+	if jsonOutput {
+		jsonOut(namespaces)
+		return
+	}
+
+	if len(namespaces) == 0 {
+		fmt.Println("No breadcrumbs found")
+		return
+	}
+
+	fmt.Printf("Namespaces (%d):\n\n", len(namespaces))
+	for _, ns := range namespaces {
+		fmt.Printf("  %-20s %d\n", ns.Name, ns.Count)
+	}
+}
+
 func cmdBreadcrumbDelete(args []string) {
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: key required")
-		fmt.Fprintln(os.Stderr, "usage: synapse breadcrumb delete <key>")
-		os.Exit(1)
+	const usage = "synapse breadcrumb delete <key> | --prefix <prefix> | --all"
+	var key, prefix string
+	var hasPrefix, all bool
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--prefix" && i+1 < len(args):
+			i++
+			prefix, hasPrefix = args[i], true
+		case args[i] == "--all":
+			all = true
+		case strings.HasPrefix(args[i], "--"):
+			fail(codeUsage, "unknown flag: %s", args[i])
+		default:
+			key = args[i]
+		}
+	}
+
+	switch {
+	case key != "" && (hasPrefix || all):
+		failHint(codeUsage, usage, "give a key or --prefix, not both")
+	case hasPrefix && all:
+		failHint(codeUsage, usage, "give --prefix or --all, not both")
+	case hasPrefix && prefix == "":
+		failHint(codeUsage, "use --all to delete every breadcrumb", "empty prefix")
+	case hasPrefix || all:
+		cmdBreadcrumbDeletePrefix(prefix)
+		return
+	case key == "":
+		failHint(codeUsage, usage, "key required")
 	}
 
-	key := args[0]
 	store := getBreadcrumbStore()
 
 	if !store.Delete(key) {
-		fmt.Fprintf(os.Stderr, "breadcrumb not found: %s\n", key)
-		os.Exit(1)
+		fail(codeNotFound, "breadcrumb not found: %s", key)
 	}
 
 	saveBreadcrumbStore(store)
@@ -844,19 +2043,204 @@ func cmdBreadcrumbDelete(args []string) {
 	fmt.Printf("Deleted breadcrumb: %s\n", key)
 }
 
-func cmdSkill(args []string) {
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "error: subcommand required (install, uninstall, list, update, show)")
-		os.Exit(1)
+// cmdBreadcrumbDeletePrefix deletes every breadcrumb under prefix, or all
+// of them if prefix is empty.
+func cmdBreadcrumbDeletePrefix(prefix string) {
+	store := getBreadcrumbStore()
+	count := store.DeletePrefix(prefix)
+	if count > 0 {
+		saveBreadcrumbStore(store)
 	}
 
-	subcmd := args[0]
-	subargs := args[1:]
-
-	switch subcmd {
-	case "install":
-		cmdSkillInstall(subargs)
-	case "uninstall":
+	if jsonOutput {
+		jsonOut(map[string]any{"prefix": prefix, "deleted": count})
+		return
+	}
+	if prefix == "" {
+		fmt.Printf("Deleted %d breadcrumb(s)\n", count)
+		return
+	}
+	fmt.Printf("Deleted %d breadcrumb(s) with prefix: %s\n", count, prefix)
+}
+
+func getTemplateStore() *storage.TemplateStore {
+	store := storage.NewTemplateStore(dataDir())
+	if err := store.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading templates: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+func saveTemplateStore(store *storage.TemplateStore) {
+	if err := store.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving templates: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdTemplate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: subcommand required (set, list, delete, instantiate)")
+		os.Exit(1)
+	}
+
+	subcmd := args[0]
+	subargs := args[1:]
+
+	switch subcmd {
+	case "set":
+		cmdTemplateSet(subargs)
+	case "list", "ls":
+		cmdTemplateList()
+	case "delete", "rm":
+		cmdTemplateDelete(subargs)
+	case "instantiate", "new":
+		cmdTemplateInstantiate(subargs)
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown template subcommand: %s\n", subcmd)
+		os.Exit(1)
+	}
+}
+
+func cmdTemplateSet(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		fmt.Fprintln(os.Stderr, "error: template name required")
+		fmt.Fprintln(os.Stderr, "usage: synapse template set <name> --title T [--description D] [--label X] [--assignee X] [--interval D]")
+		os.Exit(1)
+	}
+
+	tmpl := &types.Template{Name: args[0]}
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--title" && i+1 < len(args):
+			i++
+			tmpl.Title = args[i]
+		case args[i] == "--description" && i+1 < len(args):
+			i++
+			tmpl.Description = args[i]
+		case args[i] == "--label" && i+1 < len(args):
+			i++
+			tmpl.Labels = append(tmpl.Labels, args[i])
+		case args[i] == "--assignee" && i+1 < len(args):
+			i++
+			tmpl.Assignee = args[i]
+		case args[i] == "--interval" && i+1 < len(args):
+			i++
+			tmpl.Interval = args[i]
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag or missing value: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+
+	store := getTemplateStore()
+	created, err := store.Set(tmpl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	saveTemplateStore(store)
+
+	if jsonOutput {
+		jsonOut(tmpl)
+		return
+	}
+
+	if created {
+		fmt.Printf("Created template %s: %s\n", tmpl.Name, tmpl.Title)
+	} else {
+		fmt.Printf("Updated template %s: %s\n", tmpl.Name, tmpl.Title)
+	}
+}
+
+func cmdTemplateList() {
+	templates := getTemplateStore().List()
+
+	if jsonOutput {
+		jsonOut(templates)
+		return
+	}
+
+	if len(templates) == 0 {
+		fmt.Println("No templates found")
+		return
+	}
+
+	fmt.Printf("Templates (%d):\n\n", len(templates))
+	for _, tmpl := range templates {
+		fmt.Printf("  %s: %s\n", tmpl.Name, tmpl.Title)
+		if tmpl.Interval != "" {
+			fmt.Printf("    Every: %s\n", tmpl.Interval)
+		}
+		if tmpl.Assignee != "" {
+			fmt.Printf("    Assignee: %s\n", tmpl.Assignee)
+		}
+		if tmpl.LastInstantiated != nil {
+			fmt.Printf("    Last used: %s\n", tmpl.LastInstantiated.Local().Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func cmdTemplateDelete(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: template name required")
+		os.Exit(1)
+	}
+
+	store := getTemplateStore()
+	if !store.Delete(args[0]) {
+		fmt.Fprintf(os.Stderr, "error: template not found: %s\n", args[0])
+		os.Exit(1)
+	}
+	saveTemplateStore(store)
+
+	if jsonOutput {
+		jsonOut(map[string]any{"deleted": args[0]})
+		return
+	}
+	fmt.Printf("Deleted template %s\n", args[0])
+}
+
+func cmdTemplateInstantiate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: template name required")
+		os.Exit(1)
+	}
+
+	store := getLockedStore()
+	templates := getTemplateStore()
+	syn, err := templates.Instantiate(args[0], store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	saveStore(store)
+	saveTemplateStore(templates)
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+	fmt.Printf("Created synapse #%d from template %s: %s\n", syn.ID, args[0], syn.Title)
+	if syn.DueAt != nil {
+		fmt.Printf("Due: %s\n", syn.DueAt.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+func cmdSkill(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: subcommand required (install, uninstall, list, update, show)")
+		os.Exit(1)
+	}
+
+	subcmd := args[0]
+	subargs := args[1:]
+
+	switch subcmd {
+	case "install":
+		cmdSkillInstall(subargs)
+	case "uninstall":
 		cmdSkillUninstall(subargs)
 	case "list", "ls":
 		cmdSkillList()
@@ -1027,10 +2411,23 @@ func cmdSkillShow() {
 	fmt.Print(content)
 }
 
-func cmdServe() {
+func cmdServe(args []string) {
+	args, verbose := extractFlag(args, "--verbose")
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "error: unknown flag: %s\n", args[0])
+		os.Exit(1)
+	}
+
 	store := getStore()
 	bcStore := getBreadcrumbStore()
 	server := mcp.NewServer(store, bcStore)
+	server.SetVersion(version)
+	server.SetDefaultAssignee(getConfig().DefaultAssignee)
+	server.SetDefaultAgent(os.Getenv("SYNAPSE_AGENT"))
+	server.SetDefaultSession(sessionID)
+	if verbose {
+		server.SetLogLevel("debug")
+	}
 	if err := server.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -1038,10 +2435,17 @@ func cmdServe() {
 }
 
 func cmdView(args []string) {
-	port := 8080
+	args, includeArchived := extractFlag(args, "--include-archived")
+	port := getConfig().ViewPort
+	host := view.DefaultHost
+	autoReload := true
+	authToken := os.Getenv("SYNAPSE_VIEW_TOKEN")
+	var corsOrigins []string
+	var exportFormat, outputPath string
 
 	for i := 0; i < len(args); i++ {
-		if args[i] == "--port" && i+1 < len(args) {
+		switch {
+		case args[i] == "--port" && i+1 < len(args):
 			i++
 			p, err := strconv.Atoi(args[i])
 			if err != nil {
@@ -1049,14 +2453,1381 @@ func cmdView(args []string) {
 				os.Exit(1)
 			}
 			port = p
+		case args[i] == "--host" && i+1 < len(args):
+			i++
+			host = args[i]
+		case args[i] == "--no-reload":
+			autoReload = false
+		case args[i] == "--auth-token" && i+1 < len(args):
+			i++
+			authToken = args[i]
+		case args[i] == "--cors-origin" && i+1 < len(args):
+			i++
+			corsOrigins = append(corsOrigins, strings.Split(args[i], ",")...)
+		case args[i] == "--export" && i+1 < len(args):
+			i++
+			exportFormat = args[i]
+		case args[i] == "--output" && i+1 < len(args):
+			i++
+			outputPath = args[i]
 		}
 	}
 
 	store := getStore()
+	theme, err := graph.LoadTheme(dataDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if exportFormat != "" {
+		synapses := store.All()
+		if !includeArchived {
+			synapses = storage.Unarchived(synapses)
+		}
+		viewExport(synapses, view.ReadyIDs(store), theme, exportFormat, outputPath)
+		return
+	}
+	if outputPath != "" {
+		fmt.Fprintf(os.Stderr, "error: --output requires --export\n")
+		os.Exit(1)
+	}
+
 	server := view.NewServer(store, port)
-	fmt.Printf("Starting visualization at http://localhost:%d\n", port)
+	server.SetHost(host)
+	server.SetAutoReload(autoReload)
+	server.SetIncludeArchived(includeArchived)
+	server.SetTheme(theme)
+	server.SetAuthToken(authToken)
+	server.SetCORSOrigins(corsOrigins)
+	server.SetVersion(version)
+	fmt.Printf("Starting visualization at http://%s\n", server.Addr())
 	if err := server.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// viewExport renders the task graph once and writes it to outputPath, or to
+// stdout if outputPath is empty. Mermaid output is drawn with theme and
+// flags the tasks in ready.
+func viewExport(synapses []*types.Synapse, ready map[int]bool, theme graph.Theme, format, outputPath string) {
+	var out string
+	switch format {
+	case "mermaid", "mmd":
+		out = view.GenerateThemedMermaid(synapses, ready, theme) + "\n"
+	case "dot":
+		out = export.ExportDOT(synapses)
+	default:
+		fmt.Fprintf(os.Stderr, "error: unsupported graph format: %s (must be 'mermaid' or 'dot')\n", format)
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := os.WriteFile(outputPath, []byte(out), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if jsonOutput {
+		jsonOut(map[string]any{"format": format, "output": outputPath, "tasks": len(synapses)})
+		return
+	}
+	fmt.Printf("Wrote %s graph of %d task(s) to %s\n", format, len(synapses), outputPath)
+}
+
+func cmdExport(args []string) {
+	format := "markdown"
+	var statusFilter string
+	var assigneeFilter string
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		case args[i] == "--status" && i+1 < len(args):
+			i++
+			statusFilter = args[i]
+		case args[i] == "--assignee" && i+1 < len(args):
+			i++
+			assigneeFilter = args[i]
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag or missing value: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+
+	if statusFilter != "" && !types.Status(statusFilter).IsValid() {
+		fmt.Fprintf(os.Stderr, "error: invalid status: %s\n", statusFilter)
+		fmt.Fprintf(os.Stderr, "valid statuses: open, in-progress, blocked, review, done\n")
+		os.Exit(1)
+	}
+
+	store := getStore()
+	var synapses []*types.Synapse
+	for _, syn := range store.All() {
+		if statusFilter != "" && syn.Status != types.Status(statusFilter) {
+			continue
+		}
+		if assigneeFilter != "" && syn.Assignee != assigneeFilter {
+			continue
+		}
+		synapses = append(synapses, syn)
+	}
+
+	switch format {
+	case "markdown", "md":
+		fmt.Print(export.ExportMarkdown(synapses))
+	case "dot":
+		fmt.Print(export.ExportDOT(synapses))
+	case "csv":
+		if err := export.ExportCSV(os.Stdout, synapses); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "error: unsupported export format: %s (must be 'markdown', 'dot', or 'csv')\n", format)
+		os.Exit(1)
+	}
+}
+
+func cmdImport(args []string) {
+	var path string
+	var dryRun bool
+	var githubRepo string
+	var token string
+	var state string
+	var force bool
+	var opts importer.Options
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--link-existing":
+			opts.LinkExisting = true
+		case arg == "--force":
+			force = true
+		case arg == "--github" && i+1 < len(args):
+			i++
+			githubRepo = args[i]
+		case arg == "--token" && i+1 < len(args):
+			i++
+			token = args[i]
+		case arg == "--state" && i+1 < len(args):
+			i++
+			state = args[i]
+		case !strings.HasPrefix(arg, "--") && path == "":
+			path = arg
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag or missing value: %s\n", arg)
+			os.Exit(1)
+		}
+	}
+
+	if (path == "") == (githubRepo == "") {
+		fmt.Fprintln(os.Stderr, "error: provide either a file or --github owner/repo")
+		fmt.Fprintln(os.Stderr, "usage: synapse import <file> [--link-existing] [--dry-run] [--force]")
+		fmt.Fprintln(os.Stderr, "       synapse import --github owner/repo [--token T] [--state open|closed|all] [--link-existing] [--dry-run] [--force]")
+		os.Exit(1)
+	}
+
+	var incoming []*types.Synapse
+	var err error
+	if githubRepo != "" {
+		if state != "" && state != "open" && state != "closed" && state != "all" {
+			fmt.Fprintf(os.Stderr, "error: invalid state: %s (must be 'open', 'closed', or 'all')\n", state)
+			os.Exit(1)
+		}
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		var src importer.Source = &importer.GitHubSource{Repo: githubRepo, Token: token, State: state}
+		incoming, err = src.Fetch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", src.Name(), err)
+			os.Exit(1)
+		}
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		incoming, err = importer.Parse(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !dryRun {
+		confirmDirty("import", force)
+	}
+	store := getLockedStore()
+	plan, err := importer.Prepare(store, incoming, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !dryRun {
+		if err := importer.Apply(store, plan); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		saveStore(store)
+	}
+
+	if jsonOutput {
+		jsonOut(map[string]any{
+			"imported": len(plan.Mappings),
+			"dry_run":  dryRun,
+			"mappings": plan.Mappings,
+		})
+		return
+	}
+
+	if len(plan.Mappings) == 0 {
+		fmt.Println("No tasks to import")
+		return
+	}
+
+	if dryRun {
+		fmt.Printf("Would import %d task(s):\n\n", len(plan.Mappings))
+	} else {
+		fmt.Printf("Imported %d task(s):\n\n", len(plan.Mappings))
+	}
+	for _, m := range plan.Mappings {
+		if m.SourceID > 0 {
+			fmt.Printf("  %d -> #%d: %s\n", m.SourceID, m.NewID, m.Title)
+		} else {
+			fmt.Printf("  (no id) -> #%d: %s\n", m.NewID, m.Title)
+		}
+	}
+}
+
+func cmdLog(args []string) {
+	var filter eventFilter
+	follow := false
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--task" && i+1 < len(args):
+			i++
+			filter.taskID = parseID(args[i])
+		case args[i] == "--agent" && i+1 < len(args):
+			i++
+			filter.agent = args[i]
+		case args[i] == "--follow" || args[i] == "-f":
+			follow = true
+		default:
+			fail(codeUsage, "unknown flag or missing value: %s", args[i])
+		}
+	}
+
+	eventLog := storage.NewEventLog(dataDir())
+	if follow {
+		followLog(eventLog, filter)
+		return
+	}
+
+	all, err := eventLog.All()
+	if err != nil {
+		fail(codeInternal, "reading event log: %v", err)
+	}
+	events := []types.Event{}
+	for _, e := range all {
+		if filter.matches(e) {
+			events = append(events, e)
+		}
+	}
+
+	if jsonOutput {
+		jsonOut(events)
+		return
+	}
+
+	if len(events) == 0 {
+		fmt.Println("No events recorded")
+		return
+	}
+
+	for _, e := range events {
+		printEvent(e)
+	}
+}
+
+// eventFilter selects audit events for `synapse log`.
+type eventFilter struct {
+	taskID int
+	agent  string
+}
+
+func (f eventFilter) matches(e types.Event) bool {
+	return (f.taskID == 0 || e.TaskID == f.taskID) && (f.agent == "" || e.Agent == f.agent)
+}
+
+// followLog prints the events already in the log, then polls for new ones
+// like tail -f until interrupted. In --json mode each event is printed as
+// one line of JSON. If the log is truncated or replaced by a new file, it
+// is read again from the start.
+func followLog(eventLog *storage.EventLog, filter eventFilter) {
+	path := filepath.Join(dataDir(), storage.EventsFile)
+	info, _ := os.Stat(path)
+	var offset int64
+
+	for {
+		events, next, err := eventLog.ReadFrom(offset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: reading event log: %v\n", err)
+		}
+		offset = next
+		for _, e := range events {
+			if !filter.matches(e) {
+				continue
+			}
+			if jsonOutput {
+				data, _ := json.Marshal(e)
+				fmt.Println(string(data))
+			} else {
+				printEvent(e)
+			}
+		}
+
+		time.Sleep(500 * time.Millisecond)
+
+		// ReadFrom notices a log that shrank, but not one replaced by a
+		// file at least as long, so compare file identity too
+		cur, err := os.Stat(path)
+		if info != nil && (err != nil || !os.SameFile(info, cur)) {
+			offset = 0
+		}
+		info = cur
+	}
+}
+
+// printEvent prints an audit event on one line.
+func printEvent(e types.Event) {
+	detail := e.From + e.To
+	if e.From != "" && e.To != "" {
+		detail = e.From + " -> " + e.To
+	}
+	if e.Agent != "" && e.Agent != e.To {
+		detail += " (" + e.Agent + ")"
+	}
+	fmt.Printf("%s  #%-4d %-10s %s\n", e.At.Local().Format("2006-01-02 15:04:05"), e.TaskID, e.Event, detail)
+}
+
+func cmdBlame(args []string) {
+	if len(args) == 0 {
+		fail(codeUsage, "usage: synapse blame <id>")
+	}
+	id := parseID(args[0])
+
+	store := getStore()
+	p, err := storage.BuildProvenance(store, id)
+	if err != nil {
+		failErr(err)
+	}
+
+	if jsonOutput {
+		jsonOut(p)
+		return
+	}
+
+	syn := p.Task
+	const stamp = "2006-01-02 15:04:05"
+	indent := strings.Repeat(" ", len(stamp)+2)
+	fmt.Printf("%s\n\n", header("#%d: %s", syn.ID, syn.Title))
+
+	created := "Created"
+	if syn.CreatedBy != "" {
+		created += " by " + syn.CreatedBy
+	}
+	fmt.Printf("%s  %s\n", syn.CreatedAt.Local().Format(stamp), created)
+	if ref := p.DiscoveredFrom; ref != nil {
+		fmt.Printf("%sDiscovered while working on %s\n", indent, formatTaskRef(*ref))
+	}
+	if len(p.Parents) > 0 {
+		chain := make([]string, len(p.Parents))
+		for i, parent := range p.Parents {
+			chain[i] = formatTaskRef(parent)
+		}
+		fmt.Printf("%sUnder %s\n", indent, strings.Join(chain, " < "))
+	}
+
+	// The audit log has the full history; tasks from before it existed
+	// only have their claim and completion fields to go on
+	for _, e := range p.Events {
+		if e.Event == types.EventCreated {
+			continue
+		}
+		fmt.Printf("%s  %s\n", e.At.Local().Format(stamp), describeEvent(e))
+	}
+	if len(p.Events) == 0 {
+		if syn.ClaimedBy != "" && syn.ClaimedAt != nil {
+			fmt.Printf("%s  Claimed by %s\n", syn.ClaimedAt.Local().Format(stamp), syn.ClaimedBy)
+		}
+		if syn.Status == types.StatusDone {
+			done := "Completed"
+			if syn.CompletedBy != "" {
+				done += " by " + syn.CompletedBy
+			}
+			fmt.Printf("%s  %s\n", syn.UpdatedAt.Local().Format(stamp), done)
+		}
+	}
+
+	if len(syn.Notes) > 0 {
+		fmt.Println("\nNotes, oldest first:")
+		for i, note := range syn.Notes {
+			fmt.Printf("  %d. %s\n", i+1, note)
+		}
+	}
+	if len(p.Discovered) > 0 {
+		fmt.Println("\nLed to:")
+		for _, ref := range p.Discovered {
+			fmt.Printf("  %s\n", formatTaskRef(ref))
+		}
+	}
+}
+
+func cmdSession(args []string) {
+	session := sessionID
+	if len(args) > 0 {
+		session = args[0]
+	}
+	if session == "" {
+		fail(codeUsage, "usage: synapse session <id> (or set --session / SYNAPSE_SESSION)")
+	}
+
+	store := getStore()
+	tasks, err := store.BySession(session)
+	if err != nil {
+		failErr(err)
+	}
+	events, err := store.Events().ForSession(session)
+	if err != nil {
+		failErr(err)
+	}
+
+	if jsonOutput {
+		if tasks == nil {
+			tasks = []*types.Synapse{}
+		}
+		if events == nil {
+			events = []types.Event{}
+		}
+		jsonOut(map[string]any{"session_id": session, "tasks": tasks, "events": events})
+		return
+	}
+
+	if len(tasks) == 0 && len(events) == 0 {
+		fmt.Printf("Nothing recorded in session %s\n", session)
+		return
+	}
+
+	fmt.Printf("%s\n\n", header("Session %s (%d event(s)):", session, len(events)))
+	for _, e := range events {
+		printEvent(e)
+	}
+
+	fmt.Printf("\n%s\n\n", header("Tasks touched (%d):", len(tasks)))
+	for _, syn := range tasks {
+		fmt.Printf("%s [%s] #%d: %s\n", statusIcon(syn.Status), syn.Status, syn.ID, syn.Title)
+	}
+}
+
+func cmdDiff(args []string) {
+	var ref string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--since" && i+1 < len(args):
+			i++
+			ref = args[i]
+		default:
+			fail(codeUsage, "unknown flag or missing value: %s", args[i])
+		}
+	}
+	if ref == "" {
+		fail(codeUsage, "usage: synapse diff --since <ref>")
+	}
+
+	git := storage.NewGitIntegration()
+	if git == nil {
+		fail(codeUsage, "synapse diff needs a Git repository")
+	}
+	memPath, err := git.RelPath(filepath.Join(dataDir(), storage.MemoryFile))
+	if err != nil {
+		failErr(err)
+	}
+
+	// A board that didn't exist yet at ref is empty: every task is new
+	before := storage.NewJSONLStore(dataDir())
+	data, err := git.ReadFileAtRef(ref, memPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fail(codeUsage, "%v", err)
+	}
+	if err := before.LoadFrom(bytes.NewReader(data)); err != nil {
+		fail(codeInternal, "reading %s at %s: %v", memPath, ref, err)
+	}
+
+	diff, err := storage.DiffBoards(before.All(), getStore().All())
+	if err != nil {
+		failErr(err)
+	}
+
+	if jsonOutput {
+		jsonOut(diff)
+		return
+	}
+
+	if diff.IsEmpty() {
+		fmt.Printf("No changes since %s\n", ref)
+		return
+	}
+	fmt.Println(header("Changes since %s: %d added, %d removed, %d changed", ref, len(diff.Added), len(diff.Removed), len(diff.Changed)))
+	for _, syn := range diff.Added {
+		fmt.Printf("  + #%d: %s [%s]\n", syn.ID, syn.Title, syn.Status)
+	}
+	for _, syn := range diff.Removed {
+		fmt.Printf("  - #%d: %s [%s]\n", syn.ID, syn.Title, syn.Status)
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("  ~ #%d: %s\n", change.ID, change.Title)
+		for _, field := range change.Fields {
+			fmt.Printf("      %s: %s -> %s\n", field.Field, diffValue(field.From), diffValue(field.To))
+		}
+	}
+}
+
+// diffValue renders a field value for synapse diff, or "(unset)".
+func diffValue(v json.RawMessage) string {
+	if len(v) == 0 {
+		return "(unset)"
+	}
+	return string(v)
+}
+
+// formatTaskRef renders a task reference as "#N: title", or notes that
+// the task no longer exists.
+func formatTaskRef(ref storage.TaskRef) string {
+	if ref.Missing {
+		return fmt.Sprintf("#%d (deleted)", ref.ID)
+	}
+	return fmt.Sprintf("#%d: %s", ref.ID, ref.Title)
+}
+
+// describeEvent renders an audit event as a sentence for blame.
+func describeEvent(e types.Event) string {
+	var text string
+	switch e.Event {
+	case types.EventClaimed:
+		text = "Claimed by " + e.To
+		if e.From != "" {
+			text += " (taken from " + e.From + ")"
+		}
+		return text
+	case types.EventReleased:
+		return "Released by " + e.From
+	case types.EventCompleted:
+		text = "Completed"
+	case types.EventStatus:
+		text = "Moved from " + e.From + " to " + e.To
+	case types.EventUpdated:
+		text = "Edited"
+	default:
+		text = string(e.Event)
+	}
+	if e.Agent != "" {
+		text += " by " + e.Agent
+	}
+	return text
+}
+
+func cmdEdit(args []string) {
+	var rest []string
+	var dueSet bool
+	var dueAt *time.Time
+	var related, unrelated []int
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--related" && i+1 < len(args):
+			i++
+			related = append(related, parseID(args[i]))
+		case args[i] == "--unrelated" && i+1 < len(args):
+			i++
+			unrelated = append(unrelated, parseID(args[i]))
+		case args[i] == "--due" && i+1 < len(args):
+			i++
+			dueSet = true
+			if args[i] != "none" {
+				due, err := storage.ParseDue(args[i], time.Now())
+				if err != nil {
+					fail(codeUsage, "%v", err)
+				}
+				dueAt = &due
+			}
+		case !strings.HasPrefix(args[i], "--"):
+			rest = append(rest, args[i])
+		default:
+			fail(codeUsage, "unknown flag or missing value: %s", args[i])
+		}
+	}
+	if len(rest) != 1 || (!dueSet && len(related) == 0 && len(unrelated) == 0) {
+		fail(codeUsage, "usage: synapse edit <id> [--due <date|none>] [--related N] [--unrelated N]")
+	}
+
+	id := parseID(rest[0])
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		failErr(err)
+	}
+	for _, relatedID := range related {
+		if relatedID == id {
+			fail(codeUsage, "synapse #%d can't be related to itself", id)
+		}
+		if _, err := store.Get(relatedID); err != nil {
+			fail(codeNotFound, "related task: %v", err)
+		}
+		syn.AddRelated(relatedID)
+	}
+	for _, relatedID := range unrelated {
+		syn.RemoveRelated(relatedID)
+	}
+	if dueSet {
+		syn.DueAt = dueAt
+	}
+	syn.UpdatedAt = time.Now().UTC()
+	updateSynapse(store, syn)
+	saveStore(store)
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+
+	fmt.Printf("Updated synapse #%d: %s\n", syn.ID, syn.Title)
+	switch {
+	case !dueSet:
+	case syn.DueAt != nil:
+		fmt.Printf("  Due: %s\n", syn.DueAt.Local().Format("2006-01-02 15:04"))
+	default:
+		fmt.Println("  No due date")
+	}
+	if len(related) > 0 || len(unrelated) > 0 {
+		fmt.Printf("  Related: %v\n", syn.RelatedTo)
+	}
+}
+
+func cmdMove(args []string) {
+	args, subtree := extractFlag(args, "--subtree")
+	parentID := -1
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--under" && i+1 < len(args):
+			i++
+			parentID = parseID(args[i])
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if len(rest) == 0 || parentID < 0 {
+		fail(codeUsage, "usage: synapse move <id> --under <parent> [--subtree]")
+	}
+
+	id := parseID(rest[0])
+
+	store := getLockedStore()
+	result, err := store.Move(id, parentID, subtree)
+	if errors.Is(err, storage.ErrParentCycle) {
+		fail(codeConflict, "%v", err)
+	} else if err != nil {
+		failErr(err)
+	}
+	saveStore(store)
+
+	if jsonOutput {
+		jsonOut(result)
+		return
+	}
+
+	if parentID == 0 {
+		fmt.Printf("Moved synapse #%d to the top level", id)
+	} else {
+		fmt.Printf("Moved synapse #%d under #%d", id, parentID)
+	}
+	if n := len(result.Moved) - 1; n > 0 {
+		fmt.Printf(" with %d descendant(s)", n)
+	}
+	fmt.Println()
+	if len(result.Reparented) > 0 {
+		to := "the top level"
+		if result.OldParentID != 0 {
+			to = fmt.Sprintf("#%d", result.OldParentID)
+		}
+		fmt.Printf("  Children %v moved up to %s\n", result.Reparented, to)
+	}
+	fmt.Println()
+	printTree(result.Tree, "", "")
+}
+
+// printTree prints node and its subtasks as an indented tree. prefix
+// starts node's own line; indent starts the lines below it.
+func printTree(node *storage.TreeNode, prefix, indent string) {
+	fmt.Printf("%s%s #%d: %s\n", prefix, statusIcon(node.Status), node.ID, node.Title)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			printTree(child, indent+"└── ", indent+"    ")
+		} else {
+			printTree(child, indent+"├── ", indent+"│   ")
+		}
+	}
+}
+
+func cmdArchive(args []string, archive bool) {
+	verb := "archive"
+	if !archive {
+		verb = "unarchive"
+	}
+	if len(args) == 0 {
+		fail(codeUsage, "usage: synapse %s <id>", verb)
+	}
+
+	id := parseID(args[0])
+
+	store := getLockedStore()
+	syn, err := store.Get(id)
+	if err != nil {
+		failErr(err)
+	}
+
+	if archive {
+		syn.MarkArchived()
+	} else {
+		syn.Unarchive()
+	}
+	updateSynapse(store, syn)
+	saveStore(store)
+
+	if jsonOutput {
+		jsonOut(syn)
+		return
+	}
+
+	if archive {
+		fmt.Printf("Archived synapse #%d: %s\n", syn.ID, syn.Title)
+	} else {
+		fmt.Printf("Unarchived synapse #%d: %s\n", syn.ID, syn.Title)
+	}
+}
+
+func cmdCompact(args []string) {
+	var opts storage.CompactOptions
+	olderThanSet := false
+	rewrite := false
+	force := false
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--older-than" && i+1 < len(args):
+			i++
+			d, err := storage.ParseAge(args[i])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: invalid --older-than: %v\n", err)
+				os.Exit(1)
+			}
+			opts.OlderThan = d
+			olderThanSet = true
+		case args[i] == "--status" && i+1 < len(args):
+			i++
+			opts.Status = types.Status(args[i])
+			if !opts.Status.IsValid() {
+				fmt.Fprintf(os.Stderr, "error: invalid status: %s\n", args[i])
+				os.Exit(1)
+			}
+		case args[i] == "--archive":
+			opts.Archive = true
+		case args[i] == "--dry-run":
+			opts.DryRun = true
+		case args[i] == "--rewrite":
+			rewrite = true
+		case args[i] == "--force":
+			force = true
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag or missing value: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+
+	if !olderThanSet && !rewrite {
+		fmt.Fprintln(os.Stderr, "error: --older-than or --rewrite is required (e.g. --older-than 30d)")
+		os.Exit(1)
+	}
+
+	if !opts.DryRun {
+		confirmDirty("compact", force)
+	}
+	store := getLockedStore()
+	result := &storage.CompactResult{DryRun: opts.DryRun, RemovedIDs: []int{}, SkippedIDs: []int{}}
+	if olderThanSet {
+		var err error
+		if result, err = store.Compact(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if !opts.DryRun && result.Removed > 0 {
+		saveStore(store)
+	}
+	if rewrite && !opts.DryRun {
+		if err := store.Rewrite(); err != nil {
+			fail(codeInternal, "rewriting store: %v", err)
+		}
+		result.Rewritten = true
+	}
+
+	if jsonOutput {
+		jsonOut(result)
+		return
+	}
+
+	if olderThanSet {
+		verb := "Removed"
+		if opts.DryRun {
+			verb = "Would remove"
+		}
+		fmt.Printf("%s %d task(s)", verb, result.Removed)
+		if result.Archived > 0 {
+			fmt.Printf(", archived to %s", storage.ArchiveFile)
+		}
+		fmt.Println()
+		if len(result.RemovedIDs) > 0 {
+			fmt.Printf("  Tasks: %v\n", result.RemovedIDs)
+		}
+		if result.Skipped > 0 {
+			fmt.Printf("  Kept %d still blocking other tasks: %v\n", result.Skipped, result.SkippedIDs)
+		}
+	}
+	if result.Rewritten {
+		fmt.Printf("Rewrote %s in canonical form\n", storage.MemoryFile)
+	}
+}
+
+func cmdDoctor(args []string) {
+	args, fix := extractFlag(args, "--fix")
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "error: unknown flag: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	var store *storage.JSONLStore
+	if fix {
+		store = getLockedStore()
+	} else {
+		store = getStore()
+	}
+	issues, err := store.Diagnose(storage.DefaultChecks, fix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	remaining, fixed := 0, 0
+	for _, issue := range issues {
+		if issue.Fixed {
+			fixed++
+		} else {
+			remaining++
+		}
+	}
+	if fixed > 0 {
+		saveStore(store)
+	}
+
+	if jsonOutput {
+		jsonOut(map[string]any{"issues": issues, "fixed": fixed, "remaining": remaining})
+	} else if len(issues) == 0 {
+		fmt.Println("No issues found")
+	} else {
+		for _, issue := range issues {
+			status := ""
+			if issue.Fixed {
+				status = " (fixed)"
+			} else if issue.Fixable {
+				status = " (fixable with --fix)"
+			}
+			fmt.Printf("[%s] %s%s\n", issue.Check, issue.Message, status)
+		}
+		fmt.Printf("\n%d issue(s) found, %d fixed\n", len(issues), fixed)
+	}
+
+	if remaining > 0 {
+		os.Exit(1)
+	}
+}
+
+func cmdConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: subcommand required (get, set, list)")
+		os.Exit(1)
+	}
+
+	subcmd := args[0]
+	subargs := args[1:]
+
+	switch subcmd {
+	case "get":
+		cmdConfigGet(subargs)
+	case "set":
+		cmdConfigSet(subargs)
+	case "list", "ls":
+		cmdConfigList()
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown config subcommand: %s\n", subcmd)
+		os.Exit(1)
+	}
+}
+
+func cmdConfigGet(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: synapse config get <key>")
+		os.Exit(1)
+	}
+
+	value, err := getConfig().Get(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		jsonOut(map[string]string{"key": args[0], "value": value})
+		return
+	}
+	fmt.Println(value)
+}
+
+func cmdConfigSet(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: synapse config set <key> <value>")
+		os.Exit(1)
+	}
+	key, value := args[0], args[1]
+
+	// Only the file's own values are written back, never the environment
+	dir := storage.ConfigDir()
+	cfg, err := storage.LoadConfig(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Set(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Save(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		jsonOut(cfg)
+		return
+	}
+	fmt.Printf("Set %s = %s\n", key, value)
+}
+
+func cmdConfigList() {
+	cfg, sources, err := storage.ResolveConfig(storage.ConfigDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	type entry struct {
+		Key    string `json:"key"`
+		Value  string `json:"value"`
+		Source string `json:"source"` // env, file, or default
+		Env    string `json:"env"`
+	}
+	entries := make([]entry, 0, len(storage.ConfigSettings))
+	for _, setting := range storage.ConfigSettings {
+		value, _ := cfg.Get(setting.Key)
+		entries = append(entries, entry{Key: setting.Key, Value: value, Source: sources[setting.Key], Env: setting.Env})
+	}
+
+	if jsonOutput {
+		jsonOut(entries)
+		return
+	}
+	for _, e := range entries {
+		fmt.Printf("%-17s %-12s (%s, $%s)\n", e.Key, e.Value, e.Source, e.Env)
+	}
+}
+
+func cmdRole(args []string) {
+	if len(args) == 0 {
+		fail(codeUsage, "subcommand required (list, add, rm)")
+	}
+
+	dir := dataDir()
+	roles, err := storage.LoadRoles(dir)
+	if err != nil {
+		fail(codeInternal, "loading roles: %v", err)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		if jsonOutput {
+			jsonOut(roles)
+			return
+		}
+		if len(roles) == 0 {
+			fmt.Println("No roles defined")
+			return
+		}
+		for _, name := range roles.Names() {
+			fmt.Printf("%s: %s\n", name, strings.Join(roles[name], ", "))
+		}
+		return
+	case "add":
+		if len(args) < 3 {
+			fail(codeUsage, "usage: synapse role add <role> <agent>...")
+		}
+		roles.Add(args[1], args[2:]...)
+	case "rm", "remove":
+		if len(args) < 2 {
+			fail(codeUsage, "usage: synapse role rm <role> [agent]...")
+		}
+		if !roles.Remove(args[1], args[2:]...) {
+			fail(codeNotFound, "nothing to remove from role %s", args[1])
+		}
+	default:
+		fail(codeUsage, "unknown role subcommand: %s", args[0])
+	}
+
+	if err := roles.Save(dir); err != nil {
+		fail(codeInternal, "saving roles: %v", err)
+	}
+
+	role := args[1]
+	members, ok := roles[role]
+	if jsonOutput {
+		if members == nil {
+			members = []string{}
+		}
+		jsonOut(map[string]any{"role": role, "members": members})
+		return
+	}
+	if ok {
+		fmt.Printf("%s: %s\n", role, strings.Join(members, ", "))
+	} else {
+		fmt.Printf("Removed role %s\n", role)
+	}
+}
+
+func cmdWebhook(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "error: subcommand required (test)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "test":
+		cmdWebhookTest(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown webhook subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func cmdWebhookTest(args []string) {
+	var urls []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--url" && i+1 < len(args):
+			i++
+			urls = append(urls, args[i])
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+	if len(urls) == 0 {
+		hooks, err := storage.LoadWebhooks(dataDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, hook := range hooks {
+			urls = append(urls, hook.URL)
+		}
+	}
+	if len(urls) == 0 {
+		fmt.Fprintf(os.Stderr, "error: no webhooks configured in %s/%s\n", dataDir(), storage.WebhooksFile)
+		os.Exit(1)
+	}
+
+	notifier := storage.NewWebhookNotifier(nil)
+	payload := storage.WebhookPayload{
+		Event:     storage.EventTest,
+		Timestamp: time.Now().UTC(),
+		Text:      "Test notification from synapse",
+	}
+	type result struct {
+		URL   string `json:"url"`
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}
+	results := make([]result, 0, len(urls))
+	failed := 0
+	for _, url := range urls {
+		r := result{URL: url, OK: true}
+		if err := notifier.Send(url, payload); err != nil {
+			r.OK, r.Error = false, err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	if jsonOutput {
+		jsonOut(results)
+	} else {
+		for _, r := range results {
+			if r.OK {
+				fmt.Printf("ok    %s\n", r.URL)
+			} else {
+				fmt.Printf("FAIL  %s: %s\n", r.URL, r.Error)
+			}
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func cmdSnapshot(args []string) {
+	var name string
+	force, list := false, false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--name" && i+1 < len(args):
+			i++
+			name = args[i]
+		case args[i] == "--force":
+			force = true
+		case args[i] == "--list":
+			list = true
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag or missing value: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+
+	if list {
+		snapshots, err := storage.ListSnapshots(dataDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if jsonOutput {
+			jsonOut(snapshots)
+			return
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots")
+			return
+		}
+		for _, snap := range snapshots {
+			fmt.Printf("%-24s %s  %s\n", snap.Name, snap.CreatedAt.Local().Format("2006-01-02 15:04:05"), strings.Join(snap.Files, ", "))
+		}
+		return
+	}
+
+	// Hold the lock so a concurrent save can't land between the two copies
+	getLockedStore()
+	snap, err := storage.CreateSnapshot(dataDir(), name, force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		jsonOut(snap)
+		return
+	}
+	fmt.Printf("Created snapshot %s (%s)\n", snap.Name, strings.Join(snap.Files, ", "))
+	fmt.Printf("Restore it with: synapse restore %s\n", snap.Name)
+}
+
+func cmdResolve(args []string) {
+	args, yes := extractFlag(args, "--yes")
+	args, keepLatest := extractFlag(args, "--keep-latest")
+	args, renumber := extractFlag(args, "--renumber")
+	if len(args) > 0 || (keepLatest && renumber) {
+		fail(codeUsage, "usage: synapse resolve [--keep-latest | --renumber] [--yes]")
+	}
+	strategy := storage.ResolveAuto
+	if keepLatest {
+		strategy = storage.ResolveKeepLatest
+	} else if renumber {
+		strategy = storage.ResolveRenumber
+	}
+
+	// Load can't read a file with conflict markers, so read it by hand
+	store := lockStore()
+	data, err := os.ReadFile(filepath.Join(dataDir(), storage.MemoryFile))
+	if err != nil {
+		fail(codeInternal, "reading store: %v", err)
+	}
+	cleaned, markers := storage.StripConflictMarkers(data)
+	if err := store.LoadFrom(bytes.NewReader(cleaned)); err != nil {
+		failLoad(err)
+	}
+	resolutions, err := store.ResolveDuplicates(strategy)
+	if err != nil {
+		failErr(err)
+	}
+
+	if markers == 0 && len(resolutions) == 0 {
+		if jsonOutput {
+			jsonOut(map[string]any{"markers_removed": 0, "resolved": resolutions})
+			return
+		}
+		fmt.Println("Nothing to resolve")
+		return
+	}
+
+	if !jsonOutput {
+		if markers > 0 {
+			fmt.Printf("Remove %d conflict marker line(s), keeping both sides\n", markers)
+		}
+		for _, res := range resolutions {
+			fmt.Printf("#%d: %s (%d versions): keep the latest", res.ID, res.Title, res.Versions)
+			if len(res.Renumbered) > 0 {
+				fmt.Printf(", renumber %d as %v", len(res.Renumbered), res.Renumbered)
+			}
+			if res.Dropped > 0 {
+				fmt.Printf(", drop %d", res.Dropped)
+			}
+			fmt.Println()
+		}
+	}
+	if !yes {
+		fmt.Print("Rewrite memory.jsonl? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted")
+			os.Exit(1)
+		}
+	}
+
+	saveStore(store)
+	if jsonOutput {
+		jsonOut(map[string]any{"markers_removed": markers, "resolved": resolutions})
+		return
+	}
+	fmt.Println("Resolved")
+}
+
+func cmdRestore(args []string) {
+	args, yes := extractFlag(args, "--yes")
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: synapse restore <name> [--yes]")
+		os.Exit(1)
+	}
+	name := args[0]
+
+	getLockedStore()
+	if !yes {
+		fmt.Printf("Replace current tasks and breadcrumbs with snapshot %s? [y/N] ", name)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted")
+			os.Exit(1)
+		}
+	}
+
+	snap, err := storage.RestoreSnapshot(dataDir(), name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		jsonOut(snap)
+		return
+	}
+	fmt.Printf("Restored snapshot %s\n", snap.Name)
+}
+
+func cmdWorkload(args []string) {
+	if len(args) > 0 {
+		fail(codeUsage, "usage: synapse workload")
+	}
+
+	store := getStore()
+	workloads := store.WorkloadByAssignee()
+
+	if jsonOutput {
+		jsonOut(workloads)
+		return
+	}
+
+	if len(workloads) == 0 {
+		fmt.Println("No tasks")
+		return
+	}
+	fmt.Printf("%-20s %6s %12s %8s %7s %6s %7s\n", "ASSIGNEE", "OPEN", "IN-PROGRESS", "BLOCKED", "REVIEW", "DONE", "CLAIMS")
+	for _, w := range workloads {
+		assignee := w.Assignee
+		if assignee == "" {
+			assignee = "(unassigned)"
+		}
+		fmt.Printf("%-20s %6d %12d %8d %7d %6d %7d\n", assignee, w.Open, w.InProgress, w.Blocked, w.Review, w.Done, w.ActiveClaims)
+	}
+}
+
+func cmdStats(args []string) {
+	args, effort := extractFlag(args, "--effort")
+	if len(args) > 0 || !effort {
+		fmt.Fprintln(os.Stderr, "usage: synapse stats --effort")
+		os.Exit(1)
+	}
+
+	store := getStore()
+	stats := store.EffortStats()
+
+	if jsonOutput {
+		jsonOut(stats)
+		return
+	}
+
+	if stats.Measured == 0 {
+		fmt.Println("No done tasks with both an estimate and a measured time")
+	} else {
+		fmt.Printf("Effort across %d measured task(s):\n", stats.Measured)
+		fmt.Printf("  Estimated:     %dm\n", stats.EstimateSum)
+		fmt.Printf("  Actual:        %dm (%.2fx estimate)\n", stats.ActualSum, stats.Ratio)
+		fmt.Printf("  Within 25%%:    %d/%d\n", stats.WithinTarget, stats.Measured)
+		fmt.Println()
+		for _, task := range stats.Tasks {
+			fmt.Printf("  #%-4d %5dm est %5dm actual  %.2fx  %s\n", task.ID, task.EstimateMinutes, task.ActualMinutes, task.Ratio, task.Title)
+		}
+	}
+	if stats.Unestimated > 0 {
+		fmt.Printf("\n%d done task(s) had no estimate\n", stats.Unestimated)
+	}
+	if stats.Unmeasured > 0 {
+		fmt.Printf("%d estimated task(s) were completed without being claimed, so took no measurable time\n", stats.Unmeasured)
+	}
+}
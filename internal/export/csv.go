@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// CSVHeader lists the columns written by ExportCSV, in order.
+var CSVHeader = []string{
+	"id", "title", "status", "priority", "assignee", "parent_id",
+	"blocked_by", "labels", "created_at", "updated_at",
+}
+
+// ExportCSV writes synapses as CSV suitable for spreadsheet import.
+// Multi-valued columns (blocked_by, labels) are semicolon-joined, and
+// fields containing commas or quotes are quoted by encoding/csv.
+func ExportCSV(w io.Writer, synapses []*types.Synapse) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	for _, syn := range synapses {
+		blockers := make([]string, len(syn.BlockedBy))
+		for i, id := range syn.BlockedBy {
+			blockers[i] = strconv.Itoa(id)
+		}
+
+		parentID := ""
+		if syn.ParentID > 0 {
+			parentID = strconv.Itoa(syn.ParentID)
+		}
+
+		record := []string{
+			strconv.Itoa(syn.ID),
+			syn.Title,
+			string(syn.Status),
+			strconv.Itoa(syn.Priority),
+			syn.Assignee,
+			parentID,
+			strings.Join(blockers, ";"),
+			strings.Join(syn.Labels, ";"),
+			syn.CreatedAt.Format(time.RFC3339),
+			syn.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ExportDOT renders synapses as a Graphviz DOT digraph using the same
+// node, edge, and coloring semantics as the Mermaid view: solid edges for
+// BlockedBy, dashed edges for ParentID, thin gray undirected edges for
+// RelatedTo, and fill colors by status.
+func ExportDOT(synapses []*types.Synapse) string {
+	var sb strings.Builder
+	sb.WriteString("digraph synapse {\n")
+	sb.WriteString("    rankdir=TB;\n")
+	sb.WriteString("    node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+
+	if len(synapses) == 0 {
+		sb.WriteString("    empty [label=\"No tasks yet\", fillcolor=\"#FFFFFF\"];\n")
+		sb.WriteString("}\n")
+		return sb.String()
+	}
+
+	g := graph.Build(synapses)
+
+	sb.WriteString("\n")
+	for _, node := range g.Nodes {
+		sb.WriteString(fmt.Sprintf("    %d [label=\"%s\", fillcolor=\"%s\"];\n",
+			node.ID, escapeForDOT(node.Label), node.Fill))
+	}
+
+	if len(g.Edges) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, edge := range g.Edges {
+		switch edge.Kind {
+		case graph.EdgeParent:
+			sb.WriteString(fmt.Sprintf("    %d -> %d [style=dashed];\n", edge.From, edge.To))
+		case graph.EdgeRelated:
+			sb.WriteString(fmt.Sprintf("    %d -> %d [dir=none, color=\"%s\", penwidth=0.5, constraint=false];\n",
+				edge.From, edge.To, graph.RelatedStroke))
+		default:
+			sb.WriteString(fmt.Sprintf("    %d -> %d;\n", edge.From, edge.To))
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// escapeForDOT escapes a string for use inside a double-quoted DOT ID.
+func escapeForDOT(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,220 @@
+package export
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func testSynapses() []*types.Synapse {
+	syn1 := types.NewSynapse(1, "Setup project")
+	syn1.Status = types.StatusDone
+
+	syn2 := types.NewSynapse(2, "Implement MCP")
+	syn2.Status = types.StatusInProgress
+	syn2.Assignee = "@coder"
+	syn2.BlockedBy = []int{1}
+	syn2.Notes = []string{"Started on handlers"}
+
+	syn3 := types.NewSynapse(3, "Add visualization")
+	syn3.Status = types.StatusBlocked
+	syn3.ParentID = 1
+	syn3.BlockedBy = []int{2, 99}
+
+	return []*types.Synapse{syn1, syn2, syn3}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	md := ExportMarkdown(testSynapses())
+
+	checks := []string{
+		"# Synapse Report",
+		"| done | 1 |",
+		"| in-progress | 1 |",
+		"| blocked | 1 |",
+		"| **Total** | 3 |",
+		`<a id="task-2"></a>`,
+		"### #2: Implement MCP",
+		"- **Assignee:** @coder",
+		"- **Blocked by:** [#1](#task-1)",
+		"- **Parent:** [#1](#task-1)",
+		"- Started on handlers",
+	}
+	for _, want := range checks {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q", want)
+		}
+	}
+
+	// Blockers missing from the report are not linked
+	if !strings.Contains(md, "- **Blocked by:** [#2](#task-2), #99") {
+		t.Error("expected unknown blocker to render without a link")
+	}
+}
+
+func TestExportMarkdown_Empty(t *testing.T) {
+	md := ExportMarkdown(nil)
+
+	if !strings.Contains(md, "| **Total** | 0 |") {
+		t.Error("expected zero total in summary")
+	}
+	if !strings.Contains(md, "_No tasks yet._") {
+		t.Error("expected empty task message")
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Normal task", "Normal task"},
+		{"Fix *bold* bug", `Fix \*bold\* bug`},
+		{"snake_case name", `snake\_case name`},
+		{"[link]", `\[link\]`},
+		{"a | b", `a \| b`},
+	}
+
+	for _, tt := range tests {
+		result := escapeMarkdown(tt.input)
+		if result != tt.expected {
+			t.Errorf("escapeMarkdown(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	dot := ExportDOT(testSynapses())
+
+	checks := []string{
+		"digraph synapse {",
+		`1 [label="#1: Setup project", fillcolor="#90EE90"];`,
+		`2 [label="#2: Implement MCP", fillcolor="#FFFFE0"];`,
+		`3 [label="#3: Add visualization", fillcolor="#D3D3D3"];`,
+		"1 -> 2;",
+		"2 -> 3;",
+		"1 -> 3 [style=dashed];",
+	}
+	for _, want := range checks {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected DOT to contain %q", want)
+		}
+	}
+
+	// Edges to tasks outside the export are dropped
+	if strings.Contains(dot, "99") {
+		t.Error("expected edge to unknown blocker to be dropped")
+	}
+}
+
+func TestExportDOT_Related(t *testing.T) {
+	synapses := testSynapses()
+	synapses[0].RelatedTo = []int{3}
+	dot := ExportDOT(synapses)
+
+	want := `1 -> 3 [dir=none, color="#999999", penwidth=0.5, constraint=false];`
+	if !strings.Contains(dot, want) {
+		t.Errorf("expected DOT to contain %q", want)
+	}
+}
+
+func TestExportDOT_Empty(t *testing.T) {
+	dot := ExportDOT(nil)
+	if !strings.Contains(dot, "No tasks yet") {
+		t.Error("expected empty graph message")
+	}
+}
+
+func TestEscapeForDOT(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Task with "quotes"`, `Task with \"quotes\"`},
+		{`back\slash`, `back\\slash`},
+		{"Normal task", "Normal task"},
+	}
+
+	for _, tt := range tests {
+		result := escapeForDOT(tt.input)
+		if result != tt.expected {
+			t.Errorf("escapeForDOT(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	synapses := testSynapses()
+	synapses[1].Title = `Implement "MCP", fast`
+	synapses[1].Labels = []string{"backend", "api"}
+
+	var buf strings.Builder
+	if err := ExportCSV(&buf, synapses); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV output: %v", err)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected header + 3 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(CSVHeader, ",") {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+
+	row := records[2]
+	if row[1] != `Implement "MCP", fast` {
+		t.Errorf("title not round-tripped: %q", row[1])
+	}
+	if row[4] != "@coder" {
+		t.Errorf("assignee = %q, want @coder", row[4])
+	}
+	if row[6] != "1" {
+		t.Errorf("blocked_by = %q, want 1", row[6])
+	}
+	if row[7] != "backend;api" {
+		t.Errorf("labels = %q, want backend;api", row[7])
+	}
+
+	row = records[3]
+	if row[5] != "1" {
+		t.Errorf("parent_id = %q, want 1", row[5])
+	}
+	if row[6] != "2;99" {
+		t.Errorf("blocked_by = %q, want 2;99", row[6])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if _, err := ParseFormat("{{.ID} {{.Title}}"); err == nil {
+		t.Error("expected error for bad syntax")
+	}
+	_, err := ParseFormat("{{.ID}} {{.Bogus}}")
+	if err == nil || !strings.Contains(err.Error(), "Bogus") {
+		t.Errorf("expected error naming the unknown field, got %v", err)
+	}
+}
+
+func TestWriteFormatted(t *testing.T) {
+	synapses := testSynapses()
+	synapses[1].Labels = []string{"mcp", "core"}
+
+	tmpl, err := ParseFormat(`{{.ID}} {{icon .Status}} {{truncate 8 .Title}} {{join .Labels ","}}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var buf strings.Builder
+	if err := WriteFormatted(&buf, tmpl, synapses[:2]); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := "1 " + types.StatusDone.Icon() + " Setup pr... \n" +
+		"2 " + types.StatusInProgress.Icon() + " Implemen... mcp,core\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// FormatFuncs are the helpers available to --format templates:
+//
+//	icon .Status        status icon, e.g. ◐
+//	truncate 20 .Title  keep the first 20 characters, adding "..." if cut
+//	join .Labels ","    join a string list
+var FormatFuncs = template.FuncMap{
+	"icon":     func(s types.Status) string { return s.Icon() },
+	"truncate": func(n int, s string) string { return graph.TruncateTitle(s, n) },
+	"join":     func(elems []string, sep string) string { return strings.Join(elems, sep) },
+}
+
+// ParseFormat parses a text/template evaluated once per synapse, such as
+// "{{.ID}} {{.Status}} {{.Title}}". The template is also run against an
+// empty synapse, so references to unknown fields fail here rather than
+// partway through the output.
+func ParseFormat(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(FormatFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, &types.Synapse{}); err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return tmpl, nil
+}
+
+// WriteFormatted executes tmpl for each synapse, one per line.
+func WriteFormatted(w io.Writer, tmpl *template.Template, synapses []*types.Synapse) error {
+	for _, syn := range synapses {
+		if err := tmpl.Execute(w, syn); err != nil {
+			return fmt.Errorf("format synapse %d: %w", syn.ID, err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
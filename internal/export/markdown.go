@@ -0,0 +1,123 @@
+// Package export renders Synapse tasks into formats suitable for sharing
+// outside the CLI (Markdown reports, spreadsheets, graph tools).
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ExportMarkdown renders synapses as a single Markdown report: a status
+// summary table followed by one section per task. Blockers and parents are
+// rendered as links to the corresponding task sections.
+func ExportMarkdown(synapses []*types.Synapse) string {
+	var sb strings.Builder
+	sb.WriteString("# Synapse Report\n\n")
+
+	// Status summary
+	counts := make(map[types.Status]int)
+	for _, syn := range synapses {
+		counts[syn.Status]++
+	}
+
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("| Status | Count |\n")
+	sb.WriteString("|--------|-------|\n")
+	for _, status := range types.ValidStatuses() {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", status, counts[status]))
+	}
+	sb.WriteString(fmt.Sprintf("| **Total** | %d |\n\n", len(synapses)))
+
+	sb.WriteString("## Tasks\n\n")
+	if len(synapses) == 0 {
+		sb.WriteString("_No tasks yet._\n")
+		return sb.String()
+	}
+
+	// Only link to tasks that have a section in this report
+	known := make(map[int]bool, len(synapses))
+	for _, syn := range synapses {
+		known[syn.ID] = true
+	}
+
+	for _, syn := range synapses {
+		sb.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n", taskAnchor(syn.ID)))
+		sb.WriteString(fmt.Sprintf("### #%d: %s\n\n", syn.ID, escapeMarkdown(syn.Title)))
+
+		sb.WriteString(fmt.Sprintf("- **Status:** %s\n", syn.Status))
+		if syn.Priority != 0 {
+			sb.WriteString(fmt.Sprintf("- **Priority:** %d\n", syn.Priority))
+		}
+		if syn.Assignee != "" {
+			sb.WriteString(fmt.Sprintf("- **Assignee:** %s\n", escapeMarkdown(syn.Assignee)))
+		}
+		if syn.ParentID > 0 {
+			sb.WriteString(fmt.Sprintf("- **Parent:** %s\n", taskLink(syn.ParentID, known)))
+		}
+		if len(syn.BlockedBy) > 0 {
+			links := make([]string, len(syn.BlockedBy))
+			for i, id := range syn.BlockedBy {
+				links[i] = taskLink(id, known)
+			}
+			sb.WriteString(fmt.Sprintf("- **Blocked by:** %s\n", strings.Join(links, ", ")))
+		}
+		if len(syn.Labels) > 0 {
+			sb.WriteString(fmt.Sprintf("- **Labels:** %s\n", escapeMarkdown(strings.Join(syn.Labels, ", "))))
+		}
+		if syn.ClaimedBy != "" {
+			sb.WriteString(fmt.Sprintf("- **Claimed by:** %s\n", escapeMarkdown(syn.ClaimedBy)))
+		}
+		if syn.CompletedBy != "" {
+			sb.WriteString(fmt.Sprintf("- **Completed by:** %s\n", escapeMarkdown(syn.CompletedBy)))
+		}
+		sb.WriteString(fmt.Sprintf("- **Updated:** %s\n", syn.UpdatedAt.Format("2006-01-02 15:04:05")))
+
+		if syn.Description != "" {
+			sb.WriteString("\n")
+			sb.WriteString(syn.Description)
+			sb.WriteString("\n")
+		}
+
+		if len(syn.Notes) > 0 {
+			sb.WriteString("\n**Notes:**\n\n")
+			for _, note := range syn.Notes {
+				sb.WriteString(fmt.Sprintf("- %s\n", strings.ReplaceAll(note, "\n", " ")))
+			}
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// taskAnchor returns the HTML anchor ID used for a task's section.
+func taskAnchor(id int) string {
+	return fmt.Sprintf("task-%d", id)
+}
+
+// taskLink renders a reference to another task, linking to its section when
+// that task is part of the report.
+func taskLink(id int, known map[int]bool) string {
+	if !known[id] {
+		return fmt.Sprintf("#%d", id)
+	}
+	return fmt.Sprintf("[#%d](#%s)", id, taskAnchor(id))
+}
+
+// escapeMarkdown escapes characters that would otherwise be interpreted as
+// inline Markdown formatting in titles and short fields.
+func escapeMarkdown(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"`", "\\`",
+		`*`, `\*`,
+		`_`, `\_`,
+		`[`, `\[`,
+		`]`, `\]`,
+		`|`, `\|`,
+	)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"slices"
+	"sort"
+)
+
+// Reachable returns the IDs reachable from from by repeatedly following
+// next, e.g. parent, child or blocker links, not counting from itself. Each
+// ID is visited once, so the walk ends even if the links already loop.
+func Reachable(from int, next func(id int) []int) map[int]bool {
+	found := make(map[int]bool)
+	stack := slices.Clone(next(from))
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == from || found[id] {
+			continue
+		}
+		found[id] = true
+		stack = append(stack, next(id)...)
+	}
+	return found
+}
+
+// Reaches reports whether to can be reached from from by repeatedly
+// following next. It is the cycle check for adding a link that points from
+// to at from: the new link closes a cycle exactly when from already reaches
+// to (or they are the same task).
+func Reaches(from, to int, next func(id int) []int) bool {
+	return from == to || Reachable(from, next)[to]
+}
+
+// Cycle returns the IDs on the cycle closed when a walk along path reaches
+// id, already on path, again, in ascending order.
+func Cycle(path []int, id int) []int {
+	cycle := slices.Clone(path[slices.Index(path, id):])
+	sort.Ints(cycle)
+	return cycle
+}
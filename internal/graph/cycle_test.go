@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReaches(t *testing.T) {
+	// 3 -> 2 -> 1, and a pre-existing loop 4 <-> 5
+	links := map[int][]int{2: {1}, 3: {2}, 4: {5}, 5: {4}}
+	next := func(id int) []int { return links[id] }
+
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{3, 1, true},
+		{1, 3, false},
+		{2, 2, true}, // A task always reaches itself
+		{4, 1, false},
+		{4, 5, true},
+	}
+	for _, tt := range tests {
+		if got := Reaches(tt.from, tt.to, next); got != tt.want {
+			t.Errorf("Reaches(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	// Children: 1 -> 2 -> 3, and a pre-existing loop 4 -> 5 -> 4
+	links := map[int][]int{1: {2}, 2: {3}, 4: {5}, 5: {4}}
+	next := func(id int) []int { return links[id] }
+
+	if got := Reachable(1, next); len(got) != 2 || !got[2] || !got[3] {
+		t.Errorf("Reachable(1) = %v, want 2 and 3", got)
+	}
+	if got := Reachable(3, next); len(got) != 0 {
+		t.Errorf("Reachable(3) = %v, want none", got)
+	}
+	// The walk ends at the loop and leaves out the starting task
+	if got := Reachable(4, next); len(got) != 1 || !got[5] {
+		t.Errorf("Reachable(4) = %v, want only 5", got)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	if got := Cycle([]int{7, 3, 9, 5}, 3); !slices.Equal(got, []int{3, 5, 9}) {
+		t.Errorf("Cycle = %v, want [3 5 9]", got)
+	}
+}
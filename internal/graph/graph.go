@@ -0,0 +1,227 @@
+// Package graph builds a renderer-independent model of the Synapse task DAG.
+// Emitters (Mermaid, Graphviz DOT) consume the same model so that node
+// labels, edges, and status colors stay consistent across output formats.
+package graph
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// MaxLabelTitle is the maximum number of title characters shown in a node label.
+const MaxLabelTitle = 40
+
+// EdgeKind distinguishes the relationship an edge represents.
+type EdgeKind int
+
+const (
+	// EdgeBlocks points from a blocker to the task it blocks (solid).
+	EdgeBlocks EdgeKind = iota
+	// EdgeParent points from a parent to its child task (dashed).
+	EdgeParent
+	// EdgeRelated is an undirected "see also" link (thin gray).
+	EdgeRelated
+)
+
+// Node is a single task in the graph.
+type Node struct {
+	ID        int
+	Label     string // Unescaped display label, e.g. "#3: Add visualization"
+	Status    types.Status
+	Fill      string // Fill color derived from Status
+	ClaimedBy string // Agent holding the claim, if any
+	Overdue   bool   // Unfinished and past its due date
+}
+
+// Edge is a directed relationship between two tasks.
+type Edge struct {
+	From int
+	To   int
+	Kind EdgeKind
+}
+
+// Graph is the renderer-independent DAG model.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// StatusColors maps each status to its node fill color.
+var StatusColors = map[types.Status]string{
+	types.StatusOpen:       "#FFFFFF",
+	types.StatusInProgress: "#FFFFE0",
+	types.StatusBlocked:    "#D3D3D3",
+	types.StatusReview:     "#87CEEB",
+	types.StatusDone:       "#90EE90",
+}
+
+// ClaimStroke is the border color marking tasks claimed by an agent.
+const ClaimStroke = "#1565C0"
+
+// RelatedStroke is the line color for EdgeRelated links.
+const RelatedStroke = "#999999"
+
+// OverdueStroke is the border color marking overdue tasks. It takes
+// precedence over ClaimStroke.
+const OverdueStroke = "#C62828"
+
+// CriticalStroke is the line color marking the blocker edges on a
+// critical path.
+const CriticalStroke = "#E65100"
+
+// DefaultFill is used for statuses without an entry in StatusColors.
+const DefaultFill = "#FFFFFF"
+
+// StatusColor returns the fill color for a status.
+func StatusColor(status types.Status) string {
+	if color, ok := StatusColors[status]; ok {
+		return color
+	}
+	return DefaultFill
+}
+
+// Build creates the graph model from synapses. Nodes keep the input order.
+// Blocker edges are emitted before parent edges, then related edges (one
+// per linked pair, even if both tasks list each other). Edges referencing
+// tasks outside the input set are dropped.
+func Build(synapses []*types.Synapse) *Graph {
+	g := &Graph{
+		Nodes: make([]Node, 0, len(synapses)),
+	}
+
+	// Create a map for quick lookup
+	known := make(map[int]bool, len(synapses))
+	for _, syn := range synapses {
+		known[syn.ID] = true
+	}
+
+	now := time.Now()
+	for _, syn := range synapses {
+		g.Nodes = append(g.Nodes, Node{
+			ID:        syn.ID,
+			Label:     fmt.Sprintf("#%d: %s", syn.ID, TruncateTitle(syn.Title, MaxLabelTitle)),
+			Status:    syn.Status,
+			Fill:      StatusColor(syn.Status),
+			ClaimedBy: syn.ClaimedBy,
+			Overdue:   syn.IsOverdue(now),
+		})
+	}
+
+	// Edges for BlockedBy relationships (blocker -> blocked)
+	for _, syn := range synapses {
+		for _, blockerID := range syn.BlockedBy {
+			if known[blockerID] {
+				g.Edges = append(g.Edges, Edge{From: blockerID, To: syn.ID, Kind: EdgeBlocks})
+			}
+		}
+	}
+
+	// Edges for ParentID relationships (parent -> child)
+	for _, syn := range synapses {
+		if syn.ParentID > 0 && known[syn.ParentID] {
+			g.Edges = append(g.Edges, Edge{From: syn.ParentID, To: syn.ID, Kind: EdgeParent})
+		}
+	}
+
+	// Edges for RelatedTo links, deduplicated as unordered pairs
+	linked := make(map[[2]int]bool)
+	for _, syn := range synapses {
+		for _, relatedID := range syn.RelatedTo {
+			pair := [2]int{min(syn.ID, relatedID), max(syn.ID, relatedID)}
+			if !known[relatedID] || relatedID == syn.ID || linked[pair] {
+				continue
+			}
+			linked[pair] = true
+			g.Edges = append(g.Edges, Edge{From: syn.ID, To: relatedID, Kind: EdgeRelated})
+		}
+	}
+
+	return g
+}
+
+// ReadySet returns the IDs of synapses that are ready to start, using the
+// same rule as JSONLStore.Ready: not in progress, in review, or done, and
+// every blocker is a done task within synapses. Blockers outside synapses
+// count as not done, so for a filtered slice of a store, ask the store.
+func ReadySet(synapses []*types.Synapse) map[int]bool {
+	done := make(map[int]bool, len(synapses))
+	for _, syn := range synapses {
+		if syn.Status == types.StatusDone {
+			done[syn.ID] = true
+		}
+	}
+
+	ready := make(map[int]bool)
+	for _, syn := range synapses {
+		if syn.IsReady(func(id int) bool { return done[id] }) {
+			ready[syn.ID] = true
+		}
+	}
+	return ready
+}
+
+// TruncateTitle shortens a title to maxLen characters.
+func TruncateTitle(title string, maxLen int) string {
+	if len(title) <= maxLen {
+		return title
+	}
+	return title[:maxLen] + "..."
+}
+
+// Subgraph returns the root task, its transitive children (via ParentID),
+// and every task transitively blocking any of those, in the input order.
+// It returns nil if rootID is not among synapses.
+func Subgraph(synapses []*types.Synapse, rootID int) []*types.Synapse {
+	byID := make(map[int]*types.Synapse, len(synapses))
+	children := make(map[int][]int)
+	for _, syn := range synapses {
+		byID[syn.ID] = syn
+		if syn.ParentID > 0 {
+			children[syn.ParentID] = append(children[syn.ParentID], syn.ID)
+		}
+	}
+
+	if _, ok := byID[rootID]; !ok {
+		return nil
+	}
+
+	included := map[int]bool{rootID: true}
+
+	// Walk down the parent hierarchy
+	queue := []int{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if !included[child] {
+				included[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	// Walk up the blocker chains of everything in the subtree
+	for id := range included {
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, blocker := range byID[id].BlockedBy {
+			if _, exists := byID[blocker]; exists && !included[blocker] {
+				included[blocker] = true
+				queue = append(queue, blocker)
+			}
+		}
+	}
+
+	result := make([]*types.Synapse, 0, len(included))
+	for _, syn := range synapses {
+		if included[syn.ID] {
+			result = append(result, syn)
+		}
+	}
+	return result
+}
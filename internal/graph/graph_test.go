@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestBuild(t *testing.T) {
+	syn1 := types.NewSynapse(1, "Setup project")
+	syn1.Status = types.StatusDone
+
+	syn2 := types.NewSynapse(2, "Implement MCP")
+	syn2.BlockedBy = []int{1, 42} // 42 does not exist
+
+	syn3 := types.NewSynapse(3, "Add visualization")
+	syn3.ParentID = 1
+
+	g := Build([]*types.Synapse{syn1, syn2, syn3})
+
+	if len(g.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(g.Nodes))
+	}
+	if g.Nodes[0].Label != "#1: Setup project" {
+		t.Errorf("unexpected label: %q", g.Nodes[0].Label)
+	}
+	if g.Nodes[0].Fill != "#90EE90" {
+		t.Errorf("expected done fill, got %q", g.Nodes[0].Fill)
+	}
+
+	want := []Edge{
+		{From: 1, To: 2, Kind: EdgeBlocks},
+		{From: 1, To: 3, Kind: EdgeParent},
+	}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("expected %d edges, got %d: %+v", len(want), len(g.Edges), g.Edges)
+	}
+	for i, e := range want {
+		if g.Edges[i] != e {
+			t.Errorf("edge %d = %+v, want %+v", i, g.Edges[i], e)
+		}
+	}
+}
+
+func TestStatusColor_Unknown(t *testing.T) {
+	if got := StatusColor(types.Status("bogus")); got != DefaultFill {
+		t.Errorf("StatusColor(bogus) = %q, want %q", got, DefaultFill)
+	}
+}
+
+func TestReadySet(t *testing.T) {
+	done := types.NewSynapse(1, "Done")
+	done.Status = types.StatusDone
+	open := types.NewSynapse(2, "Open")
+	byDone := types.NewSynapse(3, "Blocked by done")
+	byDone.BlockedBy = []int{1}
+	byOpen := types.NewSynapse(4, "Blocked by open")
+	byOpen.BlockedBy = []int{2}
+	byMissing := types.NewSynapse(5, "Blocked by missing")
+	byMissing.BlockedBy = []int{99}
+	active := types.NewSynapse(6, "In progress")
+	active.Status = types.StatusInProgress
+
+	ready := ReadySet([]*types.Synapse{done, open, byDone, byOpen, byMissing, active})
+
+	want := map[int]bool{2: true, 3: true}
+	if len(ready) != len(want) {
+		t.Fatalf("ReadySet = %v, want %v", ready, want)
+	}
+	for id := range want {
+		if !ready[id] {
+			t.Errorf("expected task %d to be ready", id)
+		}
+	}
+}
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"Short title", 20, "Short title"},
+		{"This is a very long title that exceeds the maximum length", 20, "This is a very long ..."},
+		{"Exactly twenty chars", 20, "Exactly twenty chars"},
+		{"", 10, ""},
+	}
+
+	for _, tt := range tests {
+		result := TruncateTitle(tt.input, tt.maxLen)
+		if result != tt.expected {
+			t.Errorf("TruncateTitle(%q, %d) = %q, expected %q",
+				tt.input, tt.maxLen, result, tt.expected)
+		}
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	// 1 blocks 3; 3 is a child of 2; 4 is a child of 3 and blocked by 5;
+	// 6 is unrelated; 7 is a child of the blocker 1 and must be excluded.
+	syn1 := types.NewSynapse(1, "Blocker of child")
+	syn2 := types.NewSynapse(2, "Root")
+	syn3 := types.NewSynapse(3, "Child")
+	syn3.ParentID = 2
+	syn3.BlockedBy = []int{1}
+	syn4 := types.NewSynapse(4, "Grandchild")
+	syn4.ParentID = 3
+	syn4.BlockedBy = []int{5}
+	syn5 := types.NewSynapse(5, "Blocker of grandchild")
+	syn6 := types.NewSynapse(6, "Unrelated")
+	syn7 := types.NewSynapse(7, "Child of blocker")
+	syn7.ParentID = 1
+
+	all := []*types.Synapse{syn1, syn2, syn3, syn4, syn5, syn6, syn7}
+
+	got := Subgraph(all, 2)
+	var ids []int
+	for _, syn := range got {
+		ids = append(ids, syn.ID)
+	}
+
+	want := []int{1, 2, 3, 4, 5}
+	if len(ids) != len(want) {
+		t.Fatalf("Subgraph(2) = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Subgraph(2) = %v, want %v", ids, want)
+		}
+	}
+
+	if Subgraph(all, 99) != nil {
+		t.Error("expected nil for unknown root")
+	}
+}
+
+func TestBuild_RelatedEdges(t *testing.T) {
+	syn1 := types.NewSynapse(1, "Schema")
+	syn1.RelatedTo = []int{2, 1, 99} // self and unknown links are dropped
+	syn2 := types.NewSynapse(2, "Docs")
+	syn2.RelatedTo = []int{1}
+
+	g := Build([]*types.Synapse{syn1, syn2})
+
+	want := []Edge{{From: 1, To: 2, Kind: EdgeRelated}}
+	if len(g.Edges) != len(want) || g.Edges[0] != want[0] {
+		t.Fatalf("expected one related edge for the reciprocal pair, got %+v", g.Edges)
+	}
+
+	// Related links never block
+	if ready := ReadySet([]*types.Synapse{syn1, syn2}); !ready[1] || !ready[2] {
+		t.Errorf("expected both related tasks ready, got %v", ready)
+	}
+}
@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ThemeFile is the optional JSON file in the data directory overriding
+// the graph colors.
+const ThemeFile = "theme.json"
+
+// Overlay keys in ThemeFile, next to one key per status.
+const (
+	ThemeClaimed  = "claimed"
+	ThemeReady    = "ready"
+	ThemeOverdue  = "overdue"
+	ThemeRelated  = "related"
+	ThemeCritical = "critical"
+)
+
+// hexColor matches #RGB and #RRGGBB colors.
+var hexColor = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// Theme holds the colors a graph is drawn with.
+type Theme struct {
+	Status   map[types.Status]string // Node fill per status
+	Claimed  string                  // Border of tasks claimed by an agent
+	Ready    string                  // Border of tasks ready to start; empty for none
+	Overdue  string                  // Border of overdue tasks
+	Related  string                  // Line color of EdgeRelated links
+	Critical string                  // Line color of blocker edges on a critical path
+}
+
+// DefaultTheme returns the built-in palette: StatusColors, ClaimStroke,
+// OverdueStroke, RelatedStroke and CriticalStroke, with no ready border.
+func DefaultTheme() Theme {
+	status := make(map[types.Status]string, len(StatusColors))
+	for s, color := range StatusColors {
+		status[s] = color
+	}
+	return Theme{
+		Status:   status,
+		Claimed:  ClaimStroke,
+		Overdue:  OverdueStroke,
+		Related:  RelatedStroke,
+		Critical: CriticalStroke,
+	}
+}
+
+// Fill returns the fill color for a status.
+func (t Theme) Fill(status types.Status) string {
+	if color, ok := t.Status[status]; ok {
+		return color
+	}
+	return DefaultFill
+}
+
+// Colors returns the theme as ThemeFile keys mapped to colors, the form
+// it is written in and served to the browser. An unset ready border is
+// left out.
+func (t Theme) Colors() map[string]string {
+	colors := map[string]string{
+		ThemeClaimed:  t.Claimed,
+		ThemeOverdue:  t.Overdue,
+		ThemeRelated:  t.Related,
+		ThemeCritical: t.Critical,
+	}
+	if t.Ready != "" {
+		colors[ThemeReady] = t.Ready
+	}
+	for _, status := range types.ValidStatuses() {
+		colors[string(status)] = t.Fill(status)
+	}
+	return colors
+}
+
+// ParseTheme reads ThemeFile content, a JSON object of status and overlay
+// keys to hex colors. Keys not given keep their DefaultTheme color.
+// Unknown keys and malformed colors are rejected.
+func ParseTheme(data []byte) (Theme, error) {
+	var colors map[string]string
+	if err := json.Unmarshal(data, &colors); err != nil {
+		return Theme{}, fmt.Errorf("parse theme: %w", err)
+	}
+
+	keys := make([]string, 0, len(colors))
+	for key := range colors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	theme := DefaultTheme()
+	for _, key := range keys {
+		color := colors[key]
+		status := types.Status(key)
+		switch key {
+		case ThemeClaimed, ThemeReady, ThemeOverdue, ThemeRelated, ThemeCritical:
+		default:
+			if !status.IsValid() {
+				return Theme{}, fmt.Errorf("theme: unknown key %q", key)
+			}
+		}
+		if !hexColor.MatchString(color) {
+			return Theme{}, fmt.Errorf("theme %s: invalid color %q (want #RGB or #RRGGBB)", key, color)
+		}
+
+		switch key {
+		case ThemeClaimed:
+			theme.Claimed = color
+		case ThemeReady:
+			theme.Ready = color
+		case ThemeOverdue:
+			theme.Overdue = color
+		case ThemeRelated:
+			theme.Related = color
+		case ThemeCritical:
+			theme.Critical = color
+		default:
+			theme.Status[status] = color
+		}
+	}
+	return theme, nil
+}
+
+// LoadTheme reads ThemeFile from dir. A missing file means DefaultTheme
+// and is not an error.
+func LoadTheme(dir string) (Theme, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ThemeFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultTheme(), nil
+		}
+		return Theme{}, fmt.Errorf("read theme file: %w", err)
+	}
+	return ParseTheme(data)
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestLoadTheme_MissingFileIsDefault(t *testing.T) {
+	theme, err := LoadTheme(t.TempDir())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if theme.Fill(types.StatusDone) != StatusColors[types.StatusDone] || theme.Claimed != ClaimStroke || theme.Ready != "" {
+		t.Errorf("expected the default palette, got %+v", theme)
+	}
+}
+
+func TestParseTheme_Overrides(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{"done": "#00ff00", "ready": "#FA0", "related": "#123456"}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if theme.Fill(types.StatusDone) != "#00ff00" || theme.Ready != "#FA0" || theme.Related != "#123456" {
+		t.Errorf("expected overrides applied, got %+v", theme)
+	}
+	if theme.Fill(types.StatusOpen) != StatusColors[types.StatusOpen] || theme.Overdue != OverdueStroke {
+		t.Errorf("expected keys not given to keep their defaults, got %+v", theme)
+	}
+	if StatusColors[types.StatusDone] == "#00ff00" {
+		t.Error("parsing a theme must not change StatusColors")
+	}
+
+	colors := theme.Colors()
+	if colors["done"] != "#00ff00" || colors["ready"] != "#FA0" || colors["in-progress"] != StatusColors[types.StatusInProgress] {
+		t.Errorf("unexpected colors: %v", colors)
+	}
+}
+
+func TestParseTheme_Rejects(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"unknown key", `{"archived": "#FFFFFF"}`, `unknown key "archived"`},
+		{"named color", `{"open": "red"}`, "invalid color"},
+		{"missing hash", `{"claimed": "1565C0"}`, "invalid color"},
+		{"bad length", `{"done": "#12345"}`, "invalid color"},
+		{"non-hex digit", `{"done": "#12345G"}`, "invalid color"},
+		{"not an object", `["#FFFFFF"]`, "parse theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTheme([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadTheme_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ThemeFile), []byte(`{"open": "#GGG"}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadTheme(dir); err == nil {
+		t.Error("expected an invalid theme file to be rejected")
+	}
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// DefaultGitHubAPI is the base URL for the GitHub REST API.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// blockedByPattern matches phrases like "blocked by #3" or "Blocked-by: #3, #4 and #7".
+var blockedByPattern = regexp.MustCompile(`(?i)blocked[ -]by:?\s*(#\d+(?:\s*(?:,|and|&)\s*#\d+)*)`)
+
+// issueRefPattern extracts the number from a "#N" reference.
+var issueRefPattern = regexp.MustCompile(`#(\d+)`)
+
+// GitHubSource imports issues from a GitHub repository. It uses the gh CLI
+// when no token is supplied, and the REST API directly otherwise.
+type GitHubSource struct {
+	Repo    string // "owner/repo"
+	Token   string // Optional API token; when empty the gh CLI is used
+	State   string // "open", "closed", or "all" (default "open")
+	BaseURL string // API base URL (default DefaultGitHubAPI)
+	Client  *http.Client
+}
+
+// githubIssue is the subset of the GitHub issue payload we consume.
+type githubIssue struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	State       string          `json:"state"`
+	HTMLURL     string          `json:"html_url"`
+	Labels      []githubLabel   `json:"labels"`
+	PullRequest json.RawMessage `json:"pull_request,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+// Name implements Source.
+func (g *GitHubSource) Name() string {
+	return "github:" + g.Repo
+}
+
+// Fetch implements Source.
+func (g *GitHubSource) Fetch() ([]*types.Synapse, error) {
+	if !strings.Contains(g.Repo, "/") {
+		return nil, fmt.Errorf("invalid repository %q (expected owner/repo)", g.Repo)
+	}
+
+	var issues []githubIssue
+	var err error
+	if g.Token != "" {
+		issues, err = g.fetchAPI()
+	} else {
+		issues, err = g.fetchGH()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return convertIssues(issues), nil
+}
+
+func (g *GitHubSource) state() string {
+	if g.State == "" {
+		return "open"
+	}
+	return g.State
+}
+
+// fetchGH retrieves issues through the gh CLI, which handles authentication.
+func (g *GitHubSource) fetchGH() ([]githubIssue, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, errors.New("gh CLI not found; install it or provide a token (--token or GITHUB_TOKEN)")
+	}
+
+	endpoint := fmt.Sprintf("repos/%s/issues?state=%s&per_page=100", g.Repo, g.state())
+	cmd := exec.Command("gh", "api", "--paginate", endpoint)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gh api: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// --paginate concatenates one JSON array per page
+	var issues []githubIssue
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var page []githubIssue
+		if err := dec.Decode(&page); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decode gh output: %w", err)
+		}
+		issues = append(issues, page...)
+	}
+	return issues, nil
+}
+
+// fetchAPI retrieves issues from the REST API using the configured token.
+func (g *GitHubSource) fetchAPI() ([]githubIssue, error) {
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultGitHubAPI
+	}
+	client := g.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	const perPage = 100
+	var issues []githubIssue
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/issues?state=%s&per_page=%d&page=%d",
+			strings.TrimRight(baseURL, "/"), g.Repo, g.state(), perPage, page)
+
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch issues: %w", err)
+		}
+
+		var batch []githubIssue
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetch issues: unexpected status %s", resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&batch)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode issues: %w", err)
+		}
+
+		issues = append(issues, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	return issues, nil
+}
+
+// convertIssues maps GitHub issues onto synapses keyed by issue number.
+// Pull requests are skipped, and "blocked by #N" references are kept only
+// when issue N is part of the same import.
+func convertIssues(issues []githubIssue) []*types.Synapse {
+	imported := make(map[int]bool, len(issues))
+	for _, issue := range issues {
+		if issue.PullRequest == nil {
+			imported[issue.Number] = true
+		}
+	}
+
+	var synapses []*types.Synapse
+	for _, issue := range issues {
+		if issue.PullRequest != nil {
+			continue
+		}
+
+		syn := &types.Synapse{
+			ID:          issue.Number,
+			Title:       issue.Title,
+			Description: issue.Body,
+			Status:      types.StatusOpen,
+			BlockedBy:   []int{},
+			CreatedAt:   issue.CreatedAt,
+			UpdatedAt:   issue.UpdatedAt,
+		}
+		if issue.State == "closed" {
+			syn.Status = types.StatusDone
+		}
+		for _, label := range issue.Labels {
+			syn.Labels = append(syn.Labels, label.Name)
+		}
+		seen := make(map[int]bool)
+		for _, ref := range parseBlockedBy(issue.Body) {
+			if imported[ref] && ref != issue.Number && !seen[ref] {
+				seen[ref] = true
+				syn.BlockedBy = append(syn.BlockedBy, ref)
+			}
+		}
+		if issue.HTMLURL != "" {
+			syn.Notes = append(syn.Notes, "Imported from "+issue.HTMLURL)
+		}
+
+		synapses = append(synapses, syn)
+	}
+	return synapses
+}
+
+// parseBlockedBy extracts issue numbers from "blocked by #N" phrases.
+func parseBlockedBy(body string) []int {
+	var refs []int
+	for _, match := range blockedByPattern.FindAllStringSubmatch(body, -1) {
+		for _, ref := range issueRefPattern.FindAllStringSubmatch(match[1], -1) {
+			if n, err := strconv.Atoi(ref[1]); err == nil {
+				refs = append(refs, n)
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestParseBlockedBy(t *testing.T) {
+	tests := []struct {
+		body string
+		want []int
+	}{
+		{"This is blocked by #3", []int{3}},
+		{"Blocked-by: #3, #4 and #7", []int{3, 4, 7}},
+		{"blocked by #1\n\nAlso BLOCKED BY #2", []int{1, 2}},
+		{"Relates to #5", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := parseBlockedBy(tt.body)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseBlockedBy(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestConvertIssues(t *testing.T) {
+	issues := []githubIssue{
+		{Number: 1, Title: "Design", State: "closed", Labels: []githubLabel{{Name: "design"}}},
+		{Number: 2, Title: "Build", State: "open", Body: "Blocked by #1 and #99"},
+		{Number: 3, Title: "A pull request", PullRequest: json.RawMessage(`{}`)},
+	}
+
+	synapses := convertIssues(issues)
+	if len(synapses) != 2 {
+		t.Fatalf("expected 2 synapses (PR skipped), got %d", len(synapses))
+	}
+
+	if synapses[0].Status != types.StatusDone {
+		t.Errorf("closed issue status = %s, want done", synapses[0].Status)
+	}
+	if !reflect.DeepEqual(synapses[0].Labels, []string{"design"}) {
+		t.Errorf("labels = %v, want [design]", synapses[0].Labels)
+	}
+
+	// #99 was not imported, so only #1 becomes a blocker
+	if synapses[1].Status != types.StatusOpen {
+		t.Errorf("open issue status = %s, want open", synapses[1].Status)
+	}
+	if !reflect.DeepEqual(synapses[1].BlockedBy, []int{1}) {
+		t.Errorf("BlockedBy = %v, want [1]", synapses[1].BlockedBy)
+	}
+	if synapses[1].Description != "Blocked by #1 and #99" {
+		t.Errorf("body not mapped to description: %q", synapses[1].Description)
+	}
+}
+
+func TestGitHubSource_FetchAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/widgets/issues" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]githubIssue{
+			{Number: 10, Title: "First", State: "open"},
+			{Number: 11, Title: "Second", State: "open", Body: "blocked by #10"},
+		})
+	}))
+	defer srv.Close()
+
+	src := &GitHubSource{Repo: "acme/widgets", Token: "secret", BaseURL: srv.URL}
+	synapses, err := src.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if len(synapses) != 2 {
+		t.Fatalf("expected 2 synapses, got %d", len(synapses))
+	}
+	if synapses[1].ID != 11 || !reflect.DeepEqual(synapses[1].BlockedBy, []int{10}) {
+		t.Errorf("unexpected second synapse: id=%d blocked_by=%v", synapses[1].ID, synapses[1].BlockedBy)
+	}
+}
+
+func TestGitHubSource_InvalidRepo(t *testing.T) {
+	src := &GitHubSource{Repo: "not-a-repo", Token: "x"}
+	if _, err := src.Fetch(); err == nil {
+		t.Fatal("expected error for repo without owner")
+	}
+}
@@ -0,0 +1,221 @@
+// Package importer seeds a Synapse store from external sources. Imported
+// tasks always receive fresh IDs, and every reference between them
+// (BlockedBy, RelatedTo, ParentID, DiscoveredFrom) is remapped consistently.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// Source fetches tasks from an external tracker. Returned synapses carry
+// their source-side IDs; references between them are remapped by Prepare.
+type Source interface {
+	// Name identifies the source in messages, e.g. "github:owner/repo".
+	Name() string
+	// Fetch retrieves the tasks to import.
+	Fetch() ([]*types.Synapse, error)
+}
+
+// Mapping records the ID assigned to a single imported task.
+type Mapping struct {
+	SourceID int    `json:"source_id"`
+	NewID    int    `json:"new_id"`
+	Title    string `json:"title"`
+}
+
+// Options adjusts how Prepare resolves references.
+type Options struct {
+	// LinkExisting lets a blocker, related or parent reference that isn't
+	// in the import resolve to the store's existing task with that ID.
+	// Without it such references are rejected, since source IDs usually
+	// have nothing to do with the store's.
+	LinkExisting bool
+}
+
+// Plan is a validated import, ready to be applied to a store.
+type Plan struct {
+	Mappings []Mapping        `json:"mappings"`
+	Synapses []*types.Synapse `json:"-"`
+}
+
+// Parse reads synapses from either a JSON array or JSONL (one object per line).
+// The format is detected from the first non-whitespace byte.
+func Parse(r io.Reader) ([]*types.Synapse, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if trimmed[0] == '[' {
+		var synapses []*types.Synapse
+		if err := json.Unmarshal(trimmed, &synapses); err != nil {
+			return nil, fmt.Errorf("parse JSON array: %w", err)
+		}
+		return synapses, nil
+	}
+
+	var synapses []*types.Synapse
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if _, ok := storage.ParseSchemaHeader(line); ok {
+			continue // A memory.jsonl copied from another project
+		}
+
+		var syn types.Synapse
+		if err := json.Unmarshal(line, &syn); err != nil {
+			return nil, fmt.Errorf("parse line %d: %w", lineNum, err)
+		}
+		synapses = append(synapses, &syn)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan input: %w", err)
+	}
+
+	return synapses, nil
+}
+
+// Prepare assigns fresh IDs to incoming synapses and remaps their references.
+//
+// A reference to an ID present in the import set is rewritten to that task's
+// new ID. Any other reference is rejected, and with it the whole import so
+// nothing is committed, unless opts.LinkExisting is set and the store has a
+// task with that ID.
+func Prepare(store *storage.JSONLStore, incoming []*types.Synapse, opts Options) (*Plan, error) {
+	nextID := store.NextID()
+
+	idMap := make(map[int]int, len(incoming))
+	plan := &Plan{
+		Mappings: make([]Mapping, 0, len(incoming)),
+		Synapses: make([]*types.Synapse, 0, len(incoming)),
+	}
+
+	// First pass: assign new IDs
+	externalIDs := make(map[string]int)
+	for i, syn := range incoming {
+		if syn.Title == "" {
+			return nil, fmt.Errorf("task %d (source ID %d): title is required", i+1, syn.ID)
+		}
+		if syn.ExternalID != "" {
+			if first, dup := externalIDs[syn.ExternalID]; dup {
+				return nil, fmt.Errorf("source IDs %d and %d: duplicate external ID %q", first, syn.ID, syn.ExternalID)
+			}
+			if existing, ok := store.ByExternalID(syn.ExternalID); ok {
+				return nil, fmt.Errorf("source ID %d: external ID %q is already used by synapse %d", syn.ID, syn.ExternalID, existing.ID)
+			}
+			externalIDs[syn.ExternalID] = syn.ID
+		}
+		newID := nextID + i
+		if syn.ID > 0 {
+			if _, dup := idMap[syn.ID]; dup {
+				return nil, fmt.Errorf("duplicate source ID %d", syn.ID)
+			}
+			idMap[syn.ID] = newID
+		}
+		plan.Mappings = append(plan.Mappings, Mapping{SourceID: syn.ID, NewID: newID, Title: syn.Title})
+	}
+
+	resolve := func(ref int) (int, bool) {
+		if newID, ok := idMap[ref]; ok {
+			return newID, true
+		}
+		if _, err := store.Get(ref); err == nil && opts.LinkExisting {
+			return ref, true
+		}
+		return 0, false
+	}
+	scope := "the import"
+	if opts.LinkExisting {
+		scope = "the import or the store"
+	}
+
+	// Second pass: build remapped copies
+	now := time.Now().UTC()
+	for i, src := range incoming {
+		syn := *src
+		syn.ID = plan.Mappings[i].NewID
+
+		if syn.Status == "" {
+			syn.Status = types.StatusOpen
+		}
+		if !syn.Status.IsValid() {
+			return nil, fmt.Errorf("source ID %d: invalid status: %s", src.ID, syn.Status)
+		}
+
+		blockedBy := make([]int, 0, len(src.BlockedBy))
+		for _, ref := range src.BlockedBy {
+			newRef, ok := resolve(ref)
+			if !ok {
+				return nil, fmt.Errorf("source ID %d: blocker %d is not in %s", src.ID, ref, scope)
+			}
+			blockedBy = append(blockedBy, newRef)
+		}
+		syn.BlockedBy = blockedBy
+
+		var relatedTo []int
+		for _, ref := range src.RelatedTo {
+			newRef, ok := resolve(ref)
+			if !ok {
+				return nil, fmt.Errorf("source ID %d: related task %d is not in %s", src.ID, ref, scope)
+			}
+			relatedTo = append(relatedTo, newRef)
+		}
+		syn.RelatedTo = relatedTo
+
+		if src.ParentID > 0 {
+			newRef, ok := resolve(src.ParentID)
+			if !ok {
+				return nil, fmt.Errorf("source ID %d: parent %d is not in %s", src.ID, src.ParentID, scope)
+			}
+			syn.ParentID = newRef
+		}
+
+		// DiscoveredFrom is provenance only, so a reference outside the
+		// import is kept as-is rather than rejected
+		if newRef, ok := idMap[src.DiscoveredFrom]; ok {
+			syn.DiscoveredFrom = newRef
+		}
+
+		if syn.CreatedAt.IsZero() {
+			syn.CreatedAt = now
+		}
+		if syn.UpdatedAt.IsZero() {
+			syn.UpdatedAt = now
+		}
+
+		plan.Synapses = append(plan.Synapses, &syn)
+	}
+
+	return plan, nil
+}
+
+// Apply inserts the planned synapses into the store. The caller is
+// responsible for saving the store afterwards.
+func Apply(store *storage.JSONLStore, plan *Plan) error {
+	for _, syn := range plan.Synapses {
+		if err := store.Insert(syn); err != nil {
+			return fmt.Errorf("insert synapse %d: %w", syn.ID, err)
+		}
+	}
+	return nil
+}
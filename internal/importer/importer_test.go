@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"json array", `[{"id":1,"title":"A"},{"id":2,"title":"B"}]`, 2},
+		{"jsonl", "{\"id\":1,\"title\":\"A\"}\n\n{\"id\":2,\"title\":\"B\"}\n", 2},
+		{"empty", "  \n", 0},
+		{"memory file", "{\"schema_version\":2}\n{\"id\":1,\"title\":\"A\"}\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Parse returned %d synapses, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_ReportsLineNumber(t *testing.T) {
+	_, err := Parse(strings.NewReader("{\"id\":1,\"title\":\"A\"}\n{bad\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error mentioning line 2, got %v", err)
+	}
+}
+
+func TestPrepareAndApply_RemapsReferences(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	store.Create("Existing 1")
+	store.Create("Existing 2")
+
+	incoming, err := Parse(strings.NewReader(`[
+		{"id": 10, "title": "Design", "related_to": [12]},
+		{"id": 11, "title": "Build", "blocked_by": [10, 2], "parent_id": 10, "related_to": [1]},
+		{"id": 12, "title": "Follow-up", "discovered_from": "#11", "status": "done"}
+	]`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	plan, err := Prepare(store, incoming, Options{LinkExisting: true})
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+
+	wantIDs := map[int]int{10: 3, 11: 4, 12: 5}
+	for _, m := range plan.Mappings {
+		if wantIDs[m.SourceID] != m.NewID {
+			t.Errorf("source %d mapped to %d, want %d", m.SourceID, m.NewID, wantIDs[m.SourceID])
+		}
+	}
+
+	// Preparing must not touch the store
+	if store.Count() != 2 {
+		t.Fatalf("Prepare modified the store: count = %d", store.Count())
+	}
+
+	if err := Apply(store, plan); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	build, err := store.Get(4)
+	if err != nil {
+		t.Fatalf("imported task missing: %v", err)
+	}
+	if len(build.BlockedBy) != 2 || build.BlockedBy[0] != 3 || build.BlockedBy[1] != 2 {
+		t.Errorf("BlockedBy = %v, want [3 2]", build.BlockedBy)
+	}
+	if build.ParentID != 3 {
+		t.Errorf("ParentID = %d, want 3", build.ParentID)
+	}
+	if len(build.RelatedTo) != 1 || build.RelatedTo[0] != 1 {
+		t.Errorf("RelatedTo = %v, want the existing [1]", build.RelatedTo)
+	}
+	if design, _ := store.Get(3); len(design.RelatedTo) != 1 || design.RelatedTo[0] != 5 {
+		t.Errorf("RelatedTo = %v, want [5]", design.RelatedTo)
+	}
+
+	followUp, _ := store.Get(5)
+	if followUp.DiscoveredFrom != 4 {
+		t.Errorf("DiscoveredFrom = %d, want 4", followUp.DiscoveredFrom)
+	}
+	if followUp.Status != types.StatusDone {
+		t.Errorf("Status = %s, want done", followUp.Status)
+	}
+
+	next, _ := store.Create("After import")
+	if next.ID != 6 {
+		t.Errorf("next created ID = %d, want 6", next.ID)
+	}
+}
+
+func TestPrepare_RejectsDanglingBlocker(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+
+	incoming := []*types.Synapse{
+		{ID: 1, Title: "A", BlockedBy: []int{7}},
+	}
+
+	if _, err := Prepare(store, incoming, Options{LinkExisting: true}); err == nil {
+		t.Fatal("expected error for blocker referencing a nonexistent task")
+	}
+	if store.Count() != 0 {
+		t.Error("rejected import must not modify the store")
+	}
+}
+
+func TestPrepare_RejectsRefsOutsideImport(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	store.Create("Existing 1")
+	store.Create("Existing 2")
+
+	for _, syn := range []*types.Synapse{
+		{ID: 10, Title: "A", BlockedBy: []int{2}},
+		{ID: 10, Title: "A", ParentID: 1},
+		{ID: 10, Title: "A", RelatedTo: []int{1}},
+	} {
+		_, err := Prepare(store, []*types.Synapse{syn}, Options{})
+		if err == nil || !strings.Contains(err.Error(), "not in the import") {
+			t.Errorf("%+v: expected the existing task not to be linked, got %v", syn, err)
+		}
+	}
+}
+
+func TestPrepare_RejectsDuplicateExternalIDs(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	store.CreateIdempotent("Existing", "gh-1")
+
+	for _, incoming := range [][]*types.Synapse{
+		{{ID: 1, Title: "A", ExternalID: "gh-2"}, {ID: 2, Title: "B", ExternalID: "gh-2"}},
+		{{ID: 1, Title: "A", ExternalID: "gh-1"}},
+	} {
+		if _, err := Prepare(store, incoming, Options{}); err == nil || !strings.Contains(err.Error(), "external ID") {
+			t.Errorf("expected a duplicate external ID error, got %v", err)
+		}
+	}
+}
+
+func TestPrepare_RejectsDuplicateSourceIDs(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+
+	incoming := []*types.Synapse{
+		{ID: 1, Title: "A"},
+		{ID: 1, Title: "B"},
+	}
+
+	if _, err := Prepare(store, incoming, Options{}); err == nil {
+		t.Fatal("expected error for duplicate source IDs")
+	}
+}
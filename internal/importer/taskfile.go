@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// TaskFile is a single task written by hand for `synapse add --file` or
+// `--editor`: a front matter block of "key: value" lines between "---"
+// markers, followed by the description.
+//
+//	---
+//	title: Fix login bug
+//	assignee: @coder
+//	labels: auth, bug
+//	blocks: 4, 7
+//	---
+//	Sessions expire after 5 minutes instead of 24 hours.
+//
+// Without front matter, the first non-empty line is the title (a leading
+// "# " is stripped) and the rest is the description, as in a commit message.
+type TaskFile struct {
+	Title       string
+	Description string
+	Assignee    string
+	Labels      []string
+	Blocks      []int // Blocker task IDs, like --blocks
+	Related     []int
+	Parent      int
+	Priority    int
+}
+
+// TaskFileTemplate is the starting text for `synapse add --editor`.
+// Front matter lines starting with "#" are ignored.
+const TaskFileTemplate = `---
+# Labels and blocker IDs are comma-separated; parent, related and
+# priority are also accepted. Write the description below the closing ---.
+# Leave the title empty to abort.
+title:
+assignee:
+labels:
+blocks:
+---
+`
+
+// ParseTaskFile parses a task file and validates that it has a title.
+func ParseTaskFile(data []byte) (*TaskFile, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("empty task file")
+	}
+
+	tf := &TaskFile{}
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		// The leading newline lets an empty front matter match, and makes
+		// line numbers in errors count from the opening marker
+		front, body, found := strings.Cut("\n"+rest, "\n---")
+		if !found {
+			return nil, fmt.Errorf("front matter: missing closing ---")
+		}
+		if err := tf.parseFrontMatter(front); err != nil {
+			return nil, err
+		}
+		// Drop the remainder of the closing marker line
+		if _, after, ok := strings.Cut(body, "\n"); ok {
+			body = after
+		} else {
+			body = ""
+		}
+		tf.Description = strings.TrimSpace(body)
+	} else {
+		title, body, _ := strings.Cut(strings.TrimSpace(text), "\n")
+		tf.Title = strings.TrimSpace(strings.TrimPrefix(title, "# "))
+		tf.Description = strings.TrimSpace(body)
+	}
+
+	if tf.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	return tf, nil
+}
+
+func (tf *TaskFile) parseFrontMatter(front string) error {
+	scanner := bufio.NewScanner(strings.NewReader(front))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("line %d: expected \"key: value\"", lineNum)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "title":
+			tf.Title = value
+		case "assignee":
+			tf.Assignee = value
+		case "labels":
+			tf.Labels = splitList(value)
+		case "blocks", "blocked_by":
+			tf.Blocks, err = parseIDList(value)
+		case "related":
+			tf.Related, err = parseIDList(value)
+		case "parent":
+			if value != "" {
+				tf.Parent, err = parseTaskID(value)
+			}
+		case "priority":
+			if value != "" {
+				tf.Priority, err = strconv.Atoi(value)
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseIDList parses comma-separated task IDs, each optionally written "#N".
+func parseIDList(value string) ([]int, error) {
+	var ids []int
+	for _, item := range splitList(value) {
+		id, err := parseTaskID(item)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseTaskID(s string) (int, error) {
+	if id, ok := types.ParseTaskRef(s); ok {
+		return id, nil
+	}
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid task ID: %s", s)
+	}
+	return id, nil
+}
+
+// EditTemplate returns TaskFileTemplate with the assignee filled in.
+func EditTemplate(assignee string) []byte {
+	if assignee == "" {
+		return []byte(TaskFileTemplate)
+	}
+	return []byte(strings.Replace(TaskFileTemplate, "assignee:\n", "assignee: "+assignee+"\n", 1))
+}
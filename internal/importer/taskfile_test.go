@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTaskFile_FrontMatter(t *testing.T) {
+	data := "---\n" +
+		"title: Fix login bug\n" +
+		"assignee: @coder\n" +
+		"labels: auth, bug,\n" +
+		"blocks: 4, #7\n" +
+		"priority: 2\n" +
+		"---\n" +
+		"Sessions expire after 5 minutes.\n\nShould be 24 hours.\n"
+
+	tf, err := ParseTaskFile([]byte(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if tf.Title != "Fix login bug" || tf.Assignee != "@coder" || tf.Priority != 2 {
+		t.Errorf("unexpected fields: %+v", tf)
+	}
+	if !slices.Equal(tf.Labels, []string{"auth", "bug"}) || !slices.Equal(tf.Blocks, []int{4, 7}) {
+		t.Errorf("unexpected lists: labels %v, blocks %v", tf.Labels, tf.Blocks)
+	}
+	if tf.Description != "Sessions expire after 5 minutes.\n\nShould be 24 hours." {
+		t.Errorf("unexpected description: %q", tf.Description)
+	}
+}
+
+func TestParseTaskFile_CommitStyle(t *testing.T) {
+	tf, err := ParseTaskFile([]byte("\n# Add retries\n\nBack off exponentially.\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if tf.Title != "Add retries" || tf.Description != "Back off exponentially." {
+		t.Errorf("unexpected task: %+v", tf)
+	}
+}
+
+func TestParseTaskFile_Errors(t *testing.T) {
+	tests := map[string]string{
+		"empty":             "  \n",
+		"untouched editor":  TaskFileTemplate,
+		"unclosed":          "---\ntitle: x\n",
+		"unknown key":       "---\ntitle: x\nowner: me\n---\n",
+		"bad blocker":       "---\ntitle: x\nblocks: four\n---\n",
+		"missing separator": "---\ntitle x\n---\n",
+	}
+	for name, data := range tests {
+		if _, err := ParseTaskFile([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	_, err := ParseTaskFile([]byte("---\ntitle: x\nowner: me\n---\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error to name line 3, got %v", err)
+	}
+}
+
+func TestEditTemplate(t *testing.T) {
+	tf := string(EditTemplate("@qa"))
+	if !strings.Contains(tf, "assignee: @qa\n") {
+		t.Errorf("expected assignee filled in, got:\n%s", tf)
+	}
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// logLevel controls which server messages are written to stderr. Each
+// level includes the ones before it.
+type logLevel int
+
+const (
+	levelError logLevel = iota // Failures only
+	levelInfo                  // Plus lifecycle messages (default)
+	levelDebug                 // Plus every request and response body
+)
+
+// logLevels maps SYNAPSE_LOG values to levels.
+var logLevels = map[string]logLevel{
+	"error": levelError,
+	"info":  levelInfo,
+	"debug": levelDebug,
+}
+
+// redactedValue replaces breadcrumb values in logged bodies.
+const redactedValue = "[redacted]"
+
+// logger is a small leveled logger for the server. Bodies logged at debug
+// level have breadcrumb values redacted unless redact is off, since agents
+// often store tokens and other secrets in breadcrumbs.
+type logger struct {
+	level  logLevel
+	redact bool
+	out    *log.Logger
+}
+
+// newLogger creates a logger writing to w, configured from SYNAPSE_LOG
+// (error, info or debug; default info) and SYNAPSE_LOG_REDACT (set to 0 or
+// false to log breadcrumb values).
+func newLogger(w io.Writer) *logger {
+	l := &logger{level: levelInfo, redact: true, out: log.New(w, "", log.LstdFlags)}
+	if name := os.Getenv("SYNAPSE_LOG"); name != "" {
+		if err := l.setLevel(name); err != nil {
+			l.errorf("%v, using info", err)
+		}
+	}
+	switch strings.ToLower(os.Getenv("SYNAPSE_LOG_REDACT")) {
+	case "0", "false", "no", "off":
+		l.redact = false
+	}
+	return l
+}
+
+// setLevel sets the level by name.
+func (l *logger) setLevel(name string) error {
+	level, ok := logLevels[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown log level %q (want error, info or debug)", name)
+	}
+	l.level = level
+	return nil
+}
+
+func (l *logger) errorf(format string, args ...any) {
+	l.out.Printf("ERROR "+format, args...)
+}
+
+func (l *logger) infof(format string, args ...any) {
+	if l.level >= levelInfo {
+		l.out.Printf("INFO "+format, args...)
+	}
+}
+
+func (l *logger) debugf(format string, args ...any) {
+	if l.level >= levelDebug {
+		l.out.Printf("DEBUG "+format, args...)
+	}
+}
+
+// body logs a JSON-RPC message at debug level, redacting breadcrumb values.
+func (l *logger) body(prefix string, data []byte) {
+	if l.level < levelDebug {
+		return
+	}
+	if l.redact {
+		data = redactBreadcrumbValues(data)
+	}
+	l.debugf("%s: %s", prefix, data)
+}
+
+// redactBreadcrumbValues replaces the string "value" fields in a JSON
+// message, including inside tool results that carry JSON as text. Input
+// that isn't JSON is returned unchanged.
+func redactBreadcrumbValues(data []byte) []byte {
+	if !bytes.Contains(data, []byte(`value`)) {
+		return data
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if _, isString := child.(string); isString && k == "value" {
+				t[k] = redactedValue
+				continue
+			}
+			t[k] = redactValue(child)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = redactValue(child)
+		}
+	case string:
+		// Tool results and resource contents embed JSON documents as text
+		trimmed := strings.TrimSpace(t)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			return t
+		}
+		if redacted := redactBreadcrumbValues([]byte(t)); !bytes.Equal(redacted, []byte(t)) {
+			return string(redacted)
+		}
+	}
+	return v
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("SYNAPSE_LOG", "error")
+	l := newLogger(&buf)
+
+	l.infof("starting")
+	l.body("Received", []byte(`{"method":"tools/list"}`))
+	l.errorf("failed to save")
+	if out := buf.String(); strings.Contains(out, "starting") || strings.Contains(out, "tools/list") || !strings.Contains(out, "ERROR failed to save") {
+		t.Errorf("expected only the error at error level, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := l.setLevel("debug"); err != nil {
+		t.Fatalf("setLevel: %v", err)
+	}
+	l.body("Received", []byte(`{"method":"tools/list"}`))
+	if !strings.Contains(buf.String(), `DEBUG Received: {"method":"tools/list"}`) {
+		t.Errorf("expected body at debug level, got:\n%s", buf.String())
+	}
+
+	if err := l.setLevel("loud"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestLogger_RedactsBreadcrumbValues(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("SYNAPSE_LOG", "debug")
+	l := newLogger(&buf)
+
+	// A set_breadcrumb call and a get_breadcrumb result, whose text
+	// content is itself JSON
+	l.body("Received", []byte(`{"method":"tools/call","params":{"name":"set_breadcrumb","arguments":{"key":"api.token","value":"s3cret"}}}`))
+	l.body("Sending", []byte(`{"result":{"content":[{"type":"text","text":"{\n  \"key\": \"api.token\",\n  \"value\": \"s3cret\"\n}"}]}}`))
+
+	out := buf.String()
+	if strings.Contains(out, "s3cret") {
+		t.Errorf("expected breadcrumb value redacted, got:\n%s", out)
+	}
+	if strings.Count(out, redactedValue) != 2 || !strings.Contains(out, "api.token") {
+		t.Errorf("expected both values redacted and keys kept, got:\n%s", out)
+	}
+
+	buf.Reset()
+	t.Setenv("SYNAPSE_LOG_REDACT", "false")
+	l = newLogger(&buf)
+	l.body("Received", []byte(`{"arguments":{"value":"s3cret"}}`))
+	if !strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("expected value logged with redaction off, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resource URIs. Breadcrumb keys are path-escaped, since they may contain
+// slashes or other reserved characters.
+const (
+	taskURIPrefix           = "synapse://task/"
+	breadcrumbURIPrefix     = "synapse://breadcrumb/"
+	taskBreadcrumbURIPrefix = "synapse://breadcrumbs/task/"
+)
+
+// eventPollInterval is how often the audit log is checked for task changes
+// to report to subscribed clients.
+const eventPollInterval = time.Second
+
+// errResourceNotFound is the MCP error code for an unknown resource URI.
+const errResourceNotFound = -32002
+
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type resourceReadParams struct {
+	URI string `json:"uri"`
+}
+
+func taskURI(id int) string {
+	return taskURIPrefix + strconv.Itoa(id)
+}
+
+func breadcrumbURI(key string) string {
+	return breadcrumbURIPrefix + url.PathEscape(key)
+}
+
+func (s *Server) handleResourcesList(req *jsonRPCRequest) {
+	resources := []resource{}
+	for _, syn := range s.store.All() {
+		resources = append(resources, resource{
+			URI:         taskURI(syn.ID),
+			Name:        fmt.Sprintf("#%d: %s", syn.ID, syn.Title),
+			Description: "Task (" + string(syn.Status) + ")",
+			MimeType:    "application/json",
+		})
+	}
+	for _, b := range s.bcStore.List("") {
+		resources = append(resources, resource{
+			URI:         breadcrumbURI(b.Key),
+			Name:        b.Key,
+			Description: "Breadcrumb",
+			MimeType:    "application/json",
+		})
+	}
+
+	s.sendResult(req.ID, map[string]any{"resources": resources})
+}
+
+func (s *Server) handleResourceTemplatesList(req *jsonRPCRequest) {
+	templates := []resourceTemplate{
+		{
+			URITemplate: taskURIPrefix + "{id}",
+			Name:        "Task",
+			Description: "A task's full JSON; subscribe to be notified when it changes",
+			MimeType:    "application/json",
+		},
+		{
+			URITemplate: breadcrumbURIPrefix + "{key}",
+			Name:        "Breadcrumb",
+			Description: "A persisted key-value breadcrumb",
+			MimeType:    "application/json",
+		},
+		{
+			URITemplate: taskBreadcrumbURIPrefix + "{id}",
+			Name:        "Task breadcrumbs",
+			Description: "All breadcrumbs linked to a task",
+			MimeType:    "application/json",
+		},
+	}
+
+	s.sendResult(req.ID, map[string]any{"resourceTemplates": templates})
+}
+
+func (s *Server) handleResourcesRead(req *jsonRPCRequest) {
+	var params resourceReadParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendError(req.ID, -32602, "Invalid params", "uri is required")
+		return
+	}
+
+	value, err := s.readResource(params.URI)
+	if err != nil {
+		s.sendError(req.ID, errResourceNotFound, "Resource not found", err.Error())
+		return
+	}
+
+	data, _ := json.MarshalIndent(value, "", "  ")
+	s.sendResult(req.ID, map[string]any{
+		"contents": []resourceContents{{
+			URI:      params.URI,
+			MimeType: "application/json",
+			Text:     string(data),
+		}},
+	})
+}
+
+// readResource resolves a resource URI to the value it names.
+func (s *Server) readResource(uri string) (any, error) {
+	switch {
+	case strings.HasPrefix(uri, taskURIPrefix):
+		id, err := strconv.Atoi(strings.TrimPrefix(uri, taskURIPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid task ID in %s", uri)
+		}
+		// Reload so a read prompted by an update notification sees the
+		// change, even if another process made it
+		if err := s.store.Load(); err != nil {
+			return nil, fmt.Errorf("reload store: %w", err)
+		}
+		return s.store.Get(id)
+
+	case strings.HasPrefix(uri, breadcrumbURIPrefix):
+		key, err := url.PathUnescape(strings.TrimPrefix(uri, breadcrumbURIPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid breadcrumb key in %s: %w", uri, err)
+		}
+		b, found := s.bcStore.Get(key)
+		if !found {
+			return nil, fmt.Errorf("breadcrumb not found: %s", key)
+		}
+		return b, nil
+
+	case strings.HasPrefix(uri, taskBreadcrumbURIPrefix):
+		id, err := strconv.Atoi(strings.TrimPrefix(uri, taskBreadcrumbURIPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid task ID in %s", uri)
+		}
+		return s.bcStore.ListByTask(id), nil
+	}
+
+	return nil, fmt.Errorf("unknown resource: %s", uri)
+}
+
+func (s *Server) handleResourcesSubscribe(req *jsonRPCRequest, subscribe bool) {
+	var params resourceReadParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendError(req.ID, -32602, "Invalid params", "uri is required")
+		return
+	}
+
+	s.subMu.Lock()
+	if subscribe {
+		s.subscriptions[params.URI] = true
+	} else {
+		delete(s.subscriptions, params.URI)
+	}
+	s.subMu.Unlock()
+
+	s.sendResult(req.ID, map[string]any{})
+}
+
+// watchEvents tails the audit log until done is closed, notifying the
+// client of changes to subscribed tasks. Every process appends to the same
+// log, so edits made by other agents are reported too.
+func (s *Server) watchEvents(done <-chan struct{}, interval time.Duration) {
+	offset, err := s.store.Events().Size()
+	if err != nil {
+		s.logger.errorf("resource subscriptions disabled: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			offset = s.notifySubscribers(offset)
+		}
+	}
+}
+
+// notifySubscribers sends notifications/resources/updated for each
+// subscribed task with events after offset, and returns the new offset.
+func (s *Server) notifySubscribers(offset int64) int64 {
+	events, next, err := s.store.Events().ReadFrom(offset)
+	if err != nil {
+		s.logger.errorf("failed to read events: %v", err)
+	}
+
+	s.subMu.Lock()
+	var updated []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		uri := taskURI(e.TaskID)
+		if s.subscriptions[uri] && !seen[uri] {
+			seen[uri] = true
+			updated = append(updated, uri)
+		}
+	}
+	s.subMu.Unlock()
+
+	for _, uri := range updated {
+		s.sendNotification("notifications/resources/updated", map[string]any{"uri": uri})
+	}
+	return next
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// call sends a single JSON-RPC request to the server and decodes the
+// response.
+func call(t *testing.T, server *Server, method, params string) jsonRPCResponse {
+	t.Helper()
+	var out strings.Builder
+	server.writer = &out
+
+	req := &jsonRPCRequest{JSONRPC: "2.0", Method: method, ID: float64(1)}
+	if params != "" {
+		req.Params = json.RawMessage(params)
+	}
+	server.handleRequest(req)
+
+	var resp jsonRPCResponse
+	if err := json.Unmarshal([]byte(out.String()), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", out.String(), err)
+	}
+	return resp
+}
+
+// decodeResult re-decodes a response's result into v.
+func decodeResult(t *testing.T, resp jsonRPCResponse, v any) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	data, _ := json.Marshal(resp.Result)
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+}
+
+func newResourceTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	bcStore := storage.NewBreadcrumbStore(dir)
+	bcStore.Set("auth/method", "jwt", 1)
+	bcStore.Set("db.engine", "postgres", 0)
+	return NewServer(store, bcStore)
+}
+
+func TestResourcesList_Breadcrumbs(t *testing.T) {
+	server := newResourceTestServer(t)
+
+	var result struct {
+		Resources []resource `json:"resources"`
+	}
+	decodeResult(t, call(t, server, "resources/list", ""), &result)
+
+	if len(result.Resources) != 2 {
+		t.Fatalf("expected 2 resources, got %+v", result.Resources)
+	}
+	if got := result.Resources[0].URI; got != "synapse://breadcrumb/auth%2Fmethod" {
+		t.Errorf("expected escaped key in URI, got %s", got)
+	}
+	if got := result.Resources[1].Name; got != "db.engine" {
+		t.Errorf("expected key as name, got %s", got)
+	}
+}
+
+func TestResourcesRead_Breadcrumb(t *testing.T) {
+	server := newResourceTestServer(t)
+
+	var result struct {
+		Contents []resourceContents `json:"contents"`
+	}
+	decodeResult(t, call(t, server, "resources/read", `{"uri":"synapse://breadcrumb/auth%2Fmethod"}`), &result)
+
+	if len(result.Contents) != 1 {
+		t.Fatalf("expected 1 content item, got %d", len(result.Contents))
+	}
+	var b types.Breadcrumb
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &b); err != nil {
+		t.Fatalf("failed to decode breadcrumb: %v", err)
+	}
+	if b.Key != "auth/method" || b.Value != "jwt" {
+		t.Errorf("unexpected breadcrumb: %+v", b)
+	}
+}
+
+func TestResourcesRead_TaskBreadcrumbs(t *testing.T) {
+	server := newResourceTestServer(t)
+
+	var result struct {
+		Contents []resourceContents `json:"contents"`
+	}
+	decodeResult(t, call(t, server, "resources/read", `{"uri":"synapse://breadcrumbs/task/1"}`), &result)
+
+	var crumbs []types.Breadcrumb
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &crumbs); err != nil {
+		t.Fatalf("failed to decode breadcrumbs: %v", err)
+	}
+	if len(crumbs) != 1 || crumbs[0].Key != "auth/method" {
+		t.Errorf("expected only the task's breadcrumb, got %+v", crumbs)
+	}
+}
+
+func TestResourcesRead_NotFound(t *testing.T) {
+	server := newResourceTestServer(t)
+
+	for _, uri := range []string{"synapse://breadcrumb/missing", "synapse://breadcrumbs/task/abc", "file:///etc/passwd"} {
+		resp := call(t, server, "resources/read", `{"uri":"`+uri+`"}`)
+		if resp.Error == nil || resp.Error.Code != errResourceNotFound {
+			t.Errorf("%s: expected resource not found error, got %+v", uri, resp.Error)
+		}
+	}
+}
+
+func TestResourceTemplatesList(t *testing.T) {
+	server := newResourceTestServer(t)
+
+	var result struct {
+		ResourceTemplates []resourceTemplate `json:"resourceTemplates"`
+	}
+	decodeResult(t, call(t, server, "resources/templates/list", ""), &result)
+
+	var uris []string
+	for _, tmpl := range result.ResourceTemplates {
+		uris = append(uris, tmpl.URITemplate)
+	}
+	want := "synapse://breadcrumbs/task/{id}"
+	if !strings.Contains(strings.Join(uris, " "), want) {
+		t.Errorf("expected %s among templates, got %v", want, uris)
+	}
+}
+
+func TestResourcesRead_Task(t *testing.T) {
+	server := newResourceTestServer(t)
+	syn, _ := server.store.Create("Pinned task")
+	server.store.Save()
+
+	var result struct {
+		Contents []resourceContents `json:"contents"`
+	}
+	decodeResult(t, call(t, server, "resources/read", `{"uri":"synapse://task/1"}`), &result)
+
+	var got types.Synapse
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &got); err != nil {
+		t.Fatalf("failed to decode task: %v", err)
+	}
+	if got.ID != syn.ID || got.Title != "Pinned task" {
+		t.Errorf("unexpected task: %+v", got)
+	}
+
+	if resp := call(t, server, "resources/read", `{"uri":"synapse://task/99"}`); resp.Error == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestResourcesSubscribe_NotifiesOnChange(t *testing.T) {
+	server := newResourceTestServer(t)
+	server.store.Create("Watched")
+	server.store.Create("Unwatched")
+	server.store.Save()
+	offset, _ := server.store.Events().Size()
+
+	decodeResult(t, call(t, server, "resources/subscribe", `{"uri":"synapse://task/1"}`), &map[string]any{})
+
+	// Another process edits both tasks
+	other := storage.NewJSONLStore(server.store.Dir())
+	other.Load()
+	for _, id := range []int{1, 2} {
+		syn, _ := other.Get(id)
+		syn.AddNote("edited elsewhere")
+		other.Update(syn)
+	}
+	other.Save()
+
+	var out strings.Builder
+	server.writer = &out
+	offset = server.notifySubscribers(offset)
+
+	var note jsonRPCNotification
+	if err := json.Unmarshal([]byte(out.String()), &note); err != nil {
+		t.Fatalf("expected exactly one notification, got %q: %v", out.String(), err)
+	}
+	params, _ := note.Params.(map[string]any)
+	if note.Method != "notifications/resources/updated" || params["uri"] != "synapse://task/1" {
+		t.Errorf("unexpected notification: %+v", note)
+	}
+
+	// Unsubscribed tasks stay quiet
+	call(t, server, "resources/unsubscribe", `{"uri":"synapse://task/1"}`)
+	syn, _ := other.Get(1)
+	syn.AddNote("edited again")
+	other.Update(syn)
+	other.Save()
+
+	out.Reset()
+	server.writer = &out
+	server.notifySubscribers(offset)
+	if out.Len() != 0 {
+		t.Errorf("expected no notification after unsubscribe, got %q", out.String())
+	}
+}
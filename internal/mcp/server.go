@@ -6,12 +6,16 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"os"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/swiftj/synapse/internal/storage"
@@ -39,14 +43,30 @@ func toFloat64(v any) (float64, bool) {
 	}
 }
 
-// requireID extracts a required integer ID from args, returning a clear error
-// message if missing or invalid. When key is "id", it also accepts "task_id"
-// as a fallback to handle LLM parameter name variations.
+// idAliases are the argument names accepted for a tool's target task ID,
+// in order of precedence, since LLMs vary the parameter name.
+var idAliases = []string{"id", "task_id", "task"}
+
+// resolveID extracts the target task ID from args, looking under each of
+// idAliases, with the same errors for every tool.
+func resolveID(args map[string]any) (int, error) {
+	for _, key := range idAliases {
+		if v, exists := args[key]; exists && v != nil {
+			f, ok := toFloat64(v)
+			if !ok {
+				return 0, fmt.Errorf("id must be a number, got %T: %v", v, v)
+			}
+			return int(f), nil
+		}
+	}
+	return 0, fmt.Errorf("id is required")
+}
+
+// requireID extracts a required integer ID other than the target task's,
+// such as parent_id, returning a clear error message if missing or invalid.
+// Use resolveID for the target task.
 func requireID(args map[string]any, key string) (int, error) {
 	v, exists := args[key]
-	if !exists && key == "id" {
-		v, exists = args["task_id"]
-	}
 	if !exists {
 		return 0, fmt.Errorf("%s is required", key)
 	}
@@ -72,16 +92,88 @@ type Server struct {
 	bcStore *storage.BreadcrumbStore
 	reader  *bufio.Reader
 	writer  io.Writer
+	writeMu sync.Mutex // Serializes responses and notifications
+	version string
+	logger  *logger
+
+	// Assignee for new tasks that don't name one, from the project config
+	defaultAssignee string
+	// Creator recorded on new tasks that don't name one, from SYNAPSE_AGENT
+	defaultAgent string
+	// Session for calls that don't pass session_id, from SYNAPSE_SESSION
+	defaultSession string
+
+	subMu         sync.Mutex
+	subscriptions map[string]bool // Resource URIs the client subscribed to
 }
 
+// supportedProtocolVersions lists the MCP protocol revisions this server
+// speaks, newest first.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// defaultServerVersion is reported in serverInfo until SetVersion is called.
+const defaultServerVersion = "dev"
+
 // NewServer creates a new MCP server.
 func NewServer(store *storage.JSONLStore, bcStore *storage.BreadcrumbStore) *Server {
 	return &Server{
-		store:   store,
-		bcStore: bcStore,
-		reader:  bufio.NewReader(os.Stdin),
-		writer:  os.Stdout,
+		store:         store,
+		bcStore:       bcStore,
+		reader:        bufio.NewReader(os.Stdin),
+		writer:        os.Stdout,
+		logger:        newLogger(os.Stderr),
+		version:       defaultServerVersion,
+		subscriptions: make(map[string]bool),
+	}
+}
+
+// SetVersion sets the server version reported to clients on initialize.
+func (s *Server) SetVersion(version string) {
+	s.version = version
+}
+
+// SetDefaultAssignee sets the assignee given to created and spawned tasks
+// that don't specify one.
+func (s *Server) SetDefaultAssignee(assignee string) {
+	s.defaultAssignee = assignee
+}
+
+// SetDefaultAgent sets the creator recorded on created and spawned tasks
+// whose call doesn't pass agent_id.
+func (s *Server) SetDefaultAgent(agent string) {
+	s.defaultAgent = agent
+}
+
+// createdByArg returns the creator for a new task: agent_id, or
+// created_by as the field itself is named, falling back to the default
+// agent.
+func (s *Server) createdByArg(args map[string]any) string {
+	if agent, ok := args["agent_id"].(string); ok && agent != "" {
+		return agent
+	}
+	if agent, ok := args["created_by"].(string); ok && agent != "" {
+		return agent
+	}
+	return s.defaultAgent
+}
+
+// SetDefaultSession sets the session that mutating tool calls without a
+// session_id are recorded under.
+func (s *Server) SetDefaultSession(session string) {
+	s.defaultSession = session
+}
+
+// sessionArg returns the session_id argument, or the default session.
+func (s *Server) sessionArg(args map[string]any) string {
+	if session, ok := args["session_id"].(string); ok && session != "" {
+		return session
 	}
+	return s.defaultSession
+}
+
+// SetLogLevel overrides the SYNAPSE_LOG level: error, info or debug.
+func (s *Server) SetLogLevel(level string) error {
+	return s.logger.setLevel(level)
 }
 
 // JSON-RPC 2.0 structures
@@ -99,6 +191,12 @@ type jsonRPCResponse struct {
 	ID      any       `json:"id"`
 }
 
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
 type rpcError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -116,7 +214,16 @@ type serverInfo struct {
 const MaxResponseSize = 50000
 
 type serverCapabilities struct {
-	Tools struct{} `json:"tools"`
+	Tools     struct{}              `json:"tools"`
+	Resources resourcesCapabilities `json:"resources"`
+}
+
+type resourcesCapabilities struct {
+	Subscribe bool `json:"subscribe"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
 }
 
 type initializeResult struct {
@@ -143,6 +250,10 @@ type toolCallParams struct {
 type toolCallResult struct {
 	Content []toolContent `json:"content"`
 	IsError bool          `json:"isError,omitempty"`
+
+	// StructuredContent carries the result as a native JSON object so
+	// clients needn't parse it back out of Content's text.
+	StructuredContent any `json:"structuredContent,omitempty"`
 }
 
 type toolContent struct {
@@ -152,8 +263,11 @@ type toolContent struct {
 
 // Run starts the MCP server main loop.
 func (s *Server) Run() error {
-	log.SetOutput(os.Stderr) // Log to stderr, not stdout
-	log.Println("MCP server starting...")
+	s.logger.infof("MCP server starting...")
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.watchEvents(done, eventPollInterval)
 
 	scanner := bufio.NewScanner(s.reader)
 	for scanner.Scan() {
@@ -162,7 +276,7 @@ func (s *Server) Run() error {
 			continue
 		}
 
-		log.Printf("Received: %s", line)
+		s.logger.body("Received", line)
 
 		var req jsonRPCRequest
 		if err := json.Unmarshal(line, &req); err != nil {
@@ -188,25 +302,56 @@ func (s *Server) handleRequest(req *jsonRPCRequest) {
 		s.handleToolsList(req)
 	case "tools/call":
 		s.handleToolsCall(req)
+	case "resources/list":
+		s.handleResourcesList(req)
+	case "resources/templates/list":
+		s.handleResourceTemplatesList(req)
+	case "resources/read":
+		s.handleResourcesRead(req)
+	case "resources/subscribe":
+		s.handleResourcesSubscribe(req, true)
+	case "resources/unsubscribe":
+		s.handleResourcesSubscribe(req, false)
 	default:
 		s.sendError(req.ID, -32601, "Method not found", fmt.Sprintf("unknown method: %s", req.Method))
 	}
 }
 
 func (s *Server) handleInitialize(req *jsonRPCRequest) {
+	var params initializeParams
+	if len(req.Params) > 0 {
+		// A malformed body just means no version was requested
+		_ = json.Unmarshal(req.Params, &params)
+	}
+
 	result := initializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: negotiateProtocolVersion(params.ProtocolVersion),
 		ServerInfo: serverInfo{
 			Name:    "synapse-mcp-server",
-			Version: "0.1.0",
+			Version: s.version,
+		},
+		Capabilities: serverCapabilities{
+			Resources: resourcesCapabilities{Subscribe: true},
 		},
-		Capabilities: serverCapabilities{},
 	}
 
 	s.sendResult(req.ID, result)
 }
 
-func (s *Server) handleToolsList(req *jsonRPCRequest) {
+// negotiateProtocolVersion echoes the client's requested protocol version
+// if the server supports it, and otherwise offers the latest it supports.
+func negotiateProtocolVersion(requested string) string {
+	for _, v := range supportedProtocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return supportedProtocolVersions[0]
+}
+
+// toolDefinitions returns the tools the server offers, with the input
+// schemas validateArgs checks calls against.
+func toolDefinitions() []tool {
 	tools := []tool{
 		{
 			Name:        "create_task",
@@ -229,6 +374,13 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 							"type": "number",
 						},
 					},
+					"related": map[string]any{
+						"type":        "array",
+						"description": "IDs of related tasks (\"see also\" links that never block)",
+						"items": map[string]any{
+							"type": "number",
+						},
+					},
 					"parent_id": map[string]any{
 						"type":        "number",
 						"description": "Parent task ID",
@@ -241,6 +393,14 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 						"type":        "number",
 						"description": "ID of the task from which this task was discovered (provenance tracking)",
 					},
+					"estimate_minutes": map[string]any{
+						"type":        "number",
+						"description": "Estimated effort in whole minutes, 0 or more; compared with the time from claim to completion",
+					},
+					"due_at": map[string]any{
+						"type":        "string",
+						"description": "Due date: RFC3339, YYYY-MM-DD (end of that day UTC), or a duration from now like 48h or 3d",
+					},
 					"labels": map[string]any{
 						"type":        "array",
 						"description": "Labels/tags for categorization (e.g., bug, feature, security)",
@@ -248,6 +408,14 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 							"type": "string",
 						},
 					},
+					"external_id": map[string]any{
+						"type":        "string",
+						"description": "Idempotency key, e.g. a request or upstream issue ID. If a task with this external_id exists it is returned unchanged (with existing: true) instead of creating a duplicate, so retries are safe.",
+					},
+					"agent_id": map[string]any{
+						"type":        "string",
+						"description": "Your agent ID, recorded as the task's created_by (default: the server's SYNAPSE_AGENT)",
+					},
 				},
 				"required": []string{"title"},
 			},
@@ -274,6 +442,14 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 						"type":        "string",
 						"description": "New assignee",
 					},
+					"estimate_minutes": map[string]any{
+						"type":        "number",
+						"description": "Estimated effort in minutes",
+					},
+					"due_at": map[string]any{
+						"type":        "string",
+						"description": "Due date: RFC3339, YYYY-MM-DD, or a duration from now like 3d; empty string clears it",
+					},
 					"blocked_by": map[string]any{
 						"type":        "array",
 						"description": "Updated list of blocking task IDs",
@@ -281,6 +457,13 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 							"type": "number",
 						},
 					},
+					"related": map[string]any{
+						"type":        "array",
+						"description": "Updated list of related task IDs (non-blocking links)",
+						"items": map[string]any{
+							"type": "number",
+						},
+					},
 					"labels": map[string]any{
 						"type":        "array",
 						"description": "Labels/tags for categorization (e.g., bug, feature, security)",
@@ -288,6 +471,14 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 							"type": "string",
 						},
 					},
+					"version": map[string]any{
+						"type":        "number",
+						"description": "Version of the task you last read; the update is rejected as stale if it has changed since (reload and retry)",
+					},
+					"force": map[string]any{
+						"type":        "boolean",
+						"description": "Bypass status transition rules (admin override, e.g. done -> in-progress)",
+					},
 				},
 				"required": []string{"id"},
 			},
@@ -306,9 +497,59 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				"required": []string{"id"},
 			},
 		},
+		{
+			Name:        "get_discovered",
+			Description: "List tasks discovered while working on a task (those spawned from it, whose discovered_from is id)",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID whose discoveries to list",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "get_critical_path",
+			Description: "Get the critical path to a task: the longest chain of unfinished blockers leading to it, in execution order and ending with the task. It bounds how soon the task can be done. Errors on a blocker cycle",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Target task ID",
+					},
+					"by_estimate": map[string]any{
+						"type":        "boolean",
+						"description": "Weigh each task by estimate_minutes instead of counting steps",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "get_task_context",
+			Description: "Get a briefing for picking up a task: the task with its notes, its parent chain, its direct blockers with their statuses, sibling tasks under the same parent, and its linked breadcrumbs",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID",
+					},
+					"max_chars": map[string]any{
+						"type":        "number",
+						"description": "Maximum response size in characters (default: 50000). Larger briefings have long texts cut to 100 characters, then siblings dropped, and are marked truncated.",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
 		{
 			Name:        "list_tasks",
-			Description: "List tasks with optional filters and pagination. Returns summary by default to prevent response size issues. Use get_task(id) for full task details.",
+			Description: "List tasks with optional filters, all of which must match, and pagination. Returns summary by default to prevent response size issues. Use get_task(id) for full task details.",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
@@ -320,17 +561,45 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 						"type":        "string",
 						"description": "Filter by assignee",
 					},
+					"unassigned": map[string]any{
+						"type":        "boolean",
+						"description": "If true, return only tasks with no assignee (for triage)",
+					},
+					"completed_by": map[string]any{
+						"type":        "string",
+						"description": "Filter by the agent that completed the task, most recent first",
+					},
+					"created_after": map[string]any{
+						"type":        "string",
+						"description": "Only tasks created after this time: RFC3339 timestamp or relative age like \"24h\" or \"7d\"",
+					},
+					"updated_after": map[string]any{
+						"type":        "string",
+						"description": "Only tasks updated after this time: RFC3339 timestamp or relative age like \"24h\" or \"7d\"",
+					},
 					"label": map[string]any{
 						"type":        "string",
 						"description": "Filter by label",
 					},
+					"include_archived": map[string]any{
+						"type":        "boolean",
+						"description": "If true, also list archived tasks (default: false)",
+					},
 					"limit": map[string]any{
 						"type":        "number",
 						"description": "Maximum number of tasks to return (default: 20)",
 					},
+					"sort": map[string]any{
+						"type":        "string",
+						"description": "Sort key: id (default), priority, updated, created, title, status, assignee, or blockers",
+					},
+					"order": map[string]any{
+						"type":        "string",
+						"description": "Sort order: asc (default) or desc",
+					},
 					"offset": map[string]any{
 						"type":        "number",
-						"description": "Number of tasks to skip for pagination",
+						"description": "Number of tasks to skip for pagination. Responses include next_offset while more tasks remain; pass it back to fetch the next page.",
 					},
 					"summary": map[string]any{
 						"type":        "boolean",
@@ -350,6 +619,35 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				},
 			},
 		},
+		{
+			Name:        "count_tasks",
+			Description: "Count tasks without returning them, e.g. for dashboards. Filters combine: a task is counted only if it matches all of them.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"status": map[string]any{
+						"type":        "string",
+						"description": "Only tasks with this status",
+					},
+					"label": map[string]any{
+						"type":        "string",
+						"description": "Only tasks with this label",
+					},
+					"assignee": map[string]any{
+						"type":        "string",
+						"description": "Only tasks assigned to this role",
+					},
+					"unassigned": map[string]any{
+						"type":        "boolean",
+						"description": "Only tasks with no assignee",
+					},
+					"include_archived": map[string]any{
+						"type":        "boolean",
+						"description": "If true, also count archived tasks (default: false)",
+					},
+				},
+			},
+		},
 		{
 			Name:        "get_next_task",
 			Description: "Get the highest priority ready task",
@@ -358,9 +656,23 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				"properties": map[string]any{
 					"assignee": map[string]any{
 						"type":        "string",
-						"description": "Filter by assignee role",
+						"description": "Filter by assignee role; a role defined in .synapse/roles.json also matches tasks assigned to its member agents",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_parallel_tasks",
+			Description: "Suggest up to count ready tasks for that many agents to work on at once, by priority. Tasks under different parents and with different assignees are preferred, so the batch is as independent as possible",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"count": map[string]any{
+						"type":        "number",
+						"description": "Maximum number of tasks, e.g. the number of idle agents",
 					},
 				},
+				"required": []string{"count"},
 			},
 		},
 		{
@@ -395,10 +707,64 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 						"type":        "boolean",
 						"description": "Whether this task should be blocked by the parent (default false)",
 					},
+					"agent_id": map[string]any{
+						"type":        "string",
+						"description": "Your agent ID, recorded as the task's created_by (default: the server's SYNAPSE_AGENT)",
+					},
 				},
 				"required": []string{"parent_task_id", "title"},
 			},
 		},
+		{
+			Name:        "set_parent",
+			Description: "Make an existing task a subtask of another (sets parent_id). Rejects missing parents and parent cycles; pass parent_id 0 to detach.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID to re-parent",
+					},
+					"parent_id": map[string]any{
+						"type":        "number",
+						"description": "New parent task ID, or 0 to detach from its parent",
+					},
+				},
+				"required": []string{"id", "parent_id"},
+			},
+		},
+		{
+			Name:        "archive_task",
+			Description: "Archive a task that is no longer relevant but carries context worth keeping. Archived tasks are hidden from list_tasks and get_next_task; an archived done task still satisfies its dependents.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID to archive",
+					},
+					"unarchive": map[string]any{
+						"type":        "boolean",
+						"description": "If true, return the task to the board instead",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "create_from_template",
+			Description: "Create a fresh task from a recurring-task template (defined with `synapse template set`)",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": "Template name",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
 		{
 			Name:        "add_note",
 			Description: "Add a note to a task for context persistence",
@@ -453,6 +819,36 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				"required": []string{"key"},
 			},
 		},
+		{
+			Name:        "set_breadcrumbs",
+			Description: "Store several breadcrumbs in one call with a single save. Reports for each key whether it was created or updated",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"breadcrumbs": map[string]any{
+						"type":        "array",
+						"description": "Breadcrumbs to store, each {key, value, task_id?} as for set_breadcrumb",
+						"items":       map[string]any{"type": "object"},
+					},
+				},
+				"required": []string{"breadcrumbs"},
+			},
+		},
+		{
+			Name:        "get_breadcrumbs",
+			Description: "Retrieve several breadcrumbs by exact key. Reports for each key whether it was found",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"keys": map[string]any{
+						"type":        "array",
+						"description": "Exact keys to retrieve",
+						"items":       map[string]any{"type": "string"},
+					},
+				},
+				"required": []string{"keys"},
+			},
+		},
 		{
 			Name:        "list_breadcrumbs",
 			Description: "Query breadcrumbs with optional prefix filter",
@@ -470,9 +866,17 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				},
 			},
 		},
+		{
+			Name:        "list_namespaces",
+			Description: "List the distinct breadcrumb key namespaces (the segment before the first '.') with the number of keys in each, to see what context exists before listing it",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+		},
 		{
 			Name:        "delete_breadcrumb",
-			Description: "Remove a breadcrumb by key",
+			Description: "Remove a breadcrumb by exact key, or every breadcrumb under a key prefix. Give key or prefix, not both",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
@@ -480,8 +884,15 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 						"type":        "string",
 						"description": "Exact key to delete",
 					},
+					"prefix": map[string]any{
+						"type":        "string",
+						"description": "Delete all keys starting with this prefix (e.g., 'auth.')",
+					},
+					"all": map[string]any{
+						"type":        "boolean",
+						"description": "Delete every breadcrumb. Required instead of an empty prefix",
+					},
 				},
-				"required": []string{"key"},
 			},
 		},
 		{
@@ -500,7 +911,11 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 					},
 					"timeout_minutes": map[string]any{
 						"type":        "number",
-						"description": "Claim timeout in minutes (default: 30)",
+						"description": "Claim timeout in minutes (default: the project's claim-timeout setting, else 30)",
+					},
+					"steal": map[string]any{
+						"type":        "boolean",
+						"description": "Take over the task even if another agent holds an active claim; the previous claimant is recorded in a note",
 					},
 				},
 				"required": []string{"id", "agent_id"},
@@ -520,6 +935,24 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				"required": []string{"id"},
 			},
 		},
+		{
+			Name:        "renew_claim",
+			Description: "Extend your claim on a long-running task by resetting its claim time to now. Fails if you no longer hold the claim",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID to renew",
+					},
+					"agent_id": map[string]any{
+						"type":        "string",
+						"description": "Your agent identifier; must match the current claim",
+					},
+				},
+				"required": []string{"id", "agent_id"},
+			},
+		},
 		{
 			Name:        "complete_task_as",
 			Description: "Mark a task as done with agent attribution",
@@ -538,6 +971,32 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 				"required": []string{"id", "agent_id"},
 			},
 		},
+		{
+			Name:        "request_review",
+			Description: "Hand an in-progress task off for review (status review), optionally reassigning it to a reviewer role. Releases your claim so the reviewer can claim it and records the request as a note.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID to send for review",
+					},
+					"reviewer": map[string]any{
+						"type":        "string",
+						"description": "Role to reassign the task to (e.g. @qa); keeps the current assignee if omitted",
+					},
+					"agent_id": map[string]any{
+						"type":        "string",
+						"description": "Your agent identifier, recorded in the note (default: the claiming agent)",
+					},
+					"force": map[string]any{
+						"type":        "boolean",
+						"description": "Bypass status transition rules (e.g. review a task that was never started)",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
 		{
 			Name:        "get_context_window",
 			Description: "Get tasks modified within a time window (for session context)",
@@ -570,17 +1029,72 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 			},
 		},
 		{
-			Name:        "delete_task",
-			Description: "Delete a task by ID, delete all tasks, or delete all completed tasks",
+			Name:        "active_agents",
+			Description: "List agents currently holding claims, with their claimed task IDs and oldest claim age. Agents whose claims have all expired are flagged as stale",
 			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
-					"id": map[string]any{
+					"timeout_minutes": map[string]any{
 						"type":        "number",
-						"description": "Task ID to delete (omit when using delete_all or delete_completed)",
+						"description": "Claim timeout used to decide which claims have expired (default: the project's claim-timeout setting, else 30)",
 					},
-					"delete_all": map[string]any{
-						"type":        "boolean",
+				},
+			},
+		},
+		{
+			Name:        "get_workload",
+			Description: "Count each assignee's unarchived tasks by status (open, in_progress, blocked, review, done) with their active claims, busiest first by open plus in-progress, to spot overloaded or idle agents. Tasks with no assignee are in the bucket with an empty assignee",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{},
+			},
+		},
+		{
+			Name:        "get_session",
+			Description: "Replay an agent session: the audit events recorded under session_id, oldest first, and the tasks the session touched. Use it to post-mortem a specific agent run",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"session_id": map[string]any{
+						"type":        "string",
+						"description": "Session to replay (default: the server's SYNAPSE_SESSION)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_history",
+			Description: "Read the audit log of task changes (creations, status changes, claims, deletions), newest first. Use it to reconstruct who changed a task and when",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"task_id": map[string]any{
+						"type":        "number",
+						"description": "Only return events for this task",
+					},
+					"limit": map[string]any{
+						"type":        "number",
+						"description": "Maximum number of events to return (default: 50)",
+					},
+					"max_chars": map[string]any{
+						"type":        "number",
+						"description": "Maximum response size in characters (default: 50000). The oldest events are dropped to fit, and the response is marked truncated.",
+					},
+				},
+			},
+		},
+		{
+			Name:        "delete_task",
+			Description: "Delete a task by ID, delete all tasks, or delete all completed tasks",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id": map[string]any{
+						"type":        "number",
+						"description": "Task ID to delete (omit when using delete_all or delete_completed)",
+					},
+					"delete_all": map[string]any{
+						"type":        "boolean",
 						"description": "If true, delete all tasks (id is ignored)",
 					},
 					"delete_completed": map[string]any{
@@ -592,7 +1106,30 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) {
 		},
 	}
 
-	s.sendResult(req.ID, toolsListResult{Tools: tools})
+	// Every mutating tool takes the session its changes are recorded under
+	for _, t := range tools {
+		if mutatingTools[t.Name] {
+			props := t.InputSchema["properties"].(map[string]any)
+			props["session_id"] = map[string]any{
+				"type":        "string",
+				"description": "Agent session to record this change under, for get_session (default: the server's SYNAPSE_SESSION)",
+			}
+		}
+	}
+	return tools
+}
+
+// toolsByName indexes toolDefinitions for argument validation.
+var toolsByName = func() map[string]tool {
+	byName := make(map[string]tool)
+	for _, t := range toolDefinitions() {
+		byName[t.Name] = t
+	}
+	return byName
+}()
+
+func (s *Server) handleToolsList(req *jsonRPCRequest) {
+	s.sendResult(req.ID, toolsListResult{Tools: toolDefinitions()})
 }
 
 func (s *Server) handleToolsCall(req *jsonRPCRequest) {
@@ -602,6 +1139,101 @@ func (s *Server) handleToolsCall(req *jsonRPCRequest) {
 		return
 	}
 
+	if def, ok := toolsByName[params.Name]; ok {
+		if params.Arguments == nil {
+			params.Arguments = map[string]any{}
+		}
+		if err := validateArgs(def.InputSchema, params.Arguments); err != nil {
+			s.sendError(req.ID, -32602, "Invalid params", fmt.Sprintf("%s: %v", params.Name, err))
+			return
+		}
+	}
+
+	var result toolCallResult
+	var err error
+
+	if mutatingTools[params.Name] {
+		// Reload under the file lock so writes from other processes (CLI,
+		// other MCP servers) are not clobbered by this server's stale state
+		err = s.store.WithLock(func() error {
+			if err := s.store.Load(); err != nil {
+				return fmt.Errorf("reload store: %w", err)
+			}
+			s.store.SetSession(s.sessionArg(params.Arguments))
+			result, err = s.callTool(params)
+			return err
+		})
+	} else {
+		result, err = s.callTool(params)
+	}
+
+	if errors.Is(err, errUnknownTool) {
+		s.sendError(req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	if err != nil {
+		result = toolCallResult{
+			Content: []toolContent{{
+				Type: "text",
+				Text: fmt.Sprintf("Error: %v", err),
+			}},
+			IsError: true,
+		}
+	}
+
+	s.sendResult(req.ID, result)
+}
+
+// saveFailed logs a failed save after op and returns the error to report
+// to the agent. The change stays in memory, but mutating calls reload the
+// store first, so a retry starts again from what was last saved.
+func (s *Server) saveFailed(op string, err error) error {
+	s.logger.errorf("failed to save after %s: %v", op, err)
+	return fmt.Errorf("change was not saved, retry: %w", err)
+}
+
+// completionJSON renders a completed task, adding an "unblocked" list of
+// the dependents its completion freed, if any.
+func completionJSON(syn *types.Synapse, unblocked []int) ([]byte, error) {
+	if len(unblocked) == 0 {
+		return json.MarshalIndent(syn, "", "  ")
+	}
+	data, err := syn.MarshalWith(map[string]any{"unblocked": unblocked})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mutatingTools lists the tools that modify the task store and therefore
+// run under the store's cross-process file lock.
+var mutatingTools = map[string]bool{
+	"create_task":          true,
+	"update_task":          true,
+	"complete_task":        true,
+	"spawn_task":           true,
+	"set_parent":           true,
+	"archive_task":         true,
+	"create_from_template": true,
+	"add_note":             true,
+	"claim_task":           true,
+	"release_claim":        true,
+	"renew_claim":          true,
+	"complete_task_as":     true,
+	"request_review":       true,
+	"delete_task":          true,
+}
+
+// errUnknownTool is returned by callTool for tool names it doesn't handle.
+var errUnknownTool = errors.New("unknown tool")
+
+// callTool dispatches a tool call to its handler.
+func (s *Server) callTool(params toolCallParams) (toolCallResult, error) {
 	var result toolCallResult
 	var err error
 
@@ -612,52 +1244,75 @@ func (s *Server) handleToolsCall(req *jsonRPCRequest) {
 		result, err = s.updateTask(params.Arguments)
 	case "get_task":
 		result, err = s.getTask(params.Arguments)
+	case "get_discovered":
+		result, err = s.getDiscovered(params.Arguments)
+	case "get_critical_path":
+		result, err = s.getCriticalPath(params.Arguments)
+	case "get_task_context":
+		result, err = s.getTaskContext(params.Arguments)
 	case "list_tasks":
 		result, err = s.listTasks(params.Arguments)
+	case "count_tasks":
+		result, err = s.countTasks(params.Arguments)
 	case "get_next_task":
 		result, err = s.getNextTask(params.Arguments)
+	case "get_parallel_tasks":
+		result, err = s.getParallelTasks(params.Arguments)
 	case "complete_task":
 		result, err = s.completeTask(params.Arguments)
 	case "spawn_task":
 		result, err = s.spawnTask(params.Arguments)
+	case "set_parent":
+		result, err = s.setParent(params.Arguments)
+	case "archive_task":
+		result, err = s.archiveTask(params.Arguments)
+	case "create_from_template":
+		result, err = s.createFromTemplate(params.Arguments)
 	case "add_note":
 		result, err = s.addNote(params.Arguments)
 	case "set_breadcrumb":
 		result, err = s.setBreadcrumb(params.Arguments)
 	case "get_breadcrumb":
 		result, err = s.getBreadcrumb(params.Arguments)
+	case "set_breadcrumbs":
+		result, err = s.setBreadcrumbs(params.Arguments)
+	case "get_breadcrumbs":
+		result, err = s.getBreadcrumbs(params.Arguments)
 	case "list_breadcrumbs":
 		result, err = s.listBreadcrumbs(params.Arguments)
+	case "list_namespaces":
+		result, err = s.listNamespaces()
 	case "delete_breadcrumb":
 		result, err = s.deleteBreadcrumb(params.Arguments)
 	case "claim_task":
 		result, err = s.claimTask(params.Arguments)
 	case "release_claim":
 		result, err = s.releaseClaim(params.Arguments)
+	case "renew_claim":
+		result, err = s.renewClaim(params.Arguments)
 	case "complete_task_as":
 		result, err = s.completeTaskAs(params.Arguments)
+	case "request_review":
+		result, err = s.requestReview(params.Arguments)
 	case "get_context_window":
 		result, err = s.getContextWindow(params.Arguments)
 	case "my_tasks":
 		result, err = s.myTasks(params.Arguments)
+	case "active_agents":
+		result, err = s.activeAgents(params.Arguments)
+	case "get_workload":
+		result, err = s.getWorkload(params.Arguments)
+	case "get_session":
+		result, err = s.getSession(params.Arguments)
+	case "get_history":
+		result, err = s.getHistory(params.Arguments)
 	case "delete_task":
 		result, err = s.deleteTask(params.Arguments)
 	default:
-		s.sendError(req.ID, -32602, "Invalid params", fmt.Sprintf("unknown tool: %s", params.Name))
-		return
-	}
-
-	if err != nil {
-		result = toolCallResult{
-			Content: []toolContent{{
-				Type: "text",
-				Text: fmt.Sprintf("Error: %v", err),
-			}},
-			IsError: true,
-		}
+		return toolCallResult{}, fmt.Errorf("%w: %s", errUnknownTool, params.Name)
 	}
 
-	s.sendResult(req.ID, result)
+	return result, err
 }
 
 func (s *Server) createTask(args map[string]any) (toolCallResult, error) {
@@ -666,10 +1321,43 @@ func (s *Server) createTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, fmt.Errorf("title is required")
 	}
 
-	syn, err := s.store.Create(title)
+	// Validated up front so a bad value doesn't leave a half-created task
+	related, hasRelated, err := s.relatedArg(args, 0)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	estimate, hasEstimate, err := estimateArg(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
+	var dueAt *time.Time
+	if dueRaw, ok := args["due_at"].(string); ok && dueRaw != "" {
+		due, err := storage.ParseDue(dueRaw, time.Now())
+		if err != nil {
+			return toolCallResult{}, err
+		}
+		dueAt = &due
+	}
+
+	externalID, _ := args["external_id"].(string)
+	syn, created, err := s.store.CreateIdempotent(title, externalID)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	if !created {
+		// A retry of an earlier create: return the task as it is now
+		data, err := syn.MarshalWith(map[string]any{"existing": true})
+		if err != nil {
+			return toolCallResult{}, err
+		}
+		return toolCallResult{
+			Content: []toolContent{{
+				Type: "text",
+				Text: string(data),
+			}},
+			StructuredContent: json.RawMessage(data),
+		}, nil
+	}
 
 	// Set optional fields
 	if priority, ok := optionalFloat64(args, "priority"); ok {
@@ -690,12 +1378,23 @@ func (s *Server) createTask(args map[string]any) (toolCallResult, error) {
 		syn.ParentID = int(parentID)
 	}
 
+	syn.Assignee = s.defaultAssignee
 	if assignee, ok := args["assignee"].(string); ok {
 		syn.Assignee = assignee
 	}
+	syn.CreatedBy = s.createdByArg(args)
 
 	if discoveredFrom, ok := optionalFloat64(args, "discovered_from"); ok {
-		syn.DiscoveredFrom = fmt.Sprintf("#%d", int(discoveredFrom))
+		syn.DiscoveredFrom = int(discoveredFrom)
+	}
+
+	if hasEstimate {
+		syn.EstimateMinutes = estimate
+	}
+
+	syn.DueAt = dueAt
+	if hasRelated {
+		syn.RelatedTo = related
 	}
 
 	if labelsRaw, ok := args["labels"].([]any); ok {
@@ -713,7 +1412,7 @@ func (s *Server) createTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after create: %v", err)
+		return toolCallResult{}, s.saveFailed("create", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -722,11 +1421,53 @@ func (s *Server) createTask(args map[string]any) (toolCallResult, error) {
 			Type: "text",
 			Text: string(data),
 		}},
+		StructuredContent: syn,
 	}, nil
 }
 
+// relatedArg reads the optional "related" ID list, reporting whether it was
+// given. Every ID must name an existing task other than self.
+func (s *Server) relatedArg(args map[string]any, self int) ([]int, bool, error) {
+	raw, ok := args["related"].([]any)
+	if !ok {
+		return nil, false, nil
+	}
+	related := make([]int, 0, len(raw))
+	for _, v := range raw {
+		id, ok := toFloat64(v)
+		if !ok {
+			return nil, false, fmt.Errorf("related IDs must be numbers, got %T: %v", v, v)
+		}
+		if int(id) == self {
+			return nil, false, fmt.Errorf("task %d cannot be related to itself", self)
+		}
+		if _, err := s.store.Get(int(id)); err != nil {
+			return nil, false, fmt.Errorf("related task: %w", err)
+		}
+		if !slices.Contains(related, int(id)) {
+			related = append(related, int(id))
+		}
+	}
+	return related, true, nil
+}
+
+// estimateArg reads the optional "estimate_minutes", reporting whether it
+// was given. Like the CLI's --estimate, it must be a whole number of
+// minutes, zero or more.
+func estimateArg(args map[string]any) (int, bool, error) {
+	v, exists := args["estimate_minutes"]
+	if !exists {
+		return 0, false, nil
+	}
+	f, ok := toFloat64(v)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return 0, false, fmt.Errorf("invalid estimate_minutes: %v (must be a whole number of minutes, 0 or more)", v)
+	}
+	return int(f), true, nil
+}
+
 func (s *Server) updateTask(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -736,12 +1477,20 @@ func (s *Server) updateTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, err
 	}
 
+	// Edit a copy so a rejected update (stale version or illegal
+	// transition) leaves the stored task untouched
+	edited := *syn
+	syn = &edited
+	if version, ok := optionalFloat64(args, "version"); ok {
+		syn.Version = int(version)
+	}
+
+	newStatus := syn.Status
 	if status, ok := args["status"].(string); ok {
-		newStatus := types.Status(status)
+		newStatus = types.Status(status)
 		if !newStatus.IsValid() {
 			return toolCallResult{}, fmt.Errorf("invalid status: %s", status)
 		}
-		syn.Status = newStatus
 	}
 
 	if priority, ok := optionalFloat64(args, "priority"); ok {
@@ -752,6 +1501,30 @@ func (s *Server) updateTask(args map[string]any) (toolCallResult, error) {
 		syn.Assignee = assignee
 	}
 
+	if estimate, ok, err := estimateArg(args); err != nil {
+		return toolCallResult{}, err
+	} else if ok {
+		syn.EstimateMinutes = estimate
+	}
+
+	if related, ok, err := s.relatedArg(args, syn.ID); err != nil {
+		return toolCallResult{}, err
+	} else if ok {
+		syn.RelatedTo = related
+	}
+
+	if dueRaw, ok := args["due_at"].(string); ok {
+		if dueRaw == "" {
+			syn.DueAt = nil
+		} else {
+			due, err := storage.ParseDue(dueRaw, time.Now())
+			if err != nil {
+				return toolCallResult{}, err
+			}
+			syn.DueAt = &due
+		}
+	}
+
 	if blockedByRaw, ok := args["blocked_by"].([]any); ok {
 		blockedBy := make([]int, 0, len(blockedByRaw))
 		for _, v := range blockedByRaw {
@@ -772,12 +1545,27 @@ func (s *Server) updateTask(args map[string]any) (toolCallResult, error) {
 		syn.Labels = labels
 	}
 
+	// Validated after blocked_by is applied, so clearing blockers and
+	// starting work can happen in one call
+	if force, _ := args["force"].(bool); !force {
+		if err := syn.ValidateTransition(newStatus, s.store.IsDone); err != nil {
+			return toolCallResult{}, fmt.Errorf("%w; pass force=true to override", err)
+		}
+	}
+	if newStatus == types.StatusDone && syn.Status != types.StatusDone {
+		syn.MarkDone() // Records the actual time spent
+	}
+	syn.Status = newStatus
+
 	if err := s.store.Update(syn); err != nil {
+		if errors.Is(err, storage.ErrStaleWrite) {
+			return toolCallResult{}, fmt.Errorf("%w; reload the task with get_task and retry", err)
+		}
 		return toolCallResult{}, err
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after update: %v", err)
+		return toolCallResult{}, s.saveFailed("update", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -790,7 +1578,7 @@ func (s *Server) updateTask(args map[string]any) (toolCallResult, error) {
 }
 
 func (s *Server) getTask(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -801,6 +1589,96 @@ func (s *Server) getTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+		StructuredContent: syn,
+	}, nil
+}
+
+func (s *Server) getTaskContext(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	maxChars := MaxResponseSize
+	if mc, ok := optionalFloat64(args, "max_chars"); ok && mc > 0 {
+		maxChars = int(mc)
+	}
+
+	ctx, err := storage.BuildTaskContext(s.store, s.bcStore, id, maxChars)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	data, _ := json.MarshalIndent(ctx, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+		StructuredContent: ctx,
+	}, nil
+}
+
+func (s *Server) getDiscovered(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	if _, err := s.store.Get(id); err != nil {
+		return toolCallResult{}, err
+	}
+
+	tasks := s.store.DiscoveredFrom(id)
+	if tasks == nil {
+		tasks = []*types.Synapse{}
+	}
+
+	result := map[string]any{
+		"discovered_from": id,
+		"count":           len(tasks),
+		"tasks":           tasks,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) getCriticalPath(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	criticalPath := s.store.CriticalPath
+	if byEstimate, _ := args["by_estimate"].(bool); byEstimate {
+		criticalPath = s.store.CriticalPathByEstimate
+	}
+	chain, err := criticalPath(id)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	estimate := 0
+	for _, syn := range chain {
+		estimate += syn.EstimateMinutes
+	}
+
+	result := map[string]any{
+		"id":               id,
+		"steps":            len(chain),
+		"estimate_minutes": estimate,
+		"path":             chain,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
 	return toolCallResult{
 		Content: []toolContent{{
 			Type: "text",
@@ -810,17 +1688,54 @@ func (s *Server) getTask(args map[string]any) (toolCallResult, error) {
 }
 
 func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
+	// Every filter given must match, as in count_tasks and the CLI. An
+	// empty assignee, like unassigned, matches tasks nobody owns.
+	var filter storage.CountFilter
+	if status, _ := args["status"].(string); status != "" {
+		filter.Status = types.Status(status)
+	}
+	filter.Label, _ = args["label"].(string)
+	filter.Unassigned, _ = args["unassigned"].(bool)
+	filter.IncludeArchived, _ = args["include_archived"].(bool)
+	if assignee, ok := args["assignee"].(string); ok {
+		filter.Assignee = assignee
+		filter.Unassigned = filter.Unassigned || assignee == ""
+	}
+
 	var tasks []*types.Synapse
+	for _, syn := range s.store.All() {
+		if filter.Matches(syn) {
+			tasks = append(tasks, syn)
+		}
+	}
+	// Most recently completed first, for auditing an agent's work
+	if completedBy, ok := args["completed_by"].(string); ok && completedBy != "" {
+		tasks = storage.Intersect(s.store.CompletedBy(completedBy), tasks)
+	}
 
-	// Apply filters
-	if label, ok := args["label"].(string); ok {
-		tasks = s.store.ByLabel(label)
-	} else if status, ok := args["status"].(string); ok {
-		tasks = s.store.ByStatus(types.Status(status))
-	} else if assignee, ok := args["assignee"].(string); ok {
-		tasks = s.store.ByAssignee(assignee)
-	} else {
-		tasks = s.store.All()
+	// Time filters narrow whichever selection was made above
+	now := time.Now()
+	if createdAfter, ok := args["created_after"].(string); ok && createdAfter != "" {
+		since, err := storage.ParseSince(createdAfter, now)
+		if err != nil {
+			return toolCallResult{}, fmt.Errorf("created_after: %w", err)
+		}
+		tasks = storage.Intersect(tasks, s.store.CreatedSince(since))
+	}
+	if updatedAfter, ok := args["updated_after"].(string); ok && updatedAfter != "" {
+		since, err := storage.ParseSince(updatedAfter, now)
+		if err != nil {
+			return toolCallResult{}, fmt.Errorf("updated_after: %w", err)
+		}
+		tasks = storage.Intersect(tasks, s.store.ModifiedSince(since))
+	}
+
+	sortKey, _ := args["sort"].(string)
+	order, _ := args["order"].(string)
+	if sortKey != "" || order != "" {
+		if err := types.SortSynapses(tasks, sortKey, order); err != nil {
+			return toolCallResult{}, err
+		}
 	}
 
 	totalCount := len(tasks)
@@ -853,6 +1768,12 @@ func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
 		tasks = tasks[:limit]
 	}
 
+	// Offset of the next page, if there is one
+	nextOffset := -1
+	if offset+len(tasks) < totalCount {
+		nextOffset = offset + len(tasks)
+	}
+
 	// Check for summary mode (default true) and fields selection
 	summary := true
 	if s, ok := args["summary"].(bool); ok {
@@ -907,6 +1828,9 @@ func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
 			"limit":  limit,
 			"offset": offset,
 		}
+		if nextOffset >= 0 {
+			response["next_offset"] = nextOffset
+		}
 		data, _ = json.Marshal(response)
 	} else {
 		// Full mode: return complete task objects
@@ -916,11 +1840,14 @@ func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
 			"limit":  limit,
 			"offset": offset,
 		}
+		if nextOffset >= 0 {
+			response["next_offset"] = nextOffset
+		}
 		data, _ = json.Marshal(response)
 
 		// Check if response exceeds size limit - auto-fallback to summary mode
 		if len(data) > maxChars {
-			log.Printf("Response size %d exceeds limit %d, falling back to summary mode", len(data), maxChars)
+			s.logger.infof("Response size %d exceeds limit %d, falling back to summary mode", len(data), maxChars)
 
 			// Rebuild as summary with truncated notes indicator
 			summaryTasks := make([]map[string]any, 0, len(tasks))
@@ -955,6 +1882,9 @@ func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
 				"truncation_reason": "response_size_exceeded",
 				"hint":             "Use get_task(id) to retrieve full task details, or use fields parameter to select specific fields",
 			}
+			if nextOffset >= 0 {
+				response["next_offset"] = nextOffset
+			}
 			data, _ = json.Marshal(response)
 		}
 	}
@@ -964,6 +1894,31 @@ func (s *Server) listTasks(args map[string]any) (toolCallResult, error) {
 			Type: "text",
 			Text: string(data),
 		}},
+		StructuredContent: response,
+	}, nil
+}
+
+func (s *Server) countTasks(args map[string]any) (toolCallResult, error) {
+	var filter storage.CountFilter
+	if status, ok := args["status"].(string); ok && status != "" {
+		filter.Status = types.Status(status)
+		if !filter.Status.IsValid() {
+			return toolCallResult{}, fmt.Errorf("invalid status: %s", status)
+		}
+	}
+	filter.Label, _ = args["label"].(string)
+	filter.Assignee, _ = args["assignee"].(string)
+	filter.Unassigned, _ = args["unassigned"].(bool)
+	filter.IncludeArchived, _ = args["include_archived"].(bool)
+
+	response := map[string]any{"count": s.store.CountWhere(filter)}
+	data, _ := json.Marshal(response)
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+		StructuredContent: response,
 	}, nil
 }
 
@@ -989,6 +1944,9 @@ func (s *Server) synapseToFieldMap(t *types.Synapse, fields map[string]bool) map
 	if fields["blocked_by"] {
 		result["blocked_by"] = t.BlockedBy
 	}
+	if fields["related_to"] {
+		result["related_to"] = t.RelatedTo
+	}
 	if fields["parent_id"] {
 		result["parent_id"] = t.ParentID
 	}
@@ -1004,6 +1962,9 @@ func (s *Server) synapseToFieldMap(t *types.Synapse, fields map[string]bool) map
 	if fields["notes"] {
 		result["notes"] = t.Notes
 	}
+	if fields["created_by"] {
+		result["created_by"] = t.CreatedBy
+	}
 	if fields["claimed_by"] {
 		result["claimed_by"] = t.ClaimedBy
 	}
@@ -1013,6 +1974,18 @@ func (s *Server) synapseToFieldMap(t *types.Synapse, fields map[string]bool) map
 	if fields["completed_by"] {
 		result["completed_by"] = t.CompletedBy
 	}
+	if fields["estimate_minutes"] {
+		result["estimate_minutes"] = t.EstimateMinutes
+	}
+	if fields["actual_minutes"] {
+		result["actual_minutes"] = t.ActualMinutes
+	}
+	if fields["due_at"] {
+		result["due_at"] = t.DueAt
+	}
+	if fields["archived"] {
+		result["archived"] = t.Archived
+	}
 	if fields["created_at"] {
 		result["created_at"] = t.CreatedAt
 	}
@@ -1027,24 +2000,21 @@ func (s *Server) getNextTask(args map[string]any) (toolCallResult, error) {
 	ready := s.store.Ready()
 
 	if assignee, ok := args["assignee"].(string); ok {
-		// Filter by assignee
-		for _, task := range ready {
-			if task.Assignee == assignee {
-				data, _ := json.MarshalIndent(task, "", "  ")
-				return toolCallResult{
-					Content: []toolContent{{
-						Type: "text",
-						Text: string(data),
-					}},
-				}, nil
-			}
+		// Roles are edited from the CLI, so read them fresh on each call
+		roles, err := storage.LoadRoles(s.store.Dir())
+		if err != nil {
+			return toolCallResult{}, err
+		}
+		s.store.SetRoles(roles)
+		ready = s.store.ReadyFor(assignee)
+		if len(ready) == 0 {
+			return toolCallResult{
+				Content: []toolContent{{
+					Type: "text",
+					Text: "null",
+				}},
+			}, nil
 		}
-		return toolCallResult{
-			Content: []toolContent{{
-				Type: "text",
-				Text: "null",
-			}},
-		}, nil
 	}
 
 	if len(ready) > 0 {
@@ -1065,8 +2035,35 @@ func (s *Server) getNextTask(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+func (s *Server) getParallelTasks(args map[string]any) (toolCallResult, error) {
+	count, err := requireID(args, "count")
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	if count < 1 {
+		return toolCallResult{}, fmt.Errorf("count must be at least 1, got %d", count)
+	}
+
+	tasks := s.store.ParallelBatch(count)
+	if tasks == nil {
+		tasks = []*types.Synapse{}
+	}
+
+	result := map[string]any{
+		"count": len(tasks),
+		"tasks": tasks,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
 func (s *Server) completeTask(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -1076,17 +2073,16 @@ func (s *Server) completeTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, err
 	}
 
-	syn.MarkDone()
-
-	if err := s.store.Update(syn); err != nil {
+	unblocked, err := s.store.Complete(syn, "")
+	if err != nil {
 		return toolCallResult{}, err
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after complete: %v", err)
+		return toolCallResult{}, s.saveFailed("complete", err)
 	}
 
-	data, _ := json.MarshalIndent(syn, "", "  ")
+	data, _ := completionJSON(syn, unblocked)
 	return toolCallResult{
 		Content: []toolContent{{
 			Type: "text",
@@ -1117,8 +2113,10 @@ func (s *Server) spawnTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, err
 	}
 
-	syn.DiscoveredFrom = fmt.Sprintf("#%d", parentID)
+	syn.DiscoveredFrom = parentID
 	syn.ParentID = parentID
+	syn.Assignee = s.defaultAssignee
+	syn.CreatedBy = s.createdByArg(args)
 
 	if blockedByParent, ok := args["blocked_by_parent"].(bool); ok && blockedByParent {
 		syn.BlockedBy = []int{parentID}
@@ -1130,7 +2128,72 @@ func (s *Server) spawnTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after spawn: %v", err)
+		return toolCallResult{}, s.saveFailed("spawn", err)
+	}
+
+	data, _ := json.MarshalIndent(syn, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) setParent(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	parentID, err := requireID(args, "parent_id")
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	// The same move as `synapse move --subtree`: the task keeps its
+	// children, and a parent among them (or the task itself) is a cycle
+	result, err := s.store.Move(id, parentID, true)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	syn := result.Task
+
+	if err := s.store.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("set_parent", err)
+	}
+
+	data, _ := json.MarshalIndent(syn, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) archiveTask(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	syn, err := s.store.Get(id)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	if unarchive, _ := args["unarchive"].(bool); unarchive {
+		syn.Unarchive()
+	} else {
+		syn.MarkArchived()
+	}
+
+	if err := s.store.Update(syn); err != nil {
+		return toolCallResult{}, err
+	}
+
+	if err := s.store.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("archive_task", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -1139,11 +2202,53 @@ func (s *Server) spawnTask(args map[string]any) (toolCallResult, error) {
 			Type: "text",
 			Text: string(data),
 		}},
+		StructuredContent: syn,
+	}, nil
+}
+
+func (s *Server) createFromTemplate(args map[string]any) (toolCallResult, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return toolCallResult{}, fmt.Errorf("name is required")
+	}
+
+	// Templates are edited from the CLI, so read them fresh on each call
+	templates := storage.NewTemplateStore(s.store.Dir())
+	if err := templates.Load(); err != nil {
+		return toolCallResult{}, err
+	}
+	if _, ok := templates.Get(name); !ok {
+		var names []string
+		for _, t := range templates.List() {
+			names = append(names, t.Name)
+		}
+		return toolCallResult{}, fmt.Errorf("template not found: %s (available: %v)", name, names)
+	}
+
+	syn, err := templates.Instantiate(name, s.store)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	if err := s.store.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("create_from_template", err)
+	}
+	if err := templates.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("template update", err)
+	}
+
+	data, _ := json.MarshalIndent(syn, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+		StructuredContent: syn,
 	}, nil
 }
 
 func (s *Server) addNote(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -1165,7 +2270,7 @@ func (s *Server) addNote(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after add_note: %v", err)
+		return toolCallResult{}, s.saveFailed("add_note", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -1177,38 +2282,53 @@ func (s *Server) addNote(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
-func (s *Server) setBreadcrumb(args map[string]any) (toolCallResult, error) {
+// breadcrumbArgs holds the validated arguments of one breadcrumb write.
+type breadcrumbArgs struct {
+	key    string
+	value  string
+	taskID int
+}
+
+func parseBreadcrumbArgs(args map[string]any) (breadcrumbArgs, error) {
 	key, ok := args["key"].(string)
 	if !ok || key == "" {
-		return toolCallResult{}, fmt.Errorf("key is required")
+		return breadcrumbArgs{}, fmt.Errorf("key is required")
 	}
 
 	value, ok := args["value"].(string)
 	if !ok {
-		return toolCallResult{}, fmt.Errorf("value is required")
+		return breadcrumbArgs{}, fmt.Errorf("value is required")
 	}
 
 	var taskID int
 	if tid, ok := optionalFloat64(args, "task_id"); ok {
 		taskID = int(tid)
 	}
+	return breadcrumbArgs{key: key, value: value, taskID: taskID}, nil
+}
+
+func (s *Server) setBreadcrumb(args map[string]any) (toolCallResult, error) {
+	bc, err := parseBreadcrumbArgs(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
 
-	created, err := s.bcStore.Set(key, value, taskID)
+	created, err := s.bcStore.Set(bc.key, bc.value, bc.taskID)
 	if err != nil {
 		return toolCallResult{}, err
 	}
 
 	if err := s.bcStore.Save(); err != nil {
-		log.Printf("Warning: failed to save breadcrumb: %v", err)
+		return toolCallResult{}, s.saveFailed("set_breadcrumb", err)
 	}
 
 	result := map[string]any{
 		"success": true,
-		"key":     key,
+		"key":     bc.key,
 		"created": created,
 	}
 
-	if b, found := s.bcStore.Get(key); found {
+	if b, found := s.bcStore.Get(bc.key); found {
 		result["updated_at"] = b.UpdatedAt.Format("2006-01-02T15:04:05Z")
 	}
 
@@ -1254,6 +2374,97 @@ func (s *Server) getBreadcrumb(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+func (s *Server) setBreadcrumbs(args map[string]any) (toolCallResult, error) {
+	items, ok := args["breadcrumbs"].([]any)
+	if !ok || len(items) == 0 {
+		return toolCallResult{}, fmt.Errorf("breadcrumbs is required")
+	}
+
+	// Validate every entry before storing any, so a bad entry leaves
+	// nothing half-written
+	entries := make([]breadcrumbArgs, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return toolCallResult{}, fmt.Errorf("breadcrumbs item %d must be an object", i)
+		}
+		bc, err := parseBreadcrumbArgs(fields)
+		if err != nil {
+			return toolCallResult{}, fmt.Errorf("breadcrumbs item %d: %w", i, err)
+		}
+		entries[i] = bc
+	}
+
+	results := make([]map[string]any, len(entries))
+	for i, bc := range entries {
+		created, err := s.bcStore.Set(bc.key, bc.value, bc.taskID)
+		if err != nil {
+			return toolCallResult{}, err
+		}
+		results[i] = map[string]any{
+			"key":     bc.key,
+			"created": created,
+		}
+	}
+
+	if err := s.bcStore.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("set_breadcrumbs", err)
+	}
+
+	for i, bc := range entries {
+		if b, found := s.bcStore.Get(bc.key); found {
+			results[i]["updated_at"] = b.UpdatedAt.Format("2006-01-02T15:04:05Z")
+		}
+	}
+
+	result := map[string]any{
+		"success": true,
+		"results": results,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) getBreadcrumbs(args map[string]any) (toolCallResult, error) {
+	keys, ok := args["keys"].([]any)
+	if !ok || len(keys) == 0 {
+		return toolCallResult{}, fmt.Errorf("keys is required")
+	}
+
+	results := make([]map[string]any, len(keys))
+	found := 0
+	for i, k := range keys {
+		key, ok := k.(string)
+		if !ok || key == "" {
+			return toolCallResult{}, fmt.Errorf("keys item %d must be a non-empty string", i)
+		}
+		r := map[string]any{"key": key, "found": false}
+		if b, ok := s.bcStore.Get(key); ok {
+			r["found"] = true
+			r["breadcrumb"] = b
+			found++
+		}
+		results[i] = r
+	}
+
+	result := map[string]any{
+		"results": results,
+		"found":   found,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
 func (s *Server) listBreadcrumbs(args map[string]any) (toolCallResult, error) {
 	var breadcrumbs []*types.Breadcrumb
 
@@ -1278,16 +2489,39 @@ func (s *Server) listBreadcrumbs(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+func (s *Server) listNamespaces() (toolCallResult, error) {
+	namespaces := s.bcStore.Namespaces()
+	result := map[string]any{
+		"namespaces": namespaces,
+		"count":      len(namespaces),
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
 func (s *Server) deleteBreadcrumb(args map[string]any) (toolCallResult, error) {
-	key, ok := args["key"].(string)
-	if !ok || key == "" {
-		return toolCallResult{}, fmt.Errorf("key is required")
+	key, _ := args["key"].(string)
+	prefix, hasPrefix := args["prefix"].(string)
+	all, _ := args["all"].(bool)
+	if key != "" && (hasPrefix || all) {
+		return toolCallResult{}, fmt.Errorf("give key or prefix, not both")
+	}
+	if hasPrefix || all {
+		return s.deleteBreadcrumbPrefix(prefix, all)
+	}
+	if key == "" {
+		return toolCallResult{}, fmt.Errorf("key or prefix is required")
 	}
 
 	deleted := s.bcStore.Delete(key)
 	if deleted {
 		if err := s.bcStore.Save(); err != nil {
-			log.Printf("Warning: failed to save after delete: %v", err)
+			return toolCallResult{}, s.saveFailed("delete_breadcrumb", err)
 		}
 	}
 
@@ -1304,8 +2538,39 @@ func (s *Server) deleteBreadcrumb(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+// deleteBreadcrumbPrefix deletes the breadcrumbs under prefix. An empty
+// prefix would match every key, so it needs all to be set.
+func (s *Server) deleteBreadcrumbPrefix(prefix string, all bool) (toolCallResult, error) {
+	if prefix == "" && !all {
+		return toolCallResult{}, fmt.Errorf("empty prefix would delete every breadcrumb; set all to confirm")
+	}
+	if prefix != "" && all {
+		return toolCallResult{}, fmt.Errorf("give prefix or all, not both")
+	}
+
+	count := s.bcStore.DeletePrefix(prefix)
+	if count > 0 {
+		if err := s.bcStore.Save(); err != nil {
+			return toolCallResult{}, s.saveFailed("delete_breadcrumb", err)
+		}
+	}
+
+	result := map[string]any{
+		"success": true,
+		"prefix":  prefix,
+		"deleted": count,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
 func (s *Server) claimTask(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -1315,7 +2580,7 @@ func (s *Server) claimTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, fmt.Errorf("agent_id is required")
 	}
 
-	timeout := types.DefaultClaimTimeout
+	timeout := s.store.ClaimTimeout()
 	if minutes, ok := optionalFloat64(args, "timeout_minutes"); ok {
 		timeout = time.Duration(minutes) * time.Minute
 	}
@@ -1325,14 +2590,23 @@ func (s *Server) claimTask(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, err
 	}
 
-	claimed := syn.Claim(agentID, timeout)
+	var claimed bool
+	if steal, _ := args["steal"].(bool); steal {
+		_, claimed = syn.StealClaim(agentID)
+	} else {
+		claimed = syn.Claim(agentID, timeout)
+	}
 	if !claimed {
+		message := "Task is already claimed by another agent; pass steal=true to take it over"
+		if syn.Status == types.StatusDone {
+			message = "Task is already done"
+		}
 		result := map[string]any{
 			"success":       false,
 			"claimed":       false,
 			"claimed_by":    syn.ClaimedBy,
 			"claimed_at":    syn.ClaimedAt,
-			"error_message": "Task is already claimed by another agent",
+			"error_message": message,
 		}
 		data, _ := json.MarshalIndent(result, "", "  ")
 		return toolCallResult{
@@ -1348,7 +2622,7 @@ func (s *Server) claimTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after claim: %v", err)
+		return toolCallResult{}, s.saveFailed("claim", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -1361,7 +2635,7 @@ func (s *Server) claimTask(args map[string]any) (toolCallResult, error) {
 }
 
 func (s *Server) releaseClaim(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -1378,7 +2652,7 @@ func (s *Server) releaseClaim(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after release: %v", err)
+		return toolCallResult{}, s.saveFailed("release", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -1390,8 +2664,8 @@ func (s *Server) releaseClaim(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
-func (s *Server) completeTaskAs(args map[string]any) (toolCallResult, error) {
-	id, err := requireID(args, "id")
+func (s *Server) renewClaim(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, err
 	}
@@ -1406,14 +2680,70 @@ func (s *Server) completeTaskAs(args map[string]any) (toolCallResult, error) {
 		return toolCallResult{}, err
 	}
 
-	syn.MarkDoneBy(agentID)
+	if !syn.Renew(agentID) {
+		result := map[string]any{
+			"success":       false,
+			"renewed":       false,
+			"claimed_by":    syn.ClaimedBy,
+			"claimed_at":    syn.ClaimedAt,
+			"error_message": "You no longer hold the claim on this task",
+		}
+		data, _ := json.MarshalIndent(result, "", "  ")
+		return toolCallResult{
+			Content: []toolContent{{
+				Type: "text",
+				Text: string(data),
+			}},
+		}, nil
+	}
+
+	if err := s.store.Update(syn); err != nil {
+		return toolCallResult{}, err
+	}
+
+	if err := s.store.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("renew", err)
+	}
+
+	data, _ := json.MarshalIndent(syn, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) requestReview(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	syn, err := s.store.Get(id)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	if force, _ := args["force"].(bool); !force {
+		if err := syn.ValidateTransition(types.StatusReview, s.store.IsDone); err != nil {
+			return toolCallResult{}, fmt.Errorf("%w; pass force=true to override", err)
+		}
+	}
+
+	requestedBy, _ := args["agent_id"].(string)
+	if requestedBy == "" {
+		requestedBy = syn.ClaimedBy
+	}
+	reviewer, _ := args["reviewer"].(string)
+	syn.RequestReview(requestedBy, reviewer)
 
 	if err := s.store.Update(syn); err != nil {
 		return toolCallResult{}, err
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after complete: %v", err)
+		return toolCallResult{}, s.saveFailed("review request", err)
 	}
 
 	data, _ := json.MarshalIndent(syn, "", "  ")
@@ -1425,6 +2755,40 @@ func (s *Server) completeTaskAs(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+func (s *Server) completeTaskAs(args map[string]any) (toolCallResult, error) {
+	id, err := resolveID(args)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	agentID, ok := args["agent_id"].(string)
+	if !ok || agentID == "" {
+		return toolCallResult{}, fmt.Errorf("agent_id is required")
+	}
+
+	syn, err := s.store.Get(id)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	unblocked, err := s.store.Complete(syn, agentID)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	if err := s.store.Save(); err != nil {
+		return toolCallResult{}, s.saveFailed("complete", err)
+	}
+
+	data, _ := completionJSON(syn, unblocked)
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
 func (s *Server) getContextWindow(args map[string]any) (toolCallResult, error) {
 	minutes := 60.0
 	if m, ok := optionalFloat64(args, "minutes"); ok {
@@ -1482,6 +2846,166 @@ func (s *Server) myTasks(args map[string]any) (toolCallResult, error) {
 	}, nil
 }
 
+// agentActivity summarizes one agent's claims for active_agents.
+type agentActivity struct {
+	AgentID         string    `json:"agent_id"`
+	TaskIDs         []int     `json:"task_ids"`                 // Unexpired claims
+	StaleTaskIDs    []int     `json:"stale_task_ids,omitempty"` // Expired claims
+	OldestClaimedAt time.Time `json:"oldest_claimed_at"`        // Over unexpired claims, if any
+	OldestClaimAge  int       `json:"oldest_claim_age_seconds"`
+	Stale           bool      `json:"stale"` // Every claim has expired
+}
+
+func (s *Server) activeAgents(args map[string]any) (toolCallResult, error) {
+	timeout := s.store.ClaimTimeout()
+	if minutes, ok := optionalFloat64(args, "timeout_minutes"); ok {
+		timeout = time.Duration(minutes) * time.Minute
+	}
+
+	now := time.Now().UTC()
+	agents := []agentActivity{}
+	active := 0
+	for _, agentID := range s.store.Claimants() {
+		a := agentActivity{AgentID: agentID, TaskIDs: []int{}}
+		var oldest, oldestStale *time.Time
+		for _, syn := range s.store.ClaimedBy(agentID) {
+			if syn.IsClaimExpired(timeout) {
+				a.StaleTaskIDs = append(a.StaleTaskIDs, syn.ID)
+				if syn.ClaimedAt != nil && (oldestStale == nil || syn.ClaimedAt.Before(*oldestStale)) {
+					oldestStale = syn.ClaimedAt
+				}
+				continue
+			}
+			a.TaskIDs = append(a.TaskIDs, syn.ID)
+			if oldest == nil || syn.ClaimedAt.Before(*oldest) {
+				oldest = syn.ClaimedAt
+			}
+		}
+		if len(a.TaskIDs) == 0 {
+			a.Stale = true
+			oldest = oldestStale
+		} else {
+			active++
+		}
+		if oldest != nil {
+			a.OldestClaimedAt = *oldest
+			a.OldestClaimAge = int(now.Sub(*oldest).Seconds())
+		}
+		agents = append(agents, a)
+	}
+
+	result := map[string]any{
+		"agents": agents,
+		"active": active,
+		"stale":  len(agents) - active,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) getWorkload(args map[string]any) (toolCallResult, error) {
+	data, _ := json.MarshalIndent(map[string]any{"workload": s.store.WorkloadByAssignee()}, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) getSession(args map[string]any) (toolCallResult, error) {
+	session := s.sessionArg(args)
+	if session == "" {
+		return toolCallResult{}, fmt.Errorf("session_id is required")
+	}
+
+	tasks, err := s.store.BySession(session)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	events, err := s.store.Events().ForSession(session)
+	if err != nil {
+		return toolCallResult{}, err
+	}
+	if tasks == nil {
+		tasks = []*types.Synapse{}
+	}
+	if events == nil {
+		events = []types.Event{}
+	}
+
+	data, _ := json.MarshalIndent(map[string]any{
+		"session_id": session,
+		"tasks":      tasks,
+		"events":     events,
+	}, "", "  ")
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+	}, nil
+}
+
+func (s *Server) getHistory(args map[string]any) (toolCallResult, error) {
+	limit := 50
+	if l, ok := optionalFloat64(args, "limit"); ok && l > 0 {
+		limit = int(l)
+	}
+	maxChars := MaxResponseSize
+	if mc, ok := optionalFloat64(args, "max_chars"); ok && mc > 0 {
+		maxChars = int(mc)
+	}
+
+	var events []types.Event
+	var err error
+	if taskID, ok := optionalFloat64(args, "task_id"); ok {
+		events, err = s.store.Events().ForTask(int(taskID))
+	} else {
+		events, err = s.store.Events().All()
+	}
+	if err != nil {
+		return toolCallResult{}, err
+	}
+
+	// The log is oldest first; return the newest events first
+	total := len(events)
+	slices.Reverse(events)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	if events == nil {
+		events = []types.Event{}
+	}
+
+	result := map[string]any{
+		"events":    events,
+		"total":     total,
+		"truncated": false,
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	for len(data) > maxChars && len(events) > 0 {
+		// Drop the oldest returned events until the response fits
+		events = events[:len(events)*3/4]
+		result["events"] = events
+		result["truncated"] = true
+		data, _ = json.MarshalIndent(result, "", "  ")
+	}
+
+	return toolCallResult{
+		Content: []toolContent{{
+			Type: "text",
+			Text: string(data),
+		}},
+		StructuredContent: result,
+	}, nil
+}
+
 func (s *Server) deleteTask(args map[string]any) (toolCallResult, error) {
 	// Check if delete_all is specified
 	if deleteAll, ok := args["delete_all"].(bool); ok && deleteAll {
@@ -1501,7 +3025,7 @@ func (s *Server) deleteTask(args map[string]any) (toolCallResult, error) {
 		}
 
 		if err := s.store.Save(); err != nil {
-			log.Printf("Warning: failed to save after delete all: %v", err)
+			return toolCallResult{}, s.saveFailed("delete all", err)
 		}
 
 		return toolCallResult{
@@ -1529,7 +3053,7 @@ func (s *Server) deleteTask(args map[string]any) (toolCallResult, error) {
 		}
 
 		if err := s.store.Save(); err != nil {
-			log.Printf("Warning: failed to save after delete completed: %v", err)
+			return toolCallResult{}, s.saveFailed("delete completed", err)
 		}
 
 		return toolCallResult{
@@ -1541,7 +3065,7 @@ func (s *Server) deleteTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	// Delete single task by ID
-	id, err := requireID(args, "id")
+	id, err := resolveID(args)
 	if err != nil {
 		return toolCallResult{}, fmt.Errorf("id is required (or set delete_all or delete_completed to true)")
 	}
@@ -1557,7 +3081,7 @@ func (s *Server) deleteTask(args map[string]any) (toolCallResult, error) {
 	}
 
 	if err := s.store.Save(); err != nil {
-		log.Printf("Warning: failed to save after delete: %v", err)
+		return toolCallResult{}, s.saveFailed("delete", err)
 	}
 
 	return toolCallResult{
@@ -1593,15 +3117,33 @@ func (s *Server) sendError(id any, code int, message string, data any) {
 }
 
 func (s *Server) writeResponse(resp jsonRPCResponse) {
-	data, err := json.Marshal(resp)
+	s.writeMessage(resp)
+}
+
+// sendNotification sends a JSON-RPC notification, which has no ID and
+// expects no reply.
+func (s *Server) sendNotification(method string, params any) {
+	s.writeMessage(jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// writeMessage writes one JSON-RPC message per line. It is safe to call
+// from the event watcher while a request is being handled.
+func (s *Server) writeMessage(msg any) {
+	data, err := json.Marshal(msg)
 	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
+		s.logger.errorf("marshal response: %v", err)
 		return
 	}
 
-	log.Printf("Sending: %s", data)
+	s.logger.body("Sending", data)
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	if _, err := fmt.Fprintf(s.writer, "%s\n", data); err != nil {
-		log.Printf("Error writing response: %v", err)
+		s.logger.errorf("write response: %v", err)
 	}
 }
@@ -2,11 +2,18 @@ package mcp
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
 )
 
 func TestListTasks_ResponseSizeLimiting(t *testing.T) {
@@ -121,6 +128,68 @@ func TestListTasks_ResponseSizeLimiting(t *testing.T) {
 	})
 }
 
+func TestListTasks_Pagination(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	for i := range 50 {
+		store.Create(fmt.Sprintf("Task %d", i+1))
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	seen := make(map[int]bool)
+	args := map[string]any{"limit": float64(20)}
+	for page := 1; ; page++ {
+		if page > 3 {
+			t.Fatal("expected pagination to end after 3 pages")
+		}
+
+		result, err := server.listTasks(args)
+		if err != nil {
+			t.Fatalf("page %d: listTasks failed: %v", page, err)
+		}
+		var response struct {
+			Tasks []struct {
+				ID int `json:"id"`
+			} `json:"tasks"`
+			Total      int  `json:"total"`
+			NextOffset *int `json:"next_offset"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+			t.Fatalf("page %d: failed to unmarshal response: %v", page, err)
+		}
+		if response.Total != 50 {
+			t.Errorf("page %d: expected total 50, got %d", page, response.Total)
+		}
+
+		for _, task := range response.Tasks {
+			if seen[task.ID] {
+				t.Errorf("page %d: task %d already returned", page, task.ID)
+			}
+			seen[task.ID] = true
+		}
+
+		if response.NextOffset == nil {
+			if page != 3 || len(response.Tasks) != 10 {
+				t.Errorf("expected last page to be page 3 with 10 tasks, got page %d with %d", page, len(response.Tasks))
+			}
+			break
+		}
+		if len(response.Tasks) != 20 || *response.NextOffset != page*20 {
+			t.Errorf("page %d: got %d tasks, next_offset %d", page, len(response.Tasks), *response.NextOffset)
+		}
+		args["offset"] = float64(*response.NextOffset)
+	}
+
+	for id := 1; id <= 50; id++ {
+		if !seen[id] {
+			t.Errorf("task %d never returned", id)
+		}
+	}
+}
+
 func TestListTasks_FieldsSelection(t *testing.T) {
 	dir := t.TempDir()
 	store := storage.NewJSONLStore(dir)
@@ -172,6 +241,109 @@ func TestListTasks_FieldsSelection(t *testing.T) {
 	}
 }
 
+func TestListTasks_Unassigned(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+
+	owned, _ := store.Create("Owned task")
+	owned.Assignee = "coder"
+	orphan, _ := store.Create("Orphan task")
+
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.listTasks(map[string]any{"unassigned": true})
+	if err != nil {
+		t.Fatalf("listTasks failed: %v", err)
+	}
+
+	var response struct {
+		Tasks []struct {
+			ID int `json:"id"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(response.Tasks) != 1 || response.Tasks[0].ID != orphan.ID {
+		t.Errorf("expected only task %d, got %+v", orphan.ID, response.Tasks)
+	}
+
+	// Combined filters must all match, not just the first one given
+	owned.Status = types.StatusInProgress
+	orphan.Status = types.StatusInProgress
+	store.Create("Open orphan")
+	result, err = server.listTasks(map[string]any{"status": "in-progress", "unassigned": true})
+	if err != nil {
+		t.Fatalf("listTasks failed: %v", err)
+	}
+	response.Tasks = nil
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(response.Tasks) != 1 || response.Tasks[0].ID != orphan.ID {
+		t.Errorf("status and unassigned: expected only task %d, got %+v", orphan.ID, response.Tasks)
+	}
+}
+
+func TestListTasks_TimeFilters(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+
+	stale, _ := store.Create("Stale task")
+	stale.CreatedAt = time.Now().UTC().Add(-72 * time.Hour)
+	stale.UpdatedAt = stale.CreatedAt
+	touched, _ := store.Create("Old but touched")
+	touched.CreatedAt = time.Now().UTC().Add(-72 * time.Hour)
+	fresh, _ := store.Create("Fresh task")
+
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []int
+	}{
+		{"updated_after", map[string]any{"updated_after": "24h"}, []int{touched.ID, fresh.ID}},
+		{"created_after", map[string]any{"created_after": "1d"}, []int{fresh.ID}},
+		{"rfc3339", map[string]any{"created_after": time.Now().UTC().Add(-100 * time.Hour).Format(time.RFC3339)}, []int{stale.ID, touched.ID, fresh.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.listTasks(tt.args)
+			if err != nil {
+				t.Fatalf("listTasks failed: %v", err)
+			}
+
+			var response struct {
+				Tasks []struct {
+					ID int `json:"id"`
+				} `json:"tasks"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			var ids []int
+			for _, task := range response.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got IDs %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := server.listTasks(map[string]any{"updated_after": "last week"}); err == nil {
+		t.Error("expected error for invalid updated_after")
+	}
+}
+
 func TestStringTypedParameters(t *testing.T) {
 	dir := t.TempDir()
 	store := storage.NewJSONLStore(dir)
@@ -314,6 +486,326 @@ func TestToFloat64(t *testing.T) {
 	}
 }
 
+func TestResolveID(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    int
+		wantErr string
+	}{
+		{"id", map[string]any{"id": float64(3)}, 3, ""},
+		{"task_id alias", map[string]any{"task_id": "4"}, 4, ""},
+		{"task alias", map[string]any{"task": float64(5)}, 5, ""},
+		{"id takes precedence", map[string]any{"id": float64(1), "task_id": float64(2)}, 1, ""},
+		{"null id falls through", map[string]any{"id": nil, "task": "6"}, 6, ""},
+		{"missing", map[string]any{"title": "x"}, 0, "id is required"},
+		{"not a number", map[string]any{"task": "abc"}, 0, "id must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveID(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveID(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveID(%v) = %d, %v; want %d", tt.args, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandlers_StringNumbers calls every handler that takes a numeric
+// argument with the number sent as a string, as LLM clients often do, and
+// checks the value took effect rather than being ignored.
+func TestHandlers_StringNumbers(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *Server)
+		call  func(s *Server) (toolCallResult, error)
+		check func(t *testing.T, store *storage.JSONLStore, result toolCallResult)
+	}{
+		{
+			name: "create_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.createTask(map[string]any{"title": "New", "priority": "5", "parent_id": "1", "estimate_minutes": "30", "blocked_by": []any{"2"}})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				syn, _ := store.Get(4)
+				if syn.Priority != 5 || syn.ParentID != 1 || syn.EstimateMinutes != 30 || !slices.Equal(syn.BlockedBy, []int{2}) {
+					t.Errorf("numeric fields not applied: %+v", syn)
+				}
+			},
+		},
+		{
+			name: "update_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.updateTask(map[string]any{"id": "1", "priority": "7", "estimate_minutes": "15", "version": "0"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(1); syn.Priority != 7 || syn.EstimateMinutes != 15 {
+					t.Errorf("numeric fields not applied: %+v", syn)
+				}
+			},
+		},
+		{
+			name: "update_task task_id alias",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.updateTask(map[string]any{"task_id": "2", "priority": "4"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(2); syn.Priority != 4 {
+					t.Errorf("expected task 2 updated, got priority %d", syn.Priority)
+				}
+			},
+		},
+		{
+			name: "spawn_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.spawnTask(map[string]any{"parent_task_id": "1", "title": "Found"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(4); syn.DiscoveredFrom != 1 {
+					t.Errorf("expected discovered_from 1, got %d", syn.DiscoveredFrom)
+				}
+			},
+		},
+		{
+			name: "set_parent",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.setParent(map[string]any{"id": "3", "parent_id": "1"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(3); syn.ParentID != 1 {
+					t.Errorf("expected parent 1, got %d", syn.ParentID)
+				}
+			},
+		},
+		{
+			name: "archive_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.archiveTask(map[string]any{"id": "3"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(3); !syn.Archived {
+					t.Error("expected task 3 archived")
+				}
+			},
+		},
+		{
+			name: "add_note",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.addNote(map[string]any{"task_id": "2", "note": "checked"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(2); len(syn.Notes) != 1 {
+					t.Errorf("expected a note on task 2, got %v", syn.Notes)
+				}
+			},
+		},
+		{
+			name: "set_breadcrumb",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.setBreadcrumb(map[string]any{"key": "k", "value": "v", "task_id": "2"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				saved := storage.NewBreadcrumbStore(store.Dir())
+				if err := saved.Load(); err != nil {
+					t.Fatalf("load breadcrumbs: %v", err)
+				}
+				if b, ok := saved.Get("k"); !ok || b.TaskID != 2 {
+					t.Errorf("expected breadcrumb k linked to task 2, got %+v", b)
+				}
+			},
+		},
+		{
+			name:  "list_breadcrumbs",
+			setup: func(s *Server) { s.bcStore.Set("linked", "v", 2) },
+			call: func(s *Server) (toolCallResult, error) {
+				return s.listBreadcrumbs(map[string]any{"task_id": "2"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, result toolCallResult) {
+				if !strings.Contains(result.Content[0].Text, "linked") {
+					t.Errorf("expected the breadcrumb linked to task 2, got %s", result.Content[0].Text)
+				}
+			},
+		},
+		{
+			name: "list_tasks",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.listTasks(map[string]any{"limit": "1", "offset": "1", "max_chars": "10000"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, result toolCallResult) {
+				if !strings.Contains(result.Content[0].Text, `"offset":1`) || !strings.Contains(result.Content[0].Text, `"limit":1`) {
+					t.Errorf("expected limit and offset 1, got %s", result.Content[0].Text)
+				}
+			},
+		},
+		{
+			name: "claim_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.claimTask(map[string]any{"id": "1", "agent_id": "a1", "timeout_minutes": "10"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(1); syn.ClaimedBy != "a1" {
+					t.Errorf("expected task 1 claimed by a1, got %q", syn.ClaimedBy)
+				}
+			},
+		},
+		{
+			name: "renew_claim",
+			setup: func(s *Server) {
+				s.claimTask(map[string]any{"id": float64(1), "agent_id": "a1"})
+				syn, _ := s.store.Get(1)
+				claimedAt := syn.ClaimedAt.Add(-time.Hour)
+				syn.ClaimedAt = &claimedAt
+			},
+			call: func(s *Server) (toolCallResult, error) {
+				return s.renewClaim(map[string]any{"id": "1", "agent_id": "a1"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				syn, _ := store.Get(1)
+				if syn.ClaimedBy != "a1" || time.Since(*syn.ClaimedAt) > time.Minute {
+					t.Errorf("expected task 1's claim renewed for a1, got %q claimed at %v", syn.ClaimedBy, syn.ClaimedAt)
+				}
+			},
+		},
+		{
+			name:  "release_claim",
+			setup: func(s *Server) { s.claimTask(map[string]any{"id": float64(1), "agent_id": "a1"}) },
+			call: func(s *Server) (toolCallResult, error) {
+				return s.releaseClaim(map[string]any{"id": "1", "agent_id": "a1"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(1); syn.ClaimedBy != "" {
+					t.Errorf("expected claim released, got %q", syn.ClaimedBy)
+				}
+			},
+		},
+		{
+			name:  "complete_task_as",
+			setup: func(s *Server) { s.claimTask(map[string]any{"id": float64(1), "agent_id": "a1"}) },
+			call: func(s *Server) (toolCallResult, error) {
+				return s.completeTaskAs(map[string]any{"id": "1", "agent_id": "a1"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(1); syn.Status != types.StatusDone {
+					t.Errorf("expected task 1 done, got %s", syn.Status)
+				}
+			},
+		},
+		{
+			name:  "request_review",
+			setup: func(s *Server) { s.claimTask(map[string]any{"id": float64(1), "agent_id": "a1"}) },
+			call: func(s *Server) (toolCallResult, error) {
+				return s.requestReview(map[string]any{"id": "1", "agent_id": "a1"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if syn, _ := store.Get(1); syn.Status != types.StatusReview {
+					t.Errorf("expected task 1 in review, got %s", syn.Status)
+				}
+			},
+		},
+		{
+			name: "get_context_window",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.getContextWindow(map[string]any{"minutes": "60"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, _ toolCallResult) {},
+		},
+		{
+			name: "my_tasks",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.myTasks(map[string]any{"agent_id": "a1", "timeout_minutes": "5"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, _ toolCallResult) {},
+		},
+		{
+			name: "active_agents",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.activeAgents(map[string]any{"timeout_minutes": "5"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, _ toolCallResult) {},
+		},
+		{
+			name: "get_discovered",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.getDiscovered(map[string]any{"id": "1"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, _ toolCallResult) {},
+		},
+		{
+			name: "get_critical_path",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.getCriticalPath(map[string]any{"id": "2"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, _ toolCallResult) {},
+		},
+		{
+			name: "get_parallel_tasks",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.getParallelTasks(map[string]any{"count": "2"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, result toolCallResult) {
+				if !strings.Contains(result.Content[0].Text, `"count": 2`) {
+					t.Errorf("expected two tasks, got %s", result.Content[0].Text)
+				}
+			},
+		},
+		{
+			name: "get_task_context",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.getTaskContext(map[string]any{"task_id": "2", "max_chars": "10000"})
+			},
+			check: func(t *testing.T, _ *storage.JSONLStore, result toolCallResult) {
+				if !strings.Contains(result.Content[0].Text, `"title": "Two"`) {
+					t.Errorf("expected the context of task 2, got %s", result.Content[0].Text)
+				}
+			},
+		},
+		{
+			name: "delete_task",
+			call: func(s *Server) (toolCallResult, error) {
+				return s.deleteTask(map[string]any{"id": "3"})
+			},
+			check: func(t *testing.T, store *storage.JSONLStore, _ toolCallResult) {
+				if _, err := store.Get(3); err == nil {
+					t.Error("expected task 3 deleted")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := storage.NewJSONLStore(dir)
+			if _, err := store.Init(); err != nil {
+				t.Fatalf("failed to init store: %v", err)
+			}
+			for _, title := range []string{"One", "Two", "Three"} {
+				store.Create(title)
+			}
+			server := NewServer(store, storage.NewBreadcrumbStore(dir))
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+
+			result, err := tt.call(server)
+			if err != nil {
+				t.Fatalf("%s with string numbers failed: %v", tt.name, err)
+			}
+			if result.IsError {
+				t.Fatalf("%s returned error: %s", tt.name, result.Content[0].Text)
+			}
+			tt.check(t, store, result)
+		})
+	}
+}
+
 func TestMaxResponseSize_Constant(t *testing.T) {
 	// Verify the constant is set to a reasonable value
 	if MaxResponseSize < 10000 {
@@ -323,3 +815,1234 @@ func TestMaxResponseSize_Constant(t *testing.T) {
 		t.Error("MaxResponseSize too large, may cause MCP client issues")
 	}
 }
+
+func TestUpdateTask_StaleVersion(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Shared task")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	// Two agents read version 0; the first update wins
+	if _, err := server.updateTask(map[string]any{"id": float64(1), "priority": float64(3), "version": float64(0)}); err != nil {
+		t.Fatalf("first update failed: %v", err)
+	}
+
+	_, err := server.updateTask(map[string]any{"id": float64(1), "priority": float64(9), "version": float64(0)})
+	if err == nil || !strings.Contains(err.Error(), "stale write") {
+		t.Fatalf("expected stale write error, got %v", err)
+	}
+
+	syn, _ := store.Get(1)
+	if syn.Priority != 3 {
+		t.Errorf("stale update modified the task: priority = %d", syn.Priority)
+	}
+	if syn.Version != 1 {
+		t.Errorf("expected version 1, got %d", syn.Version)
+	}
+}
+
+func TestUpdateTask_TransitionRules(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	syn, _ := store.Create("Finished task")
+	syn.MarkDone()
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	_, err := server.updateTask(map[string]any{"id": float64(1), "status": "in-progress", "priority": float64(5)})
+	if err == nil || !strings.Contains(err.Error(), "cannot transition") {
+		t.Fatalf("expected illegal transition error, got %v", err)
+	}
+	if got, _ := store.Get(1); got.Status != "done" || got.Priority != 0 {
+		t.Errorf("rejected update modified the task: %+v", got)
+	}
+
+	if _, err := server.updateTask(map[string]any{"id": float64(1), "status": "in-progress", "force": true}); err != nil {
+		t.Fatalf("forced transition failed: %v", err)
+	}
+	if got, _ := store.Get(1); got.Status != "in-progress" {
+		t.Errorf("expected forced status in-progress, got %s", got.Status)
+	}
+}
+
+func TestUpdateTask_EstimateAndActual(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	if _, err := server.createTask(map[string]any{"title": "Estimated", "estimate_minutes": float64(30)}); err != nil {
+		t.Fatalf("createTask failed: %v", err)
+	}
+	syn, _ := store.Get(1)
+	if syn.EstimateMinutes != 30 {
+		t.Errorf("expected estimate 30, got %d", syn.EstimateMinutes)
+	}
+
+	syn.Claim("agent-1", types.DefaultClaimTimeout)
+	startedAt := syn.StartedAt.Add(-45 * time.Minute)
+	syn.StartedAt = &startedAt
+	if _, err := server.updateTask(map[string]any{"id": float64(1), "estimate_minutes": float64(40), "status": "done"}); err != nil {
+		t.Fatalf("updateTask failed: %v", err)
+	}
+	syn, _ = store.Get(1)
+	if syn.EstimateMinutes != 40 || syn.ActualMinutes != 45 {
+		t.Errorf("expected estimate 40 and actual 45, got %d and %d", syn.EstimateMinutes, syn.ActualMinutes)
+	}
+
+	// Rejected as the CLI's --estimate rejects them
+	for _, bad := range []any{float64(-5), "-5", float64(2.5), "soon"} {
+		if _, err := server.createTask(map[string]any{"title": "Bad estimate", "estimate_minutes": bad}); err == nil {
+			t.Errorf("createTask: expected error for estimate %v", bad)
+		}
+		if _, err := server.updateTask(map[string]any{"id": float64(1), "estimate_minutes": bad}); err == nil {
+			t.Errorf("updateTask: expected error for estimate %v", bad)
+		}
+	}
+	if store.Count() != 1 {
+		t.Errorf("expected no task created with an invalid estimate, got %d tasks", store.Count())
+	}
+	if syn, _ = store.Get(1); syn.EstimateMinutes != 40 {
+		t.Errorf("rejected update changed the estimate to %d", syn.EstimateMinutes)
+	}
+}
+
+func TestCreateAndUpdateTask_Related(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Schema")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	if _, err := server.createTask(map[string]any{"title": "Docs", "related": []any{float64(99)}}); err == nil {
+		t.Error("expected error for unknown related task")
+	}
+	if _, err := server.createTask(map[string]any{"title": "Docs", "related": []any{"schema"}}); err == nil {
+		t.Error("expected error for a non-numeric related ID")
+	}
+	if store.Count() != 1 {
+		t.Fatalf("expected no task created on invalid related, got %d tasks", store.Count())
+	}
+
+	if _, err := server.createTask(map[string]any{"title": "Docs", "related": []any{float64(1), float64(1)}}); err != nil {
+		t.Fatalf("createTask failed: %v", err)
+	}
+	syn, _ := store.Get(2)
+	if !slices.Equal(syn.RelatedTo, []int{1}) {
+		t.Errorf("expected related [1], got %v", syn.RelatedTo)
+	}
+
+	if _, err := server.updateTask(map[string]any{"id": float64(2), "related": []any{float64(2)}}); err == nil {
+		t.Error("expected error relating a task to itself")
+	}
+	if _, err := server.updateTask(map[string]any{"id": float64(2), "related": []any{}}); err != nil {
+		t.Fatalf("updateTask failed: %v", err)
+	}
+	if syn, _ = store.Get(2); len(syn.RelatedTo) != 0 {
+		t.Errorf("expected related links cleared, got %v", syn.RelatedTo)
+	}
+}
+
+func TestCompleteTask_ReportsUnblocked(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Blocker")
+	dependent, _ := store.Create("Dependent")
+	dependent.Status = "blocked"
+	dependent.BlockedBy = []int{1}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.completeTask(map[string]any{"id": float64(1)})
+	if err != nil {
+		t.Fatalf("completeTask failed: %v", err)
+	}
+
+	var response struct {
+		ID        int   `json:"id"`
+		Unblocked []int `json:"unblocked"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.ID != 1 || len(response.Unblocked) != 1 || response.Unblocked[0] != 2 {
+		t.Errorf("unexpected response: %+v", response)
+	}
+	if dependent.Status != "open" {
+		t.Errorf("expected dependent open, got %s", dependent.Status)
+	}
+}
+
+func TestRequestReview(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Implement feature")
+	store.Create("Not started")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	if _, err := server.claimTask(map[string]any{"id": float64(1), "agent_id": "coder-1"}); err != nil {
+		t.Fatalf("claimTask failed: %v", err)
+	}
+	if _, err := server.requestReview(map[string]any{"id": float64(1), "reviewer": "@qa"}); err != nil {
+		t.Fatalf("requestReview failed: %v", err)
+	}
+
+	got, _ := store.Get(1)
+	if got.Status != "review" || got.Assignee != "@qa" || got.ClaimedBy != "" {
+		t.Errorf("unexpected task after review request: %+v", got)
+	}
+	if len(got.Notes) == 0 || got.Notes[len(got.Notes)-1] != "Review requested by coder-1 (assigned to @qa)" {
+		t.Errorf("expected review note, got %v", got.Notes)
+	}
+
+	// open -> review skips the work and needs force
+	if _, err := server.requestReview(map[string]any{"id": float64(2)}); err == nil || !strings.Contains(err.Error(), "cannot transition") {
+		t.Errorf("expected transition error, got %v", err)
+	}
+	if _, err := server.requestReview(map[string]any{"id": float64(2), "force": true}); err != nil {
+		t.Errorf("forced review request failed: %v", err)
+	}
+}
+
+func TestListTasks_CompletedBy(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Done by coder")
+	store.Create("Done by qa")
+	store.Create("Still open")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	server.completeTaskAs(map[string]any{"id": float64(1), "agent_id": "coder-1"})
+	server.completeTaskAs(map[string]any{"id": float64(2), "agent_id": "qa-1"})
+
+	result, err := server.listTasks(map[string]any{"completed_by": "coder-1"})
+	if err != nil {
+		t.Fatalf("listTasks failed: %v", err)
+	}
+
+	var response struct {
+		Tasks []struct {
+			ID int `json:"id"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(response.Tasks) != 1 || response.Tasks[0].ID != 1 {
+		t.Errorf("expected only task 1, got %+v", response.Tasks)
+	}
+
+	// Other filters narrow the completed tasks rather than replacing them
+	for _, tt := range []struct {
+		args map[string]any
+		want int
+	}{
+		{map[string]any{"completed_by": "coder-1", "status": "done"}, 1},
+		{map[string]any{"completed_by": "coder-1", "status": "open"}, 0},
+		{map[string]any{"completed_by": "coder-1", "label": "docs"}, 0},
+	} {
+		result, err := server.listTasks(tt.args)
+		if err != nil {
+			t.Fatalf("listTasks(%v) failed: %v", tt.args, err)
+		}
+		response.Tasks = nil
+		if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if len(response.Tasks) != tt.want {
+			t.Errorf("listTasks(%v): expected %d tasks, got %+v", tt.args, tt.want, response.Tasks)
+		}
+	}
+}
+
+func TestHandleInitialize_NegotiatesProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   string
+	}{
+		{"supported older version is echoed", `{"protocolVersion":"2024-11-05"}`, "2024-11-05"},
+		{"supported latest version is echoed", `{"protocolVersion":"2025-06-18"}`, "2025-06-18"},
+		{"unknown version gets latest", `{"protocolVersion":"2099-01-01"}`, supportedProtocolVersions[0]},
+		{"missing params gets latest", ``, supportedProtocolVersions[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			server := NewServer(storage.NewJSONLStore(dir), storage.NewBreadcrumbStore(dir))
+			server.SetVersion("1.2.3")
+			var out strings.Builder
+			server.writer = &out
+
+			server.handleInitialize(&jsonRPCRequest{JSONRPC: "2.0", Method: "initialize", Params: json.RawMessage(tt.params), ID: float64(1)})
+
+			var resp struct {
+				Result initializeResult `json:"result"`
+			}
+			if err := json.Unmarshal([]byte(out.String()), &resp); err != nil {
+				t.Fatalf("failed to decode response %q: %v", out.String(), err)
+			}
+			if resp.Result.ProtocolVersion != tt.want {
+				t.Errorf("protocolVersion = %q, want %q", resp.Result.ProtocolVersion, tt.want)
+			}
+			if resp.Result.ServerInfo.Version != "1.2.3" {
+				t.Errorf("serverInfo.version = %q, want 1.2.3", resp.Result.ServerInfo.Version)
+			}
+		})
+	}
+}
+
+func TestToolsCall_StructuredContent(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	var created struct {
+		StructuredContent types.Synapse `json:"structuredContent"`
+	}
+	decodeResult(t, call(t, server, "tools/call", `{"name":"create_task","arguments":{"title":"Structured","priority":2}}`), &created)
+	if created.StructuredContent.ID != 1 || created.StructuredContent.Title != "Structured" || created.StructuredContent.Priority != 2 {
+		t.Errorf("unexpected created task: %+v", created.StructuredContent)
+	}
+
+	var got struct {
+		StructuredContent types.Synapse `json:"structuredContent"`
+	}
+	decodeResult(t, call(t, server, "tools/call", `{"name":"get_task","arguments":{"id":1}}`), &got)
+	if got.StructuredContent.ID != 1 || got.StructuredContent.Status != types.StatusOpen {
+		t.Errorf("unexpected task: %+v", got.StructuredContent)
+	}
+
+	var listed struct {
+		StructuredContent struct {
+			Tasks []types.Synapse `json:"tasks"`
+			Total int             `json:"total"`
+		} `json:"structuredContent"`
+	}
+	decodeResult(t, call(t, server, "tools/call", `{"name":"list_tasks","arguments":{"summary":false}}`), &listed)
+	if listed.StructuredContent.Total != 1 || len(listed.StructuredContent.Tasks) != 1 || listed.StructuredContent.Tasks[0].Title != "Structured" {
+		t.Errorf("unexpected list: %+v", listed.StructuredContent)
+	}
+}
+
+func TestToolsCall_Session(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.SetDefaultSession("default-run")
+
+	call(t, server, "tools/call", `{"name":"create_task","arguments":{"title":"Defaulted"}}`)
+	call(t, server, "tools/call", `{"name":"create_task","arguments":{"title":"Explicit","session_id":"run-1"}}`)
+	call(t, server, "tools/call", `{"name":"claim_task","arguments":{"id":1,"agent_id":"agent-1","session_id":"run-1"}}`)
+
+	var result toolCallResult
+	decodeResult(t, call(t, server, "tools/call", `{"name":"get_session","arguments":{"session_id":"run-1"}}`), &result)
+	var session struct {
+		SessionID string          `json:"session_id"`
+		Tasks     []types.Synapse `json:"tasks"`
+		Events    []types.Event   `json:"events"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &session); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	if session.SessionID != "run-1" || len(session.Tasks) != 2 {
+		t.Fatalf("expected both tasks in run-1, got %+v", session)
+	}
+	for _, syn := range session.Tasks {
+		if syn.SessionID != "run-1" {
+			t.Errorf("task %d session = %q, want run-1", syn.ID, syn.SessionID)
+		}
+	}
+	if len(session.Events) != 3 {
+		t.Errorf("expected create, claim and status events, got %+v", session.Events)
+	}
+
+	// Without session_id, get_session replays the default session
+	decodeResult(t, call(t, server, "tools/call", `{"name":"get_session","arguments":{}}`), &result)
+	if !strings.Contains(result.Content[0].Text, `"session_id": "default-run"`) || !strings.Contains(result.Content[0].Text, "Defaulted") {
+		t.Errorf("expected the default session, got %s", result.Content[0].Text)
+	}
+}
+
+func TestToolsCall_SaveFailureIsError(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	// Make the task file unwritable. A directory in the way of Save's temp
+	// file fails even as root, where a read-only directory would not.
+	if err := os.Mkdir(filepath.Join(dir, storage.MemoryFile+".tmp"), 0755); err != nil {
+		t.Fatalf("block temp file: %v", err)
+	}
+
+	var result toolCallResult
+	decodeResult(t, call(t, server, "tools/call", `{"name":"create_task","arguments":{"title":"Lost"}}`), &result)
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "not saved") {
+		t.Fatalf("expected the save failure reported as an error, got %+v", result)
+	}
+
+	// The failed write never reached disk
+	reloaded := storage.NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded.Count() != 0 {
+		t.Errorf("expected nothing persisted, got %d task(s)", reloaded.Count())
+	}
+}
+
+func TestListTasks_Sort(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	base := time.Now().UTC().Add(-time.Hour)
+	for i, priority := range []int{1, 5, 3} {
+		syn, _ := store.Create(fmt.Sprintf("Task %d", i+1))
+		syn.Priority = priority
+		syn.UpdatedAt = base.Add(time.Duration(3-i) * time.Minute) // Task 1 most recent
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []int
+	}{
+		{"default id asc", map[string]any{}, []int{1, 2, 3}},
+		{"priority desc", map[string]any{"sort": "priority", "order": "desc"}, []int{2, 3, 1}},
+		{"updated desc", map[string]any{"sort": "updated", "order": "desc"}, []int{1, 2, 3}},
+		{"updated asc", map[string]any{"sort": "updated"}, []int{3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.listTasks(tt.args)
+			if err != nil {
+				t.Fatalf("listTasks failed: %v", err)
+			}
+			var response struct {
+				Tasks []struct {
+					ID int `json:"id"`
+				} `json:"tasks"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			var ids []int
+			for _, task := range response.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got IDs %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := server.listTasks(map[string]any{"sort": "colour"}); err == nil {
+		t.Error("expected error for invalid sort key")
+	}
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	templates := storage.NewTemplateStore(dir)
+	templates.Set(&types.Template{Name: "weekly-deps", Title: "Update dependencies", Labels: []string{"chore"}, Interval: "1w"})
+	templates.Save()
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.createFromTemplate(map[string]any{"name": "weekly-deps"})
+	if err != nil {
+		t.Fatalf("create_from_template failed: %v", err)
+	}
+	syn, ok := result.StructuredContent.(*types.Synapse)
+	if !ok || syn.Title != "Update dependencies" || syn.DueAt == nil {
+		t.Errorf("unexpected task: %+v", result.StructuredContent)
+	}
+	if store.Count() != 1 {
+		t.Errorf("expected 1 task, got %d", store.Count())
+	}
+
+	_, err = server.createFromTemplate(map[string]any{"name": "missing"})
+	if err == nil || !strings.Contains(err.Error(), "weekly-deps") {
+		t.Errorf("expected not-found error listing available templates, got %v", err)
+	}
+}
+
+func TestSetParent(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Epic")
+	store.Create("Feature")
+	store.Create("Story")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	// Build Epic <- Feature <- Story
+	if _, err := server.setParent(map[string]any{"id": float64(2), "parent_id": float64(1)}); err != nil {
+		t.Fatalf("set_parent failed: %v", err)
+	}
+	result, err := server.setParent(map[string]any{"id": float64(3), "parent_id": float64(2)})
+	if err != nil {
+		t.Fatalf("set_parent failed: %v", err)
+	}
+	var story types.Synapse
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &story); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if story.ID != 3 || story.ParentID != 2 {
+		t.Errorf("expected task 3 under 2, got %+v", story)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		id, to   float64
+		contains string
+	}{
+		{"direct cycle", 1, 2, "cycle"},
+		{"transitive cycle", 1, 3, "cycle"},
+		{"self parent", 2, 2, "cycle"},
+		{"missing parent", 2, 99, "not found"},
+	} {
+		_, err := server.setParent(map[string]any{"id": tt.id, "parent_id": tt.to})
+		if err == nil || !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.contains, err)
+		}
+	}
+	if epic, _ := store.Get(1); epic.ParentID != 0 {
+		t.Errorf("rejected set_parent modified the task: parent %d", epic.ParentID)
+	}
+
+	// parent_id 0 detaches
+	if _, err := server.setParent(map[string]any{"id": float64(3), "parent_id": float64(0)}); err != nil {
+		t.Fatalf("detach failed: %v", err)
+	}
+	if got, _ := store.Get(3); got.ParentID != 0 {
+		t.Errorf("expected task 3 detached, got parent %d", got.ParentID)
+	}
+}
+
+func TestGetNextTask_Role(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	mine, _ := store.Create("Assigned to a QA agent")
+	mine.Assignee = "qa-agent-1"
+	other, _ := store.Create("Assigned to a coder")
+	other.Assignee = "coder-1"
+	other.Priority = 5
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	next, err := server.getNextTask(map[string]any{"assignee": "@qa"})
+	if err != nil || next.Content[0].Text != "null" {
+		t.Fatalf("expected no task for an undefined role, got %v, %v", next, err)
+	}
+
+	// Roles are read from disk on each call
+	if err := (storage.Roles{"@qa": {"qa-agent-1"}}).Save(dir); err != nil {
+		t.Fatalf("save roles: %v", err)
+	}
+	next, err = server.getNextTask(map[string]any{"assignee": "@qa"})
+	if err != nil || !strings.Contains(next.Content[0].Text, "Assigned to a QA agent") {
+		t.Errorf("expected the QA agent's task for @qa, got %v, %v", next, err)
+	}
+}
+
+func TestArchiveTask(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	spike, _ := store.Create("Spike")
+	spike.MarkDone()
+	followUp, _ := store.Create("Follow-up")
+	followUp.BlockedBy = []int{spike.ID}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	if _, err := server.archiveTask(map[string]any{"id": float64(1)}); err != nil {
+		t.Fatalf("archive_task failed: %v", err)
+	}
+
+	// Hidden from list_tasks unless asked for, but still a done blocker
+	listed := func(args map[string]any) string {
+		result, err := server.listTasks(args)
+		if err != nil {
+			t.Fatalf("list_tasks failed: %v", err)
+		}
+		return result.Content[0].Text
+	}
+	if got := listed(map[string]any{}); strings.Contains(got, "Spike") {
+		t.Errorf("expected the archived task hidden, got %s", got)
+	}
+	if got := listed(map[string]any{"include_archived": true}); !strings.Contains(got, "Spike") {
+		t.Errorf("expected the archived task with include_archived, got %s", got)
+	}
+	next, _ := server.getNextTask(map[string]any{})
+	if !strings.Contains(next.Content[0].Text, "Follow-up") {
+		t.Errorf("expected the follow-up ready, got %s", next.Content[0].Text)
+	}
+
+	if _, err := server.archiveTask(map[string]any{"id": float64(1), "unarchive": true}); err != nil {
+		t.Fatalf("unarchive failed: %v", err)
+	}
+	if syn, _ := store.Get(1); syn.Archived {
+		t.Error("expected task 1 unarchived")
+	}
+}
+
+func TestGetDiscovered(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Refactor auth")
+	store.Create("Unrelated")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	server.spawnTask(map[string]any{"parent_task_id": float64(1), "title": "Fix token expiry"})
+	server.spawnTask(map[string]any{"parent_task_id": float64(2), "title": "Other discovery"})
+
+	result, err := server.getDiscovered(map[string]any{"id": float64(1)})
+	if err != nil {
+		t.Fatalf("getDiscovered failed: %v", err)
+	}
+	var response struct {
+		Count int             `json:"count"`
+		Tasks []types.Synapse `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Count != 1 || response.Tasks[0].Title != "Fix token expiry" {
+		t.Errorf("unexpected discoveries: %+v", response)
+	}
+
+	if _, err := server.getDiscovered(map[string]any{"id": float64(99)}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestGetCriticalPath(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	design, _ := store.Create("Design")
+	design.EstimateMinutes = 60
+	build, _ := store.Create("Build")
+	build.AddBlocker(design.ID)
+	ship, _ := store.Create("Ship")
+	ship.AddBlocker(build.ID)
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.getCriticalPath(map[string]any{"id": float64(3)})
+	if err != nil {
+		t.Fatalf("getCriticalPath failed: %v", err)
+	}
+	var response struct {
+		Steps           int             `json:"steps"`
+		EstimateMinutes int             `json:"estimate_minutes"`
+		Path            []types.Synapse `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Steps != 3 || response.EstimateMinutes != 60 || response.Path[0].ID != 1 || response.Path[2].ID != 3 {
+		t.Errorf("unexpected critical path: %+v", response)
+	}
+
+	design.AddBlocker(ship.ID)
+	if _, err := server.getCriticalPath(map[string]any{"id": float64(3)}); !errors.Is(err, storage.ErrBlockerCycle) {
+		t.Errorf("expected a blocker cycle error, got %v", err)
+	}
+}
+
+func TestGetParallelTasks(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	epic, _ := store.Create("Epic")
+	epic.Status = types.StatusInProgress
+	for _, title := range []string{"Login form", "Login API"} {
+		syn, _ := store.Create(title)
+		syn.ParentID = epic.ID
+		syn.Priority = 5
+	}
+	store.Create("Docs")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.getParallelTasks(map[string]any{"count": float64(2)})
+	if err != nil {
+		t.Fatalf("getParallelTasks failed: %v", err)
+	}
+	var response struct {
+		Count int             `json:"count"`
+		Tasks []types.Synapse `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	// The second sibling loses to a task under a different parent
+	if response.Count != 2 || response.Tasks[0].ID != 2 || response.Tasks[1].ID != 4 {
+		t.Errorf("unexpected batch: %+v", response)
+	}
+
+	if _, err := server.getParallelTasks(map[string]any{"count": float64(0)}); err == nil {
+		t.Error("expected an error for count 0")
+	}
+}
+
+func TestGetWorkload(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	for _, assignee := range []string{"@coder", "@coder", ""} {
+		syn, _ := store.Create("Task")
+		syn.Assignee = assignee
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.getWorkload(map[string]any{})
+	if err != nil {
+		t.Fatalf("getWorkload failed: %v", err)
+	}
+	var response struct {
+		Workload []storage.Workload `json:"workload"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	want := []storage.Workload{{Assignee: "@coder", Open: 2}, {Assignee: "", Open: 1}}
+	if !slices.Equal(response.Workload, want) {
+		t.Errorf("expected %+v, got %+v", want, response.Workload)
+	}
+}
+
+func TestCountTasks(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.createTask(map[string]any{"title": "A", "labels": []any{"bug"}, "assignee": "@coder"})
+	server.createTask(map[string]any{"title": "B", "labels": []any{"bug"}})
+	server.createTask(map[string]any{"title": "C", "assignee": "@coder"})
+
+	tests := []struct {
+		args map[string]any
+		want int
+	}{
+		{map[string]any{}, 3},
+		{map[string]any{"label": "bug"}, 2},
+		{map[string]any{"label": "bug", "assignee": "@coder"}, 1},
+		{map[string]any{"unassigned": true, "status": "open"}, 1},
+		{map[string]any{"status": "done"}, 0},
+	}
+	for _, tt := range tests {
+		result, err := server.countTasks(tt.args)
+		if err != nil {
+			t.Fatalf("countTasks(%v) failed: %v", tt.args, err)
+		}
+		var response struct {
+			Count int `json:"count"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if response.Count != tt.want {
+			t.Errorf("countTasks(%v) = %d, want %d", tt.args, response.Count, tt.want)
+		}
+	}
+
+	if _, err := server.countTasks(map[string]any{"status": "finished"}); err == nil {
+		t.Error("expected error for invalid status")
+	}
+}
+
+func TestGetTaskContext(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Parent")
+	store.Create("Blocker")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.createTask(map[string]any{"title": "Child", "parent_id": float64(1), "blocked_by": []any{float64(2)}})
+	server.setBreadcrumb(map[string]any{"key": "child.note", "value": "remember", "task_id": float64(3)})
+
+	result, err := server.getTaskContext(map[string]any{"id": float64(3)})
+	if err != nil {
+		t.Fatalf("getTaskContext failed: %v", err)
+	}
+	var ctx storage.TaskContext
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &ctx); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if ctx.Task.ID != 3 || len(ctx.Parents) != 1 || len(ctx.Blockers) != 1 || len(ctx.Breadcrumbs) != 1 {
+		t.Errorf("unexpected context: %+v", ctx)
+	}
+
+	if _, err := server.getTaskContext(map[string]any{"id": float64(99)}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestActiveAgents(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	for _, title := range []string{"A", "B", "C", "D", "Unclaimed"} {
+		store.Create(title)
+	}
+	claim := func(id int, agent string, age time.Duration) {
+		syn, _ := store.Get(id)
+		syn.Claim(agent, types.DefaultClaimTimeout)
+		claimedAt := syn.ClaimedAt.Add(-age)
+		syn.ClaimedAt = &claimedAt
+	}
+	claim(1, "agent-a", 10*time.Minute)
+	claim(2, "agent-a", 5*time.Minute)
+	claim(3, "agent-a", 2*time.Hour)
+	claim(4, "agent-b", time.Hour)
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	result, err := server.activeAgents(map[string]any{})
+	if err != nil {
+		t.Fatalf("activeAgents failed: %v", err)
+	}
+	var response struct {
+		Agents []agentActivity `json:"agents"`
+		Active int             `json:"active"`
+		Stale  int             `json:"stale"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Active != 1 || response.Stale != 1 || len(response.Agents) != 2 {
+		t.Fatalf("expected one active and one stale agent, got %+v", response)
+	}
+
+	a := response.Agents[0]
+	if a.AgentID != "agent-a" || a.Stale || !slices.Equal(a.TaskIDs, []int{1, 2}) || !slices.Equal(a.StaleTaskIDs, []int{3}) {
+		t.Errorf("unexpected activity for agent-a: %+v", a)
+	}
+	if a.OldestClaimAge < 600 || a.OldestClaimAge > 660 {
+		t.Errorf("expected oldest active claim about 10 minutes old, got %ds", a.OldestClaimAge)
+	}
+	if b := response.Agents[1]; b.AgentID != "agent-b" || !b.Stale || len(b.TaskIDs) != 0 || !slices.Equal(b.StaleTaskIDs, []int{4}) {
+		t.Errorf("expected agent-b flagged stale, got %+v", b)
+	}
+}
+
+func TestRenewClaim(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	syn, _ := store.Create("Long task")
+	syn.Claim("agent-1", types.DefaultClaimTimeout)
+	stale := syn.ClaimedAt.Add(-20 * time.Minute)
+	syn.ClaimedAt = &stale
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	if _, err := server.renewClaim(map[string]any{"id": float64(1), "agent_id": "agent-1"}); err != nil {
+		t.Fatalf("renewClaim failed: %v", err)
+	}
+	syn, _ = store.Get(1)
+	if time.Since(*syn.ClaimedAt) > time.Minute {
+		t.Errorf("expected claim renewed to now, got %v", syn.ClaimedAt)
+	}
+
+	result, err := server.renewClaim(map[string]any{"id": float64(1), "agent_id": "agent-2"})
+	if err != nil {
+		t.Fatalf("renewClaim failed: %v", err)
+	}
+	var response struct {
+		Renewed   bool   `json:"renewed"`
+		ClaimedBy string `json:"claimed_by"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Renewed || response.ClaimedBy != "agent-1" {
+		t.Errorf("expected renewal by another agent rejected, got %+v", response)
+	}
+}
+
+func TestClaimTask_Steal(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Stuck task")
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.claimTask(map[string]any{"id": float64(1), "agent_id": "claude-1"})
+
+	server.claimTask(map[string]any{"id": float64(1), "agent_id": "coordinator"})
+	if syn, _ := store.Get(1); syn.ClaimedBy != "claude-1" {
+		t.Fatalf("expected claim refused without steal, claimed by %s", syn.ClaimedBy)
+	}
+
+	if _, err := server.claimTask(map[string]any{"id": float64(1), "agent_id": "coordinator", "steal": true}); err != nil {
+		t.Fatalf("claimTask failed: %v", err)
+	}
+	syn, _ := store.Get(1)
+	if syn.ClaimedBy != "coordinator" || !slices.Contains(syn.Notes, "Claim stolen from claude-1 by coordinator") {
+		t.Errorf("expected claim stolen with a note, got %s %v", syn.ClaimedBy, syn.Notes)
+	}
+}
+
+func TestCreateTask_DefaultAssignee(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.SetDefaultAssignee("@coder")
+
+	server.createTask(map[string]any{"title": "Defaulted"})
+	server.createTask(map[string]any{"title": "Explicit", "assignee": "@qa"})
+	server.spawnTask(map[string]any{"parent_task_id": float64(1), "title": "Spawned"})
+
+	for id, want := range map[int]string{1: "@coder", 2: "@qa", 3: "@coder"} {
+		if syn, _ := store.Get(id); syn.Assignee != want {
+			t.Errorf("task %d assignee = %q, want %q", id, syn.Assignee, want)
+		}
+	}
+}
+
+func TestCreateTask_CreatedBy(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.SetDefaultAgent("env-agent")
+
+	server.createTask(map[string]any{"title": "Defaulted"})
+	server.createTask(map[string]any{"title": "Explicit", "agent_id": "agent-1"})
+	server.createTask(map[string]any{"title": "By field name", "created_by": "agent-2"})
+	server.spawnTask(map[string]any{"parent_task_id": float64(1), "title": "Spawned", "agent_id": "agent-3"})
+
+	want := map[int]string{1: "env-agent", 2: "agent-1", 3: "agent-2", 4: "agent-3"}
+	for id, agent := range want {
+		if syn, _ := store.Get(id); syn.CreatedBy != agent {
+			t.Errorf("task %d created_by = %q, want %q", id, syn.CreatedBy, agent)
+		}
+	}
+
+	// Persisted through a reload
+	reloaded := storage.NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if syn, _ := reloaded.Get(2); syn.CreatedBy != "agent-1" {
+		t.Errorf("reloaded created_by = %q, want agent-1", syn.CreatedBy)
+	}
+}
+
+func TestCreateTask_ExternalID(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	args := map[string]any{"title": "Ship release", "priority": float64(3), "external_id": "req-7"}
+	if _, err := server.createTask(args); err != nil {
+		t.Fatalf("first create failed: %v", err)
+	}
+	result, err := server.createTask(args)
+	if err != nil {
+		t.Fatalf("retried create failed: %v", err)
+	}
+
+	if store.Count() != 1 {
+		t.Fatalf("expected one task after a retried create, got %d", store.Count())
+	}
+	var response struct {
+		ID       int  `json:"id"`
+		Priority int  `json:"priority"`
+		Existing bool `json:"existing"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.ID != 1 || response.Priority != 3 || !response.Existing {
+		t.Errorf("expected the existing task flagged existing, got %+v", response)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	server.createTask(map[string]any{"title": "A"})
+	server.createTask(map[string]any{"title": "B"})
+	if _, err := server.claimTask(map[string]any{"id": float64(1), "agent_id": "agent-1"}); err != nil {
+		t.Fatalf("claimTask failed: %v", err)
+	}
+	if _, err := server.completeTask(map[string]any{"id": float64(1)}); err != nil {
+		t.Fatalf("completeTask failed: %v", err)
+	}
+
+	type historyResponse struct {
+		Events    []types.Event `json:"events"`
+		Total     int           `json:"total"`
+		Truncated bool          `json:"truncated"`
+	}
+	history := func(args map[string]any) historyResponse {
+		t.Helper()
+		result, err := server.getHistory(args)
+		if err != nil {
+			t.Fatalf("getHistory(%v) failed: %v", args, err)
+		}
+		var response historyResponse
+		if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	all := history(map[string]any{})
+	if all.Total < 4 || len(all.Events) != all.Total {
+		t.Fatalf("got %d of %d events, want all of at least 4", len(all.Events), all.Total)
+	}
+	if all.Events[0].Event != types.EventCompleted || all.Events[0].TaskID != 1 {
+		t.Errorf("newest event = %+v, want task 1 completed", all.Events[0])
+	}
+	if last := all.Events[len(all.Events)-1]; last.Event != types.EventCreated || last.TaskID != 1 {
+		t.Errorf("oldest event = %+v, want task 1 created", last)
+	}
+
+	forTask := history(map[string]any{"task_id": float64(2)})
+	if len(forTask.Events) != 1 || forTask.Events[0].Event != types.EventCreated {
+		t.Errorf("task 2 history = %+v, want only its creation", forTask.Events)
+	}
+
+	limited := history(map[string]any{"limit": float64(2)})
+	if len(limited.Events) != 2 || limited.Events[0] != all.Events[0] {
+		t.Errorf("limit 2 returned %+v", limited.Events)
+	}
+
+	small := history(map[string]any{"max_chars": float64(200)})
+	if !small.Truncated || len(small.Events) >= all.Total {
+		t.Errorf("max_chars 200 returned %d events, truncated=%v", len(small.Events), small.Truncated)
+	}
+	if len(small.Events) > 0 && small.Events[0] != all.Events[0] {
+		t.Errorf("truncation dropped the newest event")
+	}
+}
+
+func TestBulkBreadcrumbs(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	bcStore := storage.NewBreadcrumbStore(dir)
+	server := NewServer(store, bcStore)
+	server.setBreadcrumb(map[string]any{"key": "db.engine", "value": "sqlite"})
+
+	result, err := server.setBreadcrumbs(map[string]any{"breadcrumbs": []any{
+		map[string]any{"key": "db.engine", "value": "postgres"},
+		map[string]any{"key": "db.port", "value": "5432", "task_id": float64(3)},
+	}})
+	if err != nil {
+		t.Fatalf("setBreadcrumbs failed: %v", err)
+	}
+	var setResponse struct {
+		Results []struct {
+			Key     string `json:"key"`
+			Created bool   `json:"created"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &setResponse); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(setResponse.Results) != 2 || setResponse.Results[0].Created || !setResponse.Results[1].Created {
+		t.Errorf("expected db.engine updated and db.port created, got %+v", setResponse.Results)
+	}
+
+	// Both writes reached disk
+	reloaded := storage.NewBreadcrumbStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("failed to reload breadcrumbs: %v", err)
+	}
+	if b, ok := reloaded.Get("db.port"); !ok || b.Value != "5432" || b.TaskID != 3 {
+		t.Errorf("db.port not saved, got %+v", b)
+	}
+
+	// A bad entry stores nothing
+	_, err = server.setBreadcrumbs(map[string]any{"breadcrumbs": []any{
+		map[string]any{"key": "db.user", "value": "admin"},
+		map[string]any{"key": "db.host"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "item 1") {
+		t.Errorf("expected error for item 1, got %v", err)
+	}
+	if _, ok := bcStore.Get("db.user"); ok {
+		t.Error("db.user stored despite a bad entry")
+	}
+
+	result, err = server.getBreadcrumbs(map[string]any{"keys": []any{"db.engine", "db.missing"}})
+	if err != nil {
+		t.Fatalf("getBreadcrumbs failed: %v", err)
+	}
+	var getResponse struct {
+		Results []struct {
+			Key        string            `json:"key"`
+			Found      bool              `json:"found"`
+			Breadcrumb *types.Breadcrumb `json:"breadcrumb"`
+		} `json:"results"`
+		Found int `json:"found"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &getResponse); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if getResponse.Found != 1 || len(getResponse.Results) != 2 {
+		t.Fatalf("expected 1 of 2 found, got %+v", getResponse)
+	}
+	if r := getResponse.Results[0]; !r.Found || r.Breadcrumb.Value != "postgres" {
+		t.Errorf("db.engine = %+v, want postgres", r)
+	}
+	if r := getResponse.Results[1]; r.Found || r.Key != "db.missing" {
+		t.Errorf("db.missing = %+v, want not found", r)
+	}
+}
+
+func TestListNamespaces(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+	for _, key := range []string{"db.engine", "auth.method", "db.port"} {
+		server.setBreadcrumb(map[string]any{"key": key, "value": "x"})
+	}
+
+	result, err := server.listNamespaces()
+	if err != nil {
+		t.Fatalf("listNamespaces failed: %v", err)
+	}
+	var response struct {
+		Namespaces []storage.Namespace `json:"namespaces"`
+		Count      int                 `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	want := []storage.Namespace{{Name: "auth", Count: 1}, {Name: "db", Count: 2}}
+	if response.Count != 2 || !reflect.DeepEqual(response.Namespaces, want) {
+		t.Errorf("got %+v, want %v", response, want)
+	}
+}
+
+func TestDeleteBreadcrumb_Prefix(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	bcStore := storage.NewBreadcrumbStore(dir)
+	server := NewServer(store, bcStore)
+	for _, key := range []string{"auth.method", "auth.ttl", "db.engine"} {
+		server.setBreadcrumb(map[string]any{"key": key, "value": "x"})
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"prefix": ""},
+		{"key": "db.engine", "prefix": "auth."},
+		{"prefix": "auth.", "all": true},
+	} {
+		if _, err := server.deleteBreadcrumb(args); err == nil {
+			t.Errorf("deleteBreadcrumb(%v) succeeded, want error", args)
+		}
+	}
+	if bcStore.Count() != 3 {
+		t.Fatalf("rejected calls deleted breadcrumbs, %d left", bcStore.Count())
+	}
+
+	result, err := server.deleteBreadcrumb(map[string]any{"prefix": "auth."})
+	if err != nil {
+		t.Fatalf("deleteBreadcrumb failed: %v", err)
+	}
+	var response struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Deleted != 2 {
+		t.Errorf("deleted %d, want 2", response.Deleted)
+	}
+
+	reloaded := storage.NewBreadcrumbStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("failed to reload breadcrumbs: %v", err)
+	}
+	if reloaded.Count() != 1 {
+		t.Errorf("expected 1 breadcrumb on disk, got %d", reloaded.Count())
+	}
+
+	if _, err := server.deleteBreadcrumb(map[string]any{"all": true}); err != nil || bcStore.Count() != 0 {
+		t.Errorf("all: err %v, %d left", err, bcStore.Count())
+	}
+}
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// validateArgs checks tool call arguments against the tool's input schema
+// before dispatch: required properties must be present and every property
+// must have its declared type. Numbers sent as strings (a common LLM
+// mistake) are converted in place with toFloat64, so handlers always see
+// float64. Properties the schema doesn't declare are left alone, and "id"
+// may be given under any of the idAliases that resolveID accepts.
+func validateArgs(schema map[string]any, args map[string]any) error {
+	props, _ := schema["properties"].(map[string]any)
+
+	required, _ := schema["required"].([]string)
+	for _, key := range required {
+		if v, ok := args[key]; ok && v != nil {
+			continue
+		}
+		if key == "id" && hasIDAlias(args) {
+			continue
+		}
+		return fmt.Errorf("%s is required", key)
+	}
+
+	// Sorted so the first bad field reported is deterministic
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		prop, ok := props[key].(map[string]any)
+		if !ok && slices.Contains(idAliases, key) {
+			prop, ok = props["id"].(map[string]any)
+		}
+		if !ok || args[key] == nil {
+			continue
+		}
+		v, err := checkType(prop, args[key])
+		if err != nil {
+			return fmt.Errorf("%s %w", key, err)
+		}
+		args[key] = v
+	}
+	return nil
+}
+
+// checkType checks v against a property schema's type, returning v with
+// numeric strings converted to float64.
+func checkType(prop map[string]any, v any) (any, error) {
+	want, _ := prop["type"].(string)
+	switch want {
+	case "number", "integer":
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, fmt.Errorf("must be a number, got %T: %v", v, v)
+		}
+		return f, nil
+	case "string":
+		if _, ok := v.(string); !ok {
+			return nil, fmt.Errorf("must be a string, got %T: %v", v, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("must be a boolean, got %T: %v", v, v)
+		}
+	case "object":
+		if _, ok := v.(map[string]any); !ok {
+			return nil, fmt.Errorf("must be an object, got %T: %v", v, v)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("must be an array, got %T: %v", v, v)
+		}
+		itemSchema, _ := prop["items"].(map[string]any)
+		if itemSchema == nil {
+			return items, nil
+		}
+		for i, item := range items {
+			checked, err := checkType(itemSchema, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d %w", i, err)
+			}
+			items[i] = checked
+		}
+	}
+	return v, nil
+}
+
+// hasIDAlias reports whether args give the task ID under any alias.
+func hasIDAlias(args map[string]any) bool {
+	for _, key := range idAliases {
+		if args[key] != nil {
+			return true
+		}
+	}
+	return false
+}
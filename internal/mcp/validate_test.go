@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/internal/storage"
+)
+
+func TestValidateArgs(t *testing.T) {
+	schema := toolsByName["create_task"].InputSchema
+
+	args := map[string]any{"title": "Fix", "priority": "3", "blocked_by": []any{"1", float64(2)}, "extra": 1}
+	if err := validateArgs(schema, args); err != nil {
+		t.Fatalf("expected valid args, got %v", err)
+	}
+	if args["priority"] != float64(3) || args["blocked_by"].([]any)[0] != float64(1) {
+		t.Errorf("expected numeric strings coerced, got %v", args)
+	}
+
+	tests := []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{}, "title is required"},
+		{map[string]any{"title": nil}, "title is required"},
+		{map[string]any{"title": 5}, "title must be a string"},
+		{map[string]any{"title": "x", "priority": "high"}, "priority must be a number"},
+		{map[string]any{"title": "x", "labels": "bug"}, "labels must be an array"},
+		{map[string]any{"title": "x", "blocked_by": []any{"one"}}, "blocked_by item 0 must be a number"},
+	}
+	for _, tt := range tests {
+		err := validateArgs(schema, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("validateArgs(%v) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+
+	// task_id stands in for a required id
+	if err := validateArgs(toolsByName["get_task"].InputSchema, map[string]any{"task_id": "4"}); err != nil {
+		t.Errorf("expected task_id accepted for id, got %v", err)
+	}
+}
+
+func TestToolsCall_InvalidParams(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	server := NewServer(store, storage.NewBreadcrumbStore(dir))
+
+	resp := call(t, server, "tools/call", `{"name": "claim_task", "arguments": {"id": "abc", "agent_id": "a1"}}`)
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("expected -32602 error, got %+v", resp)
+	}
+	if data := fmt.Sprint(resp.Error.Data); !strings.Contains(data, "claim_task: id must be a number") {
+		t.Errorf("expected error citing the field, got %q", data)
+	}
+
+	resp = call(t, server, "tools/call", `{"name": "create_task"}`)
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "title is required") {
+		t.Errorf("expected missing title error, got %+v", resp)
+	}
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// Storage modes for memory.jsonl, chosen with the storage-mode setting.
+const (
+	// StorageRewrite rewrites the whole file, sorted by ID, on every save.
+	StorageRewrite = "rewrite"
+	// StorageAppend appends a line per changed task and a tombstone per
+	// deleted one; on load the last line for each ID wins. Saves touch
+	// only the end of the file, so concurrent branches merge cleanly
+	// (with a union merge driver), at the cost of a growing file until
+	// it is compacted.
+	StorageAppend = "append"
+)
+
+// tombstone is the line StorageAppend writes for a deleted task.
+type tombstone struct {
+	Deleted int `json:"deleted"`
+}
+
+// parseTombstone reports whether line is a deletion tombstone and, if so,
+// the ID it deletes.
+func parseTombstone(line []byte) (int, bool) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte(`{"deleted"`)) {
+		return 0, false
+	}
+	var t tombstone
+	if err := json.Unmarshal(line, &t); err != nil || t.Deleted <= 0 {
+		return 0, false
+	}
+	return t.Deleted, true
+}
+
+// forget drops task id as a tombstone line asks. Callers must hold s.mu.
+func (s *JSONLStore) forget(id int) {
+	if syn, ok := s.synapses[id]; ok && s.externalIDs[syn.ExternalID] == id {
+		delete(s.externalIDs, syn.ExternalID)
+	}
+	delete(s.synapses, id)
+	s.index.remove(id)
+	delete(s.shadowed, id)
+}
+
+// SetAppendOnly switches the store to StorageAppend saves. Set it before
+// Load: the first save after a load made without it rewrites the whole
+// file. Repeated IDs in an append-only file are expected, so they are not
+// reported as duplicates.
+func (s *JSONLStore) SetAppendOnly(appendOnly bool) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appendOnly = appendOnly
+	if !appendOnly {
+		s.written = nil
+	}
+}
+
+// Rewrite writes the memory file in canonical form, one line per task in
+// ID order, folding away the superseded lines and tombstones an
+// append-only store accumulates. It records no audit events.
+func (s *JSONLStore) Rewrite() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int, 0, len(s.synapses))
+	for id := range s.synapses {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	tasks := make([]*types.Synapse, len(ids))
+	for i, id := range ids {
+		tasks[i] = s.synapses[id]
+	}
+
+	written, err := s.writeTemp(tasks)
+	if err != nil {
+		return err
+	}
+	if err := s.replaceMemory(); err != nil {
+		return err
+	}
+	if s.appendOnly {
+		s.written = written
+	}
+	return nil
+}
+
+// appendLines encodes what a save in StorageAppend mode adds to the file
+// for tasks, the whole store in ID order: a line for each task that
+// differs from its last written line, then a tombstone for each task
+// written before but no longer present. It returns the lines and the
+// last written line of each task afterwards. Callers must hold saveMu.
+func (s *JSONLStore) appendLines(tasks []*types.Synapse) ([]byte, map[int][]byte, error) {
+	var buf bytes.Buffer
+	written := make(map[int][]byte, len(tasks))
+	for _, syn := range tasks {
+		line, err := json.Marshal(syn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode synapse %d: %w", syn.ID, err)
+		}
+		if prev, ok := s.written[syn.ID]; ok && bytes.Equal(line, prev) {
+			line = prev
+		} else {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+		written[syn.ID] = line
+	}
+
+	var deleted []int
+	for id := range s.written {
+		if _, ok := written[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Ints(deleted)
+	for _, id := range deleted {
+		line, _ := json.Marshal(tombstone{Deleted: id})
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), written, nil
+}
+
+// appendMemory appends data to the memory file in a single write. Callers
+// must hold saveMu and s.mu.
+func (s *JSONLStore) appendMemory(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(s.memoryPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open memory file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("append to memory file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close memory file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendOnly(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.SetAppendOnly(true)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	memPath := filepath.Join(dir, MemoryFile)
+	lines := func() []string {
+		t.Helper()
+		data, err := os.ReadFile(memPath)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	first, _ := store.Create("First")
+	store.Create("Second")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	base := len(lines())
+
+	// Only the changed task is appended; the others stay put
+	first.MarkDoneBy("agent-1")
+	store.Save()
+	store.Delete(2)
+	store.Save()
+	got := lines()
+	if len(got) != base+2 || got[len(got)-1] != `{"deleted":2}` {
+		t.Fatalf("expected an update line and a tombstone appended, got:\n%s", strings.Join(got, "\n"))
+	}
+
+	// The last line for each ID wins, without being reported as a duplicate
+	reloaded := NewJSONLStore(dir)
+	reloaded.SetAppendOnly(true)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if syn, err := reloaded.Get(1); err != nil || syn.Status != "done" {
+		t.Errorf("expected task 1 done, got %+v (%v)", syn, err)
+	}
+	if _, err := reloaded.Get(2); err == nil {
+		t.Error("expected deleted task 2 gone")
+	}
+	if ids := reloaded.DuplicateIDs(); len(ids) != 0 {
+		t.Errorf("expected no duplicates reported, got %v", ids)
+	}
+	// IDs aren't reused after a tombstone
+	if syn, _ := reloaded.Create("Third"); syn.ID != 3 {
+		t.Errorf("expected new task 3, got %d", syn.ID)
+	}
+	reloaded.Save()
+
+	// A reload that changes nothing appends nothing
+	before := len(lines())
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	reloaded.Save()
+	if after := len(lines()); after != before {
+		t.Errorf("expected no lines appended, went from %d to %d", before, after)
+	}
+
+	// Rewrite folds the history into one line per task
+	if err := reloaded.Rewrite(); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	got = lines()
+	if len(got) != 3 || !strings.Contains(got[1], `"id":1`) || !strings.Contains(got[2], `"id":3`) {
+		t.Errorf("expected the header and tasks 1 and 3, got:\n%s", strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// AutoCommitter is an EventSink that commits memory.jsonl and the audit
+// log to Git after every Save that changed something, so the repository
+// history follows the board. A failed commit is logged, not returned: the
+// change is already saved and can be committed by hand.
+type AutoCommitter struct {
+	git   *GitIntegration
+	paths []string // Files to commit, relative to the repo root
+}
+
+// NewAutoCommitter creates a committer for the store in dir, which must be
+// inside git's repository.
+func NewAutoCommitter(git *GitIntegration, dir string) (*AutoCommitter, error) {
+	var paths []string
+	for _, name := range []string{MemoryFile, EventsFile} {
+		rel, err := git.RelPath(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("locate %s in repository: %w", name, err)
+		}
+		paths = append(paths, rel)
+	}
+	return &AutoCommitter{git: git, paths: paths}, nil
+}
+
+// Publish commits the saved files with a message summarizing events.
+func (c *AutoCommitter) Publish(events []types.Event, _ map[int]*types.Synapse) error {
+	if err := c.git.Commit(CommitMessage(events), c.paths...); err != nil {
+		log.Printf("Warning: auto-commit failed: %v", err)
+	}
+	return nil
+}
+
+// commitVerbs orders the event types by how much they say about a change,
+// with the verb CommitMessage uses for each. A task is described by its
+// most telling event, so a claim isn't also reported as a status change.
+var commitVerbs = []struct {
+	event types.EventType
+	verb  string
+}{
+	{types.EventCreated, "create"},
+	{types.EventCompleted, "complete"},
+	{types.EventDeleted, "delete"},
+	{types.EventClaimed, "claim"},
+	{types.EventReleased, "release"},
+	{types.EventStatus, "move"},
+	{types.EventUpdated, "update"},
+}
+
+// CommitMessage summarizes a save's events as a commit message such as
+// "synapse: complete #5" or "synapse: create #7, #8; claim #6".
+func CommitMessage(events []types.Event) string {
+	rank := make(map[types.EventType]int, len(commitVerbs))
+	for i, v := range commitVerbs {
+		rank[v.event] = i
+	}
+
+	// The most telling event per task, keeping tasks in event order
+	best := make(map[int]types.EventType)
+	var order []int
+	for _, e := range events {
+		prev, seen := best[e.TaskID]
+		if !seen {
+			order = append(order, e.TaskID)
+		}
+		if !seen || rank[e.Event] < rank[prev] {
+			best[e.TaskID] = e.Event
+		}
+	}
+
+	var parts []string
+	for _, v := range commitVerbs {
+		var refs []string
+		for _, id := range order {
+			if best[id] == v.event {
+				refs = append(refs, fmt.Sprintf("#%d", id))
+			}
+		}
+		if len(refs) > 0 {
+			parts = append(parts, v.verb+" "+strings.Join(refs, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "synapse: update tasks"
+	}
+	return "synapse: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tests := []struct {
+		events []types.Event
+		want   string
+	}{
+		{[]types.Event{{TaskID: 5, Event: types.EventCompleted}}, "synapse: complete #5"},
+		{
+			// A claim also moves the status; only the claim is named
+			[]types.Event{
+				{TaskID: 6, Event: types.EventClaimed},
+				{TaskID: 6, Event: types.EventStatus},
+				{TaskID: 7, Event: types.EventCreated},
+				{TaskID: 8, Event: types.EventCreated},
+			},
+			"synapse: create #7, #8; claim #6",
+		},
+		{nil, "synapse: update tasks"},
+	}
+	for _, tt := range tests {
+		if got := CommitMessage(tt.events); got != tt.want {
+			t.Errorf("CommitMessage(%v) = %q, want %q", tt.events, got, tt.want)
+		}
+	}
+}
+
+func TestAutoCommitter(t *testing.T) {
+	root, gitCmd := newTestRepo(t)
+
+	// Something else staged stays out of the auto-commits
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("wip\n"), 0644)
+	gitCmd("add", "notes.txt")
+
+	git := &GitIntegration{repoRoot: root}
+	store := NewJSONLStore(filepath.Join(root, DefaultDir))
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	committer, err := NewAutoCommitter(git, store.Dir())
+	if err != nil {
+		t.Fatalf("new auto-committer: %v", err)
+	}
+	store.Subscribe(committer)
+
+	syn, _ := store.Create("Committed task")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	syn.MarkDoneBy("agent-1")
+	store.Save()
+	// Nothing changed: no event, no commit, no error
+	store.Save()
+
+	log := gitCmd("log", "--format=%s")
+	if log != "synapse: complete #1\nsynapse: create #1\n" {
+		t.Errorf("unexpected commits:\n%s", log)
+	}
+	if status := gitCmd("status", "--porcelain", "--", "notes.txt"); !strings.HasPrefix(status, "A ") {
+		t.Errorf("expected notes.txt still staged, got %q", status)
+	}
+
+	// Committing with nothing changed is not an error
+	if err := git.Commit("synapse: nothing", committer.paths...); err != nil {
+		t.Errorf("commit with nothing to commit: %v", err)
+	}
+}
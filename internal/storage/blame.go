@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// Provenance is the story of a task for `synapse blame`: where it came
+// from, where it sits, and who has touched it.
+type Provenance struct {
+	Task           *types.Synapse `json:"task"`
+	DiscoveredFrom *TaskRef       `json:"discovered_from,omitempty"` // Task being worked on when this one was found
+	Parents        []TaskRef      `json:"parents"`                   // Parent chain, nearest first
+	Discovered     []TaskRef      `json:"discovered"`                // Tasks found while working on this one
+	Events         []types.Event  `json:"events"`                    // Audit log entries for the task, oldest first
+}
+
+// BuildProvenance assembles the Provenance of task id from the store and
+// its audit log. Tasks created before the log existed have no events; the
+// task's own fields (CreatedBy, ClaimedBy, CompletedBy) still tell part of
+// the story.
+func BuildProvenance(store *JSONLStore, id int) (*Provenance, error) {
+	syn, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provenance{
+		Task:       syn,
+		Parents:    []TaskRef{},
+		Discovered: []TaskRef{},
+		Events:     []types.Event{},
+	}
+
+	if syn.DiscoveredFrom > 0 {
+		ref := TaskRef{ID: syn.DiscoveredFrom, Missing: true}
+		if origin, err := store.Get(syn.DiscoveredFrom); err == nil {
+			ref = taskRef(origin)
+		}
+		p.DiscoveredFrom = &ref
+	}
+
+	// Walk up the parent chain, stopping at missing parents and cycles
+	seen := map[int]bool{syn.ID: true}
+	for parentID := syn.ParentID; parentID > 0 && !seen[parentID]; {
+		seen[parentID] = true
+		parent, err := store.Get(parentID)
+		if err != nil {
+			p.Parents = append(p.Parents, TaskRef{ID: parentID, Missing: true})
+			break
+		}
+		p.Parents = append(p.Parents, taskRef(parent))
+		parentID = parent.ParentID
+	}
+
+	for _, found := range store.DiscoveredFrom(id) {
+		p.Discovered = append(p.Discovered, taskRef(found))
+	}
+
+	events, err := store.Events().ForTask(id)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	if events != nil {
+		p.Events = events
+	}
+	return p, nil
+}
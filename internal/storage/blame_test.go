@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestBuildProvenance(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	epic, _ := store.Create("Epic")
+	origin, _ := store.Create("Investigate flaky build")
+	task, _ := store.Create("Pin toolchain version")
+	task.ParentID = epic.ID
+	task.DiscoveredFrom = origin.ID
+	task.CreatedBy = "agent-1"
+	task.AddNote("CI image drifted")
+	followUp, _ := store.Create("Document the pin")
+	followUp.DiscoveredFrom = task.ID
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	task.Claim("agent-2", types.DefaultClaimTimeout)
+	store.Save()
+	task.MarkDoneBy("agent-2")
+	store.Save()
+
+	p, err := BuildProvenance(store, task.ID)
+	if err != nil {
+		t.Fatalf("build provenance: %v", err)
+	}
+	if p.DiscoveredFrom == nil || p.DiscoveredFrom.ID != origin.ID || p.DiscoveredFrom.Title != origin.Title {
+		t.Errorf("expected discovered from #%d, got %+v", origin.ID, p.DiscoveredFrom)
+	}
+	if len(p.Parents) != 1 || p.Parents[0].ID != epic.ID {
+		t.Errorf("expected parent chain [epic], got %+v", p.Parents)
+	}
+	if len(p.Discovered) != 1 || p.Discovered[0].ID != followUp.ID {
+		t.Errorf("expected follow-up discovered, got %+v", p.Discovered)
+	}
+
+	var kinds []types.EventType
+	for _, e := range p.Events {
+		kinds = append(kinds, e.Event)
+	}
+	want := []types.EventType{types.EventCreated, types.EventClaimed, types.EventStatus, types.EventCompleted}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], kinds[i])
+		}
+	}
+	if p.Events[0].Agent != "agent-1" {
+		t.Errorf("expected created event by the creator, got %q", p.Events[0].Agent)
+	}
+
+	if _, err := BuildProvenance(store, 42); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestBuildProvenance_DeletedOrigin(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	origin, _ := store.Create("Origin")
+	task, _ := store.Create("Found later")
+	task.DiscoveredFrom = origin.ID
+	store.Delete(origin.ID)
+
+	p, err := BuildProvenance(store, task.ID)
+	if err != nil {
+		t.Fatalf("build provenance: %v", err)
+	}
+	if p.DiscoveredFrom == nil || !p.DiscoveredFrom.Missing {
+		t.Errorf("expected a missing origin, got %+v", p.DiscoveredFrom)
+	}
+	if len(p.Events) != 0 {
+		t.Errorf("expected no events for an unsaved store, got %+v", p.Events)
+	}
+}
@@ -163,6 +163,22 @@ func (s *BreadcrumbStore) Delete(key string) bool {
 	return true
 }
 
+// DeletePrefix removes every breadcrumb whose key starts with prefix and
+// returns the number removed. An empty prefix removes them all.
+func (s *BreadcrumbStore) DeletePrefix(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key := range s.breadcrumbs {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.breadcrumbs, key)
+			removed++
+		}
+	}
+	return removed
+}
+
 // List returns all breadcrumbs, optionally filtered by prefix.
 func (s *BreadcrumbStore) List(prefix string) []*types.Breadcrumb {
 	s.mu.RLock()
@@ -203,6 +219,35 @@ func (s *BreadcrumbStore) ListByTask(taskID int) []*types.Breadcrumb {
 	return result
 }
 
+// Namespace is a breadcrumb key prefix with the number of keys under it.
+type Namespace struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Namespaces returns the distinct first segments of breadcrumb keys (the
+// part before the first "."), sorted, with the number of keys in each. A
+// key without a "." is its own namespace.
+func (s *BreadcrumbStore) Namespaces() []Namespace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for key := range s.breadcrumbs {
+		name, _, _ := strings.Cut(key, ".")
+		counts[name]++
+	}
+
+	result := make([]Namespace, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, Namespace{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
 // Count returns the total number of breadcrumbs.
 func (s *BreadcrumbStore) Count() int {
 	s.mu.RLock()
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreadcrumbStore_Namespaces(t *testing.T) {
+	store := NewBreadcrumbStore(t.TempDir())
+	if got := store.Namespaces(); len(got) != 0 {
+		t.Errorf("empty store namespaces = %v, want none", got)
+	}
+
+	for _, key := range []string{"db.engine", "auth.method", "db.port", "db.pool.size", "todo"} {
+		store.Set(key, "x", 0)
+	}
+
+	want := []Namespace{{"auth", 1}, {"db", 3}, {"todo", 1}}
+	if got := store.Namespaces(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Namespaces() = %v, want %v", got, want)
+	}
+}
+
+func TestBreadcrumbStore_DeletePrefix(t *testing.T) {
+	store := NewBreadcrumbStore(t.TempDir())
+	for _, key := range []string{"auth.method", "auth.token.ttl", "authz.role", "db.engine"} {
+		store.Set(key, "x", 0)
+	}
+
+	if n := store.DeletePrefix("auth."); n != 2 {
+		t.Errorf("DeletePrefix(auth.) = %d, want 2", n)
+	}
+	if _, ok := store.Get("authz.role"); !ok {
+		t.Error("authz.role should not match prefix auth.")
+	}
+	if n := store.DeletePrefix("missing."); n != 0 {
+		t.Errorf("DeletePrefix(missing.) = %d, want 0", n)
+	}
+	if n := store.DeletePrefix(""); n != 2 || store.Count() != 0 {
+		t.Errorf("DeletePrefix(\"\") = %d leaving %d, want 2 leaving 0", n, store.Count())
+	}
+}
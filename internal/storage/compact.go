@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// ArchiveFile receives tasks removed by Compact when archiving is enabled.
+	ArchiveFile = "done-archive.jsonl"
+)
+
+// CompactOptions selects which tasks Compact removes.
+type CompactOptions struct {
+	OlderThan time.Duration // Only tasks not updated within this window
+	Status    types.Status  // Only tasks with this status (default: done)
+	Archive   bool          // Append removed tasks to done-archive.jsonl first
+	DryRun    bool          // Report what would be removed without changing anything
+}
+
+// CompactResult reports the outcome of a Compact call.
+type CompactResult struct {
+	Removed    int   `json:"removed"`
+	Archived   int   `json:"archived"`
+	Skipped    int   `json:"skipped"` // Matched but still blocking a remaining task
+	RemovedIDs []int `json:"removed_ids"`
+	SkippedIDs []int `json:"skipped_ids"`
+	DryRun     bool  `json:"dry_run"`
+	Rewritten  bool  `json:"rewritten,omitempty"` // memory.jsonl rewritten by compact --rewrite
+}
+
+// Compact removes old tasks matching opts to keep the working set small.
+// A matching task is kept if any task that remains in the store lists it
+// as a blocker, since removing it would leave that task blocked forever.
+// The caller is responsible for saving the store afterwards.
+func (s *JSONLStore) Compact(opts CompactOptions) (*CompactResult, error) {
+	if opts.Status == "" {
+		opts.Status = types.StatusDone
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-opts.OlderThan)
+	candidates := make(map[int]bool)
+	for id, syn := range s.synapses {
+		if syn.Status == opts.Status && syn.UpdatedAt.Before(cutoff) {
+			candidates[id] = true
+		}
+	}
+	matched := len(candidates)
+
+	// Keeping one task can make its own blockers referenced, so repeat
+	// until no remaining task blocks on a candidate
+	for changed := true; changed; {
+		changed = false
+		for id, syn := range s.synapses {
+			if candidates[id] {
+				continue
+			}
+			for _, blockerID := range syn.BlockedBy {
+				if candidates[blockerID] {
+					delete(candidates, blockerID)
+					changed = true
+				}
+			}
+		}
+	}
+
+	result := &CompactResult{DryRun: opts.DryRun, RemovedIDs: []int{}, SkippedIDs: []int{}}
+	for id, syn := range s.synapses {
+		if candidates[id] {
+			result.RemovedIDs = append(result.RemovedIDs, id)
+		} else if syn.Status == opts.Status && syn.UpdatedAt.Before(cutoff) {
+			result.SkippedIDs = append(result.SkippedIDs, id)
+		}
+	}
+	sort.Ints(result.RemovedIDs)
+	sort.Ints(result.SkippedIDs)
+	result.Removed = len(result.RemovedIDs)
+	result.Skipped = matched - result.Removed
+
+	if opts.DryRun || result.Removed == 0 {
+		return result, nil
+	}
+
+	if opts.Archive {
+		if err := s.archive(result.RemovedIDs); err != nil {
+			return nil, err
+		}
+		result.Archived = result.Removed
+	}
+
+	for _, id := range result.RemovedIDs {
+		delete(s.synapses, id)
+		s.index.remove(id)
+	}
+	s.unlinkRelated(result.RemovedIDs)
+	return result, nil
+}
+
+// archive appends the given synapses to the archive file. Callers must
+// hold s.mu.
+func (s *JSONLStore) archive(ids []int) error {
+	file, err := os.OpenFile(filepath.Join(s.dir, ArchiveFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open archive file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, id := range ids {
+		if err := encoder.Encode(s.synapses[id]); err != nil {
+			file.Close()
+			return fmt.Errorf("archive synapse %d: %w", id, err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close archive file: %w", err)
+	}
+	return nil
+}
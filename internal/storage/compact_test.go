@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Init()
+
+	old := time.Now().UTC().Add(-60 * 24 * time.Hour)
+
+	oldDone, _ := store.Create("Old and finished")
+	oldDone.MarkDone()
+	oldDone.UpdatedAt = old
+
+	blocker, _ := store.Create("Old but still blocking")
+	blocker.MarkDone()
+	blocker.UpdatedAt = old
+
+	live, _ := store.Create("Live task")
+	live.BlockedBy = []int{blocker.ID}
+
+	recent, _ := store.Create("Recently finished")
+	recent.MarkDone()
+
+	oldOpen, _ := store.Create("Old but open")
+	oldOpen.UpdatedAt = old
+
+	opts := CompactOptions{OlderThan: 30 * 24 * time.Hour, Archive: true, DryRun: true}
+	result, err := store.Compact(opts)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if result.Removed != 1 || result.Skipped != 1 || store.Count() != 5 {
+		t.Fatalf("dry run should only report: %+v, count %d", result, store.Count())
+	}
+
+	opts.DryRun = false
+	result, err = store.Compact(opts)
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if result.Removed != 1 || result.RemovedIDs[0] != oldDone.ID {
+		t.Errorf("expected only task %d removed, got %+v", oldDone.ID, result)
+	}
+	if result.Skipped != 1 || result.SkippedIDs[0] != blocker.ID {
+		t.Errorf("expected blocker %d kept, got %+v", blocker.ID, result)
+	}
+	if result.Archived != 1 {
+		t.Errorf("expected 1 archived, got %d", result.Archived)
+	}
+	if _, err := store.Get(oldDone.ID); err == nil {
+		t.Error("old done task should be removed")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ArchiveFile))
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if len(data) == 0 {
+		t.Error("archive should contain the removed task")
+	}
+}
+
+func TestCompact_ChainedBlockers(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	old := time.Now().UTC().Add(-60 * 24 * time.Hour)
+
+	// 1 blocks 2 (both old and done); 2 blocks live task 3. Keeping 2 must
+	// also keep 1.
+	syn1, _ := store.Create("First")
+	syn2, _ := store.Create("Second")
+	syn2.BlockedBy = []int{1}
+	syn3, _ := store.Create("Live")
+	syn3.BlockedBy = []int{2}
+	for _, syn := range []*types.Synapse{syn1, syn2} {
+		syn.Status = types.StatusDone
+		syn.UpdatedAt = old
+	}
+
+	result, err := store.Compact(CompactOptions{OlderThan: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if result.Removed != 0 || result.Skipped != 2 {
+		t.Errorf("expected both blockers kept, got %+v", result)
+	}
+}
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// ConfigFile is the optional JSON file of per-project settings.
+	ConfigFile = "config.json"
+)
+
+// Config holds per-project settings. LoadConfig reads config.json alone;
+// ResolveConfig layers environment variables over it and fills in the
+// defaults.
+type Config struct {
+	StorageDir          string `json:"storage_dir,omitempty"`           // Where tasks and breadcrumbs live
+	DefaultClaimTimeout string `json:"default_claim_timeout,omitempty"` // time.ParseDuration syntax, e.g. "1h"
+	DefaultAssignee     string `json:"default_assignee,omitempty"`      // Assignee for new tasks that don't name one
+	ViewPort            int    `json:"view_port,omitempty"`             // Port for `synapse view`
+	AutoCommit          bool   `json:"auto_commit,omitempty"`           // Commit memory.jsonl to Git after every change
+	StorageMode         string `json:"storage_mode,omitempty"`          // StorageRewrite or StorageAppend
+}
+
+// ConfigSetting describes one setting for `synapse config`.
+type ConfigSetting struct {
+	Key     string // Name used on the command line, e.g. "claim-timeout"
+	Env     string // Environment variable that overrides the file
+	Default string // Value when neither the file nor the environment sets it
+	get     func(*Config) string
+	set     func(*Config, string) error
+}
+
+// ConfigSettings lists every setting in display order.
+var ConfigSettings = []ConfigSetting{
+	{
+		Key: "storage-dir", Env: "SYNAPSE_DIR", Default: DefaultDir,
+		get: func(c *Config) string { return c.StorageDir },
+		set: func(c *Config, v string) error {
+			if v == "" {
+				return fmt.Errorf("storage dir must not be empty")
+			}
+			c.StorageDir = v
+			return nil
+		},
+	},
+	{
+		Key: "claim-timeout", Env: "SYNAPSE_CLAIM_TIMEOUT", Default: "30m",
+		get: func(c *Config) string { return c.DefaultClaimTimeout },
+		set: func(c *Config, v string) error {
+			if _, err := ParseClaimTimeout(v); err != nil {
+				return err
+			}
+			c.DefaultClaimTimeout = v
+			return nil
+		},
+	},
+	{
+		Key: "default-assignee", Env: "SYNAPSE_ASSIGNEE",
+		get: func(c *Config) string { return c.DefaultAssignee },
+		set: func(c *Config, v string) error {
+			c.DefaultAssignee = v
+			return nil
+		},
+	},
+	{
+		Key: "view-port", Env: "SYNAPSE_VIEW_PORT", Default: "8080",
+		get: func(c *Config) string {
+			if c.ViewPort == 0 {
+				return ""
+			}
+			return strconv.Itoa(c.ViewPort)
+		},
+		set: func(c *Config, v string) error {
+			port, err := strconv.Atoi(v)
+			if err != nil || port < 1 || port > 65535 {
+				return fmt.Errorf("invalid port: %s", v)
+			}
+			c.ViewPort = port
+			return nil
+		},
+	},
+	{
+		Key: "auto-commit", Env: "SYNAPSE_AUTO_COMMIT", Default: "false",
+		get: func(c *Config) string {
+			if !c.AutoCommit {
+				return ""
+			}
+			return "true"
+		},
+		set: func(c *Config, v string) error {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean: %s", v)
+			}
+			c.AutoCommit = enabled
+			return nil
+		},
+	},
+	{
+		Key: "storage-mode", Env: "SYNAPSE_STORAGE_MODE", Default: StorageRewrite,
+		get: func(c *Config) string { return c.StorageMode },
+		set: func(c *Config, v string) error {
+			if v != StorageRewrite && v != StorageAppend {
+				return fmt.Errorf("invalid storage mode: %s (want %s or %s)", v, StorageRewrite, StorageAppend)
+			}
+			c.StorageMode = v
+			return nil
+		},
+	},
+}
+
+// configSetting finds a setting by key.
+func configSetting(key string) (ConfigSetting, error) {
+	for _, setting := range ConfigSettings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	keys := make([]string, len(ConfigSettings))
+	for i, setting := range ConfigSettings {
+		keys[i] = setting.Key
+	}
+	return ConfigSetting{}, fmt.Errorf("unknown config key: %s (known: %s)", key, strings.Join(keys, ", "))
+}
+
+// Get returns the value of the named setting, "" if unset.
+func (c *Config) Get(key string) (string, error) {
+	setting, err := configSetting(key)
+	if err != nil {
+		return "", err
+	}
+	return setting.get(c), nil
+}
+
+// Set validates and sets the named setting.
+func (c *Config) Set(key, value string) error {
+	setting, err := configSetting(key)
+	if err != nil {
+		return err
+	}
+	if err := setting.set(c, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// ConfigDir returns the directory holding config.json: $SYNAPSE_DIR if
+// set, else DefaultDir. A storage_dir inside the file moves the task data
+// but not the file itself.
+func ConfigDir() string {
+	if dir := os.Getenv("SYNAPSE_DIR"); dir != "" {
+		return dir
+	}
+	return DefaultDir
+}
+
+// LoadConfig reads config.json from dir. A missing file yields an empty
+// config and is not an error.
+func LoadConfig(dir string) (*Config, error) {
+	var raw Config
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config file: %w", err)
+	}
+
+	// Validate each value the same way Set does
+	cfg := &Config{}
+	for _, setting := range ConfigSettings {
+		if v := setting.get(&raw); v != "" {
+			if err := cfg.Set(setting.Key, v); err != nil {
+				return nil, fmt.Errorf("config file: %w", err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// ResolveConfig returns the effective settings: environment variables
+// over config.json in dir over the defaults. sources maps each setting key
+// to where its value came from: "env", "file" or "default".
+func ResolveConfig(dir string) (*Config, map[string]string, error) {
+	file, err := LoadConfig(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &Config{}
+	sources := make(map[string]string, len(ConfigSettings))
+	for _, setting := range ConfigSettings {
+		value, source := setting.Default, "default"
+		if v := setting.get(file); v != "" {
+			value, source = v, "file"
+		}
+		if v := os.Getenv(setting.Env); v != "" {
+			value, source = v, "env"
+		}
+		sources[setting.Key] = source
+		if value == "" {
+			continue
+		}
+		if err := setting.set(cfg, value); err != nil {
+			if source == "env" {
+				return nil, nil, fmt.Errorf("%s: %w", setting.Env, err)
+			}
+			return nil, nil, fmt.Errorf("%s: %w", setting.Key, err)
+		}
+	}
+	return cfg, sources, nil
+}
+
+// Save writes the config to dir, via a temp file and rename.
+func (c *Config) Save(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+
+	filePath := filepath.Join(dir, ConfigFile)
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
+
+// ClaimTimeout returns the configured claim timeout, or
+// types.DefaultClaimTimeout if none is set.
+func (c *Config) ClaimTimeout() time.Duration {
+	if d, err := ParseClaimTimeout(c.DefaultClaimTimeout); err == nil {
+		return d
+	}
+	return types.DefaultClaimTimeout
+}
+
+// ParseClaimTimeout parses a claim timeout with time.ParseDuration,
+// rejecting zero and negative durations.
+func ParseClaimTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("claim timeout must be positive, got %s", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestConfig_ClaimTimeout(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("load missing config: %v", err)
+	}
+	if cfg.ClaimTimeout() != types.DefaultClaimTimeout {
+		t.Errorf("expected default timeout, got %v", cfg.ClaimTimeout())
+	}
+
+	cfg.DefaultClaimTimeout = "2h"
+	if err := cfg.Save(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg, err = LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.ClaimTimeout() != 2*time.Hour {
+		t.Errorf("expected 2h timeout, got %v", cfg.ClaimTimeout())
+	}
+
+	// Expiry checks follow the store's timeout
+	store := NewJSONLStore(dir)
+	store.SetClaimTimeout(cfg.ClaimTimeout())
+	syn, _ := store.Create("Long job")
+	syn.Claim("agent-1", store.ClaimTimeout())
+	claimedAt := time.Now().UTC().Add(-time.Hour)
+	syn.ClaimedAt = &claimedAt
+	if issues, _ := store.Diagnose(DefaultChecks, false); len(issues) != 0 {
+		t.Errorf("expected an hour-old claim to be live under a 2h timeout, got %+v", issues)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	for _, timeout := range []string{"0s", "-5m", "soon"} {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`{"default_claim_timeout": "`+timeout+`"}`), 0644)
+		if _, err := LoadConfig(dir); err == nil {
+			t.Errorf("expected error for claim timeout %q", timeout)
+		}
+	}
+}
+
+func TestResolveConfig_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.Set("claim-timeout", "1h")
+	cfg.Set("default-assignee", "@coder")
+	cfg.Save(dir)
+	t.Setenv("SYNAPSE_ASSIGNEE", "@qa")
+	t.Setenv("SYNAPSE_VIEW_PORT", "")
+
+	resolved, sources, err := ResolveConfig(dir)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	want := map[string][2]string{
+		"storage-dir":      {DefaultDir, "default"},
+		"claim-timeout":    {"1h", "file"},
+		"default-assignee": {"@qa", "env"},
+		"view-port":        {"8080", "default"},
+	}
+	for key, w := range want {
+		if got, _ := resolved.Get(key); got != w[0] || sources[key] != w[1] {
+			t.Errorf("%s = %q from %s, want %q from %s", key, got, sources[key], w[0], w[1])
+		}
+	}
+
+	t.Setenv("SYNAPSE_VIEW_PORT", "http")
+	if _, _, err := ResolveConfig(dir); err == nil || !strings.Contains(err.Error(), "SYNAPSE_VIEW_PORT") {
+		t.Errorf("expected error naming the bad env var, got %v", err)
+	}
+}
+
+func TestConfig_SetUnknownKey(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.Set("colour", "blue"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := cfg.Set("view-port", "70000"); err == nil {
+		t.Error("expected error for out-of-range port")
+	}
+}
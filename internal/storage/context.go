@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// contextTextLimit is the length long texts are cut to when a TaskContext
+// is too large, matching list_tasks' summary fallback.
+const contextTextLimit = 100
+
+// TaskRef is a brief view of a task related to the one in a TaskContext.
+type TaskRef struct {
+	ID       int          `json:"id"`
+	Title    string       `json:"title,omitempty"`
+	Status   types.Status `json:"status,omitempty"`
+	Assignee string       `json:"assignee,omitempty"`
+	Missing  bool         `json:"missing,omitempty"` // The ID refers to a deleted task
+}
+
+// TaskContext is a briefing for an agent picking up a task: the task with
+// its notes, where it sits in the hierarchy, what blocks it, and the
+// breadcrumbs linked to it.
+type TaskContext struct {
+	Task        *types.Synapse      `json:"task"`
+	Parents     []TaskRef           `json:"parents"`  // Parent chain, nearest first
+	Blockers    []TaskRef           `json:"blockers"` // Direct blockers
+	Siblings    []TaskRef           `json:"siblings"` // Other tasks under the same parent
+	Breadcrumbs []*types.Breadcrumb `json:"breadcrumbs"`
+	Truncated   bool                `json:"truncated,omitempty"`
+}
+
+// BuildTaskContext assembles the TaskContext for task id. crumbs may be
+// nil. If the encoded context would exceed maxChars (0 for no limit), long
+// texts are cut to 100 characters and, if that isn't enough, the siblings
+// are dropped; Truncated reports either.
+func BuildTaskContext(store *JSONLStore, crumbs *BreadcrumbStore, id, maxChars int) (*TaskContext, error) {
+	syn, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &TaskContext{
+		Task:        syn,
+		Parents:     []TaskRef{},
+		Blockers:    []TaskRef{},
+		Siblings:    []TaskRef{},
+		Breadcrumbs: []*types.Breadcrumb{},
+	}
+
+	// Walk up the parent chain, stopping at missing parents and cycles
+	seen := map[int]bool{syn.ID: true}
+	for parentID := syn.ParentID; parentID > 0 && !seen[parentID]; {
+		seen[parentID] = true
+		parent, err := store.Get(parentID)
+		if err != nil {
+			ctx.Parents = append(ctx.Parents, TaskRef{ID: parentID, Missing: true})
+			break
+		}
+		ctx.Parents = append(ctx.Parents, taskRef(parent))
+		parentID = parent.ParentID
+	}
+
+	for _, blockerID := range syn.BlockedBy {
+		if blocker, err := store.Get(blockerID); err == nil {
+			ctx.Blockers = append(ctx.Blockers, taskRef(blocker))
+		} else {
+			ctx.Blockers = append(ctx.Blockers, TaskRef{ID: blockerID, Missing: true})
+		}
+	}
+
+	if syn.ParentID > 0 {
+		for _, other := range store.All() {
+			if other.ParentID == syn.ParentID && other.ID != syn.ID {
+				ctx.Siblings = append(ctx.Siblings, taskRef(other))
+			}
+		}
+	}
+
+	if crumbs != nil {
+		if linked := crumbs.ListByTask(syn.ID); linked != nil {
+			ctx.Breadcrumbs = linked
+		}
+	}
+
+	if maxChars > 0 && ctx.size() > maxChars {
+		ctx.truncate()
+		if ctx.size() > maxChars {
+			ctx.Siblings = []TaskRef{}
+		}
+	}
+	return ctx, nil
+}
+
+func taskRef(syn *types.Synapse) TaskRef {
+	return TaskRef{ID: syn.ID, Title: syn.Title, Status: syn.Status, Assignee: syn.Assignee}
+}
+
+// size returns the length of the encoded context.
+func (c *TaskContext) size() int {
+	data, _ := json.Marshal(c)
+	return len(data)
+}
+
+// truncate cuts the description, notes and breadcrumb values to
+// contextTextLimit, on copies so the stored task and breadcrumbs are
+// untouched.
+func (c *TaskContext) truncate() {
+	task := *c.Task
+	task.Description = truncateText(task.Description)
+	task.Notes = make([]string, len(c.Task.Notes))
+	for i, note := range c.Task.Notes {
+		task.Notes[i] = truncateText(note)
+	}
+	c.Task = &task
+
+	crumbs := make([]*types.Breadcrumb, len(c.Breadcrumbs))
+	for i, b := range c.Breadcrumbs {
+		short := *b
+		short.Value = truncateText(b.Value)
+		crumbs[i] = &short
+	}
+	c.Breadcrumbs = crumbs
+	c.Truncated = true
+}
+
+func truncateText(s string) string {
+	if len(s) > contextTextLimit {
+		return s[:contextTextLimit-3] + "..."
+	}
+	return s
+}
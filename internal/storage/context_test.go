@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestBuildTaskContext(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	epic, _ := store.Create("Epic")
+	feature, _ := store.Create("Feature")
+	feature.ParentID = epic.ID
+	blocker, _ := store.Create("Schema")
+	blocker.Status = types.StatusInProgress
+	task, _ := store.Create("Endpoint")
+	task.ParentID = feature.ID
+	task.BlockedBy = []int{blocker.ID, 99}
+	task.AddNote("Use the v2 client")
+	sibling, _ := store.Create("Tests")
+	sibling.ParentID = feature.ID
+
+	crumbs := NewBreadcrumbStore(dir)
+	crumbs.Set("api.base", "https://example.test", task.ID)
+	crumbs.Set("other", "x", sibling.ID)
+
+	ctx, err := BuildTaskContext(store, crumbs, task.ID, 0)
+	if err != nil {
+		t.Fatalf("build context: %v", err)
+	}
+	if len(ctx.Parents) != 2 || ctx.Parents[0].ID != feature.ID || ctx.Parents[1].ID != epic.ID {
+		t.Errorf("expected parent chain feature, epic; got %+v", ctx.Parents)
+	}
+	if len(ctx.Blockers) != 2 || ctx.Blockers[0].Status != types.StatusInProgress || !ctx.Blockers[1].Missing {
+		t.Errorf("expected in-progress and missing blockers, got %+v", ctx.Blockers)
+	}
+	if len(ctx.Siblings) != 1 || ctx.Siblings[0].ID != sibling.ID {
+		t.Errorf("expected one sibling, got %+v", ctx.Siblings)
+	}
+	if len(ctx.Breadcrumbs) != 1 || ctx.Breadcrumbs[0].Key != "api.base" {
+		t.Errorf("expected the linked breadcrumb, got %+v", ctx.Breadcrumbs)
+	}
+	if ctx.Truncated || len(ctx.Task.Notes) != 1 {
+		t.Errorf("expected full task with notes, got %+v", ctx.Task)
+	}
+
+	if _, err := BuildTaskContext(store, crumbs, 42, 0); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestBuildTaskContext_Truncates(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	task, _ := store.Create("Big")
+	task.Description = strings.Repeat("d", 5000)
+	task.AddNote(strings.Repeat("n", 5000))
+
+	ctx, err := BuildTaskContext(store, nil, task.ID, 2000)
+	if err != nil {
+		t.Fatalf("build context: %v", err)
+	}
+	if !ctx.Truncated || len(ctx.Task.Description) != contextTextLimit || len(ctx.Task.Notes[0]) != contextTextLimit {
+		t.Errorf("expected truncated texts, got description %d, note %d", len(ctx.Task.Description), len(ctx.Task.Notes[0]))
+	}
+	if stored, _ := store.Get(task.ID); len(stored.Description) != 5000 {
+		t.Error("truncation modified the stored task")
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// FieldChange is a task field that differs between two boards. From and To
+// hold the JSON values; either is empty when the field was unset.
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to,omitempty"`
+}
+
+// TaskChange lists the fields of a task that changed between two boards.
+type TaskChange struct {
+	ID     int           `json:"id"`
+	Title  string        `json:"title"` // The current title
+	Fields []FieldChange `json:"fields"`
+}
+
+// BoardDiff is what changed between two versions of a board, each list in
+// ID order.
+type BoardDiff struct {
+	Added   []*types.Synapse `json:"added"`
+	Removed []*types.Synapse `json:"removed"`
+	Changed []TaskChange     `json:"changed"`
+}
+
+// IsEmpty reports whether the boards hold the same tasks.
+func (d *BoardDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffIgnored holds the bookkeeping fields every edit moves. A task whose
+// only differences are in these is not reported as changed.
+var diffIgnored = map[string]bool{"updated_at": true, "version": true}
+
+// DiffBoards compares two versions of a board, e.g. memory.jsonl at an
+// earlier commit and now, matching tasks by ID and creation time.
+func DiffBoards(before, after []*types.Synapse) (*BoardDiff, error) {
+	diff := &BoardDiff{
+		Added:   []*types.Synapse{},
+		Removed: []*types.Synapse{},
+		Changed: []TaskChange{},
+	}
+
+	old := make(map[int]*types.Synapse, len(before))
+	for _, syn := range before {
+		old[syn.ID] = syn
+	}
+	current := make(map[int]*types.Synapse, len(after))
+	for _, syn := range after {
+		current[syn.ID] = syn
+		prev, ok := old[syn.ID]
+		if !ok || !prev.CreatedAt.Equal(syn.CreatedAt) {
+			// A different creation time means the ID was freed by a
+			// deletion and reused, so the old task is gone
+			diff.Added = append(diff.Added, syn)
+			continue
+		}
+		fields, err := diffFields(prev, syn)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			diff.Changed = append(diff.Changed, TaskChange{ID: syn.ID, Title: syn.Title, Fields: fields})
+		}
+	}
+	for _, syn := range before {
+		if cur, ok := current[syn.ID]; !ok || !cur.CreatedAt.Equal(syn.CreatedAt) {
+			diff.Removed = append(diff.Removed, syn)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].ID < diff.Added[j].ID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].ID < diff.Removed[j].ID })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+	return diff, nil
+}
+
+// diffFields compares two versions of a task by their JSON fields, in
+// field name order.
+func diffFields(before, after *types.Synapse) ([]FieldChange, error) {
+	from, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !diffIgnored[name] {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, name := range sorted {
+		if !bytes.Equal(from[name], to[name]) {
+			changes = append(changes, FieldChange{Field: name, From: from[name], To: to[name]})
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields encodes a task and splits it into its top-level JSON fields.
+func jsonFields(syn *types.Synapse) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(syn)
+	if err != nil {
+		return nil, fmt.Errorf("encode synapse %d: %w", syn.ID, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode synapse %d: %w", syn.ID, err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestDiffBoards(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := func(id int, title string, status types.Status) *types.Synapse {
+		syn := types.NewSynapse(id, title)
+		syn.Status = status
+		syn.CreatedAt = created
+		syn.UpdatedAt = created
+		return syn
+	}
+
+	before := []*types.Synapse{
+		task(1, "Unchanged", types.StatusOpen),
+		task(2, "Finished", types.StatusOpen),
+		task(3, "Deleted", types.StatusOpen),
+		task(4, "Touched", types.StatusOpen),
+		task(5, "Replaced", types.StatusOpen),
+	}
+	finished := task(2, "Finished", types.StatusDone)
+	finished.CompletedBy = "agent-1"
+	touched := task(4, "Touched", types.StatusOpen)
+	touched.UpdatedAt = created.Add(time.Hour)
+	touched.Version = 3
+	reused := task(5, "Reuses the ID", types.StatusOpen)
+	reused.CreatedAt = created.Add(time.Hour)
+	after := []*types.Synapse{
+		task(1, "Unchanged", types.StatusOpen),
+		finished,
+		touched,
+		reused,
+		task(6, "Added", types.StatusOpen),
+	}
+
+	diff, err := DiffBoards(before, after)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diff.Added) != 2 || diff.Added[0].ID != 5 || diff.Added[1].ID != 6 {
+		t.Errorf("expected #5 and #6 added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 2 || diff.Removed[0].Title != "Deleted" || diff.Removed[1].Title != "Replaced" {
+		t.Errorf("expected the deleted and replaced tasks removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].ID != 2 {
+		t.Fatalf("expected only #2 changed, got %+v", diff.Changed)
+	}
+
+	fields := diff.Changed[0].Fields
+	if len(fields) != 2 || fields[0].Field != "completed_by" || fields[1].Field != "status" {
+		t.Fatalf("expected completed_by and status changes, got %+v", fields)
+	}
+	if fields[0].From != nil || string(fields[0].To) != `"agent-1"` {
+		t.Errorf("expected completed_by unset -> agent-1, got %s -> %s", fields[0].From, fields[0].To)
+	}
+	if string(fields[1].From) != `"open"` || string(fields[1].To) != `"done"` {
+		t.Errorf("expected status open -> done, got %s -> %s", fields[1].From, fields[1].To)
+	}
+
+	if diff, _ := DiffBoards(before, before); !diff.IsEmpty() {
+		t.Errorf("expected no changes against itself, got %+v", diff)
+	}
+}
+
+func TestLoadFrom_DoesNotWriteBack(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+
+	// A version 1 file, which Load would migrate on disk
+	old := []byte(`{"id":1,"title":"From history","status":"open","blocked_by":[],"discovered_from":"#2","created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-01T00:00:00Z"}` + "\n")
+	if err := store.LoadFrom(bytes.NewReader(old)); err != nil {
+		t.Fatalf("load from: %v", err)
+	}
+	syn, err := store.Get(1)
+	if err != nil || syn.Title != "From history" || syn.DiscoveredFrom != 2 {
+		t.Fatalf("expected the migrated task, got %+v (%v)", syn, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, MemoryFile)); !os.IsNotExist(err) {
+		t.Errorf("expected no memory file written, got %v", err)
+	}
+}
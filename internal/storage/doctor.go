@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// Issue is a single inconsistency found by a Check.
+type Issue struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+	IDs     []int  `json:"ids"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed"`
+}
+
+// Check is one board consistency check run by Diagnose. Fix is nil for
+// checks whose issues need a human to resolve; otherwise it repairs a
+// single issue returned by Run.
+type Check struct {
+	Name string
+	Run  func(s *JSONLStore) []Issue
+	Fix  func(s *JSONLStore, issue Issue) error
+}
+
+// DefaultChecks are the checks run by `synapse doctor`, in order. Fixes are
+// applied in the same order, so scrubbing dangling blockers comes before
+// unblocking tasks whose remaining blockers are done.
+var DefaultChecks = []Check{
+	{Name: "duplicate-id", Run: checkDuplicateIDs},
+	{Name: "dangling-blocker", Run: checkDanglingBlockers, Fix: fixDanglingBlockers},
+	{Name: "dangling-related", Run: checkDanglingRelated, Fix: fixDanglingRelated},
+	{Name: "parent-cycle", Run: checkParentCycles},
+	{Name: "stuck-blocked", Run: checkStuckBlocked, Fix: fixStuckBlocked},
+	{Name: "expired-claim", Run: checkExpiredClaims, Fix: fixExpiredClaim},
+}
+
+// Diagnose runs checks against the store and returns the issues found. With
+// fix set, each fixable issue is repaired as it is found and marked Fixed;
+// the caller is responsible for saving the store afterwards.
+func (s *JSONLStore) Diagnose(checks []Check, fix bool) ([]Issue, error) {
+	issues := []Issue{}
+	for _, check := range checks {
+		for _, issue := range check.Run(s) {
+			issue.Check = check.Name
+			issue.Fixable = check.Fix != nil
+			if fix && issue.Fixable {
+				if err := check.Fix(s, issue); err != nil {
+					return issues, fmt.Errorf("%s: %w", check.Name, err)
+				}
+				issue.Fixed = true
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func checkDuplicateIDs(s *JSONLStore) []Issue {
+	var issues []Issue
+	for _, id := range s.DuplicateIDs() {
+		issues = append(issues, Issue{
+			Message: fmt.Sprintf("task %d appears on more than one line; only the last is kept", id),
+			IDs:     []int{id},
+		})
+	}
+	return issues
+}
+
+func checkDanglingBlockers(s *JSONLStore) []Issue {
+	var issues []Issue
+	for _, syn := range s.All() {
+		var missing []int
+		for _, id := range syn.BlockedBy {
+			if _, err := s.Get(id); err != nil {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, Issue{
+				Message: fmt.Sprintf("task %d is blocked by missing task(s) %v", syn.ID, missing),
+				IDs:     append([]int{syn.ID}, missing...),
+			})
+		}
+	}
+	return issues
+}
+
+// fixDanglingBlockers removes the missing blockers (IDs[1:]) from the task
+// in IDs[0].
+func fixDanglingBlockers(s *JSONLStore, issue Issue) error {
+	syn, err := s.Get(issue.IDs[0])
+	if err != nil {
+		return err
+	}
+	for _, id := range issue.IDs[1:] {
+		syn.RemoveBlocker(id)
+	}
+	return s.Update(syn)
+}
+
+func checkDanglingRelated(s *JSONLStore) []Issue {
+	var issues []Issue
+	for _, syn := range s.All() {
+		var missing []int
+		for _, id := range syn.RelatedTo {
+			if _, err := s.Get(id); err != nil {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, Issue{
+				Message: fmt.Sprintf("task %d is related to missing task(s) %v", syn.ID, missing),
+				IDs:     append([]int{syn.ID}, missing...),
+			})
+		}
+	}
+	return issues
+}
+
+// fixDanglingRelated removes the links to the missing tasks (IDs[1:]) from
+// the task in IDs[0].
+func fixDanglingRelated(s *JSONLStore, issue Issue) error {
+	syn, err := s.Get(issue.IDs[0])
+	if err != nil {
+		return err
+	}
+	for _, id := range issue.IDs[1:] {
+		syn.RemoveRelated(id)
+	}
+	return s.Update(syn)
+}
+
+func checkParentCycles(s *JSONLStore) []Issue {
+	parents := make(map[int]int)
+	for _, syn := range s.All() {
+		if syn.ParentID != 0 {
+			parents[syn.ID] = syn.ParentID
+		}
+	}
+
+	// Follow each parent chain, tracking the current path; reaching a task
+	// already on the path closes a cycle. Tasks seen on an earlier walk
+	// are skipped, so each cycle is reported once.
+	var issues []Issue
+	visited := make(map[int]bool)
+	for _, syn := range s.All() {
+		var path []int
+		for id := syn.ID; id != 0 && !visited[id]; id = parents[id] {
+			if slices.Contains(path, id) {
+				cycle := graph.Cycle(path, id)
+				issues = append(issues, Issue{
+					Message: fmt.Sprintf("parent links form a cycle between tasks %v", cycle),
+					IDs:     cycle,
+				})
+				break
+			}
+			path = append(path, id)
+		}
+		for _, id := range path {
+			visited[id] = true
+		}
+	}
+	return issues
+}
+
+func checkStuckBlocked(s *JSONLStore) []Issue {
+	var issues []Issue
+	for _, syn := range s.ByStatus(types.StatusBlocked) {
+		if syn.IsReady(s.IsDone) {
+			issues = append(issues, Issue{
+				Message: fmt.Sprintf("task %d is blocked but all of its blockers are done", syn.ID),
+				IDs:     []int{syn.ID},
+			})
+		}
+	}
+	return issues
+}
+
+func fixStuckBlocked(s *JSONLStore, issue Issue) error {
+	syn, err := s.Get(issue.IDs[0])
+	if err != nil {
+		return err
+	}
+	syn.Status = types.StatusOpen
+	syn.UpdatedAt = time.Now().UTC()
+	return s.Update(syn)
+}
+
+func checkExpiredClaims(s *JSONLStore) []Issue {
+	var issues []Issue
+	timeout := s.ClaimTimeout()
+	for _, syn := range s.All() {
+		if syn.ClaimedBy != "" && syn.IsClaimExpired(timeout) {
+			issues = append(issues, Issue{
+				Message: fmt.Sprintf("task %d has an expired claim by %s", syn.ID, syn.ClaimedBy),
+				IDs:     []int{syn.ID},
+			})
+		}
+	}
+	return issues
+}
+
+func fixExpiredClaim(s *JSONLStore, issue Issue) error {
+	syn, err := s.Get(issue.IDs[0])
+	if err != nil {
+		return err
+	}
+	syn.ReleaseClaim()
+	return s.Update(syn)
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// issuesByCheck groups issues by check name.
+func issuesByCheck(issues []Issue) map[string][]Issue {
+	byCheck := make(map[string][]Issue)
+	for _, issue := range issues {
+		byCheck[issue.Check] = append(byCheck[issue.Check], issue)
+	}
+	return byCheck
+}
+
+func TestDiagnose(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	done, _ := store.Create("Done blocker")
+	done.Status = types.StatusDone
+	dangling, _ := store.Create("Blocked by a deleted task")
+	dangling.BlockedBy = []int{done.ID, 99}
+	dangling.RelatedTo = []int{done.ID, 98}
+	dangling.Status = types.StatusBlocked
+	stuck, _ := store.Create("Blocked by done tasks only")
+	stuck.BlockedBy = []int{done.ID}
+	stuck.Status = types.StatusBlocked
+	store.Update(stuck)
+	a, _ := store.Create("Cycle A")
+	b, _ := store.Create("Cycle B")
+	a.ParentID, b.ParentID = b.ID, a.ID
+	child, _ := store.Create("Child of the cycle")
+	child.ParentID = a.ID
+	claimed, _ := store.Create("Abandoned claim")
+	claimed.Claim("agent-1", types.DefaultClaimTimeout)
+	expired := time.Now().UTC().Add(-2 * types.DefaultClaimTimeout)
+	claimed.ClaimedAt = &expired
+
+	issues, err := store.Diagnose(DefaultChecks, false)
+	if err != nil {
+		t.Fatalf("diagnose: %v", err)
+	}
+	byCheck := issuesByCheck(issues)
+
+	if got := byCheck["dangling-blocker"]; len(got) != 1 || !slices.Equal(got[0].IDs, []int{dangling.ID, 99}) {
+		t.Errorf("dangling-blocker: got %+v", got)
+	}
+	if got := byCheck["dangling-related"]; len(got) != 1 || !slices.Equal(got[0].IDs, []int{dangling.ID, 98}) {
+		t.Errorf("dangling-related: got %+v", got)
+	}
+	if got := byCheck["parent-cycle"]; len(got) != 1 || !slices.Equal(got[0].IDs, []int{a.ID, b.ID}) {
+		t.Errorf("parent-cycle: expected one cycle of [%d %d], got %+v", a.ID, b.ID, got)
+	}
+	if got := byCheck["stuck-blocked"]; len(got) != 1 || got[0].IDs[0] != stuck.ID {
+		t.Errorf("stuck-blocked: got %+v", got)
+	}
+	if got := byCheck["expired-claim"]; len(got) != 1 || got[0].IDs[0] != claimed.ID {
+		t.Errorf("expired-claim: got %+v", got)
+	}
+	for _, issue := range issues {
+		if issue.Fixed {
+			t.Errorf("issue fixed without --fix: %+v", issue)
+		}
+	}
+	if len(dangling.BlockedBy) != 2 || stuck.Status != types.StatusBlocked {
+		t.Error("diagnose without fix changed the store")
+	}
+}
+
+func TestDiagnose_Fix(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	dangling, _ := store.Create("Blocked by a deleted task")
+	dangling.BlockedBy = []int{99}
+	dangling.Status = types.StatusBlocked
+	a, _ := store.Create("Cycle A")
+	b, _ := store.Create("Cycle B")
+	a.ParentID, b.ParentID = b.ID, a.ID
+	claimed, _ := store.Create("Abandoned claim")
+	claimed.Claim("agent-1", types.DefaultClaimTimeout)
+	expired := time.Now().UTC().Add(-2 * types.DefaultClaimTimeout)
+	claimed.ClaimedAt = &expired
+
+	issues, err := store.Diagnose(DefaultChecks, true)
+	if err != nil {
+		t.Fatalf("diagnose: %v", err)
+	}
+	for _, issue := range issues {
+		if issue.Fixed != issue.Fixable {
+			t.Errorf("expected fixable issues to be fixed: %+v", issue)
+		}
+	}
+
+	// Scrubbing the missing blocker leaves the task stuck, which the later
+	// check then unblocks
+	if len(dangling.BlockedBy) != 0 || dangling.Status != types.StatusOpen {
+		t.Errorf("expected dangling task scrubbed and open, got %v %s", dangling.BlockedBy, dangling.Status)
+	}
+	if claimed.ClaimedBy != "" || claimed.Status != types.StatusOpen {
+		t.Errorf("expected expired claim freed, got %q %s", claimed.ClaimedBy, claimed.Status)
+	}
+
+	// Only the unfixable cycle remains
+	issues, _ = store.Diagnose(DefaultChecks, false)
+	if len(issues) != 1 || issues[0].Check != "parent-cycle" || issues[0].Fixable {
+		t.Errorf("expected only the parent cycle left, got %+v", issues)
+	}
+}
+
+func TestDiagnose_DuplicateIDs(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		`{"id":1,"title":"First copy","status":"open","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"id":2,"title":"Unique","status":"open","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"id":1,"title":"Second copy","status":"open","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+	}
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store := NewJSONLStore(dir)
+	if err := store.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	issues, _ := store.Diagnose(DefaultChecks, true)
+	if len(issues) != 1 || issues[0].Check != "duplicate-id" || !slices.Equal(issues[0].IDs, []int{1}) || issues[0].Fixed {
+		t.Errorf("expected one unfixed duplicate-id issue for task 1, got %+v", issues)
+	}
+}
@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// EventsFile is the append-only JSONL audit log of task transitions.
+	EventsFile = "events.jsonl"
+)
+
+// EventSink receives the audit events derived by each JSONLStore.Save, in
+// the order they were recorded. tasks holds the saved state of every task
+// keyed by ID (deleted tasks are absent); sinks must not modify it or keep
+// references to it after Publish returns.
+type EventSink interface {
+	Publish(events []types.Event, tasks map[int]*types.Synapse) error
+}
+
+// EventLog is an append-only JSONL log of task state transitions.
+// Entries are never rewritten, so the file diffs cleanly in Git.
+type EventLog struct {
+	dir string
+}
+
+// NewEventLog creates an event log in the given directory.
+func NewEventLog(dir string) *EventLog {
+	return &EventLog{dir: dir}
+}
+
+// Append writes events to the end of the log.
+func (l *EventLog) Append(events ...types.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(l.filePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open events file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, e := range events {
+		if err := encoder.Encode(e); err != nil {
+			file.Close()
+			return fmt.Errorf("encode event: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close events file: %w", err)
+	}
+	return nil
+}
+
+// Publish appends events to the log. It makes EventLog an EventSink.
+func (l *EventLog) Publish(events []types.Event, _ map[int]*types.Synapse) error {
+	return l.Append(events...)
+}
+
+// All reads every event in the order it was recorded.
+func (l *EventLog) All() ([]types.Event, error) {
+	file, err := os.Open(l.filePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No events yet
+		}
+		return nil, fmt.Errorf("open events file: %w", err)
+	}
+	defer file.Close()
+
+	var events []types.Event
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var e types.Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parse line %d: %w", lineNum, err)
+		}
+		events = append(events, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan events file: %w", err)
+	}
+
+	return events, nil
+}
+
+// ForTask returns the events recorded for a single task.
+func (l *EventLog) ForTask(taskID int) ([]types.Event, error) {
+	events, err := l.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []types.Event
+	for _, e := range events {
+		if e.TaskID == taskID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// ForSession returns the events recorded in an agent session.
+func (l *EventLog) ForSession(session string) ([]types.Event, error) {
+	events, err := l.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []types.Event
+	for _, e := range events {
+		if session != "" && e.Session == session {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// Size returns the current length of the log in bytes, for use as the
+// starting offset of ReadFrom.
+func (l *EventLog) Size() (int64, error) {
+	info, err := os.Stat(l.filePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("stat events file: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// ReadFrom returns the events recorded after byte offset, and the offset
+// to pass next time. A trailing line still being written is left for the
+// next call. If the log has shrunk below offset it is read from the start.
+func (l *EventLog) ReadFrom(offset int64) ([]types.Event, int64, error) {
+	file, err := os.Open(l.filePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, offset, fmt.Errorf("open events file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, fmt.Errorf("stat events file: %w", err)
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil, offset, nil
+	}
+
+	data := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(data, offset); err != nil {
+		return nil, offset, fmt.Errorf("read events file: %w", err)
+	}
+
+	var events []types.Event
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		line := data[:end]
+		data = data[end+1:]
+		offset += int64(end + 1)
+		if len(line) == 0 {
+			continue
+		}
+
+		var e types.Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return events, offset, fmt.Errorf("parse event at offset %d: %w", offset, err)
+		}
+		events = append(events, e)
+	}
+
+	return events, offset, nil
+}
+
+// filePath returns the path to the events file.
+func (l *EventLog) filePath() string {
+	return filepath.Join(l.dir, EventsFile)
+}
+
+// taskState is the subset of a synapse tracked for audit events.
+type taskState struct {
+	Status      types.Status
+	CreatedBy   string
+	ClaimedBy   string
+	CompletedBy string
+	UpdatedAt   time.Time
+}
+
+func stateOf(syn *types.Synapse) taskState {
+	return taskState{
+		Status:      syn.Status,
+		CreatedBy:   syn.CreatedBy,
+		ClaimedBy:   syn.ClaimedBy,
+		CompletedBy: syn.CompletedBy,
+		UpdatedAt:   syn.UpdatedAt,
+	}
+}
+
+// diffEvents derives audit events from the change between two persisted
+// states, ordered by task ID.
+func diffEvents(before, after map[int]taskState, ids []int, now time.Time) []types.Event {
+	var events []types.Event
+
+	for _, id := range ids {
+		cur := after[id]
+		prev, existed := before[id]
+		if !existed {
+			agent := cur.CreatedBy
+			if agent == "" {
+				agent = cur.ClaimedBy
+			}
+			events = append(events, types.Event{
+				TaskID: id, Event: types.EventCreated, To: string(cur.Status),
+				Agent: agent, At: now,
+			})
+			continue
+		}
+
+		recorded := len(events)
+		if prev.ClaimedBy != cur.ClaimedBy {
+			if cur.ClaimedBy == "" {
+				events = append(events, types.Event{
+					TaskID: id, Event: types.EventReleased, From: prev.ClaimedBy,
+					Agent: prev.ClaimedBy, At: now,
+				})
+			} else {
+				events = append(events, types.Event{
+					TaskID: id, Event: types.EventClaimed, From: prev.ClaimedBy, To: cur.ClaimedBy,
+					Agent: cur.ClaimedBy, At: now,
+				})
+			}
+		}
+
+		if prev.Status != cur.Status {
+			e := types.Event{
+				TaskID: id, Event: types.EventStatus, From: string(prev.Status), To: string(cur.Status),
+				Agent: cur.ClaimedBy, At: now,
+			}
+			if e.Agent == "" {
+				e.Agent = prev.ClaimedBy // e.g. a release reopening the task
+			}
+			if cur.Status == types.StatusDone {
+				e.Event = types.EventCompleted
+				if cur.CompletedBy != "" {
+					e.Agent = cur.CompletedBy
+				}
+			}
+			events = append(events, e)
+		}
+
+		// Other edits only move UpdatedAt; record them so watchers (e.g.
+		// MCP resource subscriptions) see every change to a task
+		if len(events) == recorded && !prev.UpdatedAt.Equal(cur.UpdatedAt) {
+			events = append(events, types.Event{
+				TaskID: id, Event: types.EventUpdated, Agent: cur.ClaimedBy, At: now,
+			})
+		}
+	}
+
+	// Deletions, in ID order
+	var deleted []int
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Ints(deleted)
+	for _, id := range deleted {
+		events = append(events, types.Event{
+			TaskID: id, Event: types.EventDeleted, From: string(before[id].Status), At: now,
+		})
+	}
+
+	return events
+}
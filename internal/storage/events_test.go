@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestSave_RecordsEvents(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	syn, _ := store.Create("Audited task")
+	other, _ := store.Create("Deleted task")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	syn.Claim("agent-1", types.DefaultClaimTimeout)
+	store.Save()
+
+	syn.ReleaseClaim()
+	store.Save()
+
+	syn.MarkDoneBy("agent-2")
+	store.Delete(other.ID)
+	store.Save()
+
+	// Saving without changes records nothing
+	store.Save()
+
+	want := []types.Event{
+		{TaskID: 1, Event: types.EventCreated, To: "open"},
+		{TaskID: 2, Event: types.EventCreated, To: "open"},
+		{TaskID: 1, Event: types.EventClaimed, To: "agent-1", Agent: "agent-1"},
+		{TaskID: 1, Event: types.EventStatus, From: "open", To: "in-progress", Agent: "agent-1"},
+		{TaskID: 1, Event: types.EventReleased, From: "agent-1", Agent: "agent-1"},
+		{TaskID: 1, Event: types.EventStatus, From: "in-progress", To: "open", Agent: "agent-1"},
+		{TaskID: 1, Event: types.EventCompleted, From: "open", To: "done", Agent: "agent-2"},
+		{TaskID: 2, Event: types.EventDeleted, From: "open"},
+	}
+
+	// A fresh log instance reads what the store appended
+	events, err := NewEventLog(dir).All()
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, w := range want {
+		got := events[i]
+		if got.At.IsZero() {
+			t.Errorf("event %d: missing timestamp", i)
+		}
+		got.At = w.At
+		if got != w {
+			t.Errorf("event %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	forTask, err := store.Events().ForTask(2)
+	if err != nil {
+		t.Fatalf("ForTask: %v", err)
+	}
+	if len(forTask) != 2 {
+		t.Errorf("expected 2 events for task 2, got %d", len(forTask))
+	}
+}
+
+func TestLoad_DoesNotReplayExistingTasks(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Init()
+	store.Create("Existing")
+	store.Save()
+
+	reloaded := NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	reloaded.Save()
+
+	events, _ := reloaded.Events().All()
+	if len(events) != 1 {
+		t.Errorf("expected only the original created event, got %+v", events)
+	}
+}
+
+func TestSave_RecordsOtherEditsAsUpdated(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Init()
+	syn, _ := store.Create("Edited task")
+	store.Save()
+
+	syn.AddNote("Found the root cause")
+	store.Save()
+
+	events, _ := store.Events().ForTask(syn.ID)
+	if len(events) != 2 || events[1].Event != types.EventUpdated {
+		t.Fatalf("expected created then updated, got %+v", events)
+	}
+
+	// A status change is recorded as such, not additionally as updated
+	syn.MarkInProgress()
+	store.Save()
+	events, _ = store.Events().ForTask(syn.ID)
+	if len(events) != 3 || events[2].Event != types.EventStatus {
+		t.Errorf("expected a single status event, got %+v", events[2:])
+	}
+}
+
+func TestEventLog_ReadFrom(t *testing.T) {
+	dir := t.TempDir()
+	log := NewEventLog(dir)
+
+	if events, offset, err := log.ReadFrom(0); err != nil || len(events) != 0 || offset != 0 {
+		t.Fatalf("missing log: got %v, %d, %v", events, offset, err)
+	}
+
+	log.Append(types.Event{TaskID: 1, Event: types.EventCreated})
+	size, _ := log.Size()
+	log.Append(types.Event{TaskID: 2, Event: types.EventCreated}, types.Event{TaskID: 1, Event: types.EventUpdated})
+
+	events, offset, err := log.ReadFrom(size)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if len(events) != 2 || events[0].TaskID != 2 || events[1].Event != types.EventUpdated {
+		t.Errorf("expected the two later events, got %+v", events)
+	}
+	if end, _ := log.Size(); offset != end {
+		t.Errorf("expected offset %d at end of log, got %d", end, offset)
+	}
+
+	if events, _, _ := log.ReadFrom(offset); len(events) != 0 {
+		t.Errorf("expected nothing new, got %+v", events)
+	}
+}
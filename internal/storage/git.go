@@ -3,8 +3,11 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -41,7 +44,9 @@ func NewGitIntegration() *GitIntegration {
 }
 
 // AddToGitignore appends an entry to .gitignore if not already present.
-// Creates .gitignore if it doesn't exist.
+// The entry may be a glob such as ".synapse/*.tmp". It counts as present
+// if .gitignore has the same pattern, with or without a leading slash, or
+// a glob that already matches it. Creates .gitignore if it doesn't exist.
 // Returns (added, error) where added is true if the entry was actually written.
 func (g *GitIntegration) AddToGitignore(entry string) (bool, error) {
 	gitignorePath := filepath.Join(g.repoRoot, ".gitignore")
@@ -81,20 +86,130 @@ func (g *GitIntegration) StageFile(relativePath string) error {
 	return cmd.Run()
 }
 
-// gitignoreContains checks if .gitignore already contains the entry.
-func (g *GitIntegration) gitignoreContains(path, entry string) bool {
-	f, err := os.Open(path)
+// RelPath returns path relative to the repository root, in the slash
+// form Git uses. The file itself need not exist.
+func (g *GitIntegration) RelPath(path string) (string, error) {
+	absDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	// The root is symlink-resolved, so resolve the directory too
+	if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = resolved
+	}
+	rel, err := filepath.Rel(g.repoRoot, filepath.Join(absDir, filepath.Base(path)))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// ReadFileAtRef returns the content of relativePath (relative to the repo
+// root) as of the commit ref names, via git show. If the file didn't exist
+// at ref the error wraps os.ErrNotExist.
+func (g *GitIntegration) ReadFileAtRef(ref, relativePath string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":"+filepath.ToSlash(relativePath))
+	cmd.Dir = g.repoRoot
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "does not exist in") || strings.Contains(msg, "exists on disk, but not in") {
+			return nil, fmt.Errorf("%s at %s: %w", relativePath, ref, os.ErrNotExist)
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git show %s:%s: %s", ref, relativePath, msg)
+	}
+	return out, nil
+}
+
+// Commit stages paths (relative to the repo root) and commits them with
+// message. Only those paths go into the commit; anything else already
+// staged is left staged. With no paths it commits whatever is staged.
+// Having nothing to commit is not an error.
+func (g *GitIntegration) Commit(message string, paths ...string) error {
+	if len(paths) > 0 {
+		if err := g.run(append([]string{"add", "--"}, paths...)...); err != nil {
+			return err
+		}
+	}
+
+	// diff --quiet exits 1 when there are differences, 0 when none
+	check := exec.Command("git", append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...)
+	check.Dir = g.repoRoot
+	if err := check.Run(); err == nil {
+		return nil
+	} else if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		return fmt.Errorf("git diff --cached: %w", err)
+	}
+
+	args := []string{"commit", "--quiet", "-m", message}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return g.run(args...)
+}
+
+// IsClean reports whether path (relative to the repo root) has no
+// uncommitted changes, staged or not, according to git status. An
+// untracked file counts as changed; a missing or ignored one as clean.
+func (g *GitIntegration) IsClean(path string) (bool, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "--", filepath.ToSlash(path))
+	cmd.Dir = g.repoRoot
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return false, fmt.Errorf("git status: %s", msg)
+		}
+		return false, fmt.Errorf("git status: %w", err)
+	}
+	return len(bytes.TrimSpace(out)) == 0, nil
+}
+
+// run runs a git command in the repo root, reporting git's own message
+// if it fails.
+func (g *GitIntegration) run(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.repoRoot
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// gitignoreContains checks if .gitignore already contains the entry,
+// either as the same pattern or as a glob matching it.
+func (g *GitIntegration) gitignoreContains(gitignorePath, entry string) bool {
+	f, err := os.Open(gitignorePath)
 	if err != nil {
 		return false
 	}
 	defer f.Close()
 
+	entry = strings.TrimPrefix(entry, "/")
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "/")
 		if line == entry {
 			return true
 		}
+		if matched, err := path.Match(line, entry); err == nil && matched {
+			return true
+		}
 	}
 	return false
 }
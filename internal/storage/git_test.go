@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates an empty Git repository in a temp dir, skipping the
+// test if git isn't installed. It returns the repo's root, symlinks
+// resolved as DetectGitRepo would, and a helper that runs git there.
+func newTestRepo(t *testing.T) (string, func(args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	gitCmd("init", "-q")
+	gitCmd("config", "user.email", "synapse@example.test")
+	gitCmd("config", "user.name", "Synapse")
+	return root, gitCmd
+}
+
+func TestIsClean(t *testing.T) {
+	root, gitCmd := newTestRepo(t)
+	git := &GitIntegration{repoRoot: root}
+	path := filepath.Join(DefaultDir, MemoryFile)
+	write := func(content string) {
+		t.Helper()
+		os.MkdirAll(filepath.Join(root, DefaultDir), 0755)
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	assertClean := func(want bool, when string) {
+		t.Helper()
+		clean, err := git.IsClean(path)
+		if err != nil {
+			t.Fatalf("is clean: %v", err)
+		}
+		if clean != want {
+			t.Errorf("%s: IsClean = %v, want %v", when, clean, want)
+		}
+	}
+
+	assertClean(true, "missing file")
+	write("{}\n")
+	assertClean(false, "untracked file")
+	gitCmd("add", path)
+	assertClean(false, "staged file")
+	gitCmd("commit", "-q", "-m", "add")
+	assertClean(true, "committed file")
+
+	// Changes elsewhere in the repo don't count
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("wip\n"), 0644)
+	assertClean(true, "other file changed")
+
+	write("{}\n{}\n")
+	assertClean(false, "modified file")
+}
+
+func TestAddToGitignore(t *testing.T) {
+	root := t.TempDir()
+	git := &GitIntegration{repoRoot: root}
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("# build output\n/bin\nnode_modules"), 0644)
+
+	tests := []struct {
+		entry string
+		added bool
+	}{
+		{".synapse/*.tmp", true},
+		{".synapse/*.tmp", false},            // Same pattern
+		{"/.synapse/*.tmp", false},           // Same pattern, anchored
+		{".synapse/memory.jsonl.tmp", false}, // Matched by the glob
+		{"bin", false},
+		{".synapse/.lock", true},
+	}
+	for _, tt := range tests {
+		added, err := git.AddToGitignore(tt.entry)
+		if err != nil {
+			t.Fatalf("add %s: %v", tt.entry, err)
+		}
+		if added != tt.added {
+			t.Errorf("AddToGitignore(%q) = %v, want %v", tt.entry, added, tt.added)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(root, ".gitignore"))
+	want := "# build output\n/bin\nnode_modules\n.synapse/*.tmp\n.synapse/.lock\n"
+	if string(data) != want {
+		t.Errorf("unexpected .gitignore:\n%s", data)
+	}
+}
+
+func TestInit_Gitignore(t *testing.T) {
+	root, gitCmd := newTestRepo(t)
+	t.Chdir(root)
+
+	for i := 0; i < 2; i++ {
+		result, err := NewJSONLStore(DefaultDir).Init()
+		if err != nil {
+			t.Fatalf("init: %v", err)
+		}
+		if i == 0 && len(result.GitignoreAdded) == 0 {
+			t.Error("expected the first init to update .gitignore")
+		}
+		if i == 1 && len(result.GitignoreAdded) != 0 {
+			t.Errorf("expected re-init to add nothing, got %v", result.GitignoreAdded)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		t.Fatalf("read .gitignore: %v", err)
+	}
+	if n := strings.Count(string(data), ".synapse/.lock\n"); n != 1 {
+		t.Errorf("expected .synapse/.lock once, got %d times in:\n%s", n, data)
+	}
+
+	// The lock and a leftover temp file stay out of git status
+	unlock, err := NewJSONLStore(DefaultDir).Lock()
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+	os.WriteFile(filepath.Join(root, DefaultDir, MemoryFile+".tmp"), []byte("{}\n"), 0644)
+	status := gitCmd("status", "--porcelain", "--untracked-files=all")
+	for _, name := range []string{LockFile, MemoryFile + ".tmp"} {
+		if strings.Contains(status, name) {
+			t.Errorf("expected %s ignored, got status:\n%s", name, status)
+		}
+	}
+	if !strings.Contains(status, MemoryFile) {
+		t.Errorf("expected %s still listed, got status:\n%s", MemoryFile, status)
+	}
+}
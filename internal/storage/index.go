@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// taskIndex maps statuses, assignees and labels to the IDs of the tasks
+// that have them, so ByStatus, ByAssignee and ByLabel don't scan every
+// task. Every store method that adds, changes or removes a task keeps it
+// current; a task edited in place must be stored with Update (or saved)
+// before the lookups see the edit.
+type taskIndex struct {
+	status   map[types.Status]map[int]bool
+	assignee map[string]map[int]bool
+	label    map[string]map[int]bool
+
+	// What each task is indexed under, for removing it again
+	keys map[int]indexKeys
+}
+
+// indexKeys are the values a task is indexed under.
+type indexKeys struct {
+	status   types.Status
+	assignee string
+	labels   []string
+}
+
+func newTaskIndex() *taskIndex {
+	return &taskIndex{
+		status:   make(map[types.Status]map[int]bool),
+		assignee: make(map[string]map[int]bool),
+		label:    make(map[string]map[int]bool),
+		keys:     make(map[int]indexKeys),
+	}
+}
+
+// set indexes syn under its current values, replacing any earlier entry
+// for its ID.
+func (x *taskIndex) set(syn *types.Synapse) {
+	if keys, ok := x.keys[syn.ID]; ok {
+		if keys.status == syn.Status && keys.assignee == syn.Assignee && slices.Equal(keys.labels, syn.Labels) {
+			return
+		}
+		x.remove(syn.ID)
+	}
+
+	keys := indexKeys{status: syn.Status, assignee: syn.Assignee, labels: slices.Clone(syn.Labels)}
+	x.keys[syn.ID] = keys
+	addID(x.status, keys.status, syn.ID)
+	addID(x.assignee, keys.assignee, syn.ID)
+	for _, label := range keys.labels {
+		addID(x.label, label, syn.ID)
+	}
+}
+
+// remove drops task id from the index.
+func (x *taskIndex) remove(id int) {
+	keys, ok := x.keys[id]
+	if !ok {
+		return
+	}
+	delete(x.keys, id)
+	removeID(x.status, keys.status, id)
+	removeID(x.assignee, keys.assignee, id)
+	for _, label := range keys.labels {
+		removeID(x.label, label, id)
+	}
+}
+
+func addID[K comparable](m map[K]map[int]bool, key K, id int) {
+	ids, ok := m[key]
+	if !ok {
+		ids = make(map[int]bool)
+		m[key] = ids
+	}
+	ids[id] = true
+}
+
+func removeID[K comparable](m map[K]map[int]bool, key K, id int) {
+	if ids, ok := m[key]; ok {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(m, key)
+		}
+	}
+}
+
+// lookup returns the tasks with the given IDs, sorted by ID. Callers must
+// hold s.mu.
+func (s *JSONLStore) lookup(ids map[int]bool) []*types.Synapse {
+	var result []*types.Synapse
+	for id := range ids {
+		result = append(result, s.synapses[id])
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// scanIDs returns the IDs of the tasks matching, in ID order, by looking
+// at every task: what the By* queries did before they were indexed.
+func scanIDs(store *JSONLStore, matches func(*types.Synapse) bool) []int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var ids []int
+	for _, syn := range store.synapses {
+		if matches(syn) {
+			ids = append(ids, syn.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func idsOf(tasks []*types.Synapse) []int {
+	var ids []int
+	for _, syn := range tasks {
+		ids = append(ids, syn.ID)
+	}
+	return ids
+}
+
+func TestIndex_MatchesScan(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	statuses := []types.Status{types.StatusOpen, types.StatusInProgress, types.StatusBlocked, types.StatusReview, types.StatusDone}
+	assignees := []string{"", "@coder", "@qa", "agent-1"}
+	labels := []string{"bug", "ui", "api"}
+	rng := rand.New(rand.NewSource(1))
+	pick := func() *types.Synapse {
+		all := store.All()
+		if len(all) == 0 {
+			return nil
+		}
+		return all[rng.Intn(len(all))]
+	}
+
+	for step := 0; step < 2000; step++ {
+		switch op := rng.Intn(10); {
+		case op < 3:
+			syn, _ := store.Create(fmt.Sprintf("Task %d", step))
+			if rng.Intn(2) == 0 {
+				syn.Labels = []string{labels[rng.Intn(len(labels))]}
+				syn.Assignee = assignees[rng.Intn(len(assignees))]
+				store.Update(syn)
+			}
+		case op < 6:
+			if syn := pick(); syn != nil {
+				syn.Status = statuses[rng.Intn(len(statuses))]
+				syn.Assignee = assignees[rng.Intn(len(assignees))]
+				if rng.Intn(2) == 0 {
+					syn.Labels = append(syn.Labels, labels[rng.Intn(len(labels))])
+				} else {
+					syn.Labels = nil
+				}
+				store.Update(syn)
+			}
+		case op < 7:
+			if syn := pick(); syn != nil {
+				if other := pick(); other != nil && other.ID != syn.ID {
+					other.BlockedBy = []int{syn.ID}
+					other.Status = types.StatusBlocked
+					store.Update(other)
+				}
+				store.Complete(syn, "agent-1")
+			}
+		case op < 8:
+			if syn := pick(); syn != nil {
+				store.Delete(syn.ID)
+			}
+		case op < 9:
+			// Edited in place, caught up by Save
+			if syn := pick(); syn != nil {
+				syn.Status = statuses[rng.Intn(len(statuses))]
+				syn.Assignee = assignees[rng.Intn(len(assignees))]
+				syn.Labels = append(syn.Labels, labels[rng.Intn(len(labels))])
+				if err := store.Save(); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+		default:
+			if rng.Intn(4) == 0 {
+				store.DeleteByStatus(types.StatusDone)
+			} else if err := store.Save(); err == nil {
+				store.Load()
+			}
+		}
+
+		for _, status := range statuses {
+			want := scanIDs(store, func(syn *types.Synapse) bool { return syn.Status == status })
+			if got := idsOf(store.ByStatus(status)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("step %d: ByStatus(%s) = %v, scan gives %v", step, status, got, want)
+			}
+		}
+		for _, assignee := range assignees {
+			want := scanIDs(store, func(syn *types.Synapse) bool { return syn.Assignee == assignee })
+			if got := idsOf(store.ByAssignee(assignee)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("step %d: ByAssignee(%q) = %v, scan gives %v", step, assignee, got, want)
+			}
+		}
+		for _, label := range labels {
+			want := scanIDs(store, func(syn *types.Synapse) bool { return slices.Contains(syn.Labels, label) })
+			if got := idsOf(store.ByLabel(label)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("step %d: ByLabel(%s) = %v, scan gives %v", step, label, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkByStatus(b *testing.B) {
+	store := NewJSONLStore(b.TempDir())
+	statuses := []types.Status{types.StatusOpen, types.StatusInProgress, types.StatusBlocked, types.StatusReview, types.StatusDone}
+	for i := 0; i < 10000; i++ {
+		syn, _ := store.Create("Task")
+		syn.Status = statuses[i%len(statuses)]
+		if i%100 == 0 {
+			syn.Status = "cancelled" // A rare status
+		}
+		store.Update(syn)
+	}
+
+	b.Run("scan", func(b *testing.B) {
+		for b.Loop() {
+			scanIDs(store, func(syn *types.Synapse) bool { return syn.Status == "cancelled" })
+		}
+	})
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			store.ByStatus("cancelled")
+		}
+	})
+}
@@ -3,11 +3,16 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,20 +26,77 @@ const (
 	MemoryFile = "memory.jsonl"
 )
 
+// ErrStaleWrite is returned by Update when the incoming synapse was read at
+// an older Version than the one stored, i.e. someone else updated it first.
+// Callers should reload the task and retry.
+var ErrStaleWrite = errors.New("stale write")
+
+// ErrNotFound is returned when no synapse has the requested ID.
+var ErrNotFound = errors.New("not found")
+
 // JSONLStore manages JSONL-based persistence for Synapses.
 type JSONLStore struct {
 	mu       sync.RWMutex
 	dir      string
 	synapses map[int]*types.Synapse
 	nextID   int
+
+	// Task IDs by ExternalID, for idempotent creates
+	externalIDs map[string]int
+
+	// Task IDs by status, assignee and label, for the By* queries
+	index *taskIndex
+
+	// Earlier versions of IDs that appeared on more than one line at the
+	// last Load, in file order. The last line wins, so Save would silently
+	// drop these; ResolveDuplicates decides what to keep.
+	shadowed map[int][]*types.Synapse
+
+	// Append-only saves (StorageAppend) and, in that mode, the encoding of
+	// each task the file currently ends up with, so Save appends only what
+	// changed. written is guarded by saveMu.
+	appendOnly bool
+	written    map[int][]byte
+
+	// Whether this process holds the file lock (see Lock). Load upgrades
+	// a memory file in an older schema on disk only then, so a read-only
+	// command can't rewrite it under a concurrent locked save.
+	locked bool
+
+	// saveMu serializes writes to the memory file. Save holds it
+	// throughout but takes mu only to snapshot the tasks and to swap the
+	// new file in, so reads carry on while a large store is written.
+	// Take saveMu before mu.
+	saveMu sync.Mutex
+
+	// How long a claim lasts unless the caller says otherwise
+	claimTimeout time.Duration
+
+	// Agents filling each role, for ByRole and ReadyFor
+	roles Roles
+
+	// Audit log state: events are derived on Save by diffing against the
+	// state last read from or written to disk, then published to the audit
+	// log and any other subscribed sinks.
+	events    *EventLog
+	sinks     []EventSink
+	persisted map[int]taskState
+
+	// Agent session the changes being saved belong to; see SetSession
+	session string
 }
 
 // NewJSONLStore creates a new JSONL store at the given directory.
 func NewJSONLStore(dir string) *JSONLStore {
 	return &JSONLStore{
-		dir:      dir,
-		synapses: make(map[int]*types.Synapse),
-		nextID:   1,
+		dir:         dir,
+		synapses:    make(map[int]*types.Synapse),
+		nextID:      1,
+		events:      NewEventLog(dir),
+		externalIDs: make(map[string]int),
+		index:       newTaskIndex(),
+
+		claimTimeout: types.DefaultClaimTimeout,
 	}
 }
 
@@ -44,8 +106,17 @@ type InitResult struct {
 	MemoryCreated   bool `json:"memory_created"`
 	GitRepoDetected bool `json:"git_repo_detected"`
 	MemoryStaged    bool `json:"memory_staged"`
+
+	// Patterns init added to .gitignore, relative to the repo root
+	GitignoreAdded []string `json:"gitignore_added,omitempty"`
 }
 
+// localFiles are patterns for files in the storage directory that belong
+// to one checkout and shouldn't be committed; init adds them to .gitignore.
+// The temp files are what atomic saves write before renaming into place;
+// a crash can leave one behind.
+var localFiles = []string{LockFile, "*.tmp"}
+
 // Init creates the storage directory if it doesn't exist.
 func (s *JSONLStore) Init() (*InitResult, error) {
 	return s.InitWithOptions(false)
@@ -84,19 +155,25 @@ func (s *JSONLStore) InitWithOptions(stageMemory bool) (*InitResult, error) {
 	if git != nil {
 		result.GitRepoDetected = true
 
+		for _, pattern := range localFiles {
+			rel, err := git.RelPath(filepath.Join(s.dir, pattern))
+			if err != nil || strings.HasPrefix(rel, "../") {
+				continue // Storage outside the repository
+			}
+			added, err := git.AddToGitignore(rel)
+			if err != nil {
+				return nil, fmt.Errorf("update .gitignore: %w", err)
+			}
+			if added {
+				result.GitignoreAdded = append(result.GitignoreAdded, rel)
+			}
+		}
+
 		// Optionally stage memory.jsonl
 		if stageMemory {
-			absDir, err := filepath.Abs(s.dir)
-			if err == nil {
-				if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
-					absDir = resolved
-				}
-				absMemPath := filepath.Join(absDir, MemoryFile)
-				memRelPath, err := filepath.Rel(git.RepoRoot(), absMemPath)
-				if err == nil {
-					if err := git.StageFile(memRelPath); err == nil {
-						result.MemoryStaged = true
-					}
+			if memRelPath, err := git.RelPath(s.memoryPath()); err == nil {
+				if err := git.StageFile(memRelPath); err == nil {
+					result.MemoryStaged = true
 				}
 			}
 		}
@@ -105,8 +182,13 @@ func (s *JSONLStore) InitWithOptions(stageMemory bool) (*InitResult, error) {
 	return result, nil
 }
 
-// Load reads all synapses from the JSONL file into memory.
+// Load reads all synapses from the JSONL file into memory. Large files
+// are parsed on several goroutines. A file in an older schema is upgraded
+// in memory and, if the store's file lock is held, on disk too; otherwise
+// the next Save writes it in the current schema.
 func (s *JSONLStore) Load() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -120,10 +202,87 @@ func (s *JSONLStore) Load() error {
 	}
 	defer file.Close()
 
+	workers := 1
+	if info, err := file.Stat(); err == nil {
+		workers = loadWorkers(info.Size())
+	}
+	return s.read(file, true, workers)
+}
+
+// LoadFrom replaces the store's synapses with those read from r, in the
+// memory file's format, e.g. an older version of it read from Git. Older
+// schemas are upgraded in memory only; nothing is written back.
+func (s *JSONLStore) LoadFrom(r io.Reader) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(r, false, 1)
+}
+
+// read parses memory file content from r into the store. With more than
+// one worker, task lines are collected first and parsed in parallel; they
+// are still applied in file order. If writeBack is set and the file lock
+// is held, content in an older schema is upgraded on disk too. Callers
+// must hold s.mu.
+func (s *JSONLStore) read(r io.Reader, writeBack bool, workers int) error {
 	s.synapses = make(map[int]*types.Synapse)
 	s.nextID = 1
+	s.shadowed = make(map[int][]*types.Synapse)
+	s.externalIDs = make(map[string]int)
+	s.index = newTaskIndex()
+	s.written = nil
+	if s.appendOnly {
+		s.written = make(map[int][]byte)
+	}
+
+	// Files from before the schema header are version 1. Older files are
+	// upgraded line by line, keeping duplicates and order, and written back.
+	schema := 1
+	var migrated bytes.Buffer
+	encoder := json.NewEncoder(&migrated)
+	if err := encoder.Encode(schemaHeader{SchemaVersion: SchemaVersion}); err != nil {
+		return fmt.Errorf("encode schema header: %w", err)
+	}
+	tasks := 0
+
+	// add applies a parsed line to the store
+	add := func(p parsedLine, lineNum int) error {
+		if p.syn == nil {
+			if schema < SchemaVersion {
+				if err := encoder.Encode(tombstone{Deleted: p.deleted}); err != nil {
+					return fmt.Errorf("encode migrated line %d: %w", lineNum, err)
+				}
+			}
+			s.forget(p.deleted)
+			if p.deleted >= s.nextID {
+				s.nextID = p.deleted + 1
+			}
+			return nil
+		}
 
-	scanner := bufio.NewScanner(file)
+		syn := p.syn
+		if schema < SchemaVersion {
+			if err := encoder.Encode(syn); err != nil {
+				return fmt.Errorf("encode migrated line %d: %w", lineNum, err)
+			}
+		}
+
+		// An append-only file repeats IDs by design: the last line wins
+		if prev, dup := s.synapses[syn.ID]; dup && !s.appendOnly {
+			s.shadowed[syn.ID] = append(s.shadowed[syn.ID], prev)
+		}
+		s.synapses[syn.ID] = syn
+		s.indexExternalID(syn)
+		s.index.set(syn)
+		if syn.ID >= s.nextID {
+			s.nextID = syn.ID + 1
+		}
+		return nil
+	}
+
+	var pending []rawLine
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
@@ -131,30 +290,80 @@ func (s *JSONLStore) Load() error {
 		if len(line) == 0 {
 			continue
 		}
-
-		var syn types.Synapse
-		if err := json.Unmarshal(line, &syn); err != nil {
-			return fmt.Errorf("parse line %d: %w", lineNum, err)
+		if isConflictMarker(line) {
+			return fmt.Errorf("line %d: %w", lineNum, ErrMergeConflict)
+		}
+		if version, ok := ParseSchemaHeader(line); ok && tasks == 0 {
+			schema = version
+			continue
 		}
+		tasks++
 
-		s.synapses[syn.ID] = &syn
-		if syn.ID >= s.nextID {
-			s.nextID = syn.ID + 1
+		if workers > 1 {
+			pending = append(pending, rawLine{num: lineNum, data: bytes.Clone(line)})
+			continue
+		}
+		parsed, err := parseLine(line, lineNum, schema)
+		if err != nil {
+			return err
+		}
+		if err := add(parsed, lineNum); err != nil {
+			return err
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("scan memory file: %w", err)
 	}
+	if len(pending) > 0 {
+		parsed, err := parseLines(pending, schema, workers)
+		if err != nil {
+			return err
+		}
+		for i, p := range parsed {
+			if err := add(p, pending[i].num); err != nil {
+				return err
+			}
+		}
+	}
+	if schema < SchemaVersion && tasks > 0 {
+		if writeBack && s.locked {
+			if err := s.writeMigrated(migrated.Bytes()); err != nil {
+				return err
+			}
+		} else {
+			// The file keeps its old schema, so an append-only Save must
+			// rewrite it in full rather than add current lines to it
+			s.written = nil
+		}
+	}
+
+	s.persisted = make(map[int]taskState, len(s.synapses))
+	for id, syn := range s.synapses {
+		s.persisted[id] = stateOf(syn)
+		if s.written != nil {
+			data, err := json.Marshal(syn)
+			if err != nil {
+				return fmt.Errorf("encode synapse %d: %w", id, err)
+			}
+			s.written[id] = data
+		}
+	}
 
 	return nil
 }
 
-// Save writes all synapses to the JSONL file in deterministic order.
+// Save writes all synapses to the JSONL file in deterministic order, then
+// appends an audit event for every status change, claim, release,
+// completion, creation, and deletion since the last load or save. With a
+// session set, the events carry it, and tasks created, claimed or
+// completed since record it as their SessionID. Tasks are encoded from a
+// snapshot, so reads aren't blocked while the file is written.
 func (s *JSONLStore) Save() error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
 
+	s.mu.Lock()
 	// Sort by ID for deterministic Git diffs
 	ids := make([]int, 0, len(s.synapses))
 	for id := range s.synapses {
@@ -162,37 +371,151 @@ func (s *JSONLStore) Save() error {
 	}
 	sort.Ints(ids)
 
-	// Write to temp file then rename for atomicity
-	memPath := s.memoryPath()
-	tmpPath := memPath + ".tmp"
+	// Derive the events up front so the tasks they create, claim or
+	// complete can be stamped with the session before they are written
+	current := make(map[int]taskState, len(s.synapses))
+	for id, syn := range s.synapses {
+		current[id] = stateOf(syn)
+		s.index.set(syn) // Catch up with edits made in place
+	}
+	events := diffEvents(s.persisted, current, ids, time.Now().UTC())
+	if s.session != "" {
+		for i := range events {
+			events[i].Session = s.session
+			switch events[i].Event {
+			case types.EventCreated, types.EventClaimed, types.EventCompleted:
+				s.synapses[events[i].TaskID].SessionID = s.session
+			}
+		}
+	}
+
+	tasks := make([]*types.Synapse, len(ids))
+	for i, id := range ids {
+		tasks[i] = s.synapses[id].Clone()
+	}
+	appendOnly := s.appendOnly && s.written != nil
+	s.mu.Unlock()
+
+	var appended []byte
+	var written map[int][]byte
+	var err error
+	if appendOnly {
+		appended, written, err = s.appendLines(tasks)
+	} else {
+		written, err = s.writeTemp(tasks)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if appendOnly {
+		err = s.appendMemory(appended)
+	} else {
+		err = s.replaceMemory()
+	}
+	if err != nil {
+		return err
+	}
+	if s.appendOnly {
+		s.written = written
+	}
+
+	s.persisted = current
+	if err := s.events.Append(events...); err != nil {
+		return fmt.Errorf("record events: %w", err)
+	}
+	if len(events) > 0 {
+		for _, sink := range s.sinks {
+			if err := sink.Publish(events, s.synapses); err != nil {
+				return fmt.Errorf("publish events: %w", err)
+			}
+		}
+	}
 
+	return nil
+}
+
+// writeTemp writes tasks, in order, to the temp file beside the memory
+// file in canonical form: the schema header, then one line per task. It
+// returns each task's line. Callers must hold saveMu; replaceMemory then
+// swaps the file in.
+func (s *JSONLStore) writeTemp(tasks []*types.Synapse) (map[int][]byte, error) {
+	tmpPath := s.memoryPath() + ".tmp"
 	file, err := os.Create(tmpPath)
 	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
+		return nil, fmt.Errorf("create temp file: %w", err)
 	}
 
-	encoder := json.NewEncoder(file)
-	for _, id := range ids {
-		if err := encoder.Encode(s.synapses[id]); err != nil {
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(schemaHeader{SchemaVersion: SchemaVersion}); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("encode schema header: %w", err)
+	}
+	written := make(map[int][]byte, len(tasks))
+	for _, syn := range tasks {
+		line, err := json.Marshal(syn)
+		if err == nil {
+			w.Write(line)
+			err = w.WriteByte('\n')
+		}
+		if err != nil {
 			file.Close()
 			os.Remove(tmpPath)
-			return fmt.Errorf("encode synapse %d: %w", id, err)
+			return nil, fmt.Errorf("encode synapse %d: %w", syn.ID, err)
 		}
+		written[syn.ID] = line
 	}
 
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("write temp file: %w", err)
+	}
 	if err := file.Close(); err != nil {
 		os.Remove(tmpPath)
-		return fmt.Errorf("close temp file: %w", err)
+		return nil, fmt.Errorf("close temp file: %w", err)
 	}
+	return written, nil
+}
 
+// replaceMemory renames the file written by writeTemp over the memory
+// file, atomically. Callers must hold saveMu and s.mu.
+func (s *JSONLStore) replaceMemory() error {
+	memPath := s.memoryPath()
+	tmpPath := memPath + ".tmp"
 	if err := os.Rename(tmpPath, memPath); err != nil {
 		os.Remove(tmpPath)
 		return fmt.Errorf("rename temp file: %w", err)
 	}
-
 	return nil
 }
 
+// SetSession sets the agent session that later saves are made in, so
+// that BySession can find the tasks the session touched. An empty ID
+// means none.
+func (s *JSONLStore) SetSession(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = id
+}
+
+// Events returns the store's audit log.
+func (s *JSONLStore) Events() *EventLog {
+	return s.events
+}
+
+// Subscribe registers sink to receive the events recorded by each Save,
+// after they have been appended to the audit log.
+func (s *JSONLStore) Subscribe(sink EventSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks = append(s.sinks, sink)
+}
+
 // Create adds a new synapse and returns its ID.
 func (s *JSONLStore) Create(title string) (*types.Synapse, error) {
 	s.mu.Lock()
@@ -200,11 +523,95 @@ func (s *JSONLStore) Create(title string) (*types.Synapse, error) {
 
 	syn := types.NewSynapse(s.nextID, title)
 	s.synapses[syn.ID] = syn
+	s.index.set(syn)
 	s.nextID++
 
 	return syn, nil
 }
 
+// CreateIdempotent creates a synapse carrying externalID as its ExternalID,
+// unless a synapse with that ExternalID already exists, in which case it
+// returns that one with created false. An empty externalID always creates.
+func (s *JSONLStore) CreateIdempotent(title, externalID string) (syn *types.Synapse, created bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byExternalID(externalID); ok {
+		return existing, false, nil
+	}
+	syn = types.NewSynapse(s.nextID, title)
+	syn.ExternalID = externalID
+	s.synapses[syn.ID] = syn
+	s.indexExternalID(syn)
+	s.index.set(syn)
+	s.nextID++
+
+	return syn, true, nil
+}
+
+// ByExternalID returns the synapse whose ExternalID is externalID.
+func (s *JSONLStore) ByExternalID(externalID string) (*types.Synapse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byExternalID(externalID)
+}
+
+func (s *JSONLStore) byExternalID(externalID string) (*types.Synapse, bool) {
+	if externalID == "" {
+		return nil, false
+	}
+	id, ok := s.externalIDs[externalID]
+	if !ok {
+		return nil, false
+	}
+	// The index can lag a task whose ExternalID was edited in place
+	syn, ok := s.synapses[id]
+	if !ok || syn.ExternalID != externalID {
+		return nil, false
+	}
+	return syn, true
+}
+
+// indexExternalID records syn in the ExternalID index, if it has one.
+func (s *JSONLStore) indexExternalID(syn *types.Synapse) {
+	if syn.ExternalID != "" {
+		s.externalIDs[syn.ExternalID] = syn.ID
+	}
+}
+
+// Insert adds a synapse that already carries an ID (e.g. from an import).
+// It fails if the ID or the synapse's ExternalID is taken, and advances
+// the next ID past it.
+func (s *JSONLStore) Insert(syn *types.Synapse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if syn.ID <= 0 {
+		return fmt.Errorf("invalid synapse ID: %d", syn.ID)
+	}
+	if _, exists := s.synapses[syn.ID]; exists {
+		return fmt.Errorf("synapse %d already exists", syn.ID)
+	}
+	if other, ok := s.byExternalID(syn.ExternalID); ok {
+		return fmt.Errorf("synapse %d: external ID %q is already used by synapse %d", syn.ID, syn.ExternalID, other.ID)
+	}
+
+	s.synapses[syn.ID] = syn
+	s.indexExternalID(syn)
+	s.index.set(syn)
+	if syn.ID >= s.nextID {
+		s.nextID = syn.ID + 1
+	}
+	return nil
+}
+
+// NextID returns the ID that will be assigned to the next created synapse.
+func (s *JSONLStore) NextID() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nextID
+}
+
 // Get retrieves a synapse by ID.
 func (s *JSONLStore) Get(id int) (*types.Synapse, error) {
 	s.mu.RLock()
@@ -212,21 +619,82 @@ func (s *JSONLStore) Get(id int) (*types.Synapse, error) {
 
 	syn, ok := s.synapses[id]
 	if !ok {
-		return nil, fmt.Errorf("synapse %d not found", id)
+		return nil, fmt.Errorf("synapse %d %w", id, ErrNotFound)
 	}
 	return syn, nil
 }
 
-// Update modifies an existing synapse.
+// Update modifies an existing synapse and increments its Version. The
+// write is rejected with ErrStaleWrite if syn.Version does not match the
+// stored version.
+//
+// Updating a synapse to done also unblocks its dependents (see Complete).
 func (s *JSONLStore) Update(syn *types.Synapse) error {
+	_, err := s.update(syn)
+	return err
+}
+
+// Complete marks syn done, recording agentID as the completer if it is
+// non-empty, and stores it like Update. Blocked tasks whose blockers are
+// now all done move to open; their IDs are returned.
+func (s *JSONLStore) Complete(syn *types.Synapse, agentID string) ([]int, error) {
+	if agentID != "" {
+		syn.MarkDoneBy(agentID)
+	} else {
+		syn.MarkDone()
+	}
+	return s.update(syn)
+}
+
+// update implements Update, returning the IDs unblocked if syn is done.
+func (s *JSONLStore) update(syn *types.Synapse) ([]int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.synapses[syn.ID]; !ok {
-		return fmt.Errorf("synapse %d not found", syn.ID)
+	stored, ok := s.synapses[syn.ID]
+	if !ok {
+		return nil, fmt.Errorf("synapse %d %w", syn.ID, ErrNotFound)
+	}
+	if syn.Version != stored.Version {
+		return nil, fmt.Errorf("synapse %d: %w: written at version %d, current version is %d",
+			syn.ID, ErrStaleWrite, syn.Version, stored.Version)
 	}
+	if other, ok := s.byExternalID(syn.ExternalID); ok && other.ID != syn.ID {
+		return nil, fmt.Errorf("synapse %d: external ID %q is already used by synapse %d", syn.ID, syn.ExternalID, other.ID)
+	}
+	syn.Version++
 	s.synapses[syn.ID] = syn
-	return nil
+	s.indexExternalID(syn)
+	s.index.set(syn)
+
+	if syn.Status == types.StatusDone {
+		return s.recomputeBlocked(syn.ID), nil
+	}
+	return nil, nil
+}
+
+// recomputeBlocked moves blocked dependents of completedID to open once all
+// of their blockers are done, bumping their UpdatedAt and Version. It
+// returns the freed IDs in ascending order. Callers must hold s.mu.
+func (s *JSONLStore) recomputeBlocked(completedID int) []int {
+	done, dependents := s.doneSet(func(syn *types.Synapse) bool {
+		return syn.Status == types.StatusBlocked && slices.Contains(syn.BlockedBy, completedID)
+	})
+
+	var freed []int
+	now := time.Now().UTC()
+	for _, syn := range dependents {
+		if !syn.IsReady(done.Contains) {
+			continue
+		}
+		syn.Status = types.StatusOpen
+		syn.UpdatedAt = now
+		syn.Version++
+		s.index.set(syn)
+		freed = append(freed, syn.ID)
+	}
+	sort.Ints(freed)
+	return freed
 }
 
 // Delete removes a synapse by ID.
@@ -234,13 +702,37 @@ func (s *JSONLStore) Delete(id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.synapses[id]; !ok {
-		return fmt.Errorf("synapse %d not found", id)
+	syn, ok := s.synapses[id]
+	if !ok {
+		return fmt.Errorf("synapse %d %w", id, ErrNotFound)
 	}
 	delete(s.synapses, id)
+	s.index.remove(id)
+	if s.externalIDs[syn.ExternalID] == id {
+		delete(s.externalIDs, syn.ExternalID)
+	}
+	s.unlinkRelated([]int{id})
 	return nil
 }
 
+// unlinkRelated removes the related_to links to the deleted tasks ids
+// from the remaining ones, bumping the UpdatedAt and Version of each task
+// changed. Callers must hold s.mu.
+func (s *JSONLStore) unlinkRelated(ids []int) {
+	for _, syn := range s.synapses {
+		changed := false
+		for _, id := range ids {
+			if slices.Contains(syn.RelatedTo, id) {
+				syn.RemoveRelated(id)
+				changed = true
+			}
+		}
+		if changed {
+			syn.Version++
+		}
+	}
+}
+
 // DeleteAll removes all synapses from the store.
 func (s *JSONLStore) DeleteAll() error {
 	s.mu.Lock()
@@ -248,6 +740,8 @@ func (s *JSONLStore) DeleteAll() error {
 
 	s.synapses = make(map[int]*types.Synapse)
 	s.nextID = 1
+	s.externalIDs = make(map[string]int)
+	s.index = newTaskIndex()
 	return nil
 }
 
@@ -265,8 +759,13 @@ func (s *JSONLStore) DeleteByStatus(status types.Status) (int, error) {
 	}
 
 	for _, id := range toDelete {
+		if externalID := s.synapses[id].ExternalID; s.externalIDs[externalID] == id {
+			delete(s.externalIDs, externalID)
+		}
 		delete(s.synapses, id)
+		s.index.remove(id)
 	}
+	s.unlinkRelated(toDelete)
 
 	return len(toDelete), nil
 }
@@ -289,19 +788,89 @@ func (s *JSONLStore) All() []*types.Synapse {
 	return result
 }
 
-// Ready returns all synapses that are ready to be worked on.
-func (s *JSONLStore) Ready() []*types.Synapse {
+// IsDone reports whether the synapse with the given ID exists and is done.
+// It is suitable as the isBlockerDone callback of Synapse.IsReady and
+// Synapse.ValidateTransition.
+func (s *JSONLStore) IsDone(id int) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	isDone := func(id int) bool {
-		syn, ok := s.synapses[id]
-		return ok && syn.Status == types.StatusDone
+	syn, ok := s.synapses[id]
+	return ok && syn.Status == types.StatusDone
+}
+
+// DoneSet records which tasks are done, taken once so that checking many
+// tasks' blockers does not look each one up again.
+type DoneSet struct {
+	dense  []bool       // Indexed by task ID
+	sparse map[int]bool // IDs past the end of dense
+}
+
+// Contains reports whether task id was done when the set was taken. It is
+// suitable as the isBlockerDone callback of Synapse.IsReady.
+func (d DoneSet) Contains(id int) bool {
+	if id >= 0 && id < len(d.dense) {
+		return d.dense[id]
+	}
+	return d.sparse[id]
+}
+
+// DoneSet returns which tasks are done now.
+func (s *JSONLStore) DoneSet() DoneSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	done, _ := s.doneSet(nil)
+	return done
+}
+
+// doneSet returns which tasks are done, along with the unfinished tasks
+// that pass keep, in one pass over the store. Task IDs mostly run from 1
+// to about the number of tasks, so the set is a slice rather than a map;
+// the few an import may have put far beyond go in a map. Callers must
+// hold s.mu.
+func (s *JSONLStore) doneSet(keep func(*types.Synapse) bool) (DoneSet, []*types.Synapse) {
+	done := DoneSet{dense: make([]bool, min(s.nextID, 2*len(s.synapses)+64))}
+	var kept []*types.Synapse
+	for id, syn := range s.synapses {
+		switch {
+		case syn.Status != types.StatusDone:
+			if keep != nil && keep(syn) {
+				kept = append(kept, syn)
+			}
+		case id >= 0 && id < len(done.dense):
+			done.dense[id] = true
+		default:
+			if done.sparse == nil {
+				done.sparse = make(map[int]bool)
+			}
+			done.sparse[id] = true
+		}
 	}
+	return done, kept
+}
+
+// Ready returns all synapses that are ready to be worked on, leaving out
+// archived ones.
+func (s *JSONLStore) Ready() []*types.Synapse {
+	return s.ready(false)
+}
+
+// ReadyWithArchived is Ready including archived synapses.
+func (s *JSONLStore) ReadyWithArchived() []*types.Synapse {
+	return s.ready(true)
+}
+
+func (s *JSONLStore) ready(includeArchived bool) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	done, candidates := s.doneSet(func(syn *types.Synapse) bool {
+		return includeArchived || !syn.Archived
+	})
 
 	var ready []*types.Synapse
-	for _, syn := range s.synapses {
-		if syn.IsReady(isDone) {
+	for _, syn := range candidates {
+		if syn.IsReady(done.Contains) {
 			ready = append(ready, syn)
 		}
 	}
@@ -314,56 +883,176 @@ func (s *JSONLStore) Ready() []*types.Synapse {
 	return ready
 }
 
-// ByStatus returns all synapses with the given status.
+// ReadyFor returns the ready synapses assigned to the given role or to any
+// agent filling it (see SetRoles), in the same priority order as Ready.
+func (s *JSONLStore) ReadyFor(assignee string) []*types.Synapse {
+	s.mu.RLock()
+	assignees := s.roles.Expand(assignee)
+	s.mu.RUnlock()
+
+	var result []*types.Synapse
+	for _, syn := range s.Ready() {
+		if slices.Contains(assignees, syn.Assignee) {
+			result = append(result, syn)
+		}
+	}
+	return result
+}
+
+// ByStatus returns all synapses with the given status. A task edited in
+// place is found under its new status once it is stored with Update.
 func (s *JSONLStore) ByStatus(status types.Status) []*types.Synapse {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.lookup(s.index.status[status])
+}
+
+// ByAssignee returns all synapses assigned to the given role.
+func (s *JSONLStore) ByAssignee(assignee string) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(s.index.assignee[assignee])
+}
+
+// ByLabel returns all synapses with the given label.
+func (s *JSONLStore) ByLabel(label string) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(s.index.label[label])
+}
+
+// Count returns the total number of synapses.
+func (s *JSONLStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.synapses)
+}
+
+// CountFilter selects the synapses counted by CountWhere. Every set field
+// must match; the zero filter counts every task that isn't archived, as
+// listings show them.
+type CountFilter struct {
+	Status          types.Status
+	Label           string
+	Assignee        string
+	Unassigned      bool // Only synapses with no assignee
+	IncludeArchived bool // Archived synapses match too
+}
+
+// Matches reports whether syn passes every set field of the filter.
+func (f CountFilter) Matches(syn *types.Synapse) bool {
+	if syn.Archived && !f.IncludeArchived {
+		return false
+	}
+	if f.Status != "" && syn.Status != f.Status {
+		return false
+	}
+	if f.Assignee != "" && syn.Assignee != f.Assignee {
+		return false
+	}
+	if f.Unassigned && syn.Assignee != "" {
+		return false
+	}
+	return f.Label == "" || slices.Contains(syn.Labels, f.Label)
+}
+
+// CountWhere returns the number of synapses matching filter, without
+// building or sorting a result slice.
+func (s *JSONLStore) CountWhere(filter CountFilter) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, syn := range s.synapses {
+		if filter.Matches(syn) {
+			n++
+		}
+	}
+	return n
+}
+
+// ReadyCount returns len(Ready()) without building or sorting the slice.
+func (s *JSONLStore) ReadyCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	done, candidates := s.doneSet(func(syn *types.Synapse) bool {
+		return !syn.Archived
+	})
+
+	n := 0
+	for _, syn := range candidates {
+		if syn.IsReady(done.Contains) {
+			n++
+		}
+	}
+	return n
+}
+
+// CountByStatus returns the number of synapses in each status, in one
+// pass. Statuses no synapse has are left out.
+func (s *JSONLStore) CountByStatus() map[types.Status]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[types.Status]int)
+	for _, syn := range s.synapses {
+		counts[syn.Status]++
+	}
+	return counts
+}
+
+// ModifiedSince returns all synapses modified since the given time.
+func (s *JSONLStore) ModifiedSince(since time.Time) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var result []*types.Synapse
 	for _, syn := range s.synapses {
-		if syn.Status == status {
+		if syn.UpdatedAt.After(since) || syn.UpdatedAt.Equal(since) {
 			result = append(result, syn)
 		}
 	}
 
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].ID < result[j].ID
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
 	})
 
 	return result
 }
 
-// ByAssignee returns all synapses assigned to the given role.
-func (s *JSONLStore) ByAssignee(assignee string) []*types.Synapse {
+// CreatedSince returns all synapses created since the given time, newest
+// first.
+func (s *JSONLStore) CreatedSince(since time.Time) []*types.Synapse {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var result []*types.Synapse
 	for _, syn := range s.synapses {
-		if syn.Assignee == assignee {
+		if !syn.CreatedAt.Before(since) {
 			result = append(result, syn)
 		}
 	}
 
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].ID < result[j].ID
+		return result[i].CreatedAt.After(result[j].CreatedAt)
 	})
 
 	return result
 }
 
-// ByLabel returns all synapses with the given label.
-func (s *JSONLStore) ByLabel(label string) []*types.Synapse {
+// ClaimedBy returns all synapses claimed by the given agent.
+func (s *JSONLStore) ClaimedBy(agentID string) []*types.Synapse {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var result []*types.Synapse
 	for _, syn := range s.synapses {
-		for _, l := range syn.Labels {
-			if l == label {
-				result = append(result, syn)
-				break
-			}
+		if syn.ClaimedBy == agentID {
+			result = append(result, syn)
 		}
 	}
 
@@ -374,21 +1063,53 @@ func (s *JSONLStore) ByLabel(label string) []*types.Synapse {
 	return result
 }
 
-// Count returns the total number of synapses.
-func (s *JSONLStore) Count() int {
+// Claimants returns the distinct agents holding a claim on any synapse,
+// expired or not, in name order.
+func (s *JSONLStore) Claimants() []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.synapses)
+
+	seen := make(map[string]bool)
+	var agents []string
+	for _, syn := range s.synapses {
+		if syn.ClaimedBy != "" && !seen[syn.ClaimedBy] {
+			seen[syn.ClaimedBy] = true
+			agents = append(agents, syn.ClaimedBy)
+		}
+	}
+	sort.Strings(agents)
+	return agents
 }
 
-// ModifiedSince returns all synapses modified since the given time.
-func (s *JSONLStore) ModifiedSince(since time.Time) []*types.Synapse {
+// DiscoveredFrom returns the synapses discovered while working on the
+// given task, in ID order.
+func (s *JSONLStore) DiscoveredFrom(taskID int) []*types.Synapse {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var result []*types.Synapse
 	for _, syn := range s.synapses {
-		if syn.UpdatedAt.After(since) || syn.UpdatedAt.Equal(since) {
+		if taskID > 0 && syn.DiscoveredFrom == taskID {
+			result = append(result, syn)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+// CompletedBy returns all synapses completed by the given agent, most
+// recently updated first.
+func (s *JSONLStore) CompletedBy(agentID string) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*types.Synapse
+	for _, syn := range s.synapses {
+		if syn.CompletedBy == agentID {
 			result = append(result, syn)
 		}
 	}
@@ -400,25 +1121,45 @@ func (s *JSONLStore) ModifiedSince(since time.Time) []*types.Synapse {
 	return result
 }
 
-// ClaimedBy returns all synapses claimed by the given agent.
-func (s *JSONLStore) ClaimedBy(agentID string) []*types.Synapse {
+// Overdue returns the unfinished synapses whose due date has passed, most
+// overdue first.
+func (s *JSONLStore) Overdue() []*types.Synapse {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	now := time.Now().UTC()
 	var result []*types.Synapse
 	for _, syn := range s.synapses {
-		if syn.ClaimedBy == agentID {
+		if syn.IsOverdue(now) {
 			result = append(result, syn)
 		}
 	}
 
 	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DueAt.Equal(*result[j].DueAt) {
+			return result[i].DueAt.Before(*result[j].DueAt)
+		}
 		return result[i].ID < result[j].ID
 	})
 
 	return result
 }
 
+// ClaimTimeout returns the default claim timeout for this project.
+func (s *JSONLStore) ClaimTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.claimTimeout
+}
+
+// SetClaimTimeout overrides the default claim timeout, e.g. from the
+// project's config.json.
+func (s *JSONLStore) SetClaimTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimTimeout = timeout
+}
+
 // ReleaseExpiredClaims releases claims that have exceeded the timeout.
 // Returns the number of claims released.
 func (s *JSONLStore) ReleaseExpiredClaims(timeout time.Duration) int {
@@ -429,6 +1170,7 @@ func (s *JSONLStore) ReleaseExpiredClaims(timeout time.Duration) int {
 	for _, syn := range s.synapses {
 		if syn.ClaimedBy != "" && syn.IsClaimExpired(timeout) {
 			syn.ReleaseClaim()
+			s.index.set(syn)
 			count++
 		}
 	}
@@ -436,11 +1178,32 @@ func (s *JSONLStore) ReleaseExpiredClaims(timeout time.Duration) int {
 	return count
 }
 
+// DuplicateIDs returns the IDs that appeared on more than one line of the
+// memory file at the last Load, in ascending order. Only the last line for
+// each is kept in the store.
+func (s *JSONLStore) DuplicateIDs() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.shadowed))
+	for id := range s.shadowed {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
 // memoryPath returns the full path to the memory file.
 func (s *JSONLStore) memoryPath() string {
 	return filepath.Join(s.dir, MemoryFile)
 }
 
+// FileInfo returns file metadata (size, modification time) for the memory
+// file, so callers can detect changes made by other processes.
+func (s *JSONLStore) FileInfo() (os.FileInfo, error) {
+	return os.Stat(s.memoryPath())
+}
+
 // Dir returns the storage directory path.
 func (s *JSONLStore) Dir() string {
 	return s.dir
@@ -0,0 +1,549 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestUpdate_IncrementsVersion(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	syn, _ := store.Create("Versioned")
+
+	for want := 1; want <= 3; want++ {
+		if err := store.Update(syn); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if syn.Version != want {
+			t.Errorf("expected version %d, got %d", want, syn.Version)
+		}
+	}
+}
+
+func TestUpdate_StaleWrite(t *testing.T) {
+	dir := t.TempDir()
+	agentA := NewJSONLStore(dir)
+	agentA.Init()
+	syn, _ := agentA.Create("Contended task")
+	agentA.Save()
+
+	// Agent A reads the task and starts editing a private copy
+	staleCopy := *syn
+
+	// Agent B, in another process, updates the same task and saves
+	agentB := NewJSONLStore(dir)
+	if err := agentB.Load(); err != nil {
+		t.Fatalf("load B: %v", err)
+	}
+	fromB, _ := agentB.Get(syn.ID)
+	edited := *fromB
+	edited.Title = "Renamed by B"
+	if err := agentB.Update(&edited); err != nil {
+		t.Fatalf("update B: %v", err)
+	}
+	if err := agentB.Save(); err != nil {
+		t.Fatalf("save B: %v", err)
+	}
+
+	// Agent A picks up B's write, then tries to commit its stale copy
+	if err := agentA.Load(); err != nil {
+		t.Fatalf("reload A: %v", err)
+	}
+	staleCopy.Title = "Renamed by A"
+	err := agentA.Update(&staleCopy)
+	if !errors.Is(err, ErrStaleWrite) {
+		t.Fatalf("expected ErrStaleWrite, got %v", err)
+	}
+
+	current, _ := agentA.Get(syn.ID)
+	if current.Title != "Renamed by B" {
+		t.Errorf("stale write clobbered B's update: title = %q", current.Title)
+	}
+
+	// Retrying from the fresh copy succeeds
+	retry := *current
+	retry.Title = "Renamed by A"
+	if err := agentA.Update(&retry); err != nil {
+		t.Errorf("retry after reload failed: %v", err)
+	}
+	if retry.Version != 2 {
+		t.Errorf("expected version 2 after two updates, got %d", retry.Version)
+	}
+}
+
+func TestLoadSave_PreservesUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"id":1,"title":"From the future","status":"open","blocked_by":[],"created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z","estimate":{"hours":3,"confidence":"low"},"zz_flag":true}`
+	content := `{"schema_version":2}` + "\n" + line + "\n"
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store := NewJSONLStore(dir)
+	if err := store.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	syn, _ := store.Get(1)
+	if len(syn.Extra) != 2 {
+		t.Fatalf("expected 2 preserved fields, got %v", syn.Extra)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, MemoryFile))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := string(data); got != content {
+		t.Errorf("round-trip changed the file:\n got: %s\nwant: %s", got, content)
+	}
+}
+
+func TestLoad_MigratesLegacyDiscoveredFrom(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		`{"id":1,"title":"Legacy ref","status":"open","discovered_from":"#5","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"id":2,"title":"Legacy text","status":"open","discovered_from":"code review","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"id":3,"title":"Current","status":"open","discovered_from":1,"created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+	}
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store := NewJSONLStore(dir)
+	if err := store.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for id, want := range map[int]int{1: 5, 2: 0, 3: 1} {
+		syn, _ := store.Get(id)
+		if syn.DiscoveredFrom != want {
+			t.Errorf("task %d: DiscoveredFrom = %d, want %d", id, syn.DiscoveredFrom, want)
+		}
+		if _, ok := syn.Extra["discovered_from"]; ok {
+			t.Errorf("task %d: discovered_from should not be kept as an unknown field, got %v", id, syn.Extra)
+		}
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+	if !strings.Contains(string(data), `"discovered_from":5`) {
+		t.Errorf("expected numeric discovered_from after save, got:\n%s", data)
+	}
+
+	// Free-text provenance survives the save and a reload
+	reloaded := NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if syn, _ := reloaded.Get(2); string(syn.Extra[types.DiscoveredFromNote]) != `"code review"` {
+		t.Errorf("expected the free-text discovered_from kept as a note, got %v", syn.Extra)
+	}
+}
+
+func TestComplete_UnblocksDependents(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	first, _ := store.Create("First blocker")
+	second, _ := store.Create("Second blocker")
+
+	single, _ := store.Create("Blocked by first only")
+	single.Status = types.StatusBlocked
+	single.BlockedBy = []int{first.ID}
+
+	both, _ := store.Create("Blocked by both")
+	both.Status = types.StatusBlocked
+	both.BlockedBy = []int{first.ID, second.ID}
+
+	freed, err := store.Complete(first, "agent-1")
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if len(freed) != 1 || freed[0] != single.ID {
+		t.Fatalf("expected only %d freed, got %v", single.ID, freed)
+	}
+	if single.Status != types.StatusOpen {
+		t.Errorf("expected freed task open, got %s", single.Status)
+	}
+	if single.Version != 1 || single.UpdatedAt.Before(first.UpdatedAt) {
+		t.Errorf("expected freed task version bumped and UpdatedAt refreshed, got v%d at %v", single.Version, single.UpdatedAt)
+	}
+	if both.Status != types.StatusBlocked {
+		t.Errorf("task with pending blocker should stay blocked, got %s", both.Status)
+	}
+	if first.CompletedBy != "agent-1" {
+		t.Errorf("expected completer recorded, got %q", first.CompletedBy)
+	}
+
+	freed, _ = store.Complete(second, "")
+	if len(freed) != 1 || freed[0] != both.ID {
+		t.Errorf("expected %d freed after second blocker, got %v", both.ID, freed)
+	}
+}
+
+func TestReadyFor(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	low, _ := store.Create("Low priority QA")
+	low.Assignee = "qa"
+	high, _ := store.Create("High priority QA")
+	high.Assignee = "qa"
+	high.Priority = 5
+	coder, _ := store.Create("Coder task")
+	coder.Assignee = "coder"
+	done, _ := store.Create("Finished QA")
+	done.Assignee = "qa"
+	done.Status = types.StatusDone
+
+	ready := store.ReadyFor("qa")
+	if len(ready) != 2 || ready[0].ID != high.ID || ready[1].ID != low.ID {
+		t.Errorf("expected [%d %d] in priority order, got %v", high.ID, low.ID, ready)
+	}
+
+	if got := store.ReadyFor("nobody"); len(got) != 0 {
+		t.Errorf("expected no ready tasks for unknown role, got %d", len(got))
+	}
+}
+
+func TestReady_SkipsArchived(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	blocker, _ := store.Create("Old spike")
+	blocker.MarkDone()
+	blocker.MarkArchived()
+	dependent, _ := store.Create("Build on the spike")
+	dependent.BlockedBy = []int{blocker.ID}
+	shelved, _ := store.Create("No longer relevant")
+	shelved.MarkArchived()
+
+	// An archived done task still counts as a finished blocker
+	ready := store.Ready()
+	if len(ready) != 1 || ready[0].ID != dependent.ID {
+		t.Errorf("expected only task %d ready, got %v", dependent.ID, ready)
+	}
+	if got := store.ReadyWithArchived(); len(got) != 2 {
+		t.Errorf("expected the archived task included on request, got %v", got)
+	}
+	if got := Unarchived(store.All()); len(got) != 1 || got[0].ID != dependent.ID {
+		t.Errorf("Unarchived: expected only task %d, got %v", dependent.ID, got)
+	}
+}
+
+func TestCompletedBy(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	first, _ := store.Create("First")
+	first.MarkDoneBy("agent-1")
+	first.UpdatedAt = first.UpdatedAt.Add(-time.Hour)
+	second, _ := store.Create("Second")
+	second.MarkDoneBy("agent-1")
+	other, _ := store.Create("Other agent")
+	other.MarkDoneBy("agent-2")
+	store.Create("Not done")
+
+	got := store.CompletedBy("agent-1")
+	if len(got) != 2 || got[0].ID != second.ID || got[1].ID != first.ID {
+		t.Errorf("expected [%d %d] most recent first, got %v", second.ID, first.ID, got)
+	}
+}
+
+func TestDiscoveredFrom(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	store.Create("Origin")
+	a, _ := store.Create("Found first")
+	a.DiscoveredFrom = 1
+	b, _ := store.Create("Found second")
+	b.DiscoveredFrom = 1
+	other, _ := store.Create("Found elsewhere")
+	other.DiscoveredFrom = 10
+
+	got := store.DiscoveredFrom(1)
+	if len(got) != 2 || got[0].ID != a.ID || got[1].ID != b.ID {
+		t.Errorf("expected [%d %d], got %v", a.ID, b.ID, got)
+	}
+	for _, id := range []int{0, 99} {
+		if got := store.DiscoveredFrom(id); len(got) != 0 {
+			t.Errorf("DiscoveredFrom(%d): expected no matches, got %v", id, got)
+		}
+	}
+}
+
+func TestJSONLStore_ErrNotFound(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	_, err := store.Get(42)
+	if !errors.Is(err, ErrNotFound) || err.Error() != "synapse 42 not found" {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Delete(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Delete, got %v", err)
+	}
+}
+
+func TestJSONLStore_DeleteUnlinksRelated(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	a, _ := store.Create("A")
+	b, _ := store.Create("B")
+	c, _ := store.Create("C")
+	a.AddRelated(b.ID)
+	a.AddRelated(c.ID)
+	store.Update(a)
+	c.Status = types.StatusDone
+	store.Update(c)
+	version := a.Version
+
+	if err := store.Delete(b.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !slices.Equal(a.RelatedTo, []int{c.ID}) || a.Version != version+1 {
+		t.Errorf("expected the link to %d dropped and the version bumped, got %v at %d", b.ID, a.RelatedTo, a.Version)
+	}
+	if _, err := store.DeleteByStatus(types.StatusDone); err != nil {
+		t.Fatalf("delete by status: %v", err)
+	}
+	if len(a.RelatedTo) != 0 {
+		t.Errorf("expected no links left, got %v", a.RelatedTo)
+	}
+}
+
+func TestJSONLStore_CreateIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+
+	first, created, err := store.CreateIdempotent("Deploy", "req-42")
+	if err != nil || !created {
+		t.Fatalf("first create: created=%v err=%v", created, err)
+	}
+	second, created, err := store.CreateIdempotent("Deploy (retry)", "req-42")
+	if err != nil || created || second.ID != first.ID {
+		t.Fatalf("expected retry to return #%d, got #%d created=%v err=%v", first.ID, second.ID, created, err)
+	}
+	if store.Count() != 1 {
+		t.Fatalf("expected one task, got %d", store.Count())
+	}
+
+	// The index is rebuilt on load
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	reloaded := NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if syn, ok := reloaded.ByExternalID("req-42"); !ok || syn.ID != first.ID {
+		t.Errorf("expected lookup after reload to find #%d", first.ID)
+	}
+
+	// Another task can't take the key, and deleting frees it
+	other, _, _ := reloaded.CreateIdempotent("Other", "")
+	other.ExternalID = "req-42"
+	if err := reloaded.Update(other); err == nil {
+		t.Error("expected error reusing an external ID")
+	}
+	other.ExternalID = ""
+	reloaded.Delete(first.ID)
+	if _, created, _ := reloaded.CreateIdempotent("Deploy again", "req-42"); !created {
+		t.Error("expected a new task once the original was deleted")
+	}
+}
+
+func TestJSONLStore_InsertRejectsDuplicateExternalID(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	store.CreateIdempotent("Deploy", "req-42")
+
+	dup := types.NewSynapse(5, "Deploy again")
+	dup.ExternalID = "req-42"
+	if err := store.Insert(dup); err == nil || !strings.Contains(err.Error(), "already used by synapse 1") {
+		t.Fatalf("expected the duplicate external ID rejected, got %v", err)
+	}
+	if _, err := store.Get(5); err == nil {
+		t.Error("rejected insert added the task")
+	}
+	if syn, ok := store.ByExternalID("req-42"); !ok || syn.ID != 1 {
+		t.Errorf("expected req-42 to still map to #1, got %+v", syn)
+	}
+}
+
+func TestJSONLStore_CountWhere(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	for _, spec := range []struct {
+		status   types.Status
+		assignee string
+		labels   []string
+	}{
+		{types.StatusOpen, "@coder", []string{"bug"}},
+		{types.StatusOpen, "@coder", nil},
+		{types.StatusOpen, "", []string{"bug"}},
+		{types.StatusDone, "@coder", []string{"bug"}},
+	} {
+		syn, _ := store.Create("Task")
+		syn.Status, syn.Assignee, syn.Labels = spec.status, spec.assignee, spec.labels
+	}
+	shelved, _ := store.Create("Shelved")
+	shelved.Labels = []string{"bug"}
+	shelved.MarkArchived()
+
+	tests := []struct {
+		filter CountFilter
+		want   int
+	}{
+		{CountFilter{}, 4},
+		{CountFilter{IncludeArchived: true}, 5},
+		{CountFilter{Label: "bug", IncludeArchived: true}, 4},
+		{CountFilter{Status: types.StatusOpen}, 3},
+		{CountFilter{Label: "bug"}, 3},
+		{CountFilter{Status: types.StatusOpen, Label: "bug", Assignee: "@coder"}, 1},
+		{CountFilter{Unassigned: true}, 1},
+		{CountFilter{Status: types.StatusBlocked}, 0},
+	}
+	for _, tt := range tests {
+		if got := store.CountWhere(tt.filter); got != tt.want {
+			t.Errorf("CountWhere(%+v) = %d, want %d", tt.filter, got, tt.want)
+		}
+	}
+
+	// The default count agrees with what a listing shows
+	if got, want := store.CountWhere(CountFilter{}), len(Unarchived(store.All())); got != want {
+		t.Errorf("CountWhere counts %d tasks, but %d are listed", got, want)
+	}
+}
+
+func TestJSONLStore_ReadyCountAndCountByStatus(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	design, _ := store.Create("Design")
+	design.MarkDone()
+	build, _ := store.Create("Build")
+	build.BlockedBy = []int{design.ID}
+	test, _ := store.Create("Test")
+	test.BlockedBy = []int{build.ID}
+	test.Status = types.StatusBlocked
+	review, _ := store.Create("Review")
+	review.Status = types.StatusReview
+	shelved, _ := store.Create("Shelved")
+	shelved.MarkArchived()
+	store.Create("Docs")
+	for _, syn := range []*types.Synapse{design, build, test, review, shelved} {
+		store.Update(syn)
+	}
+
+	if got, want := store.ReadyCount(), len(store.Ready()); got != want {
+		t.Errorf("ReadyCount() = %d, len(Ready()) = %d", got, want)
+	}
+
+	counts := store.CountByStatus()
+	total := 0
+	for _, status := range []types.Status{types.StatusOpen, types.StatusInProgress, types.StatusBlocked, types.StatusReview, types.StatusDone} {
+		if got, want := counts[status], len(store.ByStatus(status)); got != want {
+			t.Errorf("CountByStatus()[%s] = %d, len(ByStatus) = %d", status, got, want)
+		}
+		total += counts[status]
+	}
+	if total != store.Count() {
+		t.Errorf("CountByStatus() totals %d, want %d: %v", total, store.Count(), counts)
+	}
+}
+
+func TestJSONLStore_DoneSet(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	finished, _ := store.Create("Finished")
+	finished.MarkDone()
+	store.Create("Open")
+	imported := types.NewSynapse(1_000_000, "Imported far ahead")
+	imported.MarkDone()
+	if err := store.Insert(imported); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	done := store.DoneSet()
+	for _, id := range []int{-1, 0, finished.ID, 2, 999_999, imported.ID, 1_000_001} {
+		if got, want := done.Contains(id), store.IsDone(id); got != want {
+			t.Errorf("Contains(%d) = %v, IsDone = %v", id, got, want)
+		}
+	}
+}
+
+func TestSave_DoesNotBlockReads(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	for i := 0; i < 30000; i++ {
+		syn, _ := store.Create("Task")
+		syn.Notes = []string{strings.Repeat("context ", 20)}
+	}
+
+	var saveErr error
+	saved := make(chan struct{})
+	go func() {
+		saveErr = store.Save()
+		close(saved)
+	}()
+
+	// The temp file appears once the save is encoding its snapshot
+	tmpPath := filepath.Join(dir, MemoryFile+".tmp")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(tmpPath); err == nil {
+			break
+		}
+		select {
+		case <-saved:
+			t.Skip("save finished before it could be observed")
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("save never started writing")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := store.Get(1); err != nil {
+		t.Fatalf("get during save: %v", err)
+	}
+	// A read made mid-save shouldn't have waited for it
+	select {
+	case <-saved:
+		t.Error("read waited for the save to finish")
+	default:
+	}
+
+	<-saved
+	if saveErr != nil {
+		t.Fatalf("save: %v", saveErr)
+	}
+	reloaded := NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil || reloaded.Count() != 30000 {
+		t.Fatalf("expected 30000 tasks saved, got %d (%v)", reloaded.Count(), err)
+	}
+}
+
+func BenchmarkReady(b *testing.B) {
+	store := NewJSONLStore(b.TempDir())
+	for i := 1; i <= 10000; i++ {
+		syn, _ := store.Create("Task")
+		for _, blocker := range []int{i / 2, i - 1, i - 7} {
+			if blocker > 0 {
+				syn.BlockedBy = append(syn.BlockedBy, blocker)
+			}
+		}
+		if i%3 != 0 {
+			syn.MarkDone()
+		}
+		store.Update(syn)
+	}
+
+	for b.Loop() {
+		store.Ready()
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// parallelLoadSize is the memory file size from which Load parses task
+// lines on several goroutines. Smaller files parse faster on one.
+const parallelLoadSize = 4 << 20
+
+// parseChunk is how many lines a load worker takes at a time.
+const parseChunk = 512
+
+// loadWorkers returns how many goroutines Load uses to parse a memory file
+// of size bytes.
+func loadWorkers(size int64) int {
+	if size < parallelLoadSize {
+		return 1
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// rawLine is a task line of the memory file waiting to be parsed.
+type rawLine struct {
+	num  int // 1-based line number, for errors
+	data []byte
+}
+
+// parsedLine is a decoded task line: a task, or a tombstone deleting one.
+type parsedLine struct {
+	syn     *types.Synapse // nil for a tombstone
+	deleted int
+}
+
+// parseLine decodes a task or tombstone line written at schema, upgrading
+// tasks to SchemaVersion.
+func parseLine(line []byte, lineNum, schema int) (parsedLine, error) {
+	if id, ok := parseTombstone(line); ok {
+		return parsedLine{deleted: id}, nil
+	}
+
+	line, err := Migrate(line, schema)
+	if err != nil {
+		return parsedLine{}, fmt.Errorf("migrate line %d: %w", lineNum, err)
+	}
+
+	var syn types.Synapse
+	if err := json.Unmarshal(line, &syn); err != nil {
+		return parsedLine{}, fmt.Errorf("parse line %d: %w", lineNum, err)
+	}
+	return parsedLine{syn: &syn}, nil
+}
+
+// parseLines parses lines on workers goroutines, returning the results in
+// the same order. If any line fails it returns the error for the first
+// bad line, as parsing them one by one would.
+func parseLines(lines []rawLine, schema, workers int) ([]parsedLine, error) {
+	parsed := make([]parsedLine, len(lines))
+	errs := make([]error, len(lines))
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+parseChunk, len(lines))
+				for i := start; i < end; i++ {
+					parsed[i], errs[i] = parseLine(lines[i].data, lines[i].num, schema)
+				}
+			}
+		}()
+	}
+	for start := 0; start < len(lines); start += parseChunk {
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
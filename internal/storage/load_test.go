@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// memoryContent encodes n tasks as memory file content, header first.
+func memoryContent(t testing.TB, n int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.Encode(schemaHeader{SchemaVersion: SchemaVersion})
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for id := 1; id <= n; id++ {
+		syn := types.NewSynapse(id, fmt.Sprintf("Task %d", id))
+		syn.CreatedAt, syn.UpdatedAt = created, created
+		if id > 1 {
+			syn.BlockedBy = []int{id - 1}
+		}
+		syn.Labels = []string{"bench"}
+		if err := encoder.Encode(syn); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRead_Parallel(t *testing.T) {
+	content := memoryContent(t, 2000)
+	// A second version of task 5 and a tombstone for task 2000
+	content = append(content, `{"id":5,"title":"Task 5 again","status":"done","blocked_by":[],"created_at":"2025-01-02T00:00:00Z","updated_at":"2025-01-02T00:00:00Z"}`+"\n"...)
+	content = append(content, `{"deleted":2000}`+"\n"...)
+
+	sequential := NewJSONLStore(t.TempDir())
+	if err := sequential.read(bytes.NewReader(content), false, 1); err != nil {
+		t.Fatalf("sequential read: %v", err)
+	}
+	parallel := NewJSONLStore(t.TempDir())
+	if err := parallel.read(bytes.NewReader(content), false, 4); err != nil {
+		t.Fatalf("parallel read: %v", err)
+	}
+
+	if !reflect.DeepEqual(sequential.synapses, parallel.synapses) {
+		t.Error("parallel read loaded different tasks")
+	}
+	if sequential.nextID != 2001 || parallel.nextID != sequential.nextID {
+		t.Errorf("nextID = %d (parallel %d), want 2001", sequential.nextID, parallel.nextID)
+	}
+	if ids := parallel.DuplicateIDs(); !reflect.DeepEqual(ids, []int{5}) {
+		t.Errorf("expected task 5 reported as duplicated, got %v", ids)
+	}
+
+	// The first bad line is reported, wherever the workers got to
+	lines := strings.Split(string(content), "\n")
+	lines[700] = "{not json"
+	lines[1500] = "{not json either"
+	err := NewJSONLStore(t.TempDir()).read(strings.NewReader(strings.Join(lines, "\n")), false, 4)
+	if err == nil || !strings.Contains(err.Error(), "line 701") {
+		t.Errorf("expected an error at line 701, got %v", err)
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	content := memoryContent(b, 50000)
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"sequential", 1},
+		{"parallel", runtime.GOMAXPROCS(0)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			for b.Loop() {
+				store := NewJSONLStore(b.TempDir())
+				if err := store.read(bytes.NewReader(content), false, bench.workers); err != nil {
+					b.Fatalf("read: %v", err)
+				}
+			}
+		})
+	}
+}
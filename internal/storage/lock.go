@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// LockFile is the advisory lock guarding memory.jsonl across processes.
+	LockFile = ".lock"
+)
+
+// Lock takes an exclusive advisory lock on the store's directory, blocking
+// until any other process holding it releases it. The returned function
+// releases the lock. Hold it across a Load, mutate, Save sequence so that
+// concurrent CLI and MCP processes cannot lose each other's updates.
+func (s *JSONLStore) Lock() (func() error, error) {
+	f, err := os.OpenFile(filepath.Join(s.dir, LockFile), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("acquire lock: %w", err)
+	}
+	s.mu.Lock()
+	s.locked = true
+	s.mu.Unlock()
+
+	return func() error {
+		s.mu.Lock()
+		s.locked = false
+		s.mu.Unlock()
+		err := unlockFile(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("release lock: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// WithLock runs fn while holding the store's file lock. fn should Load the
+// store before mutating it so it starts from the latest state on disk.
+func (s *JSONLStore) WithLock(fn func() error) error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn()
+	if err := unlock(); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
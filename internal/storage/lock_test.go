@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWithLock_NoLostUpdates(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewJSONLStore(dir).Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	const writers, perWriter = 2, 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each writer is a separate store, as if in its own process
+			store := NewJSONLStore(dir)
+			for i := 0; i < perWriter; i++ {
+				err := store.WithLock(func() error {
+					if err := store.Load(); err != nil {
+						return err
+					}
+					if _, err := store.Create("task"); err != nil {
+						return err
+					}
+					return store.Save()
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("writer failed: %v", err)
+	}
+
+	final := NewJSONLStore(dir)
+	if err := final.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := final.Count(); got != writers*perWriter {
+		t.Errorf("expected %d tasks, got %d (lost updates)", writers*perWriter, got)
+	}
+}
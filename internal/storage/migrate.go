@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// SchemaVersion is the memory file format written by this release. Bump it
+// whenever a field is added with a non-zero default, renamed, or changes
+// type, and append the matching step to migrations.
+//
+//	1  No header; discovered_from may be a "#N" string or free text and
+//	   blocked_by may be omitted
+//	2  Schema header line; discovered_from is a task ID, with free text
+//	   kept in discovered_from_note, and blocked_by is always an array
+const SchemaVersion = 2
+
+// ErrUnsupportedSchema is returned by Load when the memory file was
+// written by a newer release than this one.
+var ErrUnsupportedSchema = errors.New("unsupported schema version")
+
+// schemaHeader is the first line of a memory file, recording the format
+// its task lines are in. Files without one are schema version 1.
+type schemaHeader struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// ParseSchemaHeader reports whether line is a memory file schema header
+// and, if so, the version it declares.
+func ParseSchemaHeader(line []byte) (int, bool) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte(`{"schema_version"`)) {
+		return 0, false
+	}
+	var header schemaHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.SchemaVersion <= 0 {
+		return 0, false
+	}
+	return header.SchemaVersion, true
+}
+
+// migration upgrades one decoded task line by a single schema version.
+type migration func(task map[string]json.RawMessage) error
+
+// migrations[i] upgrades a task from schema version i+1 to i+2.
+var migrations = []migration{
+	migrateV1ToV2,
+}
+
+// Migrate upgrades a task line written at schema version from to
+// SchemaVersion, applying each migration in order. Lines already current
+// are returned unchanged.
+func Migrate(line []byte, from int) ([]byte, error) {
+	if from > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d (this release supports up to %d; upgrade synapse)", ErrUnsupportedSchema, from, SchemaVersion)
+	}
+	if from == SchemaVersion {
+		return line, nil
+	}
+
+	var task map[string]json.RawMessage
+	if err := json.Unmarshal(line, &task); err != nil {
+		return nil, err
+	}
+	for version := from; version < SchemaVersion; version++ {
+		if err := migrations[version-1](task); err != nil {
+			return nil, fmt.Errorf("migrate to schema %d: %w", version+1, err)
+		}
+	}
+	return json.Marshal(task)
+}
+
+// migrateV1ToV2 rewrites a legacy "#N" discovered_from as a task ID,
+// moving free-text provenance to discovered_from_note, and fills in a
+// missing blocked_by.
+func migrateV1ToV2(task map[string]json.RawMessage) error {
+	if raw, ok := task["discovered_from"]; ok && len(raw) > 0 && raw[0] == '"' {
+		var ref string
+		if err := json.Unmarshal(raw, &ref); err != nil {
+			return fmt.Errorf("discovered_from: %w", err)
+		}
+		if id, ok := types.ParseTaskRef(ref); ok {
+			task["discovered_from"] = json.RawMessage(fmt.Sprint(id))
+		} else {
+			if _, ok := task[types.DiscoveredFromNote]; !ok {
+				task[types.DiscoveredFromNote] = raw
+			}
+			delete(task, "discovered_from")
+		}
+	}
+	if raw, ok := task["blocked_by"]; !ok || string(raw) == "null" {
+		task["blocked_by"] = json.RawMessage("[]")
+	}
+	return nil
+}
+
+// writeMigrated atomically replaces the memory file with the upgraded
+// contents read by Load. Callers must hold s.mu.
+func (s *JSONLStore) writeMigrated(data []byte) error {
+	memPath := s.memoryPath()
+	tmpPath := memPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write migrated memory file: %w", err)
+	}
+	if err := os.Rename(tmpPath, memPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename migrated memory file: %w", err)
+	}
+	return nil
+}
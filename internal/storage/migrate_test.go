@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// writeFixture copies testdata/name into a fresh store directory as the
+// memory file.
+func writeFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), data, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return dir
+}
+
+// loadFixture writes testdata/name as the memory file and loads it under
+// the file lock, as a command about to write would.
+func loadFixture(t *testing.T, name string) (*JSONLStore, string) {
+	t.Helper()
+	dir := writeFixture(t, name)
+	store := NewJSONLStore(dir)
+	if err := store.WithLock(store.Load); err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return store, dir
+}
+
+func TestLoad_MigratesEachPriorSchema(t *testing.T) {
+	current, err := os.ReadFile(filepath.Join("testdata", "schema_v2.jsonl"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	for _, fixture := range []string{"schema_v1.jsonl", "schema_v2.jsonl"} {
+		t.Run(fixture, func(t *testing.T) {
+			store, dir := loadFixture(t, fixture)
+
+			if store.Count() != 3 {
+				t.Fatalf("expected 3 tasks, got %d", store.Count())
+			}
+			for id, want := range map[int]int{1: 0, 2: 1, 3: 0} {
+				syn, _ := store.Get(id)
+				if syn.DiscoveredFrom != want {
+					t.Errorf("task %d: DiscoveredFrom = %d, want %d", id, syn.DiscoveredFrom, want)
+				}
+				if syn.BlockedBy == nil {
+					t.Errorf("task %d: BlockedBy should not be nil", id)
+				}
+			}
+			if syn, _ := store.Get(3); len(syn.Extra) != 2 || string(syn.Extra[types.DiscoveredFromNote]) != `"code review"` {
+				t.Errorf("expected the estimate field and provenance note kept, got %v", syn.Extra)
+			}
+
+			// The file on disk is rewritten in the current format
+			data, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+			if string(data) != string(current) {
+				t.Errorf("migrated file differs from current format:\n got: %s\nwant: %s", data, current)
+			}
+		})
+	}
+}
+
+func TestLoad_MigrationKeepsDuplicateLines(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		`{"id":1,"title":"First copy","status":"open","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"id":1,"title":"Second copy","status":"open","created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}`,
+	}
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store := NewJSONLStore(dir)
+	if err := store.WithLock(store.Load); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Both copies survive the rewrite so doctor can still report them
+	data, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+	if !strings.Contains(string(data), "First copy") || !strings.Contains(string(data), "Second copy") {
+		t.Errorf("expected both duplicate lines kept, got:\n%s", data)
+	}
+	if err := store.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if ids := store.DuplicateIDs(); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expected duplicate ID 1 after migration, got %v", ids)
+	}
+}
+
+func TestLoad_UnlockedLeavesOlderSchemaOnDisk(t *testing.T) {
+	current, err := os.ReadFile(filepath.Join("testdata", "schema_v2.jsonl"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	for _, appendOnly := range []bool{false, true} {
+		dir := writeFixture(t, "schema_v1.jsonl")
+		legacy, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+
+		// A read without the lock upgrades in memory only
+		store := NewJSONLStore(dir)
+		store.SetAppendOnly(appendOnly)
+		if err := store.Load(); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if syn, _ := store.Get(2); syn.DiscoveredFrom != 1 {
+			t.Errorf("expected the legacy reference upgraded in memory, got %d", syn.DiscoveredFrom)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, MemoryFile)); string(data) != string(legacy) {
+			t.Errorf("append-only %v: an unlocked load rewrote the file:\n%s", appendOnly, data)
+		}
+
+		// The next save writes the whole file in the current schema
+		if err := store.Save(); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, MemoryFile)); string(data) != string(current) {
+			t.Errorf("append-only %v: saved file differs from current format:\n got: %s\nwant: %s", appendOnly, data, current)
+		}
+	}
+}
+
+func TestLoad_RejectsNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"schema_version":99}` + "\n" +
+		`{"id":1,"title":"From the future","status":"open","blocked_by":[],"created_at":"2026-01-01T00:00:00Z","updated_at":"2026-01-01T00:00:00Z"}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(content), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	err := NewJSONLStore(dir).Load()
+	if !errors.Is(err, ErrUnsupportedSchema) {
+		t.Fatalf("expected ErrUnsupportedSchema, got %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+	if string(data) != content {
+		t.Errorf("a newer file must not be rewritten, got:\n%s", data)
+	}
+}
+
+func TestSave_WritesSchemaHeader(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Create("Fresh")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, MemoryFile))
+	first, _, _ := strings.Cut(string(data), "\n")
+	if version, ok := ParseSchemaHeader([]byte(first)); !ok || version != SchemaVersion {
+		t.Errorf("expected schema %d header first, got %q", SchemaVersion, first)
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ErrParentCycle is returned by Move when the new parent is the task itself
+// or, for a subtree move, one of its descendants.
+var ErrParentCycle = errors.New("parent cycle")
+
+// TreeNode is a task with its subtasks, as reported by Move.
+type TreeNode struct {
+	ID       int          `json:"id"`
+	Title    string       `json:"title"`
+	Status   types.Status `json:"status"`
+	Children []*TreeNode  `json:"children,omitempty"`
+}
+
+// MoveResult reports the outcome of a Move.
+type MoveResult struct {
+	Task        *types.Synapse `json:"task"`
+	OldParentID int            `json:"old_parent_id"`
+	Moved       []int          `json:"moved"`      // The task plus, for a subtree move, its descendants
+	Reparented  []int          `json:"reparented"` // Children handed to the task's old parent
+	Tree        *TreeNode      `json:"tree"`       // The new parent (or the task, at top level) after the move
+}
+
+// Move makes parentID the parent of task id; 0 moves it to the top level.
+// With subtree, the task's descendants come along unchanged. Without it,
+// only the task moves and its children take its old place under its old
+// parent. Every changed task has its UpdatedAt and Version bumped.
+func (s *JSONLStore) Move(id, parentID int, subtree bool) (*MoveResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	syn, ok := s.synapses[id]
+	if !ok {
+		return nil, fmt.Errorf("synapse %d %w", id, ErrNotFound)
+	}
+	children := s.childIDs()
+	if err := s.checkParent(id, parentID, children, subtree); err != nil {
+		return nil, err
+	}
+
+	result := &MoveResult{OldParentID: syn.ParentID, Moved: []int{id}, Reparented: []int{}}
+	now := time.Now().UTC()
+	if subtree {
+		for descendant := range graph.Reachable(id, func(id int) []int { return children[id] }) {
+			result.Moved = append(result.Moved, descendant)
+		}
+		sort.Ints(result.Moved[1:])
+	} else {
+		for _, childID := range children[id] {
+			child := s.synapses[childID]
+			child.ParentID = syn.ParentID
+			child.UpdatedAt = now
+			child.Version++
+			result.Reparented = append(result.Reparented, childID)
+		}
+	}
+
+	syn.ParentID = parentID
+	syn.UpdatedAt = now
+	syn.Version++
+	result.Task = syn
+
+	root := parentID
+	if root == 0 {
+		root = id
+	}
+	result.Tree = s.tree(root, s.childIDs(), make(map[int]bool))
+	return result, nil
+}
+
+// CheckParent reports whether task id may take parentID as its parent
+// with its subtree, as a subtree Move checks: parentID must exist, and be
+// neither the task itself nor one of its descendants (ErrParentCycle). 0,
+// the top level, is always allowed. id need not exist yet.
+func (s *JSONLStore) CheckParent(id, parentID int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkParent(id, parentID, s.childIDs(), true)
+}
+
+// checkParent implements CheckParent and Move's check. Only a subtree move
+// can close a cycle through a descendant; moving the task alone hands its
+// children to its old parent first. Callers must hold s.mu.
+func (s *JSONLStore) checkParent(id, parentID int, children map[int][]int, subtree bool) error {
+	if parentID == 0 {
+		return nil
+	}
+	if _, ok := s.synapses[parentID]; !ok {
+		return fmt.Errorf("parent synapse %d %w", parentID, ErrNotFound)
+	}
+	cycle := parentID == id
+	if subtree {
+		cycle = graph.Reaches(id, parentID, func(id int) []int { return children[id] })
+	}
+	if cycle {
+		return fmt.Errorf("cannot move synapse %d under %d: %w", id, parentID, ErrParentCycle)
+	}
+	return nil
+}
+
+// childIDs maps each task ID to its children's IDs in ascending order.
+// Callers must hold s.mu.
+func (s *JSONLStore) childIDs() map[int][]int {
+	children := make(map[int][]int)
+	for id, syn := range s.synapses {
+		if syn.ParentID != 0 {
+			children[syn.ParentID] = append(children[syn.ParentID], id)
+		}
+	}
+	for _, ids := range children {
+		sort.Ints(ids)
+	}
+	return children
+}
+
+// tree builds the TreeNode rooted at id, visiting each task once so a
+// parent cycle can't recurse forever. Callers must hold s.mu.
+func (s *JSONLStore) tree(id int, children map[int][]int, seen map[int]bool) *TreeNode {
+	seen[id] = true
+	syn := s.synapses[id]
+	node := &TreeNode{ID: id, Title: syn.Title, Status: syn.Status}
+	for _, childID := range children[id] {
+		if !seen[childID] {
+			node.Children = append(node.Children, s.tree(childID, children, seen))
+		}
+	}
+	return node
+}
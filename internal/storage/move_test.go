@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newHierarchy creates 1 > 2 > 3 > 4 and a separate task 5.
+func newHierarchy(t *testing.T) *JSONLStore {
+	t.Helper()
+	store := NewJSONLStore(t.TempDir())
+	for i, title := range []string{"Epic", "Feature", "Story", "Subtask", "Other epic"} {
+		syn, _ := store.Create(title)
+		if i > 0 && i < 4 {
+			syn.ParentID = i
+		}
+	}
+	return store
+}
+
+func parentOf(t *testing.T, store *JSONLStore, id int) int {
+	t.Helper()
+	syn, err := store.Get(id)
+	if err != nil {
+		t.Fatalf("get %d: %v", id, err)
+	}
+	return syn.ParentID
+}
+
+func TestMove_Subtree(t *testing.T) {
+	store := newHierarchy(t)
+
+	result, err := store.Move(2, 5, true)
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if parentOf(t, store, 2) != 5 || parentOf(t, store, 3) != 2 || parentOf(t, store, 4) != 3 {
+		t.Error("expected the branch under 5 with its links intact")
+	}
+	if result.OldParentID != 1 || !slices.Equal(result.Moved, []int{2, 3, 4}) || len(result.Reparented) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// The reported fragment is 5 > 2 > 3 > 4
+	node := result.Tree
+	for _, want := range []int{5, 2, 3, 4} {
+		if node == nil || node.ID != want {
+			t.Fatalf("expected tree node %d, got %+v", want, node)
+		}
+		if len(node.Children) == 0 {
+			node = nil
+			continue
+		}
+		node = node.Children[0]
+	}
+}
+
+func TestMove_SingleTaskHandsChildrenToOldParent(t *testing.T) {
+	store := newHierarchy(t)
+
+	result, err := store.Move(2, 5, false)
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if parentOf(t, store, 2) != 5 || parentOf(t, store, 3) != 1 || parentOf(t, store, 4) != 3 {
+		t.Error("expected 2 under 5 and its child 3 under 1")
+	}
+	if !slices.Equal(result.Moved, []int{2}) || !slices.Equal(result.Reparented, []int{3}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if syn, _ := store.Get(3); syn.Version != 1 {
+		t.Errorf("expected the reparented child's version bumped, got %d", syn.Version)
+	}
+
+	// Without the subtree, a former descendant is a valid new parent
+	if _, err := store.Move(1, 4, false); err != nil {
+		t.Errorf("move under former descendant: %v", err)
+	}
+}
+
+func TestMove_RejectsCycles(t *testing.T) {
+	store := newHierarchy(t)
+
+	for _, tt := range []struct{ id, parent int }{{2, 2}, {2, 4}, {1, 3}} {
+		if _, err := store.Move(tt.id, tt.parent, true); !errors.Is(err, ErrParentCycle) {
+			t.Errorf("move %d under %d: expected ErrParentCycle, got %v", tt.id, tt.parent, err)
+		}
+	}
+	if _, err := store.Move(2, 99, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing parent, got %v", err)
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	store := newHierarchy(t)
+
+	for _, tt := range []struct{ id, parent int }{{2, 2}, {2, 4}, {1, 3}} {
+		if err := store.CheckParent(tt.id, tt.parent); !errors.Is(err, ErrParentCycle) {
+			t.Errorf("%d under %d: expected ErrParentCycle, got %v", tt.id, tt.parent, err)
+		}
+	}
+	if err := store.CheckParent(2, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing parent, got %v", err)
+	}
+	for _, tt := range []struct{ id, parent int }{{4, 5}, {2, 0}, {6, 4}} {
+		if err := store.CheckParent(tt.id, tt.parent); err != nil {
+			t.Errorf("%d under %d: %v", tt.id, tt.parent, err)
+		}
+	}
+}
+
+func TestMove_ToTopLevel(t *testing.T) {
+	store := newHierarchy(t)
+
+	result, err := store.Move(3, 0, true)
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if parentOf(t, store, 3) != 0 || result.Tree.ID != 3 || len(result.Tree.Children) != 1 {
+		t.Errorf("expected 3 at top level rooting its subtree, got %+v", result.Tree)
+	}
+}
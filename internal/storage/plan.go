@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ErrBlockerCycle is wrapped by the errors of planning queries whose
+// BlockedBy links loop back on themselves.
+var ErrBlockerCycle = errors.New("blocker cycle")
+
+// CycleError reports the tasks whose BlockedBy links form a cycle. It
+// matches ErrBlockerCycle with errors.Is.
+type CycleError struct {
+	IDs []int // The tasks on the cycle, in ascending order
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v between tasks %v", ErrBlockerCycle, e.IDs)
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrBlockerCycle
+}
+
+// newCycleError reports the cycle closed by reaching id again on path.
+func newCycleError(path []int, id int) *CycleError {
+	return &CycleError{IDs: graph.Cycle(path, id)}
+}
+
+// CriticalPath returns the longest chain of unfinished blockers leading to
+// task id, ending with the task itself: the chain that bounds how soon it
+// can be done. Each task counts as one step; done and missing blockers no
+// longer hold anything up and are skipped. Ties go to the lower blocker ID.
+func (s *JSONLStore) CriticalPath(id int) ([]*types.Synapse, error) {
+	return s.criticalPath(id, func(*types.Synapse) int { return 1 })
+}
+
+// CriticalPathByEstimate is CriticalPath with each task weighted by its
+// EstimateMinutes, so the chain with the most planned effort wins.
+func (s *JSONLStore) CriticalPathByEstimate(id int) ([]*types.Synapse, error) {
+	return s.criticalPath(id, func(syn *types.Synapse) int { return syn.EstimateMinutes })
+}
+
+func (s *JSONLStore) criticalPath(id int, weight func(*types.Synapse) int) ([]*types.Synapse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	target, ok := s.synapses[id]
+	if !ok {
+		return nil, fmt.Errorf("synapse %d %w", id, ErrNotFound)
+	}
+
+	// Depth-first over blockers, memoizing each task's heaviest chain.
+	// A task met again while still on the path closes a cycle.
+	length := make(map[int]int)
+	next := make(map[int]int) // Heaviest blocker to continue the chain through
+	var path []int
+	onPath := make(map[int]bool)
+
+	var visit func(syn *types.Synapse) error
+	visit = func(syn *types.Synapse) error {
+		if _, ok := length[syn.ID]; ok {
+			return nil
+		}
+		path = append(path, syn.ID)
+		onPath[syn.ID] = true
+
+		blockers := slices.Clone(syn.BlockedBy)
+		sort.Ints(blockers)
+		best, bestID := 0, 0
+		for _, blockerID := range slices.Compact(blockers) {
+			blocker, ok := s.synapses[blockerID]
+			if !ok || blocker.Status == types.StatusDone {
+				continue
+			}
+			if onPath[blockerID] {
+				return newCycleError(path, blockerID)
+			}
+			if err := visit(blocker); err != nil {
+				return err
+			}
+			if bestID == 0 || length[blockerID] > best {
+				best, bestID = length[blockerID], blockerID
+			}
+		}
+
+		length[syn.ID] = best + weight(syn)
+		next[syn.ID] = bestID
+		path = path[:len(path)-1]
+		onPath[syn.ID] = false
+		return nil
+	}
+	if err := visit(target); err != nil {
+		return nil, err
+	}
+
+	// Follow the heaviest blockers back from the target, then put the
+	// chain in execution order.
+	var chain []*types.Synapse
+	for cur := id; cur != 0; cur = next[cur] {
+		chain = append(chain, s.synapses[cur])
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// TopoOrder returns the unfinished, unarchived tasks in dependency order:
+// every task comes after the tasks it is blocked by. It is PlanWaves
+// flattened. A blocker cycle returns a *CycleError.
+func (s *JSONLStore) TopoOrder() ([]*types.Synapse, error) {
+	waves, err := s.PlanWaves()
+	if err != nil {
+		return nil, err
+	}
+	var order []*types.Synapse
+	for _, wave := range waves {
+		order = append(order, wave...)
+	}
+	return order, nil
+}
+
+// PlanWaves groups the unfinished, unarchived tasks into waves that can
+// run in sequence: the first wave needs nothing else finished, and each
+// later wave only waits on tasks in earlier ones, so the tasks within a
+// wave can be worked on in parallel. Done and missing blockers are
+// satisfied already. Within a wave tasks are ordered by priority, highest
+// first, then by ID. A blocker cycle returns a *CycleError naming the
+// tasks on it.
+func (s *JSONLStore) PlanWaves() ([][]*types.Synapse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Kahn's algorithm, one wave per round: count each pending task's
+	// pending blockers and release the tasks whose count reaches zero.
+	pending := make(map[int]int)
+	dependents := make(map[int][]int)
+	for id, syn := range s.synapses {
+		if syn.Status == types.StatusDone || syn.Archived {
+			continue
+		}
+		pending[id] = 0
+	}
+	for id := range pending {
+		blockers := slices.Clone(s.synapses[id].BlockedBy)
+		sort.Ints(blockers)
+		for _, blockerID := range slices.Compact(blockers) {
+			if _, ok := pending[blockerID]; ok {
+				pending[id]++
+				dependents[blockerID] = append(dependents[blockerID], id)
+			}
+		}
+	}
+
+	var wave []int
+	for id, count := range pending {
+		if count == 0 {
+			wave = append(wave, id)
+		}
+	}
+
+	var waves [][]*types.Synapse
+	placed := 0
+	for len(wave) > 0 {
+		tasks := make([]*types.Synapse, len(wave))
+		for i, id := range wave {
+			tasks[i] = s.synapses[id]
+		}
+		sort.Slice(tasks, func(i, j int) bool {
+			if tasks[i].Priority != tasks[j].Priority {
+				return tasks[i].Priority > tasks[j].Priority
+			}
+			return tasks[i].ID < tasks[j].ID
+		})
+		waves = append(waves, tasks)
+		placed += len(wave)
+
+		var next []int
+		for _, id := range wave {
+			for _, dependent := range dependents[id] {
+				if pending[dependent]--; pending[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		wave = next
+	}
+
+	if placed < len(pending) {
+		return nil, blockerCycle(pending, dependents)
+	}
+	return waves, nil
+}
+
+// blockerCycle names the tasks left on cycles once PlanWaves can place no
+// more: of the tasks still waiting, it repeatedly drops those no other
+// waiting task depends on, which leaves the cycles themselves.
+func blockerCycle(pending map[int]int, dependents map[int][]int) *CycleError {
+	left := make(map[int]bool)
+	for id, count := range pending {
+		if count > 0 {
+			left[id] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for id := range left {
+			if !slices.ContainsFunc(dependents[id], func(dependent int) bool { return left[dependent] }) {
+				delete(left, id)
+				changed = true
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(left))
+	for id := range left {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return &CycleError{IDs: ids}
+}
+
+// ParallelBatch suggests up to n ready tasks for n agents to take on at
+// once, chosen to be as independent as possible. Ready tasks are taken in
+// priority order (then ID), in three passes:
+//
+//  1. tasks under a parent and with an assignee not already picked
+//  2. tasks under a parent not already picked, whatever their assignee
+//  3. any remaining ready tasks
+//
+// Top-level tasks and unassigned tasks never conflict with each other. A
+// later pass only runs if the earlier ones found fewer than n tasks. The
+// batch is returned in priority order.
+func (s *JSONLStore) ParallelBatch(n int) []*types.Synapse {
+	ready := s.Ready()
+	sort.SliceStable(ready, func(i, j int) bool {
+		if ready[i].Priority != ready[j].Priority {
+			return ready[i].Priority > ready[j].Priority
+		}
+		return ready[i].ID < ready[j].ID
+	})
+
+	picked := make(map[int]bool)
+	parents := make(map[int]bool)
+	assignees := make(map[string]bool)
+	var batch []*types.Synapse
+	pick := func(distinctParent, distinctAssignee bool) {
+		for _, syn := range ready {
+			if len(batch) >= n {
+				return
+			}
+			if picked[syn.ID] ||
+				(distinctParent && syn.ParentID != 0 && parents[syn.ParentID]) ||
+				(distinctAssignee && syn.Assignee != "" && assignees[syn.Assignee]) {
+				continue
+			}
+			picked[syn.ID] = true
+			parents[syn.ParentID] = true
+			assignees[syn.Assignee] = true
+			batch = append(batch, syn)
+		}
+	}
+	pick(true, true)
+	pick(true, false)
+	pick(false, false)
+
+	sort.SliceStable(batch, func(i, j int) bool {
+		if batch[i].Priority != batch[j].Priority {
+			return batch[i].Priority > batch[j].Priority
+		}
+		return batch[i].ID < batch[j].ID
+	})
+	return batch
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func chainIDs(chain []*types.Synapse) []int {
+	ids := make([]int, len(chain))
+	for i, syn := range chain {
+		ids[i] = syn.ID
+	}
+	return ids
+}
+
+// newPlanStore creates tasks 1..n and applies blockers, keyed by the
+// blocked task.
+func newPlanStore(t *testing.T, n int, blockers map[int][]int) *JSONLStore {
+	t.Helper()
+	store := NewJSONLStore(t.TempDir())
+	for range n {
+		store.Create("Task")
+	}
+	for id, ids := range blockers {
+		syn, _ := store.Get(id)
+		syn.BlockedBy = ids
+	}
+	return store
+}
+
+func TestCriticalPath(t *testing.T) {
+	// 1 -> 2 -> 4 -> 5 and 3 -> 5: the longest chain to 5 is through 2 and 4
+	store := newPlanStore(t, 5, map[int][]int{2: {1}, 4: {2}, 5: {3, 4}})
+
+	chain, err := store.CriticalPath(5)
+	if err != nil {
+		t.Fatalf("critical path: %v", err)
+	}
+	if got := chainIDs(chain); !slices.Equal(got, []int{1, 2, 4, 5}) {
+		t.Errorf("expected chain [1 2 4 5], got %v", got)
+	}
+
+	// Finished blockers no longer hold anything up
+	done, _ := store.Get(1)
+	done.MarkDone()
+	chain, _ = store.CriticalPath(5)
+	if got := chainIDs(chain); !slices.Equal(got, []int{2, 4, 5}) {
+		t.Errorf("expected chain [2 4 5] once 1 is done, got %v", got)
+	}
+
+	// An unblocked task is its own critical path
+	chain, _ = store.CriticalPath(3)
+	if got := chainIDs(chain); !slices.Equal(got, []int{3}) {
+		t.Errorf("expected chain [3], got %v", got)
+	}
+}
+
+func TestCriticalPathByEstimate(t *testing.T) {
+	// 1 -> 2 -> 4 (two short steps) versus 3 -> 4 (one long step)
+	store := newPlanStore(t, 4, map[int][]int{2: {1}, 4: {2, 3}})
+	for id, minutes := range map[int]int{1: 30, 2: 30, 3: 120, 4: 10} {
+		syn, _ := store.Get(id)
+		syn.EstimateMinutes = minutes
+	}
+
+	byCount, _ := store.CriticalPath(4)
+	if got := chainIDs(byCount); !slices.Equal(got, []int{1, 2, 4}) {
+		t.Errorf("expected the longest chain [1 2 4], got %v", got)
+	}
+	byEstimate, err := store.CriticalPathByEstimate(4)
+	if err != nil {
+		t.Fatalf("critical path: %v", err)
+	}
+	if got := chainIDs(byEstimate); !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("expected the heaviest chain [3 4], got %v", got)
+	}
+}
+
+func TestCriticalPath_Errors(t *testing.T) {
+	store := newPlanStore(t, 4, map[int][]int{1: {3}, 2: {1}, 3: {2}, 4: {3}})
+
+	_, err := store.CriticalPath(4)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) || !errors.Is(err, ErrBlockerCycle) {
+		t.Fatalf("expected a CycleError, got %v", err)
+	}
+	if !slices.Equal(cycle.IDs, []int{1, 2, 3}) {
+		t.Errorf("expected cycle [1 2 3], got %v", cycle.IDs)
+	}
+
+	if _, err := store.CriticalPath(99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPlanWaves_Diamond(t *testing.T) {
+	// 1 blocks 2 and 3, which both block 4; 5 is independent
+	store := newPlanStore(t, 5, map[int][]int{2: {1}, 3: {1}, 4: {2, 3}})
+	independent, _ := store.Get(5)
+	independent.Priority = 3
+
+	waves, err := store.PlanWaves()
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	var got [][]int
+	for _, wave := range waves {
+		got = append(got, chainIDs(wave))
+	}
+	want := [][]int{{5, 1}, {2, 3}, {4}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("expected waves %v, got %v", want, got)
+	}
+
+	order, err := store.TopoOrder()
+	if err != nil {
+		t.Fatalf("topo order: %v", err)
+	}
+	if ids := chainIDs(order); !slices.Equal(ids, []int{5, 1, 2, 3, 4}) {
+		t.Errorf("expected order [5 1 2 3 4], got %v", ids)
+	}
+}
+
+func TestPlanWaves_SkipsFinishedWork(t *testing.T) {
+	store := newPlanStore(t, 4, map[int][]int{2: {1}, 3: {2}})
+	done, _ := store.Get(1)
+	done.MarkDone()
+	archived, _ := store.Get(4)
+	archived.MarkArchived()
+
+	waves, err := store.PlanWaves()
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(waves) != 2 || chainIDs(waves[0])[0] != 2 || chainIDs(waves[1])[0] != 3 {
+		t.Errorf("expected waves [[2] [3]], got %v", waves)
+	}
+}
+
+func TestTopoOrder_Cycle(t *testing.T) {
+	// 2 and 3 block each other; 4 waits on the cycle and 1 is free
+	store := newPlanStore(t, 4, map[int][]int{2: {3}, 3: {2}, 4: {3}})
+
+	_, err := store.TopoOrder()
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected a CycleError, got %v", err)
+	}
+	if !slices.Equal(cycle.IDs, []int{2, 3}) {
+		t.Errorf("expected cycle [2 3], got %v", cycle.IDs)
+	}
+}
+
+func TestParallelBatch(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	epic, _ := store.Create("Epic")
+	epic.Status = types.StatusInProgress
+	for _, task := range []struct {
+		title    string
+		parent   int
+		assignee string
+		priority int
+	}{
+		{"Sibling A", 1, "@coder", 9},   // 2
+		{"Sibling B", 1, "@qa", 8},      // 3: same parent as 2
+		{"Coder again", 0, "@coder", 7}, // 4: same assignee as 2
+		{"Free task", 0, "", 1},         // 5
+	} {
+		syn, _ := store.Create(task.title)
+		syn.ParentID = task.parent
+		syn.Assignee = task.assignee
+		syn.Priority = task.priority
+	}
+
+	tests := []struct {
+		n    int
+		want []int
+	}{
+		{1, []int{2}},
+		{2, []int{2, 5}},    // 3 shares a parent and 4 an assignee with 2
+		{3, []int{2, 4, 5}}, // relaxing assignees admits 4 before 3
+		{4, []int{2, 3, 4, 5}},
+		{10, []int{2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		if got := chainIDs(store.ParallelBatch(tt.n)); !slices.Equal(got, tt.want) {
+			t.Errorf("ParallelBatch(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ErrMergeConflict is wrapped by Load errors for a memory file that still
+// holds Git merge conflict markers.
+var ErrMergeConflict = errors.New("unresolved Git merge conflict marker")
+
+// conflictMarkers start the lines Git writes around conflicting hunks.
+// Task lines are JSON objects, so no real line starts with one.
+var conflictMarkers = [][]byte{
+	[]byte("<<<<<<<"), []byte("|||||||"), []byte("======="), []byte(">>>>>>>"),
+}
+
+// isConflictMarker reports whether line is a Git conflict marker line.
+func isConflictMarker(line []byte) bool {
+	for _, marker := range conflictMarkers {
+		if bytes.HasPrefix(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// StripConflictMarkers removes Git conflict marker lines from memory file
+// content, keeping the lines of both sides (and of the merge base, for
+// diff3-style conflicts). Tasks changed on both sides then appear more
+// than once, for ResolveDuplicates to sort out. It returns the cleaned
+// content and the number of lines removed.
+func StripConflictMarkers(data []byte) ([]byte, int) {
+	var out bytes.Buffer
+	removed := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if isConflictMarker(line) {
+			removed++
+			continue
+		}
+		out.Write(line)
+	}
+	return out.Bytes(), removed
+}
+
+// ResolveStrategy says what ResolveDuplicates does with the versions of a
+// task ID that appeared on more than one line.
+type ResolveStrategy string
+
+const (
+	// ResolveAuto treats versions with the same creation time as edits of
+	// one task, keeping the most recently updated, and versions created
+	// separately as different tasks, renumbering all but one.
+	ResolveAuto ResolveStrategy = "auto"
+	// ResolveKeepLatest keeps only the most recently updated version.
+	ResolveKeepLatest ResolveStrategy = "keep-latest"
+	// ResolveRenumber keeps the most recently updated version under the ID
+	// and gives every other distinct version a new ID.
+	ResolveRenumber ResolveStrategy = "renumber"
+)
+
+// Resolution reports how ResolveDuplicates settled one duplicated ID.
+type Resolution struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`                // Title of the version kept under ID
+	Versions   int    `json:"versions"`             // Lines the ID appeared on
+	Renumbered []int  `json:"renumbered,omitempty"` // New IDs of the versions kept as separate tasks
+	Dropped    int    `json:"dropped"`              // Versions discarded, counting exact copies
+}
+
+// ResolveDuplicates settles the IDs that appeared on more than one line at
+// the last Load, typically after a Git merge, using strategy. Exact copies
+// are always merged. Renumbered tasks get a note naming their old ID; links
+// to the old ID from other tasks still point at the version kept under it.
+// The caller saves the store afterwards.
+func (s *JSONLStore) ResolveDuplicates(strategy ResolveStrategy) ([]Resolution, error) {
+	switch strategy {
+	case ResolveAuto, ResolveKeepLatest, ResolveRenumber:
+	default:
+		return nil, fmt.Errorf("unknown resolve strategy %q (want auto, keep-latest or renumber)", strategy)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int, 0, len(s.shadowed))
+	for id := range s.shadowed {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	resolutions := []Resolution{}
+	for _, id := range ids {
+		versions := append(s.shadowed[id], s.synapses[id])
+		distinct, err := distinctVersions(versions)
+		if err != nil {
+			return nil, err
+		}
+
+		// The most recently updated version wins; on a tie, the later line
+		winner := distinct[0]
+		for _, syn := range distinct[1:] {
+			if !syn.UpdatedAt.Before(winner.UpdatedAt) {
+				winner = syn
+			}
+		}
+
+		var others []*types.Synapse
+		switch strategy {
+		case ResolveRenumber:
+			for _, syn := range distinct {
+				if syn != winner {
+					others = append(others, syn)
+				}
+			}
+		case ResolveAuto:
+			others = latestPerTask(distinct, winner)
+		}
+
+		res := Resolution{ID: id, Title: winner.Title, Versions: len(versions)}
+		for _, syn := range others {
+			newID := s.nextID
+			s.nextID++
+			syn.ID = newID
+			syn.AddNote(fmt.Sprintf("Renumbered from #%d by synapse resolve", id))
+			if syn.ExternalID == winner.ExternalID {
+				syn.ExternalID = ""
+			}
+			s.synapses[newID] = syn
+			s.indexExternalID(syn)
+			s.index.set(syn)
+			res.Renumbered = append(res.Renumbered, newID)
+		}
+		res.Dropped = len(versions) - 1 - len(res.Renumbered)
+
+		s.synapses[id] = winner
+		s.indexExternalID(winner)
+		s.index.set(winner)
+		delete(s.shadowed, id)
+		resolutions = append(resolutions, res)
+	}
+	return resolutions, nil
+}
+
+// distinctVersions drops exact copies from versions, keeping file order.
+func distinctVersions(versions []*types.Synapse) ([]*types.Synapse, error) {
+	seen := make(map[string]bool)
+	var distinct []*types.Synapse
+	for _, syn := range versions {
+		data, err := json.Marshal(syn)
+		if err != nil {
+			return nil, fmt.Errorf("encode synapse %d: %w", syn.ID, err)
+		}
+		if !seen[string(data)] {
+			seen[string(data)] = true
+			distinct = append(distinct, syn)
+		}
+	}
+	return distinct, nil
+}
+
+// latestPerTask groups versions by creation time, one group per task, and
+// returns the most recently updated version of each task other than the
+// winner's, in file order.
+func latestPerTask(versions []*types.Synapse, winner *types.Synapse) []*types.Synapse {
+	latest := make(map[int64]*types.Synapse)
+	var order []int64
+	for _, syn := range versions {
+		key := syn.CreatedAt.UnixNano()
+		prev, ok := latest[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || !syn.UpdatedAt.Before(prev.UpdatedAt) {
+			latest[key] = syn
+		}
+	}
+
+	var others []*types.Synapse
+	for _, key := range order {
+		if key != winner.CreatedAt.UnixNano() {
+			others = append(others, latest[key])
+		}
+	}
+	return others
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mergedMemory is memory.jsonl after merging two branches that both
+// changed task 1 and both added a task 2.
+const mergedMemory = `{"schema_version":2}
+<<<<<<< HEAD
+{"id":1,"title":"Shared","status":"open","blocked_by":[],"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-02T00:00:00Z"}
+{"id":2,"title":"Ours","status":"open","blocked_by":[],"created_at":"2025-01-02T00:00:00Z","updated_at":"2025-01-02T00:00:00Z"}
+=======
+{"id":1,"title":"Shared","status":"done","blocked_by":[],"created_at":"2025-01-01T00:00:00Z","updated_at":"2025-01-03T00:00:00Z"}
+{"id":2,"title":"Theirs","status":"open","blocked_by":[],"created_at":"2025-01-02T12:00:00Z","updated_at":"2025-01-02T12:00:00Z"}
+>>>>>>> feature
+`
+
+func TestLoad_RejectsConflictMarkers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, MemoryFile), []byte(mergedMemory), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	err := NewJSONLStore(dir).Load()
+	if !errors.Is(err, ErrMergeConflict) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a merge conflict error at line 2, got %v", err)
+	}
+}
+
+func TestStripConflictMarkers(t *testing.T) {
+	cleaned, removed := StripConflictMarkers([]byte(mergedMemory))
+	if removed != 3 {
+		t.Errorf("expected 3 marker lines removed, got %d", removed)
+	}
+	if bytes.Contains(cleaned, []byte("<<<<<<<")) || strings.Count(string(cleaned), "\n") != 5 {
+		t.Errorf("expected the header and both sides' four lines, got:\n%s", cleaned)
+	}
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	cleaned, _ := StripConflictMarkers([]byte(mergedMemory))
+
+	tests := []struct {
+		strategy ResolveStrategy
+		titles   map[int]string // Tasks left, by ID
+	}{
+		// Task 1 is one task edited twice; the two task 2s are different
+		{ResolveAuto, map[int]string{1: "Shared", 2: "Theirs", 3: "Ours"}},
+		{ResolveKeepLatest, map[int]string{1: "Shared", 2: "Theirs"}},
+		{ResolveRenumber, map[int]string{1: "Shared", 2: "Theirs", 3: "Shared", 4: "Ours"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			store := NewJSONLStore(t.TempDir())
+			if err := store.LoadFrom(bytes.NewReader(cleaned)); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			resolutions, err := store.ResolveDuplicates(tt.strategy)
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			if len(resolutions) != 2 || resolutions[0].Versions != 2 {
+				t.Errorf("expected IDs 1 and 2 resolved, got %+v", resolutions)
+			}
+			if ids := store.DuplicateIDs(); len(ids) != 0 {
+				t.Errorf("expected no duplicates left, got %v", ids)
+			}
+
+			all := store.All()
+			if len(all) != len(tt.titles) {
+				t.Fatalf("expected %d tasks, got %d", len(tt.titles), len(all))
+			}
+			for _, syn := range all {
+				if syn.Title != tt.titles[syn.ID] {
+					t.Errorf("task %d = %q, want %q", syn.ID, syn.Title, tt.titles[syn.ID])
+				}
+			}
+
+			// The latest edit of task 1 wins
+			if syn, _ := store.Get(1); syn.Status != "done" {
+				t.Errorf("expected the done version of task 1 kept, got %s", syn.Status)
+			}
+			if tt.strategy != ResolveKeepLatest {
+				if syn, _ := store.Get(3); len(syn.Notes) != 1 {
+					t.Errorf("expected a renumbering note on task 3, got %v", syn.Notes)
+				}
+			}
+		})
+	}
+
+	if _, err := NewJSONLStore(t.TempDir()).ResolveDuplicates("newest"); err == nil {
+		t.Error("expected error for an unknown strategy")
+	}
+}
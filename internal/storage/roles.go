@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// RolesFile is the optional JSON file mapping roles to agent IDs.
+	RolesFile = "roles.json"
+)
+
+// Roles maps a role assignee such as "@qa" to the agent IDs that fill it,
+// so work assigned to the role can be routed to any of them.
+type Roles map[string][]string
+
+// LoadRoles reads roles.json from dir. A missing file means no roles and
+// is not an error.
+func LoadRoles(dir string) (Roles, error) {
+	data, err := os.ReadFile(filepath.Join(dir, RolesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Roles{}, nil
+		}
+		return nil, fmt.Errorf("read roles file: %w", err)
+	}
+
+	roles := Roles{}
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("parse roles file: %w", err)
+	}
+	return roles, nil
+}
+
+// Save writes the roles to dir, via a temp file and rename.
+func (r Roles) Save(dir string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode roles: %w", err)
+	}
+
+	filePath := filepath.Join(dir, RolesFile)
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
+
+// Add makes agents members of role, keeping the members sorted and
+// unique.
+func (r Roles) Add(role string, agents ...string) {
+	members := append(r[role], agents...)
+	sort.Strings(members)
+	r[role] = slices.Compact(members)
+}
+
+// Remove drops agents from role, or the whole role if no agents are
+// given or none remain. It reports whether anything was removed.
+func (r Roles) Remove(role string, agents ...string) bool {
+	members, ok := r[role]
+	if !ok {
+		return false
+	}
+	if len(agents) == 0 {
+		delete(r, role)
+		return true
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(members), func(agent string) bool {
+		return slices.Contains(agents, agent)
+	})
+	if len(kept) == len(members) {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(r, role)
+	} else {
+		r[role] = kept
+	}
+	return true
+}
+
+// Names returns the role names in order.
+func (r Roles) Names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Expand returns the assignees that count as assignee: itself plus, if it
+// is a role, each of its members.
+func (r Roles) Expand(assignee string) []string {
+	return append([]string{assignee}, r[assignee]...)
+}
+
+// SetRoles sets the role memberships ByRole and ReadyFor expand, e.g.
+// from the project's roles.json.
+func (s *JSONLStore) SetRoles(roles Roles) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roles = roles
+}
+
+// ByRole returns the synapses assigned to role or to any agent filling
+// it, in ID order.
+func (s *JSONLStore) ByRole(role string) []*types.Synapse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assignees := s.roles.Expand(role)
+	var result []*types.Synapse
+	for _, syn := range s.synapses {
+		if slices.Contains(assignees, syn.Assignee) {
+			result = append(result, syn)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRoles_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	roles, err := LoadRoles(dir)
+	if err != nil || len(roles) != 0 {
+		t.Fatalf("expected no roles without a file, got %v, %v", roles, err)
+	}
+
+	roles.Add("@qa", "bob", "alice")
+	roles.Add("@qa", "alice")
+	roles.Add("@coder", "carol")
+	if err := roles.Save(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := LoadRoles(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(loaded["@qa"], []string{"alice", "bob"}) || !slices.Equal(loaded.Names(), []string{"@coder", "@qa"}) {
+		t.Errorf("unexpected roles after round-trip: %v", loaded)
+	}
+}
+
+func TestRoles_Remove(t *testing.T) {
+	roles := Roles{"@qa": {"alice", "bob"}, "@coder": {"carol"}}
+
+	if !roles.Remove("@qa", "bob") || !slices.Equal(roles["@qa"], []string{"alice"}) {
+		t.Errorf("expected bob removed, got %v", roles["@qa"])
+	}
+	if roles.Remove("@qa", "nobody") {
+		t.Error("removing a non-member should report nothing removed")
+	}
+	if !roles.Remove("@qa", "alice") {
+		t.Error("expected alice removed")
+	}
+	if _, ok := roles["@qa"]; ok {
+		t.Error("a role with no members left should be dropped")
+	}
+	if !roles.Remove("@coder") || len(roles) != 0 {
+		t.Errorf("expected the whole role removed, got %v", roles)
+	}
+}
+
+func TestByRole(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	store.SetRoles(Roles{"@qa": {"alice", "bob"}})
+
+	for _, assignee := range []string{"@qa", "alice", "carol", "bob"} {
+		syn, _ := store.Create("Task for " + assignee)
+		syn.Assignee = assignee
+	}
+
+	var ids []int
+	for _, syn := range store.ByRole("@qa") {
+		ids = append(ids, syn.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 4}) {
+		t.Errorf("ByRole(@qa) = %v, want [1 2 4]", ids)
+	}
+	if got := store.ReadyFor("@qa"); len(got) != 3 {
+		t.Errorf("expected ReadyFor to expand the role, got %d tasks", len(got))
+	}
+	if got := store.ByRole("alice"); len(got) != 1 {
+		t.Errorf("an agent ID is not a role, expected 1 task, got %d", len(got))
+	}
+}
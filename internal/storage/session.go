@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// BySession returns the tasks an agent session touched, in ID order: those
+// it last created, claimed or completed, and any other task with an audit
+// event recorded in the session. Deleted tasks are left out; their events
+// remain in the log.
+func (s *JSONLStore) BySession(session string) ([]*types.Synapse, error) {
+	if session == "" {
+		return nil, nil
+	}
+	events, err := s.events.ForSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	touched := make(map[int]bool)
+	for _, e := range events {
+		touched[e.TaskID] = true
+	}
+	var result []*types.Synapse
+	for id, syn := range s.synapses {
+		if touched[id] || syn.SessionID == session {
+			result = append(result, syn)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
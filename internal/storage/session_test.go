@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestBySession(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	before, _ := store.Create("Before any session")
+	store.Save()
+
+	store.SetSession("run-1")
+	created, _ := store.Create("Created in run-1")
+	before.AddNote("Touched in run-1")
+	store.Save()
+
+	store.SetSession("run-2")
+	created.Claim("agent-1", types.DefaultClaimTimeout)
+	store.Save()
+
+	tasks, err := store.BySession("run-1")
+	if err != nil {
+		t.Fatalf("by session: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != before.ID || tasks[1].ID != created.ID {
+		t.Fatalf("expected tasks %d and %d in run-1, got %+v", before.ID, created.ID, tasks)
+	}
+	if before.SessionID != "" {
+		t.Errorf("a note should not stamp the session, got %q", before.SessionID)
+	}
+	if created.SessionID != "run-2" {
+		t.Errorf("expected the claim to stamp run-2, got %q", created.SessionID)
+	}
+
+	events, err := store.Events().ForSession("run-2")
+	if err != nil {
+		t.Fatalf("for session: %v", err)
+	}
+	if len(events) != 2 || events[0].Event != types.EventClaimed || events[0].Session != "run-2" {
+		t.Errorf("expected claim and status events in run-2, got %+v", events)
+	}
+
+	// The stamp survives a reload
+	reloaded := NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if syn, _ := reloaded.Get(created.ID); syn.SessionID != "run-2" {
+		t.Errorf("reloaded session = %q, want run-2", syn.SessionID)
+	}
+
+	if tasks, _ := store.BySession("unknown"); len(tasks) != 0 {
+		t.Errorf("expected no tasks for an unknown session, got %+v", tasks)
+	}
+}
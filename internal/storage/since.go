@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// ParseAge parses a duration that may use day (d) or week (w) units in
+// addition to those accepted by time.ParseDuration, e.g. "30d" or "2w".
+func ParseAge(s string) (time.Duration, error) {
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		count, err := strconv.Atoi(s[:n-1])
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		unit := 24 * time.Hour
+		if s[n-1] == 'w' {
+			unit *= 7
+		}
+		return time.Duration(count) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+// ParseSince parses a point in time given either as an RFC3339 timestamp
+// or as an age relative to now, e.g. "24h" or "7d".
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	age, err := ParseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC3339 or a relative age like 24h or 7d", s)
+	}
+	return now.UTC().Add(-age), nil
+}
+
+// ParseDue parses a deadline given as an RFC3339 timestamp, a date
+// (2006-01-02, due by the end of that day UTC), or a duration from now,
+// e.g. "48h" or "3d".
+func ParseDue(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	if day, err := time.Parse(time.DateOnly, s); err == nil {
+		return day.Add(24*time.Hour - time.Second), nil
+	}
+	d, err := ParseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date %q: want RFC3339, YYYY-MM-DD, or a duration from now like 48h or 3d", s)
+	}
+	return now.UTC().Add(d), nil
+}
+
+// Intersect returns the synapses in a that also appear in b, keeping a's
+// order. It combines a store query with further filters.
+func Intersect(a, b []*types.Synapse) []*types.Synapse {
+	ids := make(map[int]bool, len(b))
+	for _, syn := range b {
+		ids[syn.ID] = true
+	}
+
+	var result []*types.Synapse
+	for _, syn := range a {
+		if ids[syn.ID] {
+			result = append(result, syn)
+		}
+	}
+	return result
+}
+
+// Unarchived returns the synapses that aren't archived, keeping their
+// order. Listings apply it unless archived tasks are asked for.
+func Unarchived(synapses []*types.Synapse) []*types.Synapse {
+	var result []*types.Synapse
+	for _, syn := range synapses {
+		if !syn.Archived {
+			result = append(result, syn)
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90m", 90 * time.Minute, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"-1d", 0, true},
+		{"-5h", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseSince("24h", now)
+	if err != nil || !got.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("relative: got %v, %v", got, err)
+	}
+
+	got, err = ParseSince("2025-06-01T08:00:00+02:00", now)
+	if err != nil || !got.Equal(time.Date(2025, 6, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC3339: got %v, %v", got, err)
+	}
+
+	if _, err := ParseSince("yesterday", now); err == nil {
+		t.Error("expected error for unparseable time")
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseDue("3d", now)
+	if err != nil || !got.Equal(now.Add(72*time.Hour)) {
+		t.Errorf("relative: got %v, %v", got, err)
+	}
+
+	got, err = ParseDue("2025-06-20", now)
+	if err != nil || !got.Equal(time.Date(2025, 6, 20, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("date: expected end of day, got %v, %v", got, err)
+	}
+
+	got, err = ParseDue("2025-06-20T09:00:00Z", now)
+	if err != nil || !got.Equal(time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC3339: got %v, %v", got, err)
+	}
+
+	if _, err := ParseDue("next week", now); err == nil {
+		t.Error("expected error for unparseable due date")
+	}
+}
+
+func TestCreatedSince(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	old, _ := store.Create("Old task")
+	old.CreatedAt = time.Now().UTC().Add(-48 * time.Hour)
+	fresh, _ := store.Create("Fresh task")
+
+	got := store.CreatedSince(time.Now().UTC().Add(-time.Hour))
+	if len(got) != 1 || got[0].ID != fresh.ID {
+		t.Errorf("expected only task %d, got %v", fresh.ID, got)
+	}
+
+	// Intersect keeps the first list's order and drops the rest
+	all := store.All()
+	if got := Intersect(all, got); len(got) != 1 || got[0].ID != fresh.ID {
+		t.Errorf("Intersect: expected only task %d, got %v", fresh.ID, got)
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	due := func(title string, offset time.Duration) *types.Synapse {
+		syn, _ := store.Create(title)
+		at := time.Now().UTC().Add(offset)
+		syn.DueAt = &at
+		return syn
+	}
+	slightly := due("Slightly late", -time.Hour)
+	very := due("Very late", -72*time.Hour)
+	due("Not due yet", time.Hour)
+	finished := due("Late but done", -48*time.Hour)
+	finished.MarkDone()
+	store.Create("No deadline")
+
+	got := store.Overdue()
+	if len(got) != 2 || got[0].ID != very.ID || got[1].ID != slightly.ID {
+		t.Errorf("expected [%d %d] most overdue first, got %v", very.ID, slightly.ID, got)
+	}
+}
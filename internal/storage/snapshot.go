@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// SnapshotsDir holds the safety copies made by CreateSnapshot, one
+	// subdirectory per snapshot.
+	SnapshotsDir = "snapshots"
+)
+
+// snapshotFiles are the files a snapshot captures.
+var snapshotFiles = []string{MemoryFile, BreadcrumbFile}
+
+// ErrSnapshotExists is returned by CreateSnapshot when a snapshot with the
+// requested name already exists and force is not set.
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// Snapshot describes a saved copy of the task and breadcrumb files.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []string  `json:"files"` // Files present when the snapshot was taken
+}
+
+// CreateSnapshot copies memory.jsonl and breadcrumbs.jsonl from dir into
+// dir/snapshots/<name>. An empty name uses the current UTC timestamp. The
+// copy is assembled in a temporary directory and renamed into place, so a
+// failed snapshot never leaves a partial one behind. Callers should hold
+// the store lock so the two files are copied in a consistent state.
+func CreateSnapshot(dir, name string, force bool) (*Snapshot, error) {
+	if name == "" {
+		name = time.Now().UTC().Format("20060102T150405Z")
+	}
+	if err := validateSnapshotName(name); err != nil {
+		return nil, err
+	}
+
+	root := filepath.Join(dir, SnapshotsDir)
+	target := filepath.Join(root, name)
+	if _, err := os.Stat(target); err == nil && !force {
+		return nil, fmt.Errorf("%w: %s (use --force to overwrite)", ErrSnapshotExists, name)
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("create snapshots directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(root, ".tmp-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	snap := &Snapshot{Name: name, Files: []string{}}
+	for _, file := range snapshotFiles {
+		copied, err := copyFile(filepath.Join(dir, file), filepath.Join(tmp, file))
+		if err != nil {
+			return nil, err
+		}
+		if copied {
+			snap.Files = append(snap.Files, file)
+		}
+	}
+
+	// Move any snapshot being overwritten aside first, so it is only
+	// discarded once the new one is in place
+	old := tmp + "-old"
+	if err := os.Rename(target, old); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("replace snapshot %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Rename(old, target)
+		return nil, fmt.Errorf("rename snapshot %s: %w", name, err)
+	}
+	os.RemoveAll(old)
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("stat snapshot %s: %w", name, err)
+	}
+	snap.CreatedAt = info.ModTime().UTC()
+	return snap, nil
+}
+
+// ListSnapshots returns the snapshots in dir, oldest first.
+func ListSnapshots(dir string) ([]Snapshot, error) {
+	root := filepath.Join(dir, SnapshotsDir)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, fmt.Errorf("read snapshots directory: %w", err)
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		snap, err := readSnapshot(root, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// RestoreSnapshot replaces memory.jsonl and breadcrumbs.jsonl in dir with
+// the copies in the named snapshot. A file the snapshot does not contain
+// did not exist when it was taken, so it is removed. Each file is replaced
+// atomically; callers should hold the store lock and reload afterwards.
+func RestoreSnapshot(dir, name string) (*Snapshot, error) {
+	if err := validateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	root := filepath.Join(dir, SnapshotsDir)
+	snap, err := readSnapshot(root, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range snapshotFiles {
+		dst := filepath.Join(dir, file)
+		tmpPath := dst + ".tmp"
+		copied, err := copyFile(filepath.Join(root, name, file), tmpPath)
+		if err != nil {
+			return nil, err
+		}
+		if !copied {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("remove %s: %w", file, err)
+			}
+			continue
+		}
+		if err := os.Rename(tmpPath, dst); err != nil {
+			os.Remove(tmpPath)
+			return nil, fmt.Errorf("rename temp file: %w", err)
+		}
+	}
+	return snap, nil
+}
+
+// readSnapshot describes the snapshot directory root/name.
+func readSnapshot(root, name string) (*Snapshot, error) {
+	info, err := os.Stat(filepath.Join(root, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot not found: %s", name)
+		}
+		return nil, fmt.Errorf("stat snapshot %s: %w", name, err)
+	}
+
+	snap := &Snapshot{Name: name, CreatedAt: info.ModTime().UTC(), Files: []string{}}
+	for _, file := range snapshotFiles {
+		if _, err := os.Stat(filepath.Join(root, name, file)); err == nil {
+			snap.Files = append(snap.Files, file)
+		}
+	}
+	return snap, nil
+}
+
+// validateSnapshotName rejects names that would escape the snapshots
+// directory or collide with temporary directories.
+func validateSnapshotName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+// copyFile copies src to dst, reporting false without error if src does
+// not exist.
+func copyFile(src, dst string) (bool, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("open %s: %w", filepath.Base(src), err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return false, fmt.Errorf("create %s: %w", filepath.Base(dst), err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return false, fmt.Errorf("copy %s: %w", filepath.Base(src), err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return false, fmt.Errorf("close %s: %w", filepath.Base(dst), err)
+	}
+	return true, nil
+}
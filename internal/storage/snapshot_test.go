@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Create("Keep me")
+	store.Save()
+	bcStore := NewBreadcrumbStore(dir)
+	bcStore.Set("db", "postgres", 0)
+	bcStore.Save()
+
+	snap, err := CreateSnapshot(dir, "before-import", false)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if !slices.Equal(snap.Files, []string{MemoryFile, BreadcrumbFile}) {
+		t.Errorf("expected both files captured, got %v", snap.Files)
+	}
+
+	// A risky operation goes wrong
+	store.DeleteAll()
+	store.Save()
+	os.Remove(filepath.Join(dir, BreadcrumbFile))
+
+	if _, err := RestoreSnapshot(dir, "before-import"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	store.Load()
+	if syn, err := store.Get(1); err != nil || syn.Title != "Keep me" {
+		t.Errorf("expected task restored, got %v, %v", syn, err)
+	}
+	bcStore = NewBreadcrumbStore(dir)
+	bcStore.Load()
+	if b, ok := bcStore.Get("db"); !ok || b.Value != "postgres" {
+		t.Errorf("expected breadcrumb restored, got %v", b)
+	}
+}
+
+func TestCreateSnapshot_RefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJSONLStore(dir)
+	store.Create("First")
+	store.Save()
+
+	if _, err := CreateSnapshot(dir, "label", false); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	store.Create("Second")
+	store.Save()
+
+	if _, err := CreateSnapshot(dir, "label", false); !errors.Is(err, ErrSnapshotExists) {
+		t.Fatalf("expected ErrSnapshotExists, got %v", err)
+	}
+	if _, err := CreateSnapshot(dir, "label", true); err != nil {
+		t.Fatalf("forced snapshot: %v", err)
+	}
+
+	// The forced snapshot holds the newer state; a missing breadcrumb file
+	// is restored as missing
+	store.DeleteAll()
+	store.Save()
+	snap, err := RestoreSnapshot(dir, "label")
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !slices.Equal(snap.Files, []string{MemoryFile}) {
+		t.Errorf("expected only memory file in snapshot, got %v", snap.Files)
+	}
+	store.Load()
+	if store.Count() != 2 {
+		t.Errorf("expected 2 tasks after restoring forced snapshot, got %d", store.Count())
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	if snapshots, err := ListSnapshots(dir); err != nil || len(snapshots) != 0 {
+		t.Fatalf("expected no snapshots, got %v, %v", snapshots, err)
+	}
+
+	CreateSnapshot(dir, "one", false)
+	CreateSnapshot(dir, "two", false)
+	snapshots, err := ListSnapshots(dir)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %v", snapshots)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, SnapshotsDir))
+	if len(entries) != 2 {
+		t.Errorf("expected no temp directories left behind, got %d entries", len(entries))
+	}
+}
+
+func TestSnapshotName_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"../escape", "a/b", ".hidden"} {
+		if _, err := CreateSnapshot(dir, name, false); err == nil {
+			t.Errorf("CreateSnapshot(%q): expected error", name)
+		}
+		if _, err := RestoreSnapshot(dir, name); err == nil {
+			t.Errorf("RestoreSnapshot(%q): expected error", name)
+		}
+	}
+	if _, err := RestoreSnapshot(dir, "missing"); err == nil {
+		t.Error("expected error restoring a missing snapshot")
+	}
+}
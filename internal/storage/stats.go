@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// EffortTask is one done task's estimate next to the time it took.
+type EffortTask struct {
+	ID              int     `json:"id"`
+	Title           string  `json:"title"`
+	EstimateMinutes int     `json:"estimate_minutes"`
+	ActualMinutes   int     `json:"actual_minutes"`
+	Ratio           float64 `json:"ratio"` // Actual / estimate; above 1 means it took longer than planned
+}
+
+// EffortStats summarizes estimate-vs-actual accuracy across done tasks.
+type EffortStats struct {
+	Measured     int          `json:"measured"`      // Done tasks with both an estimate and an actual time
+	Unestimated  int          `json:"unestimated"`   // Done tasks with an actual time but no estimate
+	Unmeasured   int          `json:"unmeasured"`    // Done tasks with an estimate but never claimed
+	EstimateSum  int          `json:"estimate_sum"`  // Minutes, over measured tasks
+	ActualSum    int          `json:"actual_sum"`    // Minutes, over measured tasks
+	Ratio        float64      `json:"ratio"`         // ActualSum / EstimateSum
+	WithinTarget int          `json:"within_target"` // Measured tasks whose actual was within 25% of the estimate
+	Tasks        []EffortTask `json:"tasks"`         // Measured tasks in ID order
+}
+
+// effortTolerance is how far actual time may stray from the estimate, as a
+// fraction of it, for the estimate to count as accurate.
+const effortTolerance = 0.25
+
+// EffortStats compares estimated with actual minutes across done tasks.
+func (s *JSONLStore) EffortStats() EffortStats {
+	stats := EffortStats{Tasks: []EffortTask{}}
+	for _, syn := range s.ByStatus(types.StatusDone) {
+		switch {
+		case syn.EstimateMinutes > 0 && syn.ActualMinutes > 0:
+			ratio := float64(syn.ActualMinutes) / float64(syn.EstimateMinutes)
+			stats.Tasks = append(stats.Tasks, EffortTask{
+				ID:              syn.ID,
+				Title:           syn.Title,
+				EstimateMinutes: syn.EstimateMinutes,
+				ActualMinutes:   syn.ActualMinutes,
+				Ratio:           ratio,
+			})
+			stats.Measured++
+			stats.EstimateSum += syn.EstimateMinutes
+			stats.ActualSum += syn.ActualMinutes
+			if ratio >= 1-effortTolerance && ratio <= 1+effortTolerance {
+				stats.WithinTarget++
+			}
+		case syn.ActualMinutes > 0:
+			stats.Unestimated++
+		case syn.EstimateMinutes > 0:
+			stats.Unmeasured++
+		}
+	}
+	if stats.EstimateSum > 0 {
+		stats.Ratio = float64(stats.ActualSum) / float64(stats.EstimateSum)
+	}
+	return stats
+}
+
+// Workload counts one assignee's tasks by status.
+type Workload struct {
+	Assignee     string `json:"assignee"` // Empty for the unassigned bucket
+	Open         int    `json:"open"`
+	InProgress   int    `json:"in_progress"`
+	Blocked      int    `json:"blocked"`
+	Review       int    `json:"review"`
+	Done         int    `json:"done"`
+	ActiveClaims int    `json:"active_claims"` // Unfinished tasks with an unexpired claim (see ClaimTimeout)
+}
+
+// Pending is the work not yet finished or waiting: open plus in-progress.
+func (w Workload) Pending() int {
+	return w.Open + w.InProgress
+}
+
+// WorkloadByAssignee summarizes how the unarchived tasks are spread across
+// assignees, including an unassigned bucket if any task has no assignee.
+// The busiest assignees, by Pending, come first; ties are in assignee
+// order.
+func (s *JSONLStore) WorkloadByAssignee() []Workload {
+	timeout := s.ClaimTimeout()
+	byAssignee := make(map[string]*Workload)
+	for _, syn := range Unarchived(s.All()) {
+		w, ok := byAssignee[syn.Assignee]
+		if !ok {
+			w = &Workload{Assignee: syn.Assignee}
+			byAssignee[syn.Assignee] = w
+		}
+		switch syn.Status {
+		case types.StatusOpen:
+			w.Open++
+		case types.StatusInProgress:
+			w.InProgress++
+		case types.StatusBlocked:
+			w.Blocked++
+		case types.StatusReview:
+			w.Review++
+		case types.StatusDone:
+			w.Done++
+		}
+		if syn.Status != types.StatusDone && syn.ClaimedBy != "" && !syn.IsClaimExpired(timeout) {
+			w.ActiveClaims++
+		}
+	}
+
+	workloads := make([]Workload, 0, len(byAssignee))
+	for _, w := range byAssignee {
+		workloads = append(workloads, *w)
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Pending() != workloads[j].Pending() {
+			return workloads[i].Pending() > workloads[j].Pending()
+		}
+		return workloads[i].Assignee < workloads[j].Assignee
+	})
+	return workloads
+}
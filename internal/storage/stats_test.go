@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestEffortStats(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+
+	finish := func(title string, estimate int, took time.Duration) *types.Synapse {
+		syn, _ := store.Create(title)
+		syn.EstimateMinutes = estimate
+		if took > 0 {
+			claimedAt := time.Now().UTC().Add(-took)
+			syn.ClaimedBy, syn.ClaimedAt = "agent-1", &claimedAt
+		}
+		store.Complete(syn, "")
+		return syn
+	}
+	onTime := finish("On time", 60, 70*time.Minute)
+	late := finish("Late", 30, 90*time.Minute)
+	finish("No estimate", 0, 20*time.Minute)
+	finish("Never claimed", 45, 0)
+	open, _ := store.Create("Still open")
+	open.EstimateMinutes = 10
+
+	stats := store.EffortStats()
+	if stats.Measured != 2 || stats.Unestimated != 1 || stats.Unmeasured != 1 {
+		t.Errorf("expected 2 measured, 1 unestimated, 1 unmeasured, got %+v", stats)
+	}
+	if stats.EstimateSum != 90 || stats.ActualSum != 160 {
+		t.Errorf("expected sums 90/160, got %d/%d", stats.EstimateSum, stats.ActualSum)
+	}
+	if math.Abs(stats.Ratio-160.0/90.0) > 1e-9 {
+		t.Errorf("unexpected ratio %f", stats.Ratio)
+	}
+	if stats.WithinTarget != 1 {
+		t.Errorf("expected only task %d within target, got %d", onTime.ID, stats.WithinTarget)
+	}
+	if len(stats.Tasks) != 2 || stats.Tasks[1].ID != late.ID || stats.Tasks[1].Ratio != 3 {
+		t.Errorf("unexpected per-task breakdown: %+v", stats.Tasks)
+	}
+}
+
+func TestWorkloadByAssignee(t *testing.T) {
+	store := NewJSONLStore(t.TempDir())
+	add := func(assignee string, status types.Status) *types.Synapse {
+		syn, _ := store.Create("Task")
+		syn.Assignee = assignee
+		syn.Status = status
+		return syn
+	}
+	add("@qa", types.StatusOpen)
+	add("@coder", types.StatusOpen)
+	add("@coder", types.StatusOpen)
+	claimed := add("@coder", types.StatusOpen)
+	claimed.Claim("agent-1", 30*time.Minute)
+	add("@coder", types.StatusDone)
+	add("", types.StatusBlocked)
+	add("", types.StatusOpen)
+	add("@idle", types.StatusDone)
+	add("@qa", types.StatusOpen).MarkArchived()
+
+	workloads := store.WorkloadByAssignee()
+	want := []Workload{
+		{Assignee: "@coder", Open: 2, InProgress: 1, Done: 1, ActiveClaims: 1},
+		{Assignee: "", Open: 1, Blocked: 1},
+		{Assignee: "@qa", Open: 1},
+		{Assignee: "@idle", Done: 1},
+	}
+	if len(workloads) != len(want) {
+		t.Fatalf("expected %d buckets, got %+v", len(want), workloads)
+	}
+	for i := range want {
+		if workloads[i] != want[i] {
+			t.Errorf("bucket %d: got %+v, want %+v", i, workloads[i], want[i])
+		}
+	}
+}
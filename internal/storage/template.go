@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// TemplateFile is the JSONL file for recurring-task templates.
+	TemplateFile = "templates.jsonl"
+)
+
+// TemplateStore manages JSONL-based persistence for task templates. It is
+// kept apart from the task store so templates never appear in list or
+// ready results.
+type TemplateStore struct {
+	mu        sync.RWMutex
+	dir       string
+	templates map[string]*types.Template
+}
+
+// NewTemplateStore creates a new template store at the given directory.
+func NewTemplateStore(dir string) *TemplateStore {
+	return &TemplateStore{
+		dir:       dir,
+		templates: make(map[string]*types.Template),
+	}
+}
+
+// Load reads all templates from the JSONL file into memory.
+func (s *TemplateStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.filePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Empty store is valid
+		}
+		return fmt.Errorf("open templates file: %w", err)
+	}
+	defer file.Close()
+
+	s.templates = make(map[string]*types.Template)
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var t types.Template
+		if err := json.Unmarshal(line, &t); err != nil {
+			return fmt.Errorf("parse line %d: %w", lineNum, err)
+		}
+
+		s.templates[t.Name] = &t
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scan templates file: %w", err)
+	}
+
+	return nil
+}
+
+// Save writes all templates to the JSONL file in name order.
+func (s *TemplateStore) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filePath := s.filePath()
+	tmpPath := filePath + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, t := range s.sorted() {
+		if err := encoder.Encode(t); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("encode template %s: %w", t.Name, err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+// Set creates or replaces a template, keeping the original CreatedAt and
+// LastInstantiated. Returns true if created, false if replaced. The
+// Interval, if any, must parse with ParseAge.
+func (s *TemplateStore) Set(t *types.Template) (created bool, err error) {
+	if t.Name == "" || t.Title == "" {
+		return false, fmt.Errorf("template name and title are required")
+	}
+	if t.Interval != "" {
+		if _, err := ParseAge(t.Interval); err != nil {
+			return false, fmt.Errorf("invalid interval: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	t.UpdatedAt = now
+	if existing, ok := s.templates[t.Name]; ok {
+		t.CreatedAt = existing.CreatedAt
+		t.LastInstantiated = existing.LastInstantiated
+	} else {
+		t.CreatedAt = now
+		created = true
+	}
+	s.templates[t.Name] = t
+	return created, nil
+}
+
+// Get retrieves a template by name.
+func (s *TemplateStore) Get(name string) (*types.Template, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.templates[name]
+	return t, ok
+}
+
+// Delete removes a template by name. Returns true if deleted, false if not found.
+func (s *TemplateStore) Delete(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.templates[name]; !ok {
+		return false
+	}
+	delete(s.templates, name)
+	return true
+}
+
+// List returns all templates sorted by name.
+func (s *TemplateStore) List() []*types.Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sorted()
+}
+
+// Instantiate creates a fresh task in tasks from the named template and
+// records when it was used. The caller is responsible for saving both
+// stores afterwards.
+func (s *TemplateStore) Instantiate(name string, tasks *JSONLStore) (*types.Synapse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template not found: %s", name)
+	}
+	var interval time.Duration
+	if t.Interval != "" {
+		d, err := ParseAge(t.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: invalid interval: %w", name, err)
+		}
+		interval = d
+	}
+
+	syn, err := tasks.Create(t.Title)
+	if err != nil {
+		return nil, err
+	}
+	t.Apply(syn, interval, time.Now().UTC())
+	if err := tasks.Update(syn); err != nil {
+		return nil, err
+	}
+	return syn, nil
+}
+
+// sorted returns the templates in name order. Callers must hold s.mu.
+func (s *TemplateStore) sorted() []*types.Template {
+	result := make([]*types.Template, 0, len(s.templates))
+	for _, t := range s.templates {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// filePath returns the full path to the templates file.
+func (s *TemplateStore) filePath() string {
+	return filepath.Join(s.dir, TemplateFile)
+}
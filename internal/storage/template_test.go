@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestTemplateStore_Instantiate(t *testing.T) {
+	dir := t.TempDir()
+	tasks := NewJSONLStore(dir)
+	templates := NewTemplateStore(dir)
+
+	if _, err := templates.Set(&types.Template{
+		Name:     "rotate-creds",
+		Title:    "Rotate credentials",
+		Labels:   []string{"security", "chore"},
+		Assignee: "@ops",
+		Interval: "7d",
+	}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	templates.Save()
+
+	// Templates are not tasks
+	if tasks.Count() != 0 {
+		t.Fatalf("defining a template created a task")
+	}
+
+	reloaded := NewTemplateStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	first, err := reloaded.Instantiate("rotate-creds", tasks)
+	if err != nil {
+		t.Fatalf("instantiate: %v", err)
+	}
+	second, _ := reloaded.Instantiate("rotate-creds", tasks)
+
+	if first.ID == second.ID || tasks.Count() != 2 {
+		t.Errorf("expected two distinct tasks, got %d and %d", first.ID, second.ID)
+	}
+	if first.Title != "Rotate credentials" || first.Assignee != "@ops" || !slices.Equal(first.Labels, []string{"security", "chore"}) {
+		t.Errorf("template fields not applied: %+v", first)
+	}
+	if first.DueAt == nil || first.DueAt.Sub(time.Now()) < 6*24*time.Hour {
+		t.Errorf("expected task due in about a week, got %v", first.DueAt)
+	}
+	// Tasks don't share the template's label slice
+	first.Labels[0] = "changed"
+	if tmpl, _ := reloaded.Get("rotate-creds"); tmpl.Labels[0] != "security" || tmpl.LastInstantiated == nil {
+		t.Errorf("unexpected template after instantiating: %+v", tmpl)
+	}
+
+	if _, err := reloaded.Instantiate("missing", tasks); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestTemplateStore_Set(t *testing.T) {
+	templates := NewTemplateStore(t.TempDir())
+
+	if _, err := templates.Set(&types.Template{Name: "bad", Title: "Bad", Interval: "weekly"}); err == nil {
+		t.Error("expected error for invalid interval")
+	}
+	if _, err := templates.Set(&types.Template{Name: "untitled"}); err == nil {
+		t.Error("expected error for missing title")
+	}
+
+	created, _ := templates.Set(&types.Template{Name: "chore", Title: "First"})
+	original, _ := templates.Get("chore")
+	createdAt := original.CreatedAt
+	replaced, _ := templates.Set(&types.Template{Name: "chore", Title: "Second"})
+	if !created || replaced {
+		t.Errorf("expected created then replaced, got %v %v", created, replaced)
+	}
+	if got, _ := templates.Get("chore"); got.Title != "Second" || !got.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected title replaced and CreatedAt kept, got %+v", got)
+	}
+}
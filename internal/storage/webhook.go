@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+const (
+	// WebhooksFile is the optional JSON config listing webhook URLs.
+	WebhooksFile = "webhooks.json"
+)
+
+// EventTest is the event type of the payload sent by `synapse webhook test`.
+const EventTest types.EventType = "test"
+
+// Webhook delivery limits. A full queue drops payloads rather than
+// blocking the Save that produced them.
+const (
+	webhookQueueSize = 256
+	webhookAttempts  = 3
+	webhookTimeout   = 5 * time.Second
+)
+
+// Webhook is one configured endpoint and the events it wants.
+type Webhook struct {
+	URL    string            `json:"url"`
+	Events []types.EventType `json:"events,omitempty"` // Empty matches every event
+}
+
+// Matches reports whether the webhook subscribes to the event type.
+func (w Webhook) Matches(event types.EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// WebhookConfig is the contents of webhooks.json.
+type WebhookConfig struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookPayload is the JSON body POSTed for each matching event. Text is
+// a one-line summary, which chat services such as Slack display as-is.
+type WebhookPayload struct {
+	Event     types.EventType `json:"event"`
+	TaskID    int             `json:"task_id"`
+	Task      *types.Synapse  `json:"task,omitempty"` // Omitted once deleted
+	Agent     string          `json:"agent,omitempty"`
+	From      string          `json:"from,omitempty"`
+	To        string          `json:"to,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	Text      string          `json:"text"`
+}
+
+// LoadWebhooks reads webhooks.json from dir. A missing file means no
+// webhooks and is not an error.
+func LoadWebhooks(dir string) ([]Webhook, error) {
+	data, err := os.ReadFile(filepath.Join(dir, WebhooksFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read webhooks file: %w", err)
+	}
+
+	var cfg WebhookConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse webhooks file: %w", err)
+	}
+	for i, hook := range cfg.Webhooks {
+		if hook.URL == "" {
+			return nil, fmt.Errorf("webhook %d: url is required", i+1)
+		}
+	}
+	return cfg.Webhooks, nil
+}
+
+// webhookDelivery is a payload queued for one endpoint.
+type webhookDelivery struct {
+	url  string
+	body []byte
+}
+
+// WebhookNotifier is an EventSink that POSTs matching events to webhooks.
+// Payloads are encoded during Publish and delivered by a background worker
+// with a bounded retry, so a slow endpoint never holds up a Save.
+type WebhookNotifier struct {
+	hooks   []Webhook
+	client  *http.Client
+	queue   chan webhookDelivery
+	pending sync.WaitGroup
+	backoff time.Duration // Delay before the first retry, doubled after each
+}
+
+// NewWebhookNotifier starts a notifier delivering to hooks.
+func NewWebhookNotifier(hooks []Webhook) *WebhookNotifier {
+	n := &WebhookNotifier{
+		hooks:   hooks,
+		client:  &http.Client{Timeout: webhookTimeout},
+		queue:   make(chan webhookDelivery, webhookQueueSize),
+		backoff: time.Second,
+	}
+	go n.run()
+	return n
+}
+
+// Publish queues a payload for every webhook matching each event.
+func (n *WebhookNotifier) Publish(events []types.Event, tasks map[int]*types.Synapse) error {
+	for _, e := range events {
+		var body []byte
+		for _, hook := range n.hooks {
+			if !hook.Matches(e.Event) {
+				continue
+			}
+			if body == nil {
+				var err error
+				if body, err = json.Marshal(newWebhookPayload(e, tasks[e.TaskID])); err != nil {
+					return fmt.Errorf("encode webhook payload: %w", err)
+				}
+			}
+			n.pending.Add(1)
+			select {
+			case n.queue <- webhookDelivery{url: hook.URL, body: body}:
+			default:
+				n.pending.Done()
+				log.Printf("Warning: webhook queue full, dropping %s event for task %d", e.Event, e.TaskID)
+			}
+		}
+	}
+	return nil
+}
+
+// Flush waits up to timeout for queued payloads to be delivered, reporting
+// whether the queue drained. Short-lived processes call it before exiting.
+func (n *WebhookNotifier) Flush(timeout time.Duration) bool {
+	drained := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Send delivers a payload to url immediately, without retrying.
+func (n *WebhookNotifier) Send(url string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+	return n.post(url, body)
+}
+
+// run delivers queued payloads until the process exits.
+func (n *WebhookNotifier) run() {
+	for d := range n.queue {
+		backoff := n.backoff
+		for attempt := 1; ; attempt++ {
+			err := n.post(d.url, d.body)
+			if err == nil {
+				break
+			}
+			if attempt == webhookAttempts {
+				log.Printf("Warning: webhook %s failed after %d attempts: %v", d.url, attempt, err)
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		n.pending.Done()
+	}
+}
+
+// post sends body to url, treating any non-2xx response as a failure.
+func (n *WebhookNotifier) post(url string, body []byte) error {
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// newWebhookPayload describes event e; task is nil once deleted.
+func newWebhookPayload(e types.Event, task *types.Synapse) WebhookPayload {
+	p := WebhookPayload{
+		Event:     e.Event,
+		TaskID:    e.TaskID,
+		Task:      task,
+		Agent:     e.Agent,
+		From:      e.From,
+		To:        e.To,
+		Timestamp: e.At,
+	}
+
+	subject := fmt.Sprintf("#%d", e.TaskID)
+	if task != nil {
+		subject += " " + task.Title
+	}
+	if e.Event == types.EventStatus {
+		p.Text = fmt.Sprintf("%s moved from %s to %s", subject, e.From, e.To)
+	} else {
+		p.Text = fmt.Sprintf("%s %s", subject, e.Event)
+	}
+	if e.Agent != "" {
+		p.Text += " by " + e.Agent
+	}
+	return p
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// recordingServer collects webhook payloads, failing the first failures
+// requests with a 500.
+func recordingServer(t *testing.T, failures int) (*httptest.Server, func() []WebhookPayload) {
+	var mu sync.Mutex
+	var received []WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var p WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		received = append(received, p)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []WebhookPayload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]WebhookPayload(nil), received...)
+	}
+}
+
+func TestWebhookNotifier_FiltersEvents(t *testing.T) {
+	srv, received := recordingServer(t, 0)
+	dir := t.TempDir()
+	config := `{"webhooks": [{"url": "` + srv.URL + `", "events": ["claimed", "completed"]}]}`
+	os.WriteFile(filepath.Join(dir, WebhooksFile), []byte(config), 0644)
+
+	hooks, err := LoadWebhooks(dir)
+	if err != nil {
+		t.Fatalf("load webhooks: %v", err)
+	}
+	notifier := NewWebhookNotifier(hooks)
+	store := NewJSONLStore(dir)
+	store.Subscribe(notifier)
+
+	syn, _ := store.Create("Ship it")
+	store.Save()
+	syn.Claim("agent-1", types.DefaultClaimTimeout)
+	store.Save()
+	syn.MarkDoneBy("agent-1")
+	store.Save()
+
+	if !notifier.Flush(5 * time.Second) {
+		t.Fatal("timed out waiting for deliveries")
+	}
+	got := received()
+	if len(got) != 2 {
+		t.Fatalf("expected claimed and completed payloads, got %+v", got)
+	}
+	if got[0].Event != types.EventClaimed || got[1].Event != types.EventCompleted {
+		t.Errorf("unexpected events: %s, %s", got[0].Event, got[1].Event)
+	}
+	if got[1].Task == nil || got[1].Task.Status != types.StatusDone || got[1].Agent != "agent-1" {
+		t.Errorf("expected payload to carry the done task and agent, got %+v", got[1])
+	}
+	if got[1].Text != "#1 Ship it completed by agent-1" {
+		t.Errorf("unexpected text: %q", got[1].Text)
+	}
+}
+
+func TestWebhookNotifier_Retries(t *testing.T) {
+	srv, received := recordingServer(t, 2)
+	notifier := NewWebhookNotifier([]Webhook{{URL: srv.URL}})
+	notifier.backoff = time.Millisecond
+
+	event := types.Event{TaskID: 1, Event: types.EventDeleted, At: time.Now().UTC()}
+	if err := notifier.Publish([]types.Event{event}, map[int]*types.Synapse{}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if !notifier.Flush(5 * time.Second) {
+		t.Fatal("timed out waiting for deliveries")
+	}
+	if got := received(); len(got) != 1 || got[0].Task != nil {
+		t.Errorf("expected one delivery after two failures, got %+v", got)
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	if hooks, err := LoadWebhooks(dir); err != nil || hooks != nil {
+		t.Fatalf("expected no webhooks without a config, got %v, %v", hooks, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, WebhooksFile), []byte(`{"webhooks": [{"events": ["claimed"]}]}`), 0644)
+	if _, err := LoadWebhooks(dir); err == nil {
+		t.Error("expected error for webhook without url")
+	}
+}
@@ -0,0 +1,332 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// maxTaskBody caps the size of a task write's request body.
+const maxTaskBody = 1 << 20
+
+// taskFields are the JSON keys of a task, the fields a write may set
+// unless they are read-only.
+var taskFields = jsonFields(reflect.TypeFor[types.Synapse]())
+
+// readOnlyFields are set by the store and may not be written.
+var readOnlyFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// jsonFields returns the JSON keys of struct type t.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// mergePatch applies an RFC 7386 JSON Merge Patch to target: objects merge
+// key by key, null removes a key, and any other value replaces it.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
+
+// checkWrite rejects a write request that a page on another site could
+// have sent: the view server has no auth by default, and browsers send
+// cross-site form-like POSTs without a CORS preflight. The body must be
+// declared as JSON, which a simple cross-site request can't do, and the
+// request must come from the server's own page or an origin allowed by
+// SetCORSOrigins. It reports whether the request may go ahead, having
+// sent the error if not.
+func (s *Server) checkWrite(w http.ResponseWriter, r *http.Request) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); (err != nil || u.Host != r.Host) && !s.allowedOrigin(origin) {
+			http.Error(w, "cross-origin writes from "+origin+" are not allowed", http.StatusForbidden)
+			return false
+		}
+	} else if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		http.Error(w, "cross-site writes are not allowed", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// readPatch decodes a request body holding a JSON object of task fields,
+// rejecting unknown and read-only ones.
+func readPatch(w http.ResponseWriter, r *http.Request) (map[string]any, error) {
+	var patch map[string]any
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTaskBody)).Decode(&patch); err != nil {
+		return nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+	if patch == nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+	for key := range patch {
+		if !taskFields[key] {
+			return nil, fmt.Errorf("unknown field: %s", key)
+		}
+		if readOnlyFields[key] {
+			return nil, fmt.Errorf("field %s is read-only", key)
+		}
+	}
+	return patch, nil
+}
+
+// patchTask returns a copy of syn with patch merged in. The status change,
+// if any, must be allowed by the transition matrix; moving to done
+// records the time spent as marking it done elsewhere does. A new parent
+// is checked as Move checks it, and new blockers must exist. On failure it
+// returns the HTTP status code to send with the error.
+func (s *Server) patchTask(syn *types.Synapse, patch map[string]any) (*types.Synapse, int, error) {
+	data, err := json.Marshal(syn)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if data, err = json.Marshal(mergePatch(doc, patch)); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	var patched types.Synapse
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid task: %w", err)
+	}
+
+	if strings.TrimSpace(patched.Title) == "" {
+		return nil, http.StatusBadRequest, errors.New("title is required")
+	}
+	next := patched.Status
+	if !next.IsValid() {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid status: %s", next)
+	}
+	if patched.ParentID != syn.ParentID {
+		if err := s.store.CheckParent(patched.ID, patched.ParentID); err != nil {
+			return nil, refStatus(err), err
+		}
+	}
+	for _, blockerID := range patched.BlockedBy {
+		if slices.Contains(syn.BlockedBy, blockerID) {
+			continue
+		}
+		if blockerID == patched.ID {
+			return nil, http.StatusConflict, fmt.Errorf("synapse %d can't block itself", patched.ID)
+		}
+		if _, err := s.store.Get(blockerID); err != nil {
+			return nil, http.StatusUnprocessableEntity, fmt.Errorf("blocker %w", err)
+		}
+	}
+	if next != syn.Status {
+		patched.Status = syn.Status
+		if err := patched.ValidateTransition(next, s.store.IsDone); err != nil {
+			return nil, http.StatusConflict, err
+		}
+		if next == types.StatusDone {
+			patched.MarkDone() // Records the actual time spent
+		}
+		patched.Status = next
+	}
+	patched.UpdatedAt = time.Now().UTC()
+	return &patched, http.StatusOK, nil
+}
+
+// refStatus is the HTTP status for a rejected parent: 422 if it doesn't
+// exist, 409 if it would close a cycle.
+func refStatus(err error) int {
+	if errors.Is(err, storage.ErrNotFound) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusConflict
+}
+
+// write runs fn against the latest tasks on disk, under the store's file
+// lock, and saves what it changed. It holds reloadMu so a refresh can't
+// swap the tasks out from under fn, and records the file it wrote as
+// loaded.
+func (s *Server) write(fn func() error) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	return s.store.WithLock(func() error {
+		if err := s.store.Load(); err != nil {
+			return fmt.Errorf("reload store: %w", err)
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		if err := s.store.Save(); err != nil {
+			return fmt.Errorf("save store: %w", err)
+		}
+		if info, err := s.store.FileInfo(); err == nil {
+			s.lastMod = info.ModTime()
+			s.lastSize = info.Size()
+			s.loaded = true
+			s.reloadErr = nil
+		}
+		return nil
+	})
+}
+
+// taskETag is the entity tag of a single task: its Version, which every
+// stored change bumps.
+func taskETag(syn *types.Synapse) string {
+	return fmt.Sprintf(`"%d"`, syn.Version)
+}
+
+// ifMatch reports whether an If-Match header lists etag, using the strong
+// comparison If-Match calls for: weak tags never match.
+func ifMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePatchTask applies a JSON Merge Patch (RFC 7386) to a task and
+// returns the updated task. The request must carry the task's ETag in
+// If-Match; if the task has changed since, it fails with 412 and the
+// client should reload the task and retry.
+func (s *Server) handlePatchTask(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWrite(w, r) {
+		return
+	}
+	id, ok := parseTaskID(w, r)
+	if !ok {
+		return
+	}
+	precondition := r.Header.Get("If-Match")
+	if precondition == "" {
+		http.Error(w, "If-Match is required: send the task's ETag from GET /api/task/"+r.PathValue("id"), http.StatusPreconditionRequired)
+		return
+	}
+	patch, err := readPatch(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var updated *types.Synapse
+	err = s.write(func() error {
+		syn, err := s.store.Get(id)
+		if err != nil {
+			status = http.StatusNotFound
+			return err
+		}
+		if !ifMatch(precondition, taskETag(syn)) {
+			status = http.StatusPreconditionFailed
+			return fmt.Errorf("synapse %d has changed: it is at version %d; reload it and retry", id, syn.Version)
+		}
+		patched, code, err := s.patchTask(syn, patch)
+		if err != nil {
+			status = code
+			return err
+		}
+		updated = patched
+		if err := s.store.Update(updated); err != nil {
+			status = http.StatusConflict
+			if errors.Is(err, storage.ErrStaleWrite) {
+				status = http.StatusPreconditionFailed
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeTask(w, http.StatusOK, updated)
+}
+
+// handleCreateTask creates a task from a JSON object of task fields, which
+// must include a title, and returns it.
+func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWrite(w, r) {
+		return
+	}
+	fields, err := readPatch(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var created *types.Synapse
+	err = s.write(func() error {
+		if externalID, _ := fields["external_id"].(string); externalID != "" {
+			if existing, ok := s.store.ByExternalID(externalID); ok {
+				status = http.StatusConflict
+				return fmt.Errorf("external ID %q is already used by synapse %d", externalID, existing.ID)
+			}
+		}
+		syn, code, err := s.patchTask(types.NewSynapse(s.store.NextID(), ""), fields)
+		if err != nil {
+			status = code
+			return err
+		}
+		created = syn
+		return s.store.Insert(created)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/task/%d", created.ID))
+	writeTask(w, http.StatusCreated, created)
+}
+
+// writeTask sends syn as a JSON response with the given status, tagged
+// with its ETag for the next write.
+func writeTask(w http.ResponseWriter, status int, syn *types.Synapse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(syn))
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(syn); err != nil {
+		log.Printf("Error encoding synapse: %v", err)
+	}
+}
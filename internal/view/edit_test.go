@@ -0,0 +1,246 @@
+package view
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+// newEditServer returns a server over a store saved in a temp directory,
+// holding an open task assigned to coder and a done one.
+func newEditServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	syn, _ := store.Create("Implement API")
+	syn.Assignee = "coder"
+	store.Update(syn)
+	done, _ := store.Create("Design API")
+	store.Complete(done, "")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	return NewServer(store, 8080), dir
+}
+
+// send serves a request with a JSON body and, if set, an If-Match header
+// through the full handler.
+func send(server *Server, method, target, ifMatch, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	server.handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPatchTask(t *testing.T) {
+	server, dir := newEditServer(t)
+
+	rec := send(server, http.MethodPatch, "/api/task/1", `"1"`, `{"status":"in-progress","assignee":null,"priority":3,"labels":["api"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got types.Synapse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Status != types.StatusInProgress || got.Assignee != "" || got.Priority != 3 || got.Title != "Implement API" {
+		t.Errorf("unexpected task after patch: %+v", got)
+	}
+	if got.Version != 2 {
+		t.Errorf("expected version 2, got %d", got.Version)
+	}
+
+	// The change is saved, not just held in memory
+	reloaded := storage.NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if syn, _ := reloaded.Get(1); syn.Status != types.StatusInProgress || syn.Priority != 3 {
+		t.Errorf("patch not saved: %+v", syn)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		code   int
+	}{
+		{"unknown field", "/api/task/1", `{"colour":"red"}`, http.StatusBadRequest},
+		{"read-only field", "/api/task/1", `{"id":7}`, http.StatusBadRequest},
+		{"not an object", "/api/task/1", `["status"]`, http.StatusBadRequest},
+		{"wrong type", "/api/task/1", `{"priority":"high"}`, http.StatusBadRequest},
+		{"invalid status", "/api/task/1", `{"status":"someday"}`, http.StatusBadRequest},
+		{"empty title", "/api/task/1", `{"title":null}`, http.StatusBadRequest},
+		{"forbidden transition", "/api/task/2", `{"status":"in-progress"}`, http.StatusConflict},
+		{"missing task", "/api/task/99", `{"priority":1}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := send(server, http.MethodPatch, tt.target, "*", tt.body); rec.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPatchTask_IfMatch(t *testing.T) {
+	server, _ := newEditServer(t)
+
+	get := httptest.NewRecorder()
+	server.handler().ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/api/task/1", nil))
+	etag := get.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("expected the task's version as its ETag, got %q", etag)
+	}
+
+	// Two clients edit from the same read: the first wins, the second must
+	// reload
+	first := send(server, http.MethodPatch, "/api/task/1", etag, `{"priority":2}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first patch: expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	if got := first.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("expected the new ETag after a patch, got %q", got)
+	}
+	second := send(server, http.MethodPatch, "/api/task/1", etag, `{"priority":5}`)
+	if second.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second patch: expected 412, got %d: %s", second.Code, second.Body.String())
+	}
+
+	if rec := send(server, http.MethodPatch, "/api/task/1", "", `{"priority":5}`); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected 428 without If-Match, got %d", rec.Code)
+	}
+	if rec := send(server, http.MethodPatch, "/api/task/1", `W/"2"`, `{"priority":5}`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected a weak ETag not to match, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/task/1", nil)
+	req.Header.Set("If-None-Match", `"2"`)
+	rec := httptest.NewRecorder()
+	server.handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for the current ETag, got %d", rec.Code)
+	}
+	if syn, _ := server.store.Get(1); syn.Priority != 2 {
+		t.Errorf("expected the first patch to stand, got priority %d", syn.Priority)
+	}
+}
+
+func TestCreateTask(t *testing.T) {
+	server, dir := newEditServer(t)
+
+	rec := send(server, http.MethodPost, "/api/task", "", `{"title":"Write docs","assignee":"writer","blocked_by":[1]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if loc := rec.Header().Get("Location"); loc != "/api/task/3" {
+		t.Errorf("Location = %q, want /api/task/3", loc)
+	}
+	var got types.Synapse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ID != 3 || got.Title != "Write docs" || got.Assignee != "writer" || got.Status != types.StatusOpen {
+		t.Errorf("unexpected created task: %+v", got)
+	}
+
+	reloaded := storage.NewJSONLStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if syn, err := reloaded.Get(3); err != nil || syn.Title != "Write docs" {
+		t.Errorf("created task not saved: %v %+v", err, syn)
+	}
+
+	for _, body := range []string{`{}`, `{"title":"Docs","owner":"me"}`, `{"title":"Docs","version":3}`} {
+		if rec := send(server, http.MethodPost, "/api/task", "", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestWriteTask_CrossSite(t *testing.T) {
+	server, _ := newEditServer(t)
+	server.SetCORSOrigins([]string{"http://dashboard.local"})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"json", map[string]string{"Content-Type": "application/json; charset=utf-8"}, http.StatusCreated},
+		{"text body", map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		{"no content type", map[string]string{}, http.StatusUnsupportedMediaType},
+		{"own page", map[string]string{"Content-Type": "application/json", "Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"}, http.StatusCreated},
+		{"allowed origin", map[string]string{"Content-Type": "application/json", "Origin": "http://dashboard.local", "Sec-Fetch-Site": "cross-site"}, http.StatusCreated},
+		{"other origin", map[string]string{"Content-Type": "application/json", "Origin": "http://evil.example"}, http.StatusForbidden},
+		{"cross-site without origin", map[string]string{"Content-Type": "application/json", "Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/task", strings.NewReader(`{"title":"Write docs"}`))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			server.handler().ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// PATCH is checked the same way
+	req := httptest.NewRequest(http.MethodPatch, "/api/task/1", strings.NewReader(`{"title":"Renamed"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("If-Match", "*")
+	rec := httptest.NewRecorder()
+	server.handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("PATCH: expected 415, got %d", rec.Code)
+	}
+}
+
+func TestWriteTask_ParentAndBlockers(t *testing.T) {
+	server, _ := newEditServer(t)
+
+	if rec := send(server, http.MethodPost, "/api/task", "", `{"title":"Child","parent_id":1}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for an existing parent, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"own parent", http.MethodPatch, "/api/task/1", `{"parent_id":1}`, http.StatusConflict},
+		{"descendant as parent", http.MethodPatch, "/api/task/1", `{"parent_id":3}`, http.StatusConflict},
+		{"missing parent", http.MethodPatch, "/api/task/1", `{"parent_id":99}`, http.StatusUnprocessableEntity},
+		{"missing parent on create", http.MethodPost, "/api/task", `{"title":"Orphan","parent_id":99}`, http.StatusUnprocessableEntity},
+		{"own blocker", http.MethodPatch, "/api/task/1", `{"blocked_by":[1]}`, http.StatusConflict},
+		{"missing blocker", http.MethodPatch, "/api/task/1", `{"blocked_by":[2,99]}`, http.StatusUnprocessableEntity},
+		{"missing blocker on create", http.MethodPost, "/api/task", `{"title":"Blocked","blocked_by":[99]}`, http.StatusUnprocessableEntity},
+		{"valid move", http.MethodPatch, "/api/task/3", `{"parent_id":2,"blocked_by":[2]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := send(server, tt.method, tt.target, "*", tt.body); rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package view
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+func TestGzipped_APIResponses(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	for range 50 {
+		store.Create("A task with a reasonably long title to compress")
+	}
+	server := NewServer(store, 8080)
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/synapses", "br, gzip")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip response, got headers %v", rec.Header())
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	var synapses []types.Synapse
+	if err := json.NewDecoder(gz).Decode(&synapses); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(synapses) != 50 {
+		t.Errorf("expected 50 synapses, got %d", len(synapses))
+	}
+
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+		rec := get("/api/synapses", acceptEncoding)
+		if rec.Header().Get("Content-Encoding") != "" {
+			t.Errorf("Accept-Encoding %q: expected a plain response", acceptEncoding)
+		}
+		if !json.Valid(rec.Body.Bytes()) {
+			t.Errorf("Accept-Encoding %q: expected plain JSON", acceptEncoding)
+		}
+	}
+
+	// The HTML page is never compressed
+	rec = get("/", "gzip")
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Header().Get("Content-Encoding") != "" || !strings.Contains(string(body), "<html") {
+		t.Error("expected the page served uncompressed")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                 false,
+		"gzip":             true,
+		"deflate, gzip":    true,
+		"gzip;q=0.5":       true,
+		"gzip; q=0":        false,
+		"x-gzip":           false,
+		"identity, br;q=1": false,
+	}
+	for header, want := range tests {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
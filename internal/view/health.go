@@ -0,0 +1,47 @@
+package view
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// health is the /healthz response body.
+type health struct {
+	Status  string `json:"status"` // "ok" or "unavailable"
+	Tasks   int    `json:"tasks"`
+	Version string `json:"version"`
+	Error   string `json:"error,omitempty"` // Why the server is unavailable
+}
+
+// handleHealth answers liveness and readiness probes: 200 once the memory
+// file has been loaded, and 503 before then or while reloading it fails.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.refresh()
+	s.reloadMu.Lock()
+	body := health{Status: "ok", Tasks: s.store.Count(), Version: s.version}
+	switch {
+	case s.reloadErr != nil:
+		body.Error = "reload failed: " + s.reloadErr.Error()
+	case !s.loaded:
+		body.Error = "no memory file loaded yet"
+	}
+	s.reloadMu.Unlock()
+
+	status := http.StatusOK
+	if body.Error != "" {
+		body.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding health: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package view
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swiftj/synapse/internal/storage"
+)
+
+func TestHandleHealth(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+
+	check := func(server *Server, wantCode int, want health) {
+		t.Helper()
+		// No credentials, even when a token is required
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != wantCode {
+			t.Fatalf("expected %d, got %d: %s", wantCode, rec.Code, rec.Body.String())
+		}
+		var got health
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.Status != want.Status || got.Version != want.Version || (got.Error == "") != (want.Error == "") ||
+			(wantCode == http.StatusOK && got.Tasks != want.Tasks) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	// Nothing to load yet
+	server := NewServer(store, 8080)
+	server.SetVersion("1.2.3")
+	server.SetAuthToken("secret")
+	check(server, http.StatusServiceUnavailable, health{Status: "unavailable", Version: "1.2.3", Error: "not loaded"})
+
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	store.Create("First")
+	store.Create("Second")
+	if err := store.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	check(server, http.StatusOK, health{Status: "ok", Tasks: 2, Version: "1.2.3"})
+
+	// A failed reload makes the server unavailable until the file is fixed
+	memPath := filepath.Join(dir, storage.MemoryFile)
+	good, _ := os.ReadFile(memPath)
+	if err := os.WriteFile(memPath, []byte("{not json\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	check(server, http.StatusServiceUnavailable, health{Status: "unavailable", Version: "1.2.3", Error: "reload failed"})
+
+	if err := os.WriteFile(memPath, good, 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	future := time.Now().Add(2 * time.Second)
+	os.Chtimes(memPath, future, future)
+	check(server, http.StatusOK, health{Status: "ok", Tasks: 2, Version: "1.2.3"})
+
+	// The rest of the server still wants the token
+	rec := httptest.NewRecorder()
+	server.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/synapses", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for the API without a token, got %d", rec.Code)
+	}
+}
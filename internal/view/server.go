@@ -2,13 +2,26 @@
 package view
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/swiftj/synapse/internal/export"
+	"github.com/swiftj/synapse/internal/graph"
 	"github.com/swiftj/synapse/internal/storage"
 	"github.com/swiftj/synapse/pkg/types"
 )
@@ -16,37 +29,356 @@ import (
 //go:embed templates/*
 var templates embed.FS
 
+// DefaultHost is the interface the server binds to unless overridden.
+// Binding beyond localhost exposes the board to the network.
+const DefaultHost = "localhost"
+
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once a shutdown signal is received.
+const shutdownTimeout = 5 * time.Second
+
 // Server provides HTTP endpoints for DAG visualization.
 type Server struct {
 	store *storage.JSONLStore
+	host  string
 	port  int
+
+	// Auto-reload state: the store is reloaded when memory.jsonl changes
+	// on disk (e.g. written by the CLI or an MCP server).
+	autoReload bool
+	reloadMu   sync.Mutex
+	lastMod    time.Time
+	lastSize   int64
+
+	// Health, reported at /healthz and guarded by reloadMu: whether the
+	// memory file has been read, and why the last reload failed if it did
+	loaded    bool
+	reloadErr error
+
+	// Release reported at /healthz
+	version string
+
+	// Whether archived tasks are shown without ?include_archived=true
+	includeArchived bool
+
+	// Graph colors, served to the page at /api/theme
+	theme graph.Theme
+
+	// Token every request must present; empty leaves the board open
+	authToken string
+
+	// Origins allowed to call /api/* from a browser; "*" allows any
+	corsOrigins []string
 }
 
-// NewServer creates a new visualization server.
+// NewServer creates a new visualization server. The store is expected to be
+// loaded already; its current file state is recorded as the baseline for
+// auto-reload.
 func NewServer(store *storage.JSONLStore, port int) *Server {
-	return &Server{
-		store: store,
-		port:  port,
+	s := &Server{
+		store:      store,
+		host:       DefaultHost,
+		port:       port,
+		autoReload: true,
+		theme:      graph.DefaultTheme(),
+	}
+	if info, err := store.FileInfo(); err == nil {
+		s.lastMod = info.ModTime()
+		s.lastSize = info.Size()
+		s.loaded = true
+	}
+	return s
+}
+
+// SetAutoReload enables or disables reloading the store when its backing
+// file changes. When disabled the server serves a snapshot taken at startup.
+func (s *Server) SetAutoReload(enabled bool) {
+	s.autoReload = enabled
+}
+
+// SetIncludeArchived sets whether the API shows archived tasks by default.
+// Requests can still ask for them with ?include_archived=true.
+func (s *Server) SetIncludeArchived(include bool) {
+	s.includeArchived = include
+}
+
+// SetTheme sets the graph colors, e.g. from the project's theme.json.
+func (s *Server) SetTheme(theme graph.Theme) {
+	s.theme = theme
+}
+
+// SetAuthToken requires every request to present token, as an
+// "Authorization: Bearer" header or a ?token= parameter. An empty token
+// (the default) disables the check.
+func (s *Server) SetAuthToken(token string) {
+	s.authToken = token
+}
+
+// SetCORSOrigins allows browser pages on origins (e.g.
+// "https://dash.example.com", or "*" for any) to call the /api/* routes.
+// No origins (the default) sends no CORS headers, so browsers block
+// cross-origin requests.
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsOrigins = origins
+}
+
+// SetVersion sets the release reported by /healthz.
+func (s *Server) SetVersion(version string) {
+	s.version = version
+}
+
+// SetHost sets the interface to bind to, e.g. "0.0.0.0" to listen on all
+// interfaces.
+func (s *Server) SetHost(host string) {
+	s.host = host
+}
+
+// Addr returns the host:port address the server listens on.
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.host, strconv.Itoa(s.port))
+}
+
+// refresh reloads the store if memory.jsonl changed since the last load.
+// The check and reload are serialized so concurrent requests trigger at
+// most one reload.
+func (s *Server) refresh() {
+	if !s.autoReload {
+		return
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	info, err := s.store.FileInfo()
+	if err != nil {
+		return // No backing file yet; keep serving in-memory state
+	}
+	if info.ModTime().Equal(s.lastMod) && info.Size() == s.lastSize {
+		return
+	}
+
+	if err := s.store.Load(); err != nil {
+		log.Printf("Error reloading store: %v", err)
+		s.reloadErr = err
+		return
 	}
+	s.lastMod = info.ModTime()
+	s.lastSize = info.Size()
+	s.loaded = true
+	s.reloadErr = nil
 }
 
-// Run starts the HTTP server and blocks until shutdown.
+// etag identifies the loaded state of the store by its backing file's
+// modification time and size. It is empty if no file has been loaded, in
+// which case responses aren't cacheable.
+func (s *Server) etag() string {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	if s.lastMod.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, s.lastMod.UnixNano(), s.lastSize)
+}
+
+// cached sets an ETag on GET responses from next, reflecting the tasks
+// they are built from, and answers 304 Not Modified when the request's
+// If-None-Match already has it, so polling clients only download changes.
+func (s *Server) cached(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next(w, r)
+			return
+		}
+
+		s.refresh()
+		etag := s.etag()
+		if etag == "" {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// etagMatch reports whether an If-None-Match header lists etag, using the
+// weak comparison If-None-Match calls for.
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Run starts the HTTP server and blocks until it fails or a SIGINT/SIGTERM
+// triggers a graceful shutdown. A clean shutdown returns nil.
 func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Serve(ctx)
+}
+
+// Serve starts the HTTP server and blocks until ctx is cancelled, at which
+// point in-flight requests are given shutdownTimeout to complete.
+func (s *Server) Serve(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:    s.Addr(),
+		Handler: s.handler(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Starting visualization server on http://%s", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down visualization server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
+}
+
+// handler routes every endpoint, all but /healthz behind requireToken.
+func (s *Server) handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Serve the HTML page
 	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/table", s.handleTable)
+	mux.HandleFunc("/task/{id}", s.handleTask)
+
+	// API endpoints, gzipped for clients that accept it. Those derived
+	// from the tasks carry an ETag (see cached)
+	mux.HandleFunc("/api/synapses", s.cached(gzipped(s.handleSynapses)))
+	mux.HandleFunc("/api/synapses.ndjson", s.cached(gzipped(s.handleSynapsesNDJSON)))
+	mux.HandleFunc("/api/ready", s.cached(gzipped(s.handleReady)))
+	mux.HandleFunc("/api/task/{id}", gzipped(s.handleAPITask)) // Tagged per task
+	mux.HandleFunc("PATCH /api/task/{id}", s.handlePatchTask)
+	mux.HandleFunc("POST /api/task", s.handleCreateTask)
+	mux.HandleFunc("/api/theme", gzipped(s.handleTheme))
+	mux.HandleFunc("/api/graph", s.cached(gzipped(s.handleGraph)))
+	mux.HandleFunc("/api/critical-path/{id}", s.cached(gzipped(s.handleCriticalPath)))
+
+	// Exports
+	mux.HandleFunc("/export.md", s.handleExportMarkdown)
+
+	// Probes usually can't send credentials, so the health check sits
+	// outside requireToken
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", s.handleHealth)
+	root.Handle("/", s.cors(s.requireToken(mux)))
+	return root
+}
 
-	// API endpoints
-	mux.HandleFunc("/api/synapses", s.handleSynapses)
-	mux.HandleFunc("/api/ready", s.handleReady)
+// cors adds CORS headers to /api/* responses for allowed origins and
+// answers their OPTIONS preflight requests itself, since browsers send
+// preflights without credentials.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("Starting visualization server on http://localhost%s", addr)
+		w.Header().Add("Vary", "Origin")
+		if !s.allowedOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-	return http.ListenAndServe(addr, mux)
+// allowedOrigin reports whether origin may make cross-origin requests.
+func (s *Server) allowedOrigin(origin string) bool {
+	for _, allowed := range s.corsOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
 }
 
+// tokenCookie carries the auth token for the page's own requests once a
+// browser has opened it with ?token=.
+const tokenCookie = "synapse_token"
+
+// requireToken rejects requests without the auth token with 401, unless
+// no token is set. The token may come as an "Authorization: Bearer"
+// header, a ?token= parameter, or the cookie set after a ?token= visit so
+// the page's API calls and links keep working.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.authToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if token := r.URL.Query().Get("token"); token != "" && s.validToken(token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			next.ServeHTTP(w, r)
+			return
+		}
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && s.validToken(token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if cookie, err := r.Cookie(tokenCookie); err == nil && s.validToken(cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="synapse"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// validToken compares token to the auth token in constant time.
+func (s *Server) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
+
+// themePlaceholder marks where the index page's script takes the theme.
+const themePlaceholder = "/*THEME*/{}"
+
 // handleIndex serves the main visualization HTML page.
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -60,19 +392,34 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error reading template: %v", err)
 		return
 	}
+	// The page draws with the same colors as the server, so overdue,
+	// claimed and critical borders follow the theme from the first render
+	colors, err := json.Marshal(s.theme.Colors())
+	if err != nil {
+		http.Error(w, "Failed to encode theme", http.StatusInternalServerError)
+		log.Printf("Error encoding theme: %v", err)
+		return
+	}
+	data = bytes.Replace(data, []byte(themePlaceholder), colors, 1)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(data)
 }
 
-// handleSynapses returns all synapses as JSON.
+// handleSynapses returns synapses as JSON, optionally filtered by query
+// parameters (see filterSynapses).
 func (s *Server) handleSynapses(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	synapses := s.store.All()
+	s.refresh()
+	synapses, status, err := s.filterSynapses(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(synapses); err != nil {
@@ -80,14 +427,120 @@ func (s *Server) handleSynapses(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleReady returns ready synapses as JSON.
+// handleSynapsesNDJSON streams the synapses handleSynapses returns as
+// newline-delimited JSON, one task per line, flushing after each so that
+// clients can start rendering a large board before the rest arrives.
+func (s *Server) handleSynapsesNDJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.refresh()
+	synapses, status, err := s.filterSynapses(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	for _, syn := range synapses {
+		if err := encoder.Encode(syn); err != nil {
+			log.Printf("Error streaming synapses: %v", err)
+			return
+		}
+		if err := flusher.Flush(); err != nil {
+			log.Printf("Error streaming synapses: %v", err)
+			return
+		}
+	}
+}
+
+// filterSynapses applies the ?status=, ?assignee=, ?label=, ?root= and
+// ?include_archived= query parameters. Filters combine conjunctively; root
+// narrows the result to that task, its transitive children, and their
+// transitive blockers. Archived tasks are left out unless included. On
+// failure it returns the HTTP status code to send with the error.
+func (s *Server) filterSynapses(r *http.Request) ([]*types.Synapse, int, error) {
+	query := r.URL.Query()
+	synapses := s.store.All()
+
+	includeArchived, err := s.includeArchivedParam(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if !includeArchived {
+		synapses = storage.Unarchived(synapses)
+	}
+
+	keep := func(subset []*types.Synapse) {
+		ids := make(map[int]bool, len(subset))
+		for _, syn := range subset {
+			ids[syn.ID] = true
+		}
+		filtered := make([]*types.Synapse, 0, len(synapses))
+		for _, syn := range synapses {
+			if ids[syn.ID] {
+				filtered = append(filtered, syn)
+			}
+		}
+		synapses = filtered
+	}
+
+	if status := query.Get("status"); status != "" {
+		if !types.Status(status).IsValid() {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid status: %s", status)
+		}
+		keep(s.store.ByStatus(types.Status(status)))
+	}
+
+	if query.Has("assignee") {
+		keep(s.store.ByAssignee(query.Get("assignee")))
+	}
+
+	if label := query.Get("label"); label != "" {
+		keep(s.store.ByLabel(label))
+	}
+
+	if rootParam := query.Get("root"); rootParam != "" {
+		rootID, err := strconv.Atoi(rootParam)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid root: %s", rootParam)
+		}
+		sub := graph.Subgraph(s.store.All(), rootID)
+		if sub == nil {
+			return nil, http.StatusNotFound, fmt.Errorf("synapse %d not found", rootID)
+		}
+		keep(sub)
+	}
+
+	return synapses, http.StatusOK, nil
+}
+
+// handleReady returns ready synapses as JSON, optionally limited to one
+// assignee via ?assignee=.
 func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	includeArchived, err := s.includeArchivedParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.refresh()
 	ready := s.store.Ready()
+	if includeArchived {
+		ready = s.store.ReadyWithArchived()
+	}
+	if r.URL.Query().Has("assignee") {
+		ready = storage.Intersect(ready, s.store.ByRole(r.URL.Query().Get("assignee")))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(ready); err != nil {
@@ -95,82 +548,198 @@ func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// generateMermaid creates Mermaid graph syntax from synapses.
-// This method is available for programmatic access but the visualization
-// page generates Mermaid code client-side for better interactivity.
-func (s *Server) generateMermaid() string {
-	synapses := s.store.All()
+// includeArchivedParam reads ?include_archived=, falling back to the
+// server's default.
+func (s *Server) includeArchivedParam(r *http.Request) (bool, error) {
+	param := r.URL.Query().Get("include_archived")
+	if param == "" {
+		return s.includeArchived, nil
+	}
+	include, err := strconv.ParseBool(param)
+	if err != nil {
+		return false, fmt.Errorf("invalid include_archived: %s", param)
+	}
+	return include, nil
+}
 
-	if len(synapses) == 0 {
-		return "graph TD\n    empty[No tasks yet]"
+// handleTheme returns the graph colors as JSON, keyed by status and by
+// overlay (claimed, ready, overdue, related), so the page draws with the
+// same palette as GenerateThemedMermaid.
+func (s *Server) handleTheme(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString("graph TD\n")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.theme.Colors()); err != nil {
+		log.Printf("Error encoding theme: %v", err)
+	}
+}
 
-	// Create a map for quick lookup
-	synMap := make(map[int]*types.Synapse)
-	for _, syn := range synapses {
-		synMap[syn.ID] = syn
+// handleExportMarkdown returns all synapses as a Markdown report.
+func (s *Server) handleExportMarkdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Generate nodes
-	for _, syn := range synapses {
-		title := truncateTitle(syn.Title, 40)
-		label := escapeForMermaid(fmt.Sprintf("#%d: %s", syn.ID, title))
-		sb.WriteString(fmt.Sprintf("    %d[\"%s\"]\n", syn.ID, label))
+	s.refresh()
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(export.ExportMarkdown(s.store.All())))
+}
+
+// handleGraph returns the server-rendered task graph as text:
+// ?format=mermaid (the default, drawn with the server's theme) or
+// ?format=dot. It accepts the same filters as /api/synapses, and Mermaid
+// output highlights the critical path to the task given by ?critical=.
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	sb.WriteString("\n")
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "mermaid", "dot":
+	default:
+		http.Error(w, fmt.Sprintf("invalid format: %s (must be 'mermaid' or 'dot')", format), http.StatusBadRequest)
+		return
+	}
 
-	// Generate edges for BlockedBy relationships
-	for _, syn := range synapses {
-		if len(syn.BlockedBy) > 0 {
-			for _, blockerID := range syn.BlockedBy {
-				if _, exists := synMap[blockerID]; exists {
-					sb.WriteString(fmt.Sprintf("    %d --> %d\n", blockerID, syn.ID))
-				}
-			}
+	s.refresh()
+	synapses, status, err := s.filterSynapses(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var criticalPath []*types.Synapse
+	if param := r.URL.Query().Get("critical"); param != "" {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid critical: %s", param), http.StatusBadRequest)
+			return
+		}
+		if criticalPath, status, err = s.criticalPath(r, id); err != nil {
+			http.Error(w, err.Error(), status)
+			return
 		}
 	}
 
-	// Generate edges for ParentID relationships (dotted style)
-	for _, syn := range synapses {
-		if syn.ParentID > 0 {
-			if _, exists := synMap[syn.ParentID]; exists {
-				sb.WriteString(fmt.Sprintf("    %d -.-> %d\n", syn.ParentID, syn.ID))
-			}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if format == "dot" {
+		w.Write([]byte(export.ExportDOT(synapses)))
+		return
+	}
+	w.Write([]byte(generateMermaid(synapses, ReadyIDs(s.store), s.theme, criticalPath) + "\n"))
+}
+
+// readyMarker prefixes the label of tasks that are ready to start.
+const readyMarker = "⚡ "
+
+// GenerateMermaid creates Mermaid graph syntax from synapses. Tasks that are
+// ready to start (see graph.ReadySet) get a ⚡ prefix, and claimed tasks are
+// annotated with the agent and drawn with a thick border.
+// The visualization page generates Mermaid code client-side for better
+// interactivity.
+func GenerateMermaid(synapses []*types.Synapse) string {
+	return GenerateThemedMermaid(synapses, graph.ReadySet(synapses), graph.DefaultTheme())
+}
+
+// GenerateThemedMermaid is GenerateMermaid drawn with theme's colors,
+// flagging the tasks in ready rather than judging readiness within
+// synapses; pass ReadyIDs when synapses is a filtered view of a store. A
+// theme with a ready color also borders ready tasks, below overdue and
+// claimed tasks in precedence.
+func GenerateThemedMermaid(synapses []*types.Synapse, ready map[int]bool, theme graph.Theme) string {
+	return generateMermaid(synapses, ready, theme, nil)
+}
+
+// ReadyIDs returns the IDs of store's ready tasks, archived or not. Unlike
+// graph.ReadySet over a filtered slice, it resolves blockers against every
+// task in the store, so a done blocker that was filtered out or archived
+// still counts as done.
+func ReadyIDs(store *storage.JSONLStore) map[int]bool {
+	ready := make(map[int]bool)
+	for _, syn := range store.ReadyWithArchived() {
+		ready[syn.ID] = true
+	}
+	return ready
+}
+
+// generateMermaid backs GenerateThemedMermaid, additionally drawing the
+// blocker edges between consecutive tasks of criticalPath (see
+// JSONLStore.CriticalPath) in the theme's critical color.
+func generateMermaid(synapses []*types.Synapse, ready map[int]bool, theme graph.Theme, criticalPath []*types.Synapse) string {
+	if len(synapses) == 0 {
+		return "graph TD\n    empty[No tasks yet]"
+	}
+
+	g := graph.Build(synapses)
+
+	var sb strings.Builder
+	sb.WriteString("graph TD\n")
+
+	// Generate nodes
+	for _, node := range g.Nodes {
+		label := node.Label
+		if ready[node.ID] {
+			label = readyMarker + label
 		}
+		if node.ClaimedBy != "" {
+			label += " @" + graph.TruncateTitle(node.ClaimedBy, 10)
+		}
+		sb.WriteString(fmt.Sprintf("    %d[\"%s\"]\n", node.ID, escapeForMermaid(label)))
 	}
 
 	sb.WriteString("\n")
 
-	// Style nodes by status
-	statusColors := map[types.Status]string{
-		types.StatusOpen:       "#FFFFFF",
-		types.StatusInProgress: "#FFFFE0",
-		types.StatusBlocked:    "#D3D3D3",
-		types.StatusReview:     "#87CEEB",
-		types.StatusDone:       "#90EE90",
+	// Generate edges (solid for BlockedBy, dotted for ParentID, plain
+	// lines for RelatedTo). Mermaid styles links by their index.
+	critical := make(map[graph.Edge]bool)
+	for i := 1; i < len(criticalPath); i++ {
+		critical[graph.Edge{From: criticalPath[i-1].ID, To: criticalPath[i].ID, Kind: graph.EdgeBlocks}] = true
 	}
-
-	for _, syn := range synapses {
-		color := statusColors[syn.Status]
-		if color == "" {
-			color = "#FFFFFF"
+	var relatedLinks, criticalLinks []string
+	for i, edge := range g.Edges {
+		arrow := "-->"
+		switch edge.Kind {
+		case graph.EdgeParent:
+			arrow = "-.->"
+		case graph.EdgeRelated:
+			arrow = "---"
+			relatedLinks = append(relatedLinks, strconv.Itoa(i))
 		}
-		sb.WriteString(fmt.Sprintf("    style %d fill:%s\n", syn.ID, color))
+		if critical[edge] {
+			criticalLinks = append(criticalLinks, strconv.Itoa(i))
+		}
+		sb.WriteString(fmt.Sprintf("    %d %s %d\n", edge.From, arrow, edge.To))
+	}
+	if len(relatedLinks) > 0 {
+		sb.WriteString(fmt.Sprintf("    linkStyle %s stroke:%s,stroke-width:1px\n", strings.Join(relatedLinks, ","), theme.Related))
+	}
+	if len(criticalLinks) > 0 {
+		sb.WriteString(fmt.Sprintf("    linkStyle %s stroke:%s,stroke-width:3px\n", strings.Join(criticalLinks, ","), theme.Critical))
 	}
 
-	return sb.String()
-}
+	sb.WriteString("\n")
 
-// truncateTitle shortens a title to maxLen characters.
-func truncateTitle(title string, maxLen int) string {
-	if len(title) <= maxLen {
-		return title
+	// Style nodes by status; a thick border marks overdue tasks, or else
+	// tasks claimed by an agent, or else ready tasks if themed
+	for _, node := range g.Nodes {
+		style := "fill:" + theme.Fill(node.Status)
+		if node.Overdue {
+			style += ",stroke:" + theme.Overdue + ",stroke-width:3px"
+		} else if node.ClaimedBy != "" {
+			style += ",stroke:" + theme.Claimed + ",stroke-width:3px"
+		} else if ready[node.ID] && theme.Ready != "" {
+			style += ",stroke:" + theme.Ready + ",stroke-width:3px"
+		}
+		sb.WriteString(fmt.Sprintf("    style %d %s\n", node.ID, style))
 	}
-	return title[:maxLen] + "..."
+
+	return sb.String()
 }
 
 // escapeForMermaid escapes special characters for Mermaid syntax.
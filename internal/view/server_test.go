@@ -1,9 +1,22 @@
 package view
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/swiftj/synapse/internal/graph"
 	"github.com/swiftj/synapse/internal/storage"
 	"github.com/swiftj/synapse/pkg/types"
 )
@@ -27,9 +40,8 @@ func TestNewServer(t *testing.T) {
 
 func TestGenerateMermaid_Empty(t *testing.T) {
 	store := storage.NewJSONLStore("/tmp/test")
-	server := NewServer(store, 8080)
 
-	mermaid := server.generateMermaid()
+	mermaid := GenerateMermaid(store.All())
 
 	if !strings.Contains(mermaid, "graph TD") {
 		t.Error("expected mermaid to contain 'graph TD'")
@@ -42,7 +54,6 @@ func TestGenerateMermaid_Empty(t *testing.T) {
 
 func TestGenerateMermaid_WithTasks(t *testing.T) {
 	store := storage.NewJSONLStore("/tmp/test")
-	server := NewServer(store, 8080)
 
 	// Create test synapses
 	syn1, _ := store.Create("Setup project")
@@ -56,7 +67,7 @@ func TestGenerateMermaid_WithTasks(t *testing.T) {
 	syn3.Status = types.StatusBlocked
 	syn3.ParentID = 1
 
-	mermaid := server.generateMermaid()
+	mermaid := GenerateMermaid(store.All())
 
 	// Check basic structure
 	if !strings.Contains(mermaid, "graph TD") {
@@ -100,25 +111,173 @@ func TestGenerateMermaid_WithTasks(t *testing.T) {
 	}
 }
 
-func TestTruncateTitle(t *testing.T) {
-	tests := []struct {
-		input    string
-		maxLen   int
-		expected string
-	}{
-		{"Short title", 20, "Short title"},
-		{"This is a very long title that exceeds the maximum length", 20, "This is a very long ..."},
-		{"Exactly twenty chars", 20, "Exactly twenty chars"},
-		{"", 10, ""},
+func TestGenerateMermaid_ClaimedTasks(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+
+	claimed, _ := store.Create("Claimed task")
+	claimed.Claim("agent-7", types.DefaultClaimTimeout)
+
+	unclaimed, _ := store.Create("Unclaimed task")
+	unclaimed.MarkInProgress()
+
+	mermaid := GenerateMermaid(store.All())
+
+	if !strings.Contains(mermaid, `1["#1: Claimed task @agent-7"]`) {
+		t.Errorf("expected claimed label annotated with agent, got:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, "style 1 fill:#FFFFE0,stroke:#1565C0,stroke-width:3px") {
+		t.Errorf("expected claim stroke on task 1, got:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, "style 2 fill:#FFFFE0\n") {
+		t.Errorf("expected unclaimed in-progress task without stroke, got:\n%s", mermaid)
 	}
+	if strings.Contains(mermaid, "#2: Unclaimed task @") {
+		t.Error("unclaimed task should not be annotated")
+	}
+}
 
-	for _, tt := range tests {
-		result := truncateTitle(tt.input, tt.maxLen)
-		if result != tt.expected {
-			t.Errorf("truncateTitle(%q, %d) = %q, expected %q",
-				tt.input, tt.maxLen, result, tt.expected)
+func TestGenerateMermaid_OverdueTasks(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+
+	past := time.Now().UTC().Add(-time.Hour)
+	late, _ := store.Create("Late task")
+	late.Claim("agent-7", types.DefaultClaimTimeout)
+	late.DueAt = &past
+
+	finished, _ := store.Create("Late but done")
+	finished.DueAt = &past
+	finished.MarkDone()
+
+	mermaid := GenerateMermaid(store.All())
+
+	if !strings.Contains(mermaid, "style 1 fill:#FFFFE0,stroke:#C62828,stroke-width:3px") {
+		t.Errorf("expected overdue stroke to win over claim stroke on task 1, got:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, "style 2 fill:#90EE90\n") {
+		t.Errorf("expected done task without overdue stroke, got:\n%s", mermaid)
+	}
+}
+
+func TestGenerateMermaid_RelatedLinks(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+
+	first, _ := store.Create("First")
+	second, _ := store.Create("Second")
+	second.BlockedBy = []int{first.ID}
+	second.AddRelated(first.ID)
+
+	mermaid := GenerateMermaid(store.All())
+
+	for _, want := range []string{
+		"1 --> 2",
+		"2 --- 1",
+		"linkStyle 1 stroke:#999999,stroke-width:1px",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("expected mermaid to contain %q, got:\n%s", want, mermaid)
+		}
+	}
+}
+
+func TestGenerateMermaid_ReadyOverlay(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+
+	done, _ := store.Create("Finished blocker")
+	done.MarkDone()
+
+	open, _ := store.Create("Open blocker")
+
+	unblocked, _ := store.Create("Blocked by done task")
+	unblocked.AddBlocker(done.ID)
+
+	blocked, _ := store.Create("Blocked by open task")
+	blocked.AddBlocker(open.ID)
+
+	mermaid := GenerateMermaid(store.All())
+
+	if !strings.Contains(mermaid, `3["⚡ #3: Blocked by done task"]`) {
+		t.Errorf("expected task blocked by done task to be flagged ready, got:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, `4["#4: Blocked by open task"]`) {
+		t.Errorf("expected task blocked by open task not to be flagged, got:\n%s", mermaid)
+	}
+	if !strings.Contains(mermaid, `2["⚡ #2: Open blocker"]`) {
+		t.Error("expected unblocked open task to be flagged ready")
+	}
+	if strings.Contains(mermaid, "⚡ #1:") {
+		t.Error("done task should not be flagged ready")
+	}
+}
+
+func TestGenerateThemedMermaid(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+	store.Create("Ready task")
+	claimed, _ := store.Create("Claimed task")
+	claimed.Claim("agent-1", 30*time.Minute)
+	done, _ := store.Create("Finished task")
+	done.MarkDone()
+
+	theme, err := graph.ParseTheme([]byte(`{"done": "#00AA00", "claimed": "#AA00AA", "ready": "#FFAA00"}`))
+	if err != nil {
+		t.Fatalf("parse theme: %v", err)
+	}
+	mermaid := GenerateThemedMermaid(store.All(), graph.ReadySet(store.All()), theme)
+
+	for _, want := range []string{
+		"style 1 fill:#FFFFFF,stroke:#FFAA00,stroke-width:3px",
+		"style 2 fill:#FFFFE0,stroke:#AA00AA,stroke-width:3px",
+		"style 3 fill:#00AA00\n",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("expected %q in:\n%s", want, mermaid)
 		}
 	}
+
+	// Without a ready color, ready tasks get no border
+	if strings.Contains(GenerateMermaid(store.All()), "style 1 fill:#FFFFFF,stroke") {
+		t.Error("default theme should not border ready tasks")
+	}
+}
+
+func TestHandleTheme(t *testing.T) {
+	server := NewServer(storage.NewJSONLStore(t.TempDir()), 8080)
+	theme, _ := graph.ParseTheme([]byte(`{"review": "#0000FF"}`))
+	server.SetTheme(theme)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/theme", nil)
+	rec := httptest.NewRecorder()
+	server.handleTheme(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var colors map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&colors); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if colors["review"] != "#0000FF" || colors["open"] != graph.StatusColors[types.StatusOpen] || colors["overdue"] != graph.OverdueStroke {
+		t.Errorf("unexpected theme: %v", colors)
+	}
+}
+
+func TestHandleIndex_Theme(t *testing.T) {
+	server := NewServer(storage.NewJSONLStore(t.TempDir()), 8080)
+	theme, _ := graph.ParseTheme([]byte(`{"overdue": "#FF00FF"}`))
+	server.SetTheme(theme)
+
+	rec := httptest.NewRecorder()
+	server.handleIndex(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, themePlaceholder) || !strings.Contains(body, `"overdue":"#FF00FF"`) {
+		t.Errorf("expected the page to carry the server's theme")
+	}
+	if strings.Contains(body, graph.OverdueStroke) {
+		t.Errorf("expected no built-in overdue color in a themed page")
+	}
 }
 
 func TestEscapeForMermaid(t *testing.T) {
@@ -141,3 +300,784 @@ func TestEscapeForMermaid(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleExportMarkdown(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	store.Create("Write report")
+
+	req := httptest.NewRequest(http.MethodGet, "/export.md", nil)
+	rec := httptest.NewRecorder()
+	server.handleExportMarkdown(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+		t.Errorf("expected markdown content type, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "### #1: Write report") {
+		t.Error("expected task section in markdown export")
+	}
+}
+
+func TestHandleGraph(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	design, _ := store.Create("Design API")
+	design.Status = types.StatusDone
+	store.Update(design)
+	build, _ := store.Create("Implement API")
+	build.AddBlocker(design.ID)
+	store.Create("Unrelated docs")
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		notWant string
+	}{
+		{"default mermaid", "", []string{"graph TD\n", "1 --> 2", `3["⚡ #3: Unrelated docs"]`}, ""},
+		{"dot", "?format=dot", []string{"digraph synapse", "1 -> 2;"}, ""},
+		{"filtered", "?format=mermaid&root=2", []string{"1 --> 2"}, "#3:"},
+		{"status filter", "?format=dot&status=done", []string{`1 [label="#1: Design API"`}, "#2:"},
+		{"ready despite filtered blocker", "?status=open", []string{`2["⚡ #2: Implement API"]`}, "#1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/graph"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			server.handleGraph(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			body := rec.Body.String()
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected %q in:\n%s", want, body)
+				}
+			}
+			if tt.notWant != "" && strings.Contains(body, tt.notWant) {
+				t.Errorf("did not expect %q in:\n%s", tt.notWant, body)
+			}
+		})
+	}
+}
+
+func TestHandleGraph_CriticalPath(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	design, _ := store.Create("Design")
+	build, _ := store.Create("Build")
+	build.AddBlocker(design.ID)
+	docs, _ := store.Create("Docs")
+	ship, _ := store.Create("Ship")
+	ship.AddBlocker(build.ID)
+	ship.AddBlocker(docs.ID)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graph?critical=4", nil)
+	rec := httptest.NewRecorder()
+	server.handleGraph(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Edges in order: 1 --> 2, 2 --> 4, 3 --> 4; the path is 1, 2, 4
+	want := "linkStyle 0,1 stroke:" + graph.CriticalStroke + ",stroke-width:3px"
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleGraph(rec, httptest.NewRequest(http.MethodGet, "/api/graph?critical=99", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing target, got %d", rec.Code)
+	}
+}
+
+func TestHandleCriticalPath(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	design, _ := store.Create("Design")
+	build, _ := store.Create("Build")
+	build.AddBlocker(design.ID)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/critical-path/2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var chain []types.Synapse
+	if err := json.Unmarshal(rec.Body.Bytes(), &chain); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(chain) != 2 || chain[0].ID != 1 || chain[1].ID != 2 {
+		t.Errorf("expected path [1 2], got %+v", chain)
+	}
+
+	design.AddBlocker(build.ID)
+	if rec := get("/api/critical-path/2"); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a blocker cycle, got %d", rec.Code)
+	}
+	if rec := get("/api/critical-path/2?by_estimate=maybe"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad by_estimate, got %d", rec.Code)
+	}
+}
+
+func TestHandleGraph_Errors(t *testing.T) {
+	server := NewServer(storage.NewJSONLStore(t.TempDir()), 8080)
+
+	for query, want := range map[string]int{
+		"?format=svg":   http.StatusBadRequest,
+		"?status=bogus": http.StatusBadRequest,
+		"?root=99":      http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/graph"+query, nil)
+		rec := httptest.NewRecorder()
+		server.handleGraph(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", query, want, rec.Code)
+		}
+	}
+}
+
+func TestHandleTable_Sorting(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	low, _ := store.Create("Low priority task")
+	low.Priority = 1
+	high, _ := store.Create("High priority task")
+	high.Priority = 5
+	high.Status = types.StatusDone
+
+	req := httptest.NewRequest(http.MethodGet, "/table?sort=priority&order=desc", nil)
+	rec := httptest.NewRecorder()
+	server.handleTable(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	hi := strings.Index(body, "High priority task")
+	lo := strings.Index(body, "Low priority task")
+	if hi < 0 || lo < 0 {
+		t.Fatal("expected both tasks in table")
+	}
+	if hi > lo {
+		t.Error("expected higher priority task first with order=desc")
+	}
+
+	if !strings.Contains(body, "background: #90EE90") {
+		t.Error("expected done status to use the graph fill color")
+	}
+	if !strings.Contains(body, "?sort=priority&amp;order=asc") {
+		t.Error("expected active column header to toggle order")
+	}
+}
+
+func TestHandleTable_InvalidSort(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	req := httptest.NewRequest(http.MethodGet, "/table?sort=bogus", nil)
+	rec := httptest.NewRecorder()
+	server.handleTable(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown sort column, got %d", rec.Code)
+	}
+}
+
+func TestHandleSynapses_Filters(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	syn1, _ := store.Create("Design API")
+	syn1.Status = types.StatusDone
+	syn1.Assignee = "architect"
+
+	syn2, _ := store.Create("Implement API")
+	syn2.Assignee = "coder"
+	syn2.Labels = []string{"backend"}
+	syn2.BlockedBy = []int{1}
+
+	syn3, _ := store.Create("Test API")
+	syn3.Assignee = "qa"
+	syn3.Labels = []string{"backend", "bug"}
+	syn3.ParentID = 2
+	for _, syn := range []*types.Synapse{syn1, syn2, syn3} {
+		store.Update(syn)
+	}
+
+	store.Create("Unrelated docs")
+
+	shelved, _ := store.Create("Shelved idea")
+	shelved.MarkArchived()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"no filter", "", []int{1, 2, 3, 4}},
+		{"include archived", "?include_archived=true", []int{1, 2, 3, 4, 5}},
+		{"status", "?status=open", []int{2, 3, 4}},
+		{"assignee", "?assignee=qa", []int{3}},
+		{"label", "?label=backend", []int{2, 3}},
+		{"root", "?root=2", []int{1, 2, 3}},
+		{"combined", "?label=backend&status=open&assignee=coder", []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/synapses"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			server.handleSynapses(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var got []types.Synapse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			var ids []int
+			for _, syn := range got {
+				ids = append(ids, syn.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got IDs %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleSynapsesNDJSON(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	for i := range 20 {
+		syn, _ := store.Create(fmt.Sprintf("Task %d", i+1))
+		if i%4 == 0 {
+			syn.Labels = []string{"backend"}
+			store.Update(syn)
+		}
+	}
+	ts := httptest.NewServer(NewServer(store, 8080).handler())
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		name           string
+		query          string
+		acceptEncoding string
+		want           int
+	}{
+		{"plain", "", "identity", 20},
+		{"gzip", "", "gzip", 20},
+		{"filtered", "?label=backend", "identity", 5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/synapses.ndjson"+tt.query, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d", resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if !slices.Contains(resp.TransferEncoding, "chunked") {
+				t.Errorf("expected a chunked response, got %v", resp.TransferEncoding)
+			}
+
+			var body io.Reader = resp.Body
+			if tt.acceptEncoding == "gzip" {
+				gz, err := gzip.NewReader(resp.Body)
+				if err != nil {
+					t.Fatalf("gzip reader: %v", err)
+				}
+				body = gz
+			}
+
+			scanner := bufio.NewScanner(body)
+			lastID := 0
+			lines := 0
+			for scanner.Scan() {
+				var syn types.Synapse
+				if err := json.Unmarshal(scanner.Bytes(), &syn); err != nil {
+					t.Fatalf("line %d: %v", lines+1, err)
+				}
+				if syn.ID <= lastID {
+					t.Errorf("line %d: task %d after task %d", lines+1, syn.ID, lastID)
+				}
+				lastID = syn.ID
+				lines++
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if lines != tt.want {
+				t.Errorf("got %d lines, want %d", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleSynapses_FilterErrors(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+	store.Create("Only task")
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"?status=bogus", http.StatusBadRequest},
+		{"?root=abc", http.StatusBadRequest},
+		{"?root=99", http.StatusNotFound},
+		{"?include_archived=maybe", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/synapses"+tt.query, nil)
+		rec := httptest.NewRecorder()
+		server.handleSynapses(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.query, tt.code, rec.Code)
+		}
+	}
+}
+
+func TestHandleSynapses_ReloadsOnExternalChange(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Original task")
+	if err := store.Save(); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	server := NewServer(store, 8080)
+
+	// Another process (simulated by a second store) adds a task
+	external := storage.NewJSONLStore(dir)
+	if err := external.Load(); err != nil {
+		t.Fatalf("failed to load external store: %v", err)
+	}
+	external.Create("Added externally")
+	if err := external.Save(); err != nil {
+		t.Fatalf("failed to save external store: %v", err)
+	}
+	// Guarantee a visible mtime change on coarse-grained filesystems
+	future := time.Now().Add(2 * time.Second)
+	os.Chtimes(filepath.Join(dir, storage.MemoryFile), future, future)
+
+	count := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/synapses", nil)
+		rec := httptest.NewRecorder()
+		server.handleSynapses(rec, req)
+		var got []types.Synapse
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		return len(got)
+	}
+
+	if n := count(); n != 2 {
+		t.Errorf("expected reloaded store with 2 tasks, got %d", n)
+	}
+
+	// With auto-reload disabled the snapshot is kept
+	server.SetAutoReload(false)
+	external.Create("Ignored while snapshotting")
+	external.Save()
+	later := future.Add(2 * time.Second)
+	os.Chtimes(filepath.Join(dir, storage.MemoryFile), later, later)
+
+	if n := count(); n != 2 {
+		t.Errorf("expected snapshot with 2 tasks when reload disabled, got %d", n)
+	}
+}
+
+func TestHandler_ETag(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewJSONLStore(dir)
+	if _, err := store.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	store.Create("Original task")
+	if err := store.Save(); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	server := NewServer(store, 8080)
+
+	get := func(target, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/api/synapses", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+
+	second := get("/api/synapses", etag)
+	if second.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for an unchanged store, got %d", second.Code)
+	}
+	if second.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 body, got %q", second.Body.String())
+	}
+	if rec := get("/api/ready", `"other", `+etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when the ETag is among several, got %d", rec.Code)
+	}
+
+	// Another process changes the file: the old ETag no longer matches
+	external := storage.NewJSONLStore(dir)
+	if err := external.Load(); err != nil {
+		t.Fatalf("failed to load external store: %v", err)
+	}
+	external.Create("Added externally")
+	if err := external.Save(); err != nil {
+		t.Fatalf("failed to save external store: %v", err)
+	}
+	future := time.Now().Add(2 * time.Second)
+	os.Chtimes(filepath.Join(dir, storage.MemoryFile), future, future)
+
+	third := get("/api/synapses", etag)
+	if third.Code != http.StatusOK {
+		t.Fatalf("expected 200 after a change, got %d", third.Code)
+	}
+	if got := third.Header().Get("ETag"); got == etag || got == "" {
+		t.Errorf("expected a new ETag, got %q", got)
+	}
+}
+
+func TestHandleTask(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+	server := NewServer(store, 8080)
+
+	parent, _ := store.Create("Parent <epic>")
+	blocker, _ := store.Create("Blocker")
+	syn, _ := store.Create("Detail task")
+	syn.Description = "Full description"
+	syn.ParentID = parent.ID
+	syn.BlockedBy = []int{blocker.ID, 99}
+	syn.Labels = []string{"backend"}
+	syn.AddNote("first note")
+	syn.Claim("agent-1", types.DefaultClaimTimeout)
+
+	req := httptest.NewRequest(http.MethodGet, "/task/3", nil)
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+	server.handleTask(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Detail task",
+		"Full description",
+		"first note",
+		"backend",
+		"agent-1",
+		`<a href="/task/1">#1: Parent &lt;epic&gt;</a>`,
+		`<a href="/task/2">#2: Blocker</a>`,
+		"#99 (missing)",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected task page to contain %q", want)
+		}
+	}
+
+	// The parent page lists the task as a child
+	req = httptest.NewRequest(http.MethodGet, "/task/1", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	server.handleTask(rec, req)
+	if !strings.Contains(rec.Body.String(), `<a href="/task/3">#3: Detail task</a>`) {
+		t.Error("expected parent page to link to its child")
+	}
+}
+
+func TestHandleTask_NotFound(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+	server := NewServer(store, 8080)
+
+	req := httptest.NewRequest(http.MethodGet, "/task/42", nil)
+	req.SetPathValue("id", "42")
+	rec := httptest.NewRecorder()
+	server.handleTask(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Task #42 not found") {
+		t.Error("expected friendly not-found page")
+	}
+}
+
+func TestHandleAPITask(t *testing.T) {
+	store := storage.NewJSONLStore("/tmp/test")
+	server := NewServer(store, 8080)
+	store.Create("API task")
+
+	tests := []struct {
+		id   string
+		code int
+	}{
+		{"1", http.StatusOK},
+		{"2", http.StatusNotFound},
+		{"abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/task/"+tt.id, nil)
+		req.SetPathValue("id", tt.id)
+		rec := httptest.NewRecorder()
+		server.handleAPITask(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("id %s: expected %d, got %d", tt.id, tt.code, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/task/1", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	server.handleAPITask(rec, req)
+
+	var got types.Synapse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got.ID != 1 || got.Title != "API task" {
+		t.Errorf("unexpected task: %+v", got)
+	}
+}
+
+func TestServe_Shutdown(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 0)
+	server.SetHost("127.0.0.1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestHandler_AuthToken(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	store.Create("Secret task")
+	server := NewServer(store, 8080)
+
+	get := func(target, authorization string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Off by default
+	if rec := get("/api/synapses", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 without a token set, got %d", rec.Code)
+	}
+
+	server.SetAuthToken("s3cret")
+	for _, target := range []string{"/", "/table", "/api/synapses", "/api/task/1", "/export.md"} {
+		if rec := get(target, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401 without the token, got %d", target, rec.Code)
+		}
+	}
+	if rec := get("/api/synapses", "Bearer wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, got %d", rec.Code)
+	}
+	if rec := get("/api/synapses?token=wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong ?token=, got %d", rec.Code)
+	}
+
+	if rec := get("/api/synapses", "Bearer s3cret"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with the Bearer token, got %d", rec.Code)
+	}
+	rec := get("/?token=s3cret", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with ?token=, got %d", rec.Code)
+	}
+
+	// The page's own requests carry the cookie set by the ?token= visit
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected a token cookie, got %v", cookies)
+	}
+	if rec := get("/api/synapses", "", cookies...); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with the token cookie, got %d", rec.Code)
+	}
+}
+
+func TestHandler_CORS(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	request := func(method, target, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Default-deny: no CORS headers at all
+	if rec := request(http.MethodGet, "/api/synapses", "https://dash.example.com"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers without configured origins")
+	}
+
+	server.SetCORSOrigins([]string{"https://dash.example.com"})
+	server.SetAuthToken("s3cret")
+
+	// Preflight is answered without credentials
+	rec := request(http.MethodOptions, "/api/synapses", "https://dash.example.com")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://dash.example.com" {
+		t.Errorf("preflight Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+		t.Errorf("expected Authorization allowed, got %q", got)
+	}
+
+	// The actual request gets the header and still needs the token
+	rec = request(http.MethodGet, "/api/synapses?token=s3cret", "https://dash.example.com")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Errorf("expected 200 with CORS header, got %d %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if rec := request(http.MethodGet, "/api/synapses", "https://dash.example.com"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected CORS not to bypass the token, got %d", rec.Code)
+	}
+
+	// Other origins and non-API routes get nothing
+	if rec := request(http.MethodOptions, "/api/synapses", "https://evil.example.com"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS header for an unlisted origin")
+	}
+	if rec := request(http.MethodGet, "/?token=s3cret", "https://dash.example.com"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS header on the HTML page")
+	}
+}
+
+func TestServe_ListenError(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+	server.SetHost("invalid host name with spaces")
+
+	if err := server.Serve(context.Background()); err == nil {
+		t.Error("expected listen error for invalid host")
+	}
+}
+
+func TestServer_Addr(t *testing.T) {
+	server := NewServer(storage.NewJSONLStore("/tmp/test"), 9000)
+	if got := server.Addr(); got != "localhost:9000" {
+		t.Errorf("default Addr() = %q, want localhost:9000", got)
+	}
+	server.SetHost("0.0.0.0")
+	if got := server.Addr(); got != "0.0.0.0:9000" {
+		t.Errorf("Addr() = %q, want 0.0.0.0:9000", got)
+	}
+}
+
+func TestHandleReady_Assignee(t *testing.T) {
+	store := storage.NewJSONLStore(t.TempDir())
+	server := NewServer(store, 8080)
+
+	syn1, _ := store.Create("Write tests")
+	syn1.Assignee = "qa"
+	syn2, _ := store.Create("Urgent tests")
+	syn2.Assignee = "qa"
+	syn2.Priority = 3
+	syn3, _ := store.Create("Write code")
+	syn3.Assignee = "coder"
+	syn3.Priority = 1
+	shelved, _ := store.Create("Shelved tests")
+	shelved.Assignee = "qa"
+	shelved.Priority = 5
+	shelved.MarkArchived()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"all", "", []int{2, 3, 1}},
+		{"assignee", "?assignee=qa", []int{2, 1}},
+		{"archived assignee", "?assignee=qa&include_archived=1", []int{4, 2, 1}},
+		{"unknown assignee", "?assignee=pm", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/ready"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			server.handleReady(rec, req)
+
+			var got []types.Synapse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			var ids []int
+			for _, syn := range got {
+				ids = append(ids, syn.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("got IDs %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package view
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+var tableTemplate = template.Must(template.ParseFS(templates, "templates/table.html"))
+
+// tableColumn describes a sortable column in the task table.
+type tableColumn struct {
+	Key       string
+	Label     string
+	NextOrder string // Order to request when the header is clicked
+	Arrow     string // Sort indicator for the active column
+}
+
+// tableRow is a single task rendered in the task table.
+type tableRow struct {
+	ID        int
+	Title     string
+	Status    types.Status
+	Icon      string
+	Fill      string
+	Assignee  string
+	Priority  int
+	BlockedBy []int
+	Updated   string
+	Overdue   bool
+}
+
+// tableColumns lists the table columns in display order.
+var tableColumns = []struct{ key, label string }{
+	{"id", "ID"},
+	{"title", "Title"},
+	{"status", "Status"},
+	{"assignee", "Assignee"},
+	{"priority", "Priority"},
+	{"blockers", "Blockers"},
+	{"updated", "Updated"},
+}
+
+// handleTable renders all synapses as an HTML table. Sorting is done
+// server-side via ?sort=<column>&order=asc|desc so it works without JS.
+func (s *Server) handleTable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sortKey := r.URL.Query().Get("sort")
+	if sortKey == "" {
+		sortKey = "id"
+	}
+	if types.LessFunc(sortKey) == nil {
+		http.Error(w, "Invalid sort column: "+sortKey, http.StatusBadRequest)
+		return
+	}
+
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = types.OrderAsc
+	}
+	if order != types.OrderAsc && order != types.OrderDesc {
+		http.Error(w, "Invalid order: "+order, http.StatusBadRequest)
+		return
+	}
+
+	s.refresh()
+	synapses := s.store.All()
+	types.SortSynapses(synapses, sortKey, order)
+
+	columns := make([]tableColumn, len(tableColumns))
+	for i, c := range tableColumns {
+		col := tableColumn{Key: c.key, Label: c.label, NextOrder: "asc"}
+		if c.key == sortKey {
+			if order == "asc" {
+				col.NextOrder = "desc"
+				col.Arrow = " ▲"
+			} else {
+				col.Arrow = " ▼"
+			}
+		}
+		columns[i] = col
+	}
+
+	now := time.Now()
+	rows := make([]tableRow, len(synapses))
+	for i, syn := range synapses {
+		rows[i] = tableRow{
+			ID:        syn.ID,
+			Title:     syn.Title,
+			Status:    syn.Status,
+			Icon:      syn.Status.Icon(),
+			Fill:      graph.StatusColor(syn.Status),
+			Assignee:  syn.Assignee,
+			Priority:  syn.Priority,
+			BlockedBy: syn.BlockedBy,
+			Updated:   syn.UpdatedAt.Format("2006-01-02 15:04"),
+			Overdue:   syn.IsOverdue(now),
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct {
+		Columns []tableColumn
+		Rows    []tableRow
+	}{columns, rows}
+	if err := tableTemplate.Execute(w, data); err != nil {
+		log.Printf("Error rendering table: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package view
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swiftj/synapse/internal/graph"
+	"github.com/swiftj/synapse/internal/storage"
+	"github.com/swiftj/synapse/pkg/types"
+)
+
+var taskTemplate = template.Must(template.ParseFS(templates, "templates/task.html"))
+
+// taskRef is a link to a related task on the detail page.
+type taskRef struct {
+	ID     int
+	Title  string
+	Icon   string
+	Status types.Status
+	Fill   string
+	Known  bool // False if the referenced task no longer exists
+}
+
+// taskPage is the data rendered by templates/task.html.
+type taskPage struct {
+	NotFound  bool
+	ID        int
+	Task      *types.Synapse
+	Icon      string
+	Fill      string
+	Parent    *taskRef
+	Found     *taskRef // Task this one was discovered from
+	Blockers  []taskRef
+	Related   []taskRef
+	Children  []taskRef
+	ClaimedAt string
+	Due       string
+	Overdue   bool
+	Created   string
+	Updated   string
+}
+
+// newTaskRef resolves a task ID to a link, marking IDs that are not in byID.
+func newTaskRef(id int, byID map[int]*types.Synapse) taskRef {
+	syn, ok := byID[id]
+	if !ok {
+		return taskRef{ID: id}
+	}
+	return taskRef{
+		ID:     id,
+		Title:  syn.Title,
+		Icon:   syn.Status.Icon(),
+		Status: syn.Status,
+		Fill:   graph.StatusColor(syn.Status),
+		Known:  true,
+	}
+}
+
+// parseTaskID extracts the {id} path value, writing a 400 response if it
+// is not a positive integer.
+func parseTaskID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid task ID: "+r.PathValue("id"), http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// handleTask renders the full detail of a single task as HTML.
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := parseTaskID(w, r)
+	if !ok {
+		return
+	}
+
+	s.refresh()
+	page := taskPage{ID: id}
+	syn, err := s.store.Get(id)
+	if err != nil {
+		page.NotFound = true
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		if err := taskTemplate.Execute(w, page); err != nil {
+			log.Printf("Error rendering task page: %v", err)
+		}
+		return
+	}
+
+	all := s.store.All()
+	byID := make(map[int]*types.Synapse, len(all))
+	for _, other := range all {
+		byID[other.ID] = other
+	}
+
+	page.Task = syn
+	page.Icon = syn.Status.Icon()
+	page.Fill = graph.StatusColor(syn.Status)
+	if syn.ParentID > 0 {
+		parent := newTaskRef(syn.ParentID, byID)
+		page.Parent = &parent
+	}
+	if syn.DiscoveredFrom > 0 {
+		found := newTaskRef(syn.DiscoveredFrom, byID)
+		page.Found = &found
+	}
+	for _, blockerID := range syn.BlockedBy {
+		page.Blockers = append(page.Blockers, newTaskRef(blockerID, byID))
+	}
+	for _, relatedID := range syn.RelatedTo {
+		page.Related = append(page.Related, newTaskRef(relatedID, byID))
+	}
+	for _, other := range all {
+		if other.ParentID == syn.ID {
+			page.Children = append(page.Children, newTaskRef(other.ID, byID))
+		}
+	}
+	if syn.ClaimedAt != nil {
+		page.ClaimedAt = syn.ClaimedAt.Format(time.RFC3339)
+	}
+	if syn.DueAt != nil {
+		page.Due = syn.DueAt.Format(time.RFC3339)
+		page.Overdue = syn.IsOverdue(time.Now())
+	}
+	page.Created = syn.CreatedAt.Format(time.RFC3339)
+	page.Updated = syn.UpdatedAt.Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := taskTemplate.Execute(w, page); err != nil {
+		log.Printf("Error rendering task page: %v", err)
+	}
+}
+
+// handleAPITask returns a single task as JSON. Its ETag is the task's own
+// (see taskETag), for If-Match on writes, so an unchanged task answers
+// If-None-Match with 304 whatever else on the board changed.
+func (s *Server) handleAPITask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := parseTaskID(w, r)
+	if !ok {
+		return
+	}
+
+	s.refresh()
+	syn, err := s.store.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	etag := taskETag(syn)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeTask(w, http.StatusOK, syn)
+}
+
+// handleCriticalPath returns the critical path to a task as a JSON array
+// of synapses in execution order (see criticalPath).
+func (s *Server) handleCriticalPath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := parseTaskID(w, r)
+	if !ok {
+		return
+	}
+
+	s.refresh()
+	chain, status, err := s.criticalPath(r, id)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(chain); err != nil {
+		log.Printf("Error encoding critical path: %v", err)
+	}
+}
+
+// criticalPath computes the critical path to task id, weighted by
+// estimates with ?by_estimate=true. On failure it returns the HTTP status
+// code to send with the error.
+func (s *Server) criticalPath(r *http.Request, id int) ([]*types.Synapse, int, error) {
+	criticalPath := s.store.CriticalPath
+	if param := r.URL.Query().Get("by_estimate"); param != "" {
+		byEstimate, err := strconv.ParseBool(param)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid by_estimate: %s", param)
+		}
+		if byEstimate {
+			criticalPath = s.store.CriticalPathByEstimate
+		}
+	}
+
+	chain, err := criticalPath(id)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, http.StatusNotFound, err
+	case errors.Is(err, storage.ErrBlockerCycle):
+		return nil, http.StatusConflict, err
+	case err != nil:
+		return nil, http.StatusInternalServerError, err
+	}
+	return chain, http.StatusOK, nil
+}
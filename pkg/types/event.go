@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// EventType identifies the kind of state transition recorded in the audit log.
+type EventType string
+
+const (
+	EventCreated   EventType = "created"
+	EventStatus    EventType = "status"
+	EventClaimed   EventType = "claimed"
+	EventReleased  EventType = "released"
+	EventCompleted EventType = "completed"
+	EventDeleted   EventType = "deleted"
+	EventUpdated   EventType = "updated" // Any other edit, e.g. a note or title change
+)
+
+// Event is a single audit log entry describing a change to a task.
+type Event struct {
+	TaskID  int       `json:"task_id"`
+	Event   EventType `json:"event"`
+	From    string    `json:"from,omitempty"`    // Previous value (status or agent)
+	To      string    `json:"to,omitempty"`      // New value (status or agent)
+	Agent   string    `json:"agent,omitempty"`   // Actor responsible, when known
+	Session string    `json:"session,omitempty"` // Agent session the change was made in, when known
+	At      time.Time `json:"at"`
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort orders accepted by SortSynapses.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// LessFunc returns the ascending comparator for a sort key, or nil if the
+// key is unknown. Keys are id, title, status, assignee, priority, blockers,
+// created, and updated. Ties are broken by ID so ordering is stable.
+func LessFunc(key string) func(a, b *Synapse) bool {
+	byID := func(a, b *Synapse) bool { return a.ID < b.ID }
+	switch key {
+	case "id":
+		return byID
+	case "title":
+		return func(a, b *Synapse) bool {
+			if ta, tb := strings.ToLower(a.Title), strings.ToLower(b.Title); ta != tb {
+				return ta < tb
+			}
+			return byID(a, b)
+		}
+	case "status":
+		return func(a, b *Synapse) bool {
+			if a.Status != b.Status {
+				return a.Status < b.Status
+			}
+			return byID(a, b)
+		}
+	case "assignee":
+		return func(a, b *Synapse) bool {
+			if a.Assignee != b.Assignee {
+				return a.Assignee < b.Assignee
+			}
+			return byID(a, b)
+		}
+	case "priority":
+		return func(a, b *Synapse) bool {
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			return byID(a, b)
+		}
+	case "blockers":
+		return func(a, b *Synapse) bool {
+			if len(a.BlockedBy) != len(b.BlockedBy) {
+				return len(a.BlockedBy) < len(b.BlockedBy)
+			}
+			return byID(a, b)
+		}
+	case "created":
+		return func(a, b *Synapse) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return byID(a, b)
+		}
+	case "updated":
+		return func(a, b *Synapse) bool {
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.Before(b.UpdatedAt)
+			}
+			return byID(a, b)
+		}
+	}
+	return nil
+}
+
+// SortSynapses sorts synapses in place by key (see LessFunc) in the given
+// order, asc or desc. Empty values default to id and asc.
+func SortSynapses(synapses []*Synapse, key, order string) error {
+	if key == "" {
+		key = "id"
+	}
+	less := LessFunc(key)
+	if less == nil {
+		return fmt.Errorf("invalid sort key: %s", key)
+	}
+
+	switch order {
+	case "", OrderAsc:
+		sort.SliceStable(synapses, func(i, j int) bool { return less(synapses[i], synapses[j]) })
+	case OrderDesc:
+		sort.SliceStable(synapses, func(i, j int) bool { return less(synapses[j], synapses[i]) })
+	default:
+		return fmt.Errorf("invalid sort order: %s (want asc or desc)", order)
+	}
+	return nil
+}
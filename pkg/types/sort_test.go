@@ -0,0 +1,66 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func sortFixture() []*Synapse {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mk := func(id, priority int, updated time.Duration) *Synapse {
+		syn := NewSynapse(id, fmt.Sprintf("Task %d", id))
+		syn.Priority = priority
+		syn.CreatedAt = base.Add(time.Duration(id) * time.Minute)
+		syn.UpdatedAt = base.Add(updated)
+		return syn
+	}
+	return []*Synapse{
+		mk(1, 1, 3*time.Hour),
+		mk(2, 5, 1*time.Hour),
+		mk(3, 1, 2*time.Hour),
+		mk(4, 3, 4*time.Hour),
+	}
+}
+
+func ids(synapses []*Synapse) string {
+	var out []int
+	for _, syn := range synapses {
+		out = append(out, syn.ID)
+	}
+	return fmt.Sprint(out)
+}
+
+func TestSortSynapses(t *testing.T) {
+	tests := []struct {
+		key, order string
+		want       string
+	}{
+		{"", "", "[1 2 3 4]"},
+		{"priority", "desc", "[2 4 3 1]"},
+		{"priority", "asc", "[1 3 4 2]"},
+		{"updated", "desc", "[4 1 3 2]"},
+		{"created", "desc", "[4 3 2 1]"},
+		{"id", "desc", "[4 3 2 1]"},
+	}
+
+	for _, tt := range tests {
+		synapses := sortFixture()
+		if err := SortSynapses(synapses, tt.key, tt.order); err != nil {
+			t.Errorf("%s %s: unexpected error: %v", tt.key, tt.order, err)
+			continue
+		}
+		if got := ids(synapses); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.key, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortSynapses_Invalid(t *testing.T) {
+	if err := SortSynapses(sortFixture(), "color", ""); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := SortSynapses(sortFixture(), "id", "sideways"); err == nil {
+		t.Error("expected error for unknown order")
+	}
+}
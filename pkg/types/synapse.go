@@ -1,7 +1,15 @@
 // Package types defines the core data structures for Synapse.
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Status represents the lifecycle state of a Synapse task.
 type Status string
@@ -28,27 +36,106 @@ func (s Status) IsValid() bool {
 	return false
 }
 
+// CanTransitionTo reports whether a task may move from status s to next.
+// Staying in the same status is always allowed. The matrix (rows are the
+// current status, columns the next):
+//
+//	              open  in-progress  blocked  review  done
+//	open           -        yes        yes      no     yes
+//	in-progress   yes        -         yes      yes    yes
+//	blocked       yes       yes*        -       no     no
+//	review        yes       yes         no       -     yes
+//	done          yes        no         no      no      -
+//
+// done -> open is an explicit reopen; finished work can't resume or be
+// reviewed without one. * blocked -> in-progress additionally requires
+// every blocker to be done, which ValidateTransition checks.
+func (s Status) CanTransitionTo(next Status) bool {
+	if s == next {
+		return true
+	}
+	switch s {
+	case StatusOpen:
+		return next == StatusInProgress || next == StatusBlocked || next == StatusDone
+	case StatusInProgress:
+		return next == StatusOpen || next == StatusBlocked || next == StatusReview || next == StatusDone
+	case StatusBlocked:
+		return next == StatusOpen || next == StatusInProgress
+	case StatusReview:
+		return next == StatusOpen || next == StatusInProgress || next == StatusDone
+	case StatusDone:
+		return next == StatusOpen
+	}
+	return false
+}
+
+// Icon returns a compact unicode glyph for the status, used in human output.
+func (s Status) Icon() string {
+	switch s {
+	case StatusOpen:
+		return "○"
+	case StatusInProgress:
+		return "◐"
+	case StatusBlocked:
+		return "◌"
+	case StatusReview:
+		return "◑"
+	case StatusDone:
+		return "●"
+	default:
+		return "?"
+	}
+}
+
 // DefaultClaimTimeout is the default duration after which a claim expires.
 const DefaultClaimTimeout = 30 * time.Minute
 
 // Synapse represents an atomic memory unit / task in the system.
 type Synapse struct {
-	ID             int        `json:"id"`
-	Title          string     `json:"title"`
-	Description    string     `json:"description,omitempty"`
-	Status         Status     `json:"status"`
-	Priority       int        `json:"priority,omitempty"` // Higher number = higher priority
-	BlockedBy      []int      `json:"blocked_by,omitempty"`
-	ParentID       int        `json:"parent_id,omitempty"`
-	Assignee       string     `json:"assignee,omitempty"`
-	DiscoveredFrom string     `json:"discovered_from,omitempty"`
-	Labels         []string   `json:"labels,omitempty"`
-	Notes          []string   `json:"notes,omitempty"`
-	ClaimedBy      string     `json:"claimed_by,omitempty"`  // Agent ID that claimed this task
-	ClaimedAt      *time.Time `json:"claimed_at,omitempty"`  // When the task was claimed
-	CompletedBy    string     `json:"completed_by,omitempty"` // Agent ID that completed this task
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description,omitempty"`
+	Status          Status     `json:"status"`
+	Priority        int        `json:"priority,omitempty"`   // Higher number = higher priority
+	BlockedBy       []int      `json:"blocked_by"`           // Never nil, so it always encodes as an array
+	RelatedTo       []int      `json:"related_to,omitempty"` // "See also" links; never affect readiness
+	ParentID        int        `json:"parent_id,omitempty"`
+	Assignee        string     `json:"assignee,omitempty"`
+	DiscoveredFrom  int        `json:"discovered_from,omitempty"` // Task being worked on when this was found (0 = none)
+	Labels          []string   `json:"labels,omitempty"`
+	Notes           []string   `json:"notes,omitempty"`
+	CreatedBy       string     `json:"created_by,omitempty"`       // Agent ID that created this task, when known
+	ClaimedBy       string     `json:"claimed_by,omitempty"`       // Agent ID that claimed this task
+	ClaimedAt       *time.Time `json:"claimed_at,omitempty"`       // When the claim was taken or last renewed; it expires a timeout after
+	StartedAt       *time.Time `json:"started_at,omitempty"`       // When work first started; kept across renewals and review
+	CompletedBy     string     `json:"completed_by,omitempty"`     // Agent ID that completed this task
+	SessionID       string     `json:"session_id,omitempty"`       // Agent session that last created, claimed or completed this task
+	EstimateMinutes int        `json:"estimate_minutes,omitempty"` // Planned effort
+	ActualMinutes   int        `json:"actual_minutes,omitempty"`   // Time from StartedAt to completion, set when marked done
+	DueAt           *time.Time `json:"due_at,omitempty"`           // Deadline; unfinished tasks past it are overdue
+	ExternalID      string     `json:"external_id,omitempty"`      // Caller-chosen idempotency key; unique within a store
+	Archived        bool       `json:"archived,omitempty"`         // Hidden from default list, ready and graph output
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Version         int        `json:"version,omitempty"` // Incremented by every store Update (optimistic concurrency)
+
+	// Extra holds JSON keys this version doesn't recognize (e.g. written by
+	// a newer release) so they are preserved when the synapse is re-saved.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ParseTaskRef parses a "#N" task reference, the legacy string form of
+// DiscoveredFrom. Surrounding whitespace is ignored.
+func ParseTaskRef(ref string) (int, bool) {
+	ref = strings.TrimSpace(ref)
+	if !strings.HasPrefix(ref, "#") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(ref[1:]))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
 }
 
 // NewSynapse creates a new Synapse with the given title and default values.
@@ -64,6 +151,26 @@ func NewSynapse(id int, title string) *Synapse {
 	}
 }
 
+// Clone returns a copy of s that shares no slices, maps or pointers with
+// it, so either can change without affecting the other.
+func (s *Synapse) Clone() *Synapse {
+	c := *s
+	c.BlockedBy = slices.Clone(s.BlockedBy)
+	c.RelatedTo = slices.Clone(s.RelatedTo)
+	c.Labels = slices.Clone(s.Labels)
+	c.Notes = slices.Clone(s.Notes)
+	if s.ClaimedAt != nil {
+		claimedAt := *s.ClaimedAt
+		c.ClaimedAt = &claimedAt
+	}
+	if s.DueAt != nil {
+		dueAt := *s.DueAt
+		c.DueAt = &dueAt
+	}
+	c.Extra = maps.Clone(s.Extra)
+	return &c
+}
+
 // IsReady returns true if this synapse can be worked on.
 // A task is ready when:
 // - Status is "open" or "blocked" (blocked tasks become ready when blockers complete)
@@ -84,10 +191,46 @@ func (s *Synapse) IsReady(isBlockerDone func(id int) bool) bool {
 	return true
 }
 
+// ValidateTransition returns an error if the synapse may not move to next:
+// either the status matrix forbids it (see Status.CanTransitionTo) or it
+// is leaving blocked for in-progress while a blocker is unfinished.
+func (s *Synapse) ValidateTransition(next Status, isBlockerDone func(id int) bool) error {
+	if !next.IsValid() {
+		return fmt.Errorf("invalid status: %s", next)
+	}
+	if !s.Status.CanTransitionTo(next) {
+		hint := ""
+		if s.Status == StatusDone {
+			hint = " (reopen it to open first)"
+		}
+		return fmt.Errorf("cannot transition synapse %d from %s to %s%s", s.ID, s.Status, next, hint)
+	}
+	if s.Status == StatusBlocked && next == StatusInProgress {
+		var pending []int
+		for _, id := range s.BlockedBy {
+			if !isBlockerDone(id) {
+				pending = append(pending, id)
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("cannot start synapse %d: still blocked by %v", s.ID, pending)
+		}
+	}
+	return nil
+}
+
 // MarkInProgress transitions the synapse to in-progress status.
 func (s *Synapse) MarkInProgress() {
 	s.Status = StatusInProgress
 	s.UpdatedAt = time.Now().UTC()
+	s.markStarted(s.UpdatedAt)
+}
+
+// markStarted records now as when work started, unless it already had.
+func (s *Synapse) markStarted(now time.Time) {
+	if s.StartedAt == nil {
+		s.StartedAt = &now
+	}
 }
 
 // Claim attempts to claim the task for an agent. Returns true if successful.
@@ -115,6 +258,43 @@ func (s *Synapse) Claim(agentID string, timeout time.Duration) bool {
 	s.ClaimedAt = &now
 	s.Status = StatusInProgress
 	s.UpdatedAt = now
+	s.markStarted(now)
+	return true
+}
+
+// StealClaim claims the task for agentID even if another agent holds an
+// active claim, e.g. when a coordinator reassigns work from a stuck agent.
+// The previous claimant is recorded in a note and returned ("" if the task
+// was unclaimed). It fails only for done tasks.
+func (s *Synapse) StealClaim(agentID string) (string, bool) {
+	if s.Status == StatusDone {
+		return "", false
+	}
+	previous := s.ClaimedBy
+
+	now := time.Now().UTC()
+	s.ClaimedBy = agentID
+	s.ClaimedAt = &now
+	s.Status = StatusInProgress
+	s.UpdatedAt = now
+	s.markStarted(now)
+	if previous != "" && previous != agentID {
+		s.AddNote(fmt.Sprintf("Claim stolen from %s by %s", previous, agentID))
+	}
+	return previous, true
+}
+
+// Renew extends the agent's claim by resetting ClaimedAt to now, so a
+// long-running task isn't lost to the claim timeout. It fails if the agent
+// no longer holds the claim (e.g. it expired and another agent took the
+// task) or the task is done. Renewal isn't an edit, so UpdatedAt is kept,
+// and StartedAt is too, so the time spent is still measured in full.
+func (s *Synapse) Renew(agentID string) bool {
+	if s.Status == StatusDone || s.ClaimedBy == "" || s.ClaimedBy != agentID {
+		return false
+	}
+	now := time.Now().UTC()
+	s.ClaimedAt = &now
 	return true
 }
 
@@ -136,10 +316,16 @@ func (s *Synapse) IsClaimExpired(timeout time.Duration) bool {
 	return time.Now().UTC().Sub(*s.ClaimedAt) >= timeout
 }
 
+// IsOverdue reports whether the synapse is unfinished and past its due date.
+func (s *Synapse) IsOverdue(now time.Time) bool {
+	return s.DueAt != nil && s.Status != StatusDone && now.After(*s.DueAt)
+}
+
 // MarkDone transitions the synapse to done status.
 func (s *Synapse) MarkDone() {
 	s.Status = StatusDone
 	s.UpdatedAt = time.Now().UTC()
+	s.recordActualMinutes()
 }
 
 // MarkDoneBy transitions the synapse to done status and records the completing agent.
@@ -147,6 +333,37 @@ func (s *Synapse) MarkDoneBy(agentID string) {
 	s.Status = StatusDone
 	s.CompletedBy = agentID
 	s.UpdatedAt = time.Now().UTC()
+	s.recordActualMinutes()
+}
+
+// recordActualMinutes sets ActualMinutes to the time between StartedAt and
+// UpdatedAt, rounded to the nearest minute but at least 1 so that a quick
+// task still counts as measured. Tasks never started are left unmeasured.
+// Tasks claimed before StartedAt was recorded are measured from their
+// claim.
+func (s *Synapse) recordActualMinutes() {
+	started := s.StartedAt
+	if started == nil {
+		started = s.ClaimedAt
+	}
+	if started == nil {
+		return
+	}
+	s.ActualMinutes = max(1, int(s.UpdatedAt.Sub(*started).Round(time.Minute)/time.Minute))
+}
+
+// MarkArchived hides the synapse from the working board without deleting
+// it. Its status is kept, so an archived done task still satisfies the
+// tasks it blocked.
+func (s *Synapse) MarkArchived() {
+	s.Archived = true
+	s.UpdatedAt = time.Now().UTC()
+}
+
+// Unarchive returns an archived synapse to the working board.
+func (s *Synapse) Unarchive() {
+	s.Archived = false
+	s.UpdatedAt = time.Now().UTC()
 }
 
 // MarkBlocked transitions the synapse to blocked status.
@@ -155,6 +372,31 @@ func (s *Synapse) MarkBlocked() {
 	s.UpdatedAt = time.Now().UTC()
 }
 
+// RequestReview hands the synapse off for review: it moves to review,
+// drops any claim so the reviewer can claim it, is reassigned to reviewer
+// if one is given, and gains a note recording who asked. StartedAt is
+// kept, so the review counts towards the time spent.
+func (s *Synapse) RequestReview(requestedBy, reviewer string) {
+	s.Status = StatusReview
+	if s.ClaimedAt != nil {
+		s.markStarted(*s.ClaimedAt) // Claimed before StartedAt was recorded
+	}
+	s.ClaimedBy = ""
+	s.ClaimedAt = nil
+	if reviewer != "" {
+		s.Assignee = reviewer
+	}
+
+	note := "Review requested"
+	if requestedBy != "" {
+		note += " by " + requestedBy
+	}
+	if reviewer != "" {
+		note += " (assigned to " + reviewer + ")"
+	}
+	s.AddNote(note)
+}
+
 // AddBlocker adds a blocking dependency.
 func (s *Synapse) AddBlocker(blockerID int) {
 	for _, id := range s.BlockedBy {
@@ -177,6 +419,23 @@ func (s *Synapse) RemoveBlocker(blockerID int) {
 	}
 }
 
+// AddRelated adds a non-blocking "see also" link to another task.
+func (s *Synapse) AddRelated(id int) {
+	if id == s.ID || slices.Contains(s.RelatedTo, id) {
+		return
+	}
+	s.RelatedTo = append(s.RelatedTo, id)
+	s.UpdatedAt = time.Now().UTC()
+}
+
+// RemoveRelated removes a "see also" link.
+func (s *Synapse) RemoveRelated(id int) {
+	if i := slices.Index(s.RelatedTo, id); i >= 0 {
+		s.RelatedTo = slices.Delete(s.RelatedTo, i, i+1)
+		s.UpdatedAt = time.Now().UTC()
+	}
+}
+
 // AddNote appends a note to the task for context persistence.
 func (s *Synapse) AddNote(note string) {
 	s.Notes = append(s.Notes, note)
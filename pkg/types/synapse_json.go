@@ -0,0 +1,152 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// synapseJSON has Synapse's fields without its JSON methods, so the
+// default encoding can be reused without recursion.
+type synapseJSON Synapse
+
+// synapseFields holds the lowercased JSON names of Synapse's declared
+// fields. encoding/json matches keys case-insensitively, so any key whose
+// lowercase form is listed here is consumed by a struct field.
+var synapseFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(Synapse{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[strings.ToLower(name)] = true
+		}
+	}
+	return fields
+}()
+
+// UnmarshalJSON decodes a synapse, keeping any keys it doesn't recognize
+// (e.g. written by a newer version) in Extra so they survive a round-trip.
+func (s *Synapse) UnmarshalJSON(data []byte) error {
+	// discovered_from is decoded separately: older versions wrote it as a
+	// "#N" string rather than a number
+	var decoded struct {
+		synapseJSON
+		DiscoveredFrom json.RawMessage `json:"discovered_from"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	discoveredFrom, note, err := decodeTaskRef(decoded.DiscoveredFrom)
+	if err != nil {
+		return fmt.Errorf("discovered_from: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for key := range raw {
+		if synapseFields[strings.ToLower(key)] {
+			delete(raw, key)
+		}
+	}
+	if _, ok := raw[DiscoveredFromNote]; note != "" && !ok {
+		raw[DiscoveredFromNote], _ = json.Marshal(note)
+	}
+
+	*s = Synapse(decoded.synapseJSON)
+	s.DiscoveredFrom = discoveredFrom
+	if s.BlockedBy == nil {
+		// Files written before blocked_by was always encoded omit it
+		s.BlockedBy = []int{}
+	}
+	s.Extra = nil
+	if len(raw) > 0 {
+		s.Extra = raw
+	}
+	return nil
+}
+
+// DiscoveredFromNote is the Extra key keeping free-form discovered_from
+// provenance text, which older releases allowed, so it isn't lost when the
+// field became a task ID.
+const DiscoveredFromNote = "discovered_from_note"
+
+// decodeTaskRef decodes a task reference given either as a number or in the
+// legacy "#N" string form. Strings that aren't references are returned as
+// note, with an ID of 0, meaning none.
+func decodeTaskRef(raw json.RawMessage) (id int, note string, err error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, "", nil
+	}
+	if raw[0] == '"' {
+		var ref string
+		if err := json.Unmarshal(raw, &ref); err != nil {
+			return 0, "", err
+		}
+		if id, ok := ParseTaskRef(ref); ok {
+			return id, "", nil
+		}
+		return 0, ref, nil
+	}
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return 0, "", err
+	}
+	return id, "", nil
+}
+
+// MarshalJSON encodes a synapse, appending the keys preserved in Extra
+// after the declared fields in sorted order. A nil BlockedBy is encoded
+// as [], like an empty one.
+func (s Synapse) MarshalJSON() ([]byte, error) {
+	if s.BlockedBy == nil {
+		s.BlockedBy = []int{}
+	}
+	data, err := json.Marshal(synapseJSON(s))
+	if err != nil || len(s.Extra) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(s.Extra))
+	for key := range s.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1]) // Drop the closing brace
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(s.Extra[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalWith encodes the synapse with additional top-level keys, e.g. to
+// annotate a command response without changing the task's own shape.
+func (s Synapse) MarshalWith(fields map[string]any) ([]byte, error) {
+	merged := make(map[string]json.RawMessage, len(s.Extra)+len(fields))
+	for key, raw := range s.Extra {
+		merged[key] = raw
+	}
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = raw
+	}
+	s.Extra = merged
+	return s.MarshalJSON()
+}
@@ -0,0 +1,278 @@
+package types
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusOpen, StatusInProgress, true},
+		{StatusOpen, StatusDone, true},
+		{StatusOpen, StatusReview, false},
+		{StatusInProgress, StatusReview, true},
+		{StatusBlocked, StatusInProgress, true},
+		{StatusBlocked, StatusDone, false},
+		{StatusReview, StatusBlocked, false},
+		{StatusReview, StatusDone, true},
+		{StatusDone, StatusOpen, true},
+		{StatusDone, StatusInProgress, false},
+		{StatusDone, StatusDone, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTransition_BlockersMustBeDone(t *testing.T) {
+	syn := NewSynapse(2, "Blocked work")
+	syn.Status = StatusBlocked
+	syn.BlockedBy = []int{1}
+
+	done := map[int]bool{}
+	isDone := func(id int) bool { return done[id] }
+
+	err := syn.ValidateTransition(StatusInProgress, isDone)
+	if err == nil || !strings.Contains(err.Error(), "still blocked by [1]") {
+		t.Fatalf("expected pending blocker error, got %v", err)
+	}
+
+	done[1] = true
+	if err := syn.ValidateTransition(StatusInProgress, isDone); err != nil {
+		t.Errorf("expected transition allowed once blockers are done, got %v", err)
+	}
+}
+
+func TestValidateTransition_DoneNeedsReopen(t *testing.T) {
+	syn := NewSynapse(1, "Finished")
+	syn.MarkDone()
+
+	err := syn.ValidateTransition(StatusInProgress, func(int) bool { return true })
+	if err == nil || !strings.Contains(err.Error(), "reopen") {
+		t.Errorf("expected reopen hint, got %v", err)
+	}
+}
+
+func TestRequestReview(t *testing.T) {
+	syn := NewSynapse(1, "Implement parser")
+	syn.Assignee = "@coder"
+	syn.Claim("coder-1", time.Hour)
+
+	syn.RequestReview("coder-1", "@qa")
+
+	if syn.Status != StatusReview {
+		t.Errorf("expected review status, got %s", syn.Status)
+	}
+	if syn.Assignee != "@qa" {
+		t.Errorf("expected reassignment to @qa, got %q", syn.Assignee)
+	}
+	if syn.ClaimedBy != "" || syn.ClaimedAt != nil {
+		t.Errorf("expected claim dropped, got %q", syn.ClaimedBy)
+	}
+	if len(syn.Notes) != 1 || syn.Notes[0] != "Review requested by coder-1 (assigned to @qa)" {
+		t.Errorf("unexpected notes: %v", syn.Notes)
+	}
+
+	// Without a reviewer the assignee is kept
+	other := NewSynapse(2, "Write docs")
+	other.Assignee = "@writer"
+	other.RequestReview("", "")
+	if other.Assignee != "@writer" || other.Notes[0] != "Review requested" {
+		t.Errorf("expected assignee kept and plain note, got %q %v", other.Assignee, other.Notes)
+	}
+}
+
+func TestMarkDone_RecordsActualMinutes(t *testing.T) {
+	syn := NewSynapse(1, "Timed")
+	syn.MarkDone()
+	if syn.ActualMinutes != 0 {
+		t.Errorf("expected unclaimed task unmeasured, got %d", syn.ActualMinutes)
+	}
+
+	syn = NewSynapse(2, "Timed")
+	syn.Claim("agent-1", DefaultClaimTimeout)
+	startedAt := syn.StartedAt.Add(-95 * time.Minute)
+	syn.StartedAt = &startedAt
+	syn.MarkDoneBy("agent-1")
+	if syn.ActualMinutes != 95 {
+		t.Errorf("expected 95 actual minutes, got %d", syn.ActualMinutes)
+	}
+
+	// Renewing the claim and handing off for review don't restart the clock
+	syn = NewSynapse(4, "Reviewed")
+	syn.Claim("agent-1", DefaultClaimTimeout)
+	startedAt = syn.StartedAt.Add(-2 * time.Hour)
+	syn.StartedAt = &startedAt
+	syn.Renew("agent-1")
+	syn.RequestReview("agent-1", "@qa")
+	syn.Claim("reviewer", DefaultClaimTimeout)
+	syn.MarkDoneBy("reviewer")
+	if syn.ActualMinutes != 120 {
+		t.Errorf("expected 120 actual minutes across renewal and review, got %d", syn.ActualMinutes)
+	}
+
+	// Tasks claimed before StartedAt was recorded are measured from the claim
+	syn = NewSynapse(5, "Legacy")
+	claimedAt := time.Now().UTC().Add(-30 * time.Minute)
+	syn.ClaimedBy, syn.ClaimedAt = "agent-1", &claimedAt
+	syn.MarkDone()
+	if syn.ActualMinutes != 30 {
+		t.Errorf("expected 30 actual minutes from the claim, got %d", syn.ActualMinutes)
+	}
+
+	// Quick tasks still count as measured
+	syn = NewSynapse(3, "Quick")
+	syn.Claim("agent-1", DefaultClaimTimeout)
+	syn.MarkDone()
+	if syn.ActualMinutes != 1 {
+		t.Errorf("expected a minimum of 1 minute, got %d", syn.ActualMinutes)
+	}
+}
+
+func TestAddRelated(t *testing.T) {
+	syn := NewSynapse(1, "Linked")
+	syn.AddRelated(2)
+	syn.AddRelated(2)
+	syn.AddRelated(1)
+	syn.AddRelated(3)
+	if !slices.Equal(syn.RelatedTo, []int{2, 3}) {
+		t.Fatalf("expected [2 3] without duplicates or self, got %v", syn.RelatedTo)
+	}
+
+	syn.RemoveRelated(2)
+	syn.RemoveRelated(42)
+	if !slices.Equal(syn.RelatedTo, []int{3}) {
+		t.Errorf("expected [3] after removal, got %v", syn.RelatedTo)
+	}
+}
+
+func TestStealClaim(t *testing.T) {
+	syn := NewSynapse(1, "Stuck")
+	syn.Claim("claude-1", DefaultClaimTimeout)
+
+	if syn.Claim("coordinator", DefaultClaimTimeout) {
+		t.Fatal("expected an active claim to refuse a plain claim")
+	}
+	from, ok := syn.StealClaim("coordinator")
+	if !ok || from != "claude-1" || syn.ClaimedBy != "coordinator" {
+		t.Fatalf("expected claim stolen from claude-1, got %q %v (claimed by %s)", from, ok, syn.ClaimedBy)
+	}
+	if len(syn.Notes) != 1 || syn.Notes[0] != "Claim stolen from claude-1 by coordinator" {
+		t.Errorf("expected audit note, got %v", syn.Notes)
+	}
+
+	syn.MarkDone()
+	if _, ok := syn.StealClaim("claude-1"); ok {
+		t.Error("expected stealing a done task to fail")
+	}
+}
+
+func TestRenew(t *testing.T) {
+	syn := NewSynapse(1, "Long task")
+	if syn.Renew("agent-1") {
+		t.Error("expected renewing an unclaimed task to fail")
+	}
+
+	syn.Claim("agent-1", DefaultClaimTimeout)
+	stale := syn.ClaimedAt.Add(-time.Hour)
+	syn.ClaimedAt = &stale
+	updatedAt := syn.UpdatedAt
+	if !syn.Renew("agent-1") {
+		t.Fatal("expected the holder to renew even after the timeout")
+	}
+	if syn.IsClaimExpired(DefaultClaimTimeout) || !syn.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("expected fresh claim and unchanged UpdatedAt, got %v %v", syn.ClaimedAt, syn.UpdatedAt)
+	}
+
+	// Once expired and taken by someone else, the old holder can't renew
+	syn.ClaimedAt = &stale
+	syn.Claim("agent-2", DefaultClaimTimeout)
+	if syn.Renew("agent-1") || syn.ClaimedBy != "agent-2" {
+		t.Errorf("expected renewal by a former holder to fail, claimed by %s", syn.ClaimedBy)
+	}
+}
+
+func TestParseTaskRef(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   int
+		wantOK bool
+	}{
+		{"#5", 5, true},
+		{"  #12 ", 12, true},
+		{"# 7", 7, true},
+		{"5", 0, false}, // Bare numbers are free-form text
+		{"#0", 0, false},
+		{"#abc", 0, false},
+		{"found in code review", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTaskRef(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseTaskRef(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestJSONRoundTrip_EmptyBlockedBy(t *testing.T) {
+	for name, blockedBy := range map[string][]int{"empty": {}, "nil": nil} {
+		syn := NewSynapse(1, "Unblocked")
+		syn.BlockedBy = blockedBy
+
+		data, err := json.Marshal(syn)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		if !strings.Contains(string(data), `"blocked_by":[]`) {
+			t.Errorf("%s: expected blocked_by to encode as [], got %s", name, data)
+		}
+
+		var decoded Synapse
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: unmarshal: %v", name, err)
+		}
+		if decoded.BlockedBy == nil || len(decoded.BlockedBy) != 0 {
+			t.Errorf("%s: BlockedBy = %#v after round-trip, want []int{}", name, decoded.BlockedBy)
+		}
+	}
+
+	// Lines written before blocked_by was always encoded omit the key
+	var legacy Synapse
+	if err := json.Unmarshal([]byte(`{"id":2,"title":"Old","status":"open"}`), &legacy); err != nil {
+		t.Fatalf("unmarshal legacy: %v", err)
+	}
+	if legacy.BlockedBy == nil {
+		t.Error("BlockedBy is nil for a line without blocked_by, want []int{}")
+	}
+}
+
+func TestClone(t *testing.T) {
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	orig := NewSynapse(1, "Original")
+	orig.BlockedBy = []int{2}
+	orig.Labels = []string{"a"}
+	orig.DueAt = &due
+	orig.Extra = map[string]json.RawMessage{"x": json.RawMessage(`1`)}
+
+	clone := orig.Clone()
+	clone.BlockedBy[0] = 3
+	clone.Labels[0] = "b"
+	*clone.DueAt = due.AddDate(0, 1, 0)
+	clone.Extra["y"] = json.RawMessage(`2`)
+
+	if orig.BlockedBy[0] != 2 || orig.Labels[0] != "a" || !orig.DueAt.Equal(due) || len(orig.Extra) != 1 {
+		t.Errorf("changing the clone changed the original: %+v", orig)
+	}
+	if data, _ := json.Marshal(NewSynapse(2, "Empty").Clone()); !strings.Contains(string(data), `"blocked_by":[]`) {
+		t.Errorf("expected blocked_by kept as an empty array, got %s", data)
+	}
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Template describes a recurring task, e.g. "rotate credentials weekly".
+// Templates live outside the task list; instantiating one creates a fresh
+// task from its fields.
+type Template struct {
+	Name             string     `json:"name"` // Unique key used to instantiate it
+	Title            string     `json:"title"`
+	Description      string     `json:"description,omitempty"`
+	Labels           []string   `json:"labels,omitempty"`
+	Assignee         string     `json:"assignee,omitempty"`
+	Interval         string     `json:"interval,omitempty"`          // How often it recurs, e.g. "7d"; also the new task's due window
+	LastInstantiated *time.Time `json:"last_instantiated,omitempty"` // When a task was last created from it
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Apply copies the template's fields onto a newly created synapse and
+// notes where it came from. interval is the parsed Interval; when
+// positive, the synapse is due that long after now.
+func (t *Template) Apply(syn *Synapse, interval time.Duration, now time.Time) {
+	syn.Title = t.Title
+	syn.Description = t.Description
+	syn.Labels = slices.Clone(t.Labels)
+	syn.Assignee = t.Assignee
+	if interval > 0 {
+		due := now.Add(interval)
+		syn.DueAt = &due
+	}
+	syn.AddNote(fmt.Sprintf("Created from template %s", t.Name))
+	t.LastInstantiated = &now
+}